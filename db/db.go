@@ -3,17 +3,73 @@ package pkg
 import (
 	"context"
 	"fmt"
-	"os"
+	"helpdesk/pkg/config"
+	"helpdesk/pkg/rastreio"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-var chaveDB string = os.Getenv("CHAVEDB")
+// ConectaDB abre o pool com a URL e os limites da configuracao. As consultas
+// feitas dentro de um trace viram spans.
+func ConectaDB(cfg config.DB) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("URL do banco de dados inválida: %w", err)
+	}
+	poolConfig.MaxConns = cfg.MaxConexoes
+	poolConfig.MinConns = cfg.MinConexoes
+	poolConfig.MaxConnLifetime = cfg.VidaMaxConexao
+	poolConfig.MaxConnIdleTime = cfg.OciosaMax
+	poolConfig.ConnConfig.Tracer = rastreio.Pgx{}
 
-func ConectaDB() (*pgxpool.Pool, error) {
-	pool, err := pgxpool.New(context.Background(), chaveDB)
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("não foi possivel conectar ao banco de dados: %w", err)
 	}
 	return pool, nil
 }
+
+// VersaoMigracoes devolve a maior versao entre os arquivos .up.sql do
+// diretorio de migracoes, que e a versao em que o banco deve estar.
+func VersaoMigracoes(dir string) (uint64, error) {
+	arquivos, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return 0, err
+	}
+	var maior uint64
+	for _, arquivo := range arquivos {
+		numero, _, _ := strings.Cut(filepath.Base(arquivo), "_")
+		versao, err := strconv.ParseUint(numero, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migração com nome inválido: %s", arquivo)
+		}
+		maior = max(maior, versao)
+	}
+	if maior == 0 {
+		return 0, fmt.Errorf("nenhuma migração encontrada em %s", dir)
+	}
+	return maior, nil
+}
+
+// ChecarMigracoes confere que o banco esta na versao esperada e que nenhuma
+// migracao ficou pela metade (dirty). Serve de verificacao para o /readyz.
+func ChecarMigracoes(pool *pgxpool.Pool, esperada uint64) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var versao uint64
+		var dirty bool
+		err := pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations`).Scan(&versao, &dirty)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar o estado das migrações: %w", err)
+		}
+		if dirty {
+			return fmt.Errorf("migração %d incompleta", versao)
+		}
+		if versao != esperada {
+			return fmt.Errorf("banco na versão %d, esperada %d", versao, esperada)
+		}
+		return nil
+	}
+}
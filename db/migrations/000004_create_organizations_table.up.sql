@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    id BIGSERIAL PRIMARY KEY,
+    nome VARCHAR(255) UNIQUE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE, -- Um usuario pertence a uma unica organizacao
+    organization_id BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    manager BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_org ON organization_members (organization_id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS ticket_cc;
\ No newline at end of file
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS ticket_cc (
+    ticket_id BIGINT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (ticket_id, user_id)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id BIGINT NOT NULL, -- Quem realmente executou a acao (o admin, em caso de personificacao)
+    user_id BIGINT NOT NULL, -- Em nome de quem a acao foi executada
+    servico VARCHAR(50) NOT NULL,
+    metodo VARCHAR(10) NOT NULL,
+    rota VARCHAR(255),
+    caminho VARCHAR(255) NOT NULL,
+    status INT NOT NULL,
+    data TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor ON audit_logs (actor_id, data DESC);
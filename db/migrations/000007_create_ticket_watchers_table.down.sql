@@ -0,0 +1 @@
+DROP TABLE IF EXISTS ticket_watchers;
\ No newline at end of file
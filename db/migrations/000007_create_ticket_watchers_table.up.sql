@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS ticket_watchers (
+    ticket_id BIGINT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    data TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (ticket_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_watchers_user ON ticket_watchers (user_id);
@@ -0,0 +1 @@
+ALTER TABLE comentarios DROP COLUMN IF EXISTS tipo;
\ No newline at end of file
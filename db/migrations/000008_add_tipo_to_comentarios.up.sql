@@ -0,0 +1 @@
+ALTER TABLE comentarios ADD COLUMN IF NOT EXISTS tipo VARCHAR(20) NOT NULL DEFAULT 'publico'; -- 'publico' (resposta ao cliente) ou 'interno' (nota entre agentes)
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS comment_mentions (
+    id BIGSERIAL PRIMARY KEY,
+    comment_id BIGINT NOT NULL REFERENCES comentarios(id) ON DELETE CASCADE,
+    ticket_id BIGINT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Usuario mencionado
+    resolvida BOOLEAN NOT NULL DEFAULT FALSE, -- Marcada pelo mencionado quando ja tratou a mencao
+    data TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (comment_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_mentions_user ON comment_mentions (user_id) WHERE NOT resolvida;
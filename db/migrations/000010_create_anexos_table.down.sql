@@ -0,0 +1 @@
+DROP TABLE IF EXISTS anexos;
\ No newline at end of file
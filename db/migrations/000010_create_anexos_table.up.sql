@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS anexos (
+    id BIGSERIAL PRIMARY KEY,
+    ticket_id BIGINT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    comment_id BIGINT REFERENCES comentarios(id) ON DELETE CASCADE, -- Nulo quando o anexo e do proprio ticket
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    nome_arquivo VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    tamanho BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    chave_storage VARCHAR(512) UNIQUE NOT NULL, -- Onde o conteudo esta no backend de storage
+    data TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_anexos_ticket ON anexos (ticket_id);
@@ -0,0 +1 @@
+ALTER TABLE anexos DROP COLUMN IF EXISTS thumbnail_chave, DROP COLUMN IF EXISTS motivo_quarentena, DROP COLUMN IF EXISTS status;
\ No newline at end of file
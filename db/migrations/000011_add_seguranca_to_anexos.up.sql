@@ -0,0 +1,4 @@
+ALTER TABLE anexos
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'disponivel', -- 'disponivel' ou 'quarentena'
+    ADD COLUMN IF NOT EXISTS motivo_quarentena TEXT, -- Assinatura encontrada pelo antivirus
+    ADD COLUMN IF NOT EXISTS thumbnail_chave VARCHAR(512); -- Miniatura no storage, apenas para imagens
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    email_habilitado BOOLEAN NOT NULL DEFAULT TRUE,
+    idioma VARCHAR(10) NOT NULL DEFAULT 'pt-BR', -- Idioma dos templates de email
+    eventos_desativados TEXT[] NOT NULL DEFAULT '{}' -- Eventos que o usuario nao quer receber
+);
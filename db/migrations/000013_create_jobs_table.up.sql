@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS jobs (
+    id BIGSERIAL PRIMARY KEY,
+    tipo VARCHAR(100) NOT NULL, -- Define o handler que processa o job
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pendente', -- pendente, processando, concluido ou morto
+    tentativas INT NOT NULL DEFAULT 0,
+    max_tentativas INT NOT NULL DEFAULT 5,
+    disponivel_em TIMESTAMPTZ NOT NULL DEFAULT NOW(), -- So e reservado a partir deste instante (backoff)
+    reservado_em TIMESTAMPTZ, -- Quando um worker pegou o job; usado para recuperar jobs de workers que cairam
+    ultimo_erro TEXT,
+    criado_em TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    atualizado_em TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_pendentes ON jobs (disponivel_em, id) WHERE status = 'pendente';
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs (status, atualizado_em);
@@ -0,0 +1,2 @@
+ALTER TABLE jobs DROP COLUMN IF EXISTS chave;
+DROP TABLE IF EXISTS outbox_events;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    tipo VARCHAR(100) NOT NULL, -- ticket.created, ticket.commented, ...
+    ticket_id BIGINT NOT NULL, -- Sem FK: o evento de exclusao sobrevive ao ticket
+    ator_id BIGINT, -- Usuario que causou o evento
+    dados JSONB NOT NULL,
+    criado_em TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    publicado_em TIMESTAMPTZ -- Preenchido pelo relay depois de entregar a todos os assinantes
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pendentes ON outbox_events (id) WHERE publicado_em IS NULL;
+
+-- Chave de idempotencia dos jobs: o relay entrega cada evento pelo menos uma
+-- vez, e a chave evita enfileirar a mesma notificacao duas vezes.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS chave VARCHAR(200) UNIQUE;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    id BIGSERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    eventos TEXT[] NOT NULL, -- Tipos de evento assinados, ex: ticket.created
+    segredo TEXT NOT NULL, -- Chave do HMAC-SHA256 enviado em X-Helpdesk-Signature
+    ativo BOOLEAN NOT NULL DEFAULT TRUE,
+    incluir_internos BOOLEAN NOT NULL DEFAULT FALSE, -- Envia tambem eventos de notas internas
+    criado_por BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    criado_em TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id BIGINT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    evento_id BIGINT NOT NULL, -- ID do evento da outbox; zero no evento de teste
+    tipo VARCHAR(100) NOT NULL,
+    tentativa INT NOT NULL,
+    status_code INT, -- Nulo quando nao houve resposta (timeout, DNS...)
+    resposta TEXT, -- Inicio do corpo da resposta, para diagnostico
+    erro TEXT,
+    duracao_ms BIGINT NOT NULL,
+    sucesso BOOLEAN NOT NULL,
+    data TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, id DESC);
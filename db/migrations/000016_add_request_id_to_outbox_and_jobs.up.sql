@@ -0,0 +1,4 @@
+-- ID da requisicao que gravou o evento ou enfileirou o job, para achar nos
+-- logs tudo o que uma requisicao causou, inclusive o que rodou depois.
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS request_id VARCHAR(128);
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS request_id VARCHAR(128);
@@ -0,0 +1,3 @@
+-- Quem realmente causou o evento quando o ator_id foi personificado por um
+-- admin. Nulo fora da personificacao.
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS ator_real_id BIGINT;
@@ -0,0 +1,85 @@
+// Package auditoria registra as acoes de escrita feitas nos servicos. O
+// middleware de autenticacao de cada servico guarda no contexto quem fez a
+// requisicao (ComAtor); daqui saem o log de auditoria das rotas HTTP e o ator
+// real gravado junto com os eventos de dominio.
+package auditoria
+
+import (
+	"context"
+	"helpdesk/pkg/registro"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+type chave int
+
+const chaveAtor chave = iota
+
+// Entrada e uma acao de escrita registrada pelo middleware de auditoria.
+// ActorID difere de UserID quando a acao foi feita sob personificacao.
+type Entrada struct {
+	ID      int64     `json:"id"`
+	ActorID int64     `json:"actor_id"`
+	UserID  int64     `json:"user_id"`
+	Servico string    `json:"servico"`
+	Metodo  string    `json:"metodo"`
+	Rota    string    `json:"rota"`
+	Caminho string    `json:"caminho"`
+	Status  int       `json:"status"`
+	Data    time.Time `json:"data"`
+}
+
+type ator struct {
+	usuarioID int64
+	realID    int64
+}
+
+// ComAtor guarda no contexto o usuario em nome de quem a requisicao e feita e
+// quem realmente a fez; os dois so diferem sob personificacao.
+func ComAtor(ctx context.Context, userID, actorID int64) context.Context {
+	return context.WithValue(ctx, chaveAtor, ator{usuarioID: userID, realID: actorID})
+}
+
+// Ator devolve o usuario e o ator real guardados por ComAtor, ou zeros se a
+// requisicao nao foi autenticada.
+func Ator(ctx context.Context) (userID, actorID int64) {
+	a, _ := ctx.Value(chaveAtor).(ator)
+	return a.usuarioID, a.realID
+}
+
+// HTTP registra toda acao de escrita (POST, PUT, PATCH e DELETE) feita por um
+// usuario autenticado. Deve ser usado depois do middleware de autenticacao do
+// servico, que chama ComAtor.
+func HTTP(servico string, registrar func(Entrada) error) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			userID, actorID := Ator(r.Context())
+			entrada := Entrada{
+				ActorID: actorID,
+				UserID:  userID,
+				Servico: servico,
+				Metodo:  r.Method,
+				Caminho: r.URL.Path,
+				Status:  ww.Status(),
+			}
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				entrada.Rota = rctx.RoutePattern()
+			}
+
+			if err := registrar(entrada); err != nil {
+				registro.Logger(r.Context()).Error("Erro ao registrar log de auditoria", "erro", err)
+			}
+		})
+	}
+}
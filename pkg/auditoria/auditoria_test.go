@@ -0,0 +1,44 @@
+package auditoria
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTP_RegistraEscritasComAtorReal(t *testing.T) {
+	var registradas []Entrada
+	registrar := func(e Entrada) error {
+		registradas = append(registradas, e)
+		return nil
+	}
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		// Faz as vezes da autenticacao: o admin 1 personifica o usuario 2.
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(ComAtor(r.Context(), 2, 1)))
+		})
+	})
+	router.Use(HTTP("tickets-service", registrar))
+	router.Get("/tickets/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	router.Delete("/tickets/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/tickets/7", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/tickets/7", nil))
+
+	if assert.Len(t, registradas, 1, "leituras nao sao auditadas") {
+		e := registradas[0]
+		assert.Equal(t, int64(1), e.ActorID)
+		assert.Equal(t, int64(2), e.UserID)
+		assert.Equal(t, "tickets-service", e.Servico)
+		assert.Equal(t, "/tickets/{id}", e.Rota)
+		assert.Equal(t, "/tickets/7", e.Caminho)
+		assert.Equal(t, http.StatusNoContent, e.Status)
+	}
+}
@@ -0,0 +1,563 @@
+// Package config e a configuracao tipada comum aos servicos do helpdesk.
+// Cada valor vem, em ordem crescente de prioridade, do padrao do servico, de
+// um arquivo JSON (-config ou CONFIG_ARQUIVO), das variaveis de ambiente e
+// das flags da linha de comando. O resultado e validado antes de o servico
+// subir e pode ser impresso com os segredos ocultos.
+//
+// Cada campo folha declara sua chave no arquivo (tag json, aninhada pela
+// secao), a variavel de ambiente (tag env) e a flag (tag flag). Campos com a
+// tag segredo nunca sao impressos.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TicketsService = "tickets-service"
+	UsersService   = "users-service"
+)
+
+// Config reune o que os servicos precisam para subir. Nem todo servico usa
+// todas as secoes: o users-service ignora Users, Workers, Fila, SMTP,
+// Notificacoes, Email, Outbox, Anexos, S3 e Scanner, e o tickets-service
+// ignora ServicoClientes.
+type Config struct {
+	Servico         string          `json:"-"`
+	Enderecos       Enderecos       `json:"enderecos"`
+	DB              DB              `json:"db"`
+	JWT             JWT             `json:"jwt"`
+	ServicoClientes ServicoClientes `json:"servico_clientes"`
+	Users           Users           `json:"users"`
+	Workers         Workers         `json:"workers"`
+	Fila            Fila            `json:"fila"`
+	SMTP            SMTP            `json:"smtp"`
+	Notificacoes    Notificacoes    `json:"notificacoes"`
+	Email           Email           `json:"email"`
+	Outbox          Outbox          `json:"outbox"`
+	Anexos          Anexos          `json:"anexos"`
+	S3              S3              `json:"s3"`
+	Scanner         Scanner         `json:"scanner"`
+	Desligamento    Desligamento    `json:"desligamento"`
+	Rastreio        Rastreio        `json:"rastreio"`
+	Log             Log             `json:"log"`
+}
+
+// Enderecos sao os enderecos em que o servico escuta.
+type Enderecos struct {
+	HTTP string `json:"http" env:"HTTP_ENDERECO" flag:"http"`
+	GRPC string `json:"grpc" env:"GRPC_ENDERECO" flag:"grpc"`
+}
+
+// DB e a conexao com o PostgreSQL e o tamanho do pool.
+type DB struct {
+	URL            string        `json:"url" env:"CHAVEDB" flag:"db-url" segredo:"url"`
+	MaxConexoes    int32         `json:"max_conexoes" env:"DB_MAX_CONEXOES" flag:"db-max-conexoes"`
+	MinConexoes    int32         `json:"min_conexoes" env:"DB_MIN_CONEXOES" flag:"db-min-conexoes"`
+	VidaMaxConexao time.Duration `json:"vida_max_conexao" env:"DB_VIDA_MAX_CONEXAO" flag:"db-vida-max-conexao"`
+	OciosaMax      time.Duration `json:"ociosa_max" env:"DB_OCIOSA_MAX" flag:"db-ociosa-max"`
+}
+
+// JWT sao as chaves dos tokens de usuario e de servico.
+type JWT struct {
+	Segredo  string        `json:"segredo" env:"SEGREDOJWT" flag:"jwt-segredo" segredo:"true"`
+	Validade time.Duration `json:"validade" env:"JWT_VALIDADE" flag:"jwt-validade"`
+	// SegredoServicos assina os tokens de servico; so o users-service usa.
+	SegredoServicos string `json:"segredo_servicos" env:"SEGREDO_TOKENS_SERVICO" flag:"jwt-segredo-servicos" segredo:"true"`
+}
+
+// ServicoClientes sao os servicos internos que podem pedir tokens ao
+// users-service.
+type ServicoClientes struct {
+	// Clientes e uma lista separada por virgula de "id:segredo:escopos", por
+	// exemplo "tickets-service:s3nha:usuarios.ler".
+	Clientes string `json:"clientes" env:"SERVICOS_CLIENTES" flag:"servicos-clientes" segredo:"true"`
+}
+
+// Users e o users-service visto pelos outros servicos, e a identidade com
+// que eles o chamam.
+type Users struct {
+	GRPC           string `json:"grpc" env:"USERS_GRPC_ENDERECO" flag:"users-grpc"`
+	URLToken       string `json:"url_token" env:"USERS_TOKEN_URL" flag:"users-url-token"`
+	ServicoID      string `json:"servico_id" env:"SERVICO_ID" flag:"servico-id"`
+	ServicoSegredo string `json:"servico_segredo" env:"SERVICO_SEGREDO" flag:"servico-segredo" segredo:"true"`
+	// Timeout e o prazo de cada chamada, incluindo as repeticoes; Tentativas
+	// vai de 1 a 5.
+	Timeout    time.Duration `json:"timeout" env:"USERS_GRPC_TIMEOUT" flag:"users-timeout"`
+	Tentativas int           `json:"tentativas" env:"USERS_GRPC_TENTATIVAS" flag:"users-tentativas"`
+	// CacheTTL e a validade dos perfis no cache de nomes.
+	CacheTTL time.Duration `json:"cache_ttl" env:"USERS_CACHE_TTL" flag:"users-cache-ttl"`
+	// Apos DisjuntorFalhas chamadas seguidas sem resposta, as consultas
+	// falham na hora por DisjuntorEspera.
+	DisjuntorFalhas int           `json:"disjuntor_falhas" env:"USERS_DISJUNTOR_FALHAS" flag:"users-disjuntor-falhas"`
+	DisjuntorEspera time.Duration `json:"disjuntor_espera" env:"USERS_DISJUNTOR_ESPERA" flag:"users-disjuntor-espera"`
+}
+
+// Workers e o tamanho dos pools de processamento em segundo plano.
+type Workers struct {
+	Fila int `json:"fila" env:"FILA_WORKERS" flag:"workers-fila"`
+}
+
+// Fila ajusta as retentativas e a manutencao da fila persistente de jobs.
+type Fila struct {
+	Intervalo     time.Duration `json:"intervalo" env:"FILA_INTERVALO" flag:"fila-intervalo"`
+	MaxTentativas int           `json:"max_tentativas" env:"FILA_MAX_TENTATIVAS" flag:"fila-max-tentativas"`
+	// A espera apos a primeira falha e BackoffBase e dobra a cada tentativa,
+	// ate BackoffMax.
+	BackoffBase time.Duration `json:"backoff_base" env:"FILA_BACKOFF_BASE" flag:"fila-backoff-base"`
+	BackoffMax  time.Duration `json:"backoff_max" env:"FILA_BACKOFF_MAX" flag:"fila-backoff-max"`
+	// Lease e quanto um job pode ficar reservado antes de voltar a fila, e
+	// portanto o tempo maximo de cada execucao.
+	Lease time.Duration `json:"lease" env:"FILA_LEASE" flag:"fila-lease"`
+	// Retencao e por quanto tempo os jobs concluidos sao mantidos.
+	Retencao time.Duration `json:"retencao" env:"FILA_RETENCAO" flag:"fila-retencao"`
+}
+
+// SMTP e o servidor que envia as notificacoes. Sem Endereco, os emails so
+// sao registrados no log.
+type SMTP struct {
+	Endereco  string `json:"endereco" env:"SMTP_ENDERECO" flag:"smtp-endereco"`
+	Remetente string `json:"remetente" env:"SMTP_REMETENTE" flag:"smtp-remetente"`
+	Usuario   string `json:"usuario" env:"SMTP_USUARIO" flag:"smtp-usuario"`
+	Senha     string `json:"senha" env:"SMTP_SENHA" flag:"smtp-senha" segredo:"true"`
+}
+
+// Notificacoes e o conteudo dos emails de notificacao.
+type Notificacoes struct {
+	// URLBase e o endereco do helpdesk usado nos links para o ticket.
+	URLBase string `json:"url_base" env:"NOTIFICACOES_URL_BASE" flag:"notificacoes-url-base"`
+}
+
+// Email sao as fontes de emails de entrada, que viram tickets e
+// comentarios. Cada uma e opcional.
+type Email struct {
+	Maildir   string        `json:"maildir" env:"EMAIL_MAILDIR" flag:"email-maildir"`
+	Mbox      string        `json:"mbox" env:"EMAIL_MBOX" flag:"email-mbox"`
+	Intervalo time.Duration `json:"intervalo" env:"EMAIL_INTERVALO" flag:"email-intervalo"`
+	// SMTPEndereco liga o servidor SMTP de entrada, que so aceita
+	// destinatarios dos SMTPDominios (separados por virgula).
+	SMTPEndereco string `json:"smtp_endereco" env:"EMAIL_SMTP_ENDERECO" flag:"email-smtp-endereco"`
+	SMTPHostname string `json:"smtp_hostname" env:"EMAIL_SMTP_HOSTNAME" flag:"email-smtp-hostname"`
+	SMTPDominios string `json:"smtp_dominios" env:"EMAIL_SMTP_DOMINIOS" flag:"email-smtp-dominios"`
+	// Autenticador e o authserv-id do MTA cujo Authentication-Results e
+	// confiavel; sem ele nenhum remetente tem direitos de agente.
+	Autenticador string `json:"autenticador" env:"EMAIL_AUTENTICADOR" flag:"email-autenticador"`
+}
+
+// Dominios devolve SMTPDominios como lista.
+func (e Email) Dominios() []string {
+	var dominios []string
+	for _, d := range strings.Split(e.SMTPDominios, ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			dominios = append(dominios, d)
+		}
+	}
+	return dominios
+}
+
+// Outbox sao os destinos extras dos eventos de dominio.
+type Outbox struct {
+	// SinkURL, se definida, recebe um POST com cada evento.
+	SinkURL string `json:"sink_url" env:"OUTBOX_SINK_URL" flag:"outbox-sink-url" segredo:"url"`
+}
+
+// Backends de anexos aceitos em Anexos.Backend.
+const (
+	AnexosLocal = "local"
+	AnexosS3    = "s3"
+)
+
+// Anexos e onde o conteudo dos anexos e guardado e o tamanho maximo aceito.
+type Anexos struct {
+	// Backend e local (em Dir) ou s3 (na secao S3).
+	Backend  string `json:"backend" env:"ANEXOS_BACKEND" flag:"anexos-backend"`
+	Dir      string `json:"dir" env:"ANEXOS_DIR" flag:"anexos-dir"`
+	MaxBytes int64  `json:"max_bytes" env:"ANEXOS_MAX_BYTES" flag:"anexos-max-bytes"`
+}
+
+// S3 e o bucket dos anexos quando Anexos.Backend e s3.
+type S3 struct {
+	Endpoint  string `json:"endpoint" env:"S3_ENDPOINT" flag:"s3-endpoint"`
+	Bucket    string `json:"bucket" env:"S3_BUCKET" flag:"s3-bucket"`
+	Regiao    string `json:"regiao" env:"S3_REGIAO" flag:"s3-regiao"`
+	AccessKey string `json:"access_key" env:"S3_ACCESS_KEY" flag:"s3-access-key"`
+	SecretKey string `json:"secret_key" env:"S3_SECRET_KEY" flag:"s3-secret-key" segredo:"true"`
+}
+
+// Scanner e o antivirus dos anexos. Sem ClamdEndereco os anexos nao sao
+// verificados.
+type Scanner struct {
+	// ClamdEndereco e host:porta ou o caminho de um socket unix.
+	ClamdEndereco string `json:"clamd_endereco" env:"CLAMD_ENDERECO" flag:"clamd-endereco"`
+}
+
+// Desligamento controla o encerramento ao receber SIGTERM ou SIGINT.
+type Desligamento struct {
+	// Espera e quanto tempo o /readyz responde 503 antes de o servidor parar
+	// de aceitar conexoes, para o balanceador tirar a replica de rotacao.
+	Espera time.Duration `json:"espera" env:"DESLIGAMENTO_ESPERA" flag:"desligamento-espera"`
+	// Prazo limita a espera pelas requisicoes e jobs em andamento.
+	Prazo time.Duration `json:"prazo" env:"DESLIGAMENTO_PRAZO" flag:"desligamento-prazo"`
+}
+
+// Exportadores de spans aceitos em Rastreio.Exportador.
+const (
+	RastreioNenhum = "nenhum"
+	RastreioStdout = "stdout"
+	RastreioOTLP   = "otlp"
+)
+
+// Rastreio e o envio dos spans do OpenTelemetry. A amostragem segue as
+// variaveis padrao do SDK (OTEL_TRACES_SAMPLER e OTEL_TRACES_SAMPLER_ARG).
+type Rastreio struct {
+	// Exportador e nenhum, stdout (para depurar localmente) ou otlp.
+	Exportador string `json:"exportador" env:"RASTREIO_EXPORTADOR" flag:"rastreio-exportador"`
+	// Endpoint e a URL do coletor OTLP/gRPC, como http://otel-collector:4317.
+	// Vazio usa OTEL_EXPORTER_OTLP_ENDPOINT ou o padrao do SDK.
+	Endpoint string `json:"endpoint" env:"RASTREIO_ENDPOINT" flag:"rastreio-endpoint"`
+}
+
+// Log e o formato e o nivel minimo dos logs.
+type Log struct {
+	// Formato e json (padrao, para agregadores de log) ou texto.
+	Formato string `json:"formato" env:"LOG_FORMATO" flag:"log-formato"`
+	// Nivel e debug, info, warn ou error.
+	Nivel string `json:"nivel" env:"LOG_NIVEL" flag:"log-nivel"`
+}
+
+// Padrao devolve os valores padrao do servico.
+func Padrao(servico string) Config {
+	c := Config{
+		Servico: servico,
+		DB: DB{
+			MaxConexoes:    10,
+			MinConexoes:    0,
+			VidaMaxConexao: time.Hour,
+			OciosaMax:      30 * time.Minute,
+		},
+		JWT: JWT{Validade: time.Hour},
+		Users: Users{
+			GRPC:            "users-service:9092",
+			URLToken:        "http://users-service:8082/services/token",
+			ServicoID:       servico,
+			Timeout:         2 * time.Second,
+			Tentativas:      3,
+			CacheTTL:        5 * time.Minute,
+			DisjuntorFalhas: 5,
+			DisjuntorEspera: 30 * time.Second,
+		},
+		Workers: Workers{Fila: 3},
+		Fila: Fila{
+			Intervalo:     time.Second,
+			MaxTentativas: 5,
+			BackoffBase:   10 * time.Second,
+			BackoffMax:    time.Hour,
+			Lease:         5 * time.Minute,
+			Retencao:      7 * 24 * time.Hour,
+		},
+		Email:        Email{Intervalo: 30 * time.Second},
+		Anexos:       Anexos{Backend: AnexosLocal, Dir: "data/anexos", MaxBytes: 10 << 20},
+		Desligamento: Desligamento{Prazo: 30 * time.Second},
+		Rastreio:     Rastreio{Exportador: RastreioNenhum},
+		Log:          Log{Formato: "json", Nivel: "info"},
+	}
+	switch servico {
+	case UsersService:
+		c.Enderecos = Enderecos{HTTP: ":8082", GRPC: ":9092"}
+	default:
+		c.Enderecos = Enderecos{HTTP: ":8080", GRPC: ":9090"}
+	}
+	return c
+}
+
+// Carregar monta a configuracao do servico a partir dos padroes, do arquivo,
+// do ambiente e dos argumentos (normalmente os.Args[1:]), e a valida.
+func Carregar(servico string, args []string) (Config, error) {
+	c := Padrao(servico)
+
+	fs := flag.NewFlagSet(servico, flag.ContinueOnError)
+	arquivo := fs.String("config", os.Getenv("CONFIG_ARQUIVO"), "arquivo JSON de configuracao")
+	flags := map[string]*string{}
+	percorrer(&c, func(caminho string, campo reflect.StructField, _ reflect.Value) {
+		if nome := campo.Tag.Get("flag"); nome != "" {
+			flags[nome] = fs.String(nome, "", fmt.Sprintf("%s (env %s)", caminho, campo.Tag.Get("env")))
+		}
+	})
+	if err := fs.Parse(args); err != nil {
+		return c, err
+	}
+
+	if *arquivo != "" {
+		if err := c.lerArquivo(*arquivo); err != nil {
+			return c, err
+		}
+	}
+
+	var erros []error
+	percorrer(&c, func(caminho string, campo reflect.StructField, v reflect.Value) {
+		if env := campo.Tag.Get("env"); env != "" {
+			if valor, ok := os.LookupEnv(env); ok && valor != "" {
+				if err := definir(v, valor); err != nil {
+					erros = append(erros, fmt.Errorf("%s: %w", env, err))
+				}
+			}
+		}
+	})
+	definidas := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { definidas[f.Name] = true })
+	percorrer(&c, func(caminho string, campo reflect.StructField, v reflect.Value) {
+		if nome := campo.Tag.Get("flag"); definidas[nome] {
+			if err := definir(v, *flags[nome]); err != nil {
+				erros = append(erros, fmt.Errorf("-%s: %w", nome, err))
+			}
+		}
+	})
+	if len(erros) > 0 {
+		return c, errors.Join(erros...)
+	}
+
+	return c, c.Validar()
+}
+
+// lerArquivo aplica o arquivo JSON sobre os padroes. Chaves desconhecidas
+// sao um erro, para que um erro de digitacao nao passe despercebido.
+func (c *Config) lerArquivo(caminho string) error {
+	conteudo, err := os.ReadFile(caminho)
+	if err != nil {
+		return fmt.Errorf("erro ao ler o arquivo de configuracao: %w", err)
+	}
+	var arvore map[string]any
+	dec := json.NewDecoder(bytes.NewReader(conteudo))
+	dec.UseNumber()
+	if err := dec.Decode(&arvore); err != nil {
+		return fmt.Errorf("arquivo de configuracao %s invalido: %w", caminho, err)
+	}
+
+	valores := map[string]string{}
+	achatar("", arvore, valores)
+
+	var erros []error
+	percorrer(c, func(chave string, _ reflect.StructField, v reflect.Value) {
+		if valor, ok := valores[chave]; ok {
+			delete(valores, chave)
+			if err := definir(v, valor); err != nil {
+				erros = append(erros, fmt.Errorf("%s: %s: %w", caminho, chave, err))
+			}
+		}
+	})
+	for chave := range valores {
+		erros = append(erros, fmt.Errorf("%s: chave desconhecida %s", caminho, chave))
+	}
+	return errors.Join(erros...)
+}
+
+// achatar transforma {"db": {"url": "..."}} em {"db.url": "..."}.
+func achatar(prefixo string, arvore map[string]any, valores map[string]string) {
+	for k, v := range arvore {
+		chave := k
+		if prefixo != "" {
+			chave = prefixo + "." + k
+		}
+		if filho, ok := v.(map[string]any); ok {
+			achatar(chave, filho, valores)
+			continue
+		}
+		valores[chave] = fmt.Sprint(v)
+	}
+}
+
+// Validar confere o que o servico precisa para subir e devolve todos os
+// problemas juntos.
+func (c Config) Validar() error {
+	var erros []error
+	checar := func(ok bool, formato string, args ...any) {
+		if !ok {
+			erros = append(erros, fmt.Errorf(formato, args...))
+		}
+	}
+
+	endereco := func(chave, valor string) {
+		_, _, err := net.SplitHostPort(valor)
+		checar(err == nil, "%s invalido: %q", chave, valor)
+	}
+	urlOpcional := func(chave, valor string) {
+		if valor == "" {
+			return
+		}
+		u, err := url.Parse(valor)
+		checar(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "%s invalida: %q", chave, ocultar("url", reflect.ValueOf(valor)))
+	}
+	endereco("enderecos.http", c.Enderecos.HTTP)
+	endereco("enderecos.grpc", c.Enderecos.GRPC)
+
+	checar(c.DB.URL != "", "db.url (CHAVEDB) e obrigatorio")
+	checar(c.DB.MaxConexoes > 0, "db.max_conexoes deve ser positivo")
+	checar(c.DB.MinConexoes >= 0 && c.DB.MinConexoes <= c.DB.MaxConexoes, "db.min_conexoes deve estar entre 0 e db.max_conexoes")
+	checar(c.DB.VidaMaxConexao > 0 && c.DB.OciosaMax > 0, "db.vida_max_conexao e db.ociosa_max devem ser positivos")
+
+	checar(c.JWT.Segredo != "", "jwt.segredo (SEGREDOJWT) e obrigatorio")
+	checar(c.JWT.Validade > 0, "jwt.validade deve ser positiva")
+	checar(c.Desligamento.Espera >= 0 && c.Desligamento.Prazo > 0, "desligamento.espera nao pode ser negativa e desligamento.prazo deve ser positivo")
+	switch c.Rastreio.Exportador {
+	case RastreioNenhum, RastreioStdout, RastreioOTLP:
+	default:
+		checar(false, "rastreio.exportador invalido, use nenhum, stdout ou otlp: %q", c.Rastreio.Exportador)
+	}
+	checar(c.Log.Formato == "json" || c.Log.Formato == "texto", "log.formato invalido, use json ou texto: %q", c.Log.Formato)
+	var nivel slog.Level
+	checar(nivel.UnmarshalText([]byte(c.Log.Nivel)) == nil, "log.nivel invalido, use debug, info, warn ou error: %q", c.Log.Nivel)
+
+	if c.Servico == UsersService {
+		// Sem eles a API gRPC interna recusaria todas as chamadas.
+		checar(c.JWT.SegredoServicos != "", "jwt.segredo_servicos (SEGREDO_TOKENS_SERVICO) e obrigatorio")
+		checar(c.ServicoClientes.Clientes != "", "servico_clientes.clientes (SERVICOS_CLIENTES) e obrigatorio")
+	}
+
+	if c.Servico == TicketsService {
+		endereco("users.grpc", c.Users.GRPC)
+		u, err := url.Parse(c.Users.URLToken)
+		checar(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "users.url_token invalida: %q", c.Users.URLToken)
+		checar(c.Users.ServicoID != "", "users.servico_id e obrigatorio")
+		checar(c.Workers.Fila > 0, "workers.fila deve ser positivo")
+		checar(c.Users.Timeout > 0 && c.Users.CacheTTL > 0, "users.timeout e users.cache_ttl devem ser positivos")
+		checar(c.Users.Tentativas >= 1 && c.Users.Tentativas <= 5, "users.tentativas deve estar entre 1 e 5")
+		checar(c.Users.DisjuntorFalhas > 0 && c.Users.DisjuntorEspera > 0, "users.disjuntor_falhas e users.disjuntor_espera devem ser positivos")
+
+		checar(c.Fila.MaxTentativas > 0, "fila.max_tentativas deve ser positivo")
+		checar(c.Fila.Intervalo > 0 && c.Fila.Lease > 0 && c.Fila.Retencao > 0, "fila.intervalo, fila.lease e fila.retencao devem ser positivos")
+		checar(c.Fila.BackoffBase > 0 && c.Fila.BackoffBase <= c.Fila.BackoffMax, "fila.backoff_base deve ser positivo e no maximo fila.backoff_max")
+
+		if c.SMTP.Endereco != "" {
+			endereco("smtp.endereco", c.SMTP.Endereco)
+		}
+		if c.SMTP.Remetente != "" {
+			_, err := mail.ParseAddress(c.SMTP.Remetente)
+			checar(err == nil, "smtp.remetente invalido: %q", c.SMTP.Remetente)
+		}
+		checar(c.SMTP.Usuario == "" || c.SMTP.Senha != "", "smtp.senha (SMTP_SENHA) e obrigatoria com smtp.usuario")
+		urlOpcional("notificacoes.url_base", c.Notificacoes.URLBase)
+
+		checar(c.Email.Intervalo > 0, "email.intervalo deve ser positivo")
+		if c.Email.SMTPEndereco != "" {
+			endereco("email.smtp_endereco", c.Email.SMTPEndereco)
+			checar(len(c.Email.Dominios()) > 0, "email.smtp_dominios e obrigatorio com email.smtp_endereco")
+		}
+		urlOpcional("outbox.sink_url", c.Outbox.SinkURL)
+
+		checar(c.Anexos.MaxBytes > 0, "anexos.max_bytes deve ser positivo")
+		switch c.Anexos.Backend {
+		case AnexosLocal:
+			checar(c.Anexos.Dir != "", "anexos.dir e obrigatorio com o backend local")
+		case AnexosS3:
+			u, err := url.Parse(c.S3.Endpoint)
+			checar(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "s3.endpoint invalido: %q", c.S3.Endpoint)
+			checar(c.S3.Bucket != "", "s3.bucket e obrigatorio com o backend s3")
+			checar(c.S3.AccessKey != "" && c.S3.SecretKey != "", "s3.access_key e s3.secret_key (S3_SECRET_KEY) sao obrigatorios com o backend s3")
+		default:
+			checar(false, "anexos.backend invalido, use local ou s3: %q", c.Anexos.Backend)
+		}
+		if c.Scanner.ClamdEndereco != "" && !strings.HasPrefix(c.Scanner.ClamdEndereco, "/") {
+			endereco("scanner.clamd_endereco", c.Scanner.ClamdEndereco)
+		}
+	}
+	return errors.Join(erros...)
+}
+
+// String lista a configuracao, uma chave por linha, com os segredos ocultos.
+// E o que os servicos imprimem ao subir.
+func (c Config) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "servico = %s", c.Servico)
+	percorrer(&c, func(chave string, campo reflect.StructField, v reflect.Value) {
+		fmt.Fprintf(&b, "\n%s = %s", chave, ocultar(campo.Tag.Get("segredo"), v))
+	})
+	return b.String()
+}
+
+func ocultar(segredo string, v reflect.Value) string {
+	valor := fmt.Sprint(v.Interface())
+	switch {
+	case segredo == "" || valor == "":
+		return valor
+	case segredo == "url":
+		// Mostra host e banco, mas nao a senha.
+		if u, err := url.Parse(valor); err == nil && u.Scheme != "" {
+			return u.Redacted()
+		}
+	}
+	return "***"
+}
+
+// percorrer chama fn para cada campo folha, com a chave pontuada do arquivo.
+func percorrer(c *Config, fn func(chave string, campo reflect.StructField, v reflect.Value)) {
+	var visitar func(prefixo string, v reflect.Value)
+	visitar = func(prefixo string, v reflect.Value) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			campo := t.Field(i)
+			nome := strings.Split(campo.Tag.Get("json"), ",")[0]
+			if nome == "-" {
+				continue
+			}
+			chave := nome
+			if prefixo != "" {
+				chave = prefixo + "." + nome
+			}
+			if campo.Type.Kind() == reflect.Struct {
+				visitar(chave, v.Field(i))
+				continue
+			}
+			fn(chave, campo, v.Field(i))
+		}
+	}
+	visitar("", reflect.ValueOf(c).Elem())
+}
+
+var tipoDuracao = reflect.TypeOf(time.Duration(0))
+
+// definir converte o texto para o tipo do campo. Duracoes aceitam o formato
+// do time.ParseDuration ("30s", "5m").
+func definir(v reflect.Value, valor string) error {
+	valor = strings.TrimSpace(valor)
+	if v.Type() == tipoDuracao {
+		d, err := time.ParseDuration(valor)
+		if err != nil {
+			return fmt.Errorf("duracao invalida %q", valor)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(valor)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(valor, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("numero invalido %q", valor)
+		}
+		v.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(valor)
+		if err != nil {
+			return fmt.Errorf("booleano invalido %q", valor)
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("tipo nao suportado %s", v.Type())
+	}
+	return nil
+}
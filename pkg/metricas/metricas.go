@@ -0,0 +1,61 @@
+// Package metricas expoe as metricas Prometheus comuns aos servicos: contagem
+// e latencia das requisicoes HTTP por rota e o estado do pool de conexoes do
+// PostgreSQL. Cada servico registra as suas proprias metricas no mesmo
+// registro padrao, e o Handler serve todas em /metrics.
+package metricas
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// rotaDesconhecida agrupa as requisicoes que nao casaram com nenhuma rota,
+// para que URLs arbitrarias nao criem uma serie nova cada uma.
+const rotaDesconhecida = "desconhecida"
+
+var (
+	requisicoes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "helpdesk_http_requisicoes_total",
+		Help: "Requisicoes HTTP atendidas, por metodo, rota e status.",
+	}, []string{"metodo", "rota", "status"})
+
+	duracao = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "helpdesk_http_duracao_segundos",
+		Help:    "Latencia das requisicoes HTTP, por metodo e rota.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"metodo", "rota"})
+)
+
+// Handler serve as metricas de todo o processo no formato do Prometheus.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// HTTP mede cada requisicao pelo padrao da rota do chi ("/tickets/{id}"), e
+// nao pela URL, para manter o numero de series pequeno. Deve ser o primeiro
+// middleware do router: o padrao so e conhecido depois que o chi roteou.
+func HTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inicio := time.Now()
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		rota, metodo := rotaDesconhecida, "OUTRO"
+		if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" {
+			rota, metodo = rc.RoutePattern(), r.Method
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		requisicoes.WithLabelValues(metodo, rota, strconv.Itoa(status)).Inc()
+		duracao.WithLabelValues(metodo, rota).Observe(time.Since(inicio).Seconds())
+	})
+}
@@ -0,0 +1,69 @@
+package metricas
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ColetorPool le as estatisticas do pgxpool a cada coleta. Conexoes em uso
+// perto do maximo e aquisicoes que precisaram esperar indicam pool saturado.
+type ColetorPool struct {
+	pool *pgxpool.Pool
+
+	conexoes   *prometheus.Desc
+	maximo     *prometheus.Desc
+	aquisicoes *prometheus.Desc
+	espera     *prometheus.Desc
+	vazias     *prometheus.Desc
+	canceladas *prometheus.Desc
+	criadas    *prometheus.Desc
+	destruidas *prometheus.Desc
+}
+
+func NovoColetorPool(pool *pgxpool.Pool) *ColetorPool {
+	return &ColetorPool{
+		pool: pool,
+		conexoes: prometheus.NewDesc("helpdesk_db_conexoes",
+			"Conexoes do pool por estado: em_uso, ociosas ou construindo.", []string{"estado"}, nil),
+		maximo: prometheus.NewDesc("helpdesk_db_conexoes_max",
+			"Tamanho maximo do pool.", nil, nil),
+		aquisicoes: prometheus.NewDesc("helpdesk_db_aquisicoes_total",
+			"Conexoes obtidas do pool.", nil, nil),
+		espera: prometheus.NewDesc("helpdesk_db_aquisicoes_espera_segundos_total",
+			"Tempo total gasto obtendo conexoes do pool.", nil, nil),
+		vazias: prometheus.NewDesc("helpdesk_db_aquisicoes_vazias_total",
+			"Aquisicoes que encontraram o pool sem conexao livre e tiveram de esperar.", nil, nil),
+		canceladas: prometheus.NewDesc("helpdesk_db_aquisicoes_canceladas_total",
+			"Aquisicoes canceladas pelo contexto antes de obter uma conexao.", nil, nil),
+		criadas: prometheus.NewDesc("helpdesk_db_conexoes_criadas_total",
+			"Conexoes abertas pelo pool.", nil, nil),
+		destruidas: prometheus.NewDesc("helpdesk_db_conexoes_destruidas_total",
+			"Conexoes fechadas pelo pool, por motivo: vida_max ou ociosa_max.", []string{"motivo"}, nil),
+	}
+}
+
+func (c *ColetorPool) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.conexoes
+	ch <- c.maximo
+	ch <- c.aquisicoes
+	ch <- c.espera
+	ch <- c.vazias
+	ch <- c.canceladas
+	ch <- c.criadas
+	ch <- c.destruidas
+}
+
+func (c *ColetorPool) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.conexoes, prometheus.GaugeValue, float64(s.AcquiredConns()), "em_uso")
+	ch <- prometheus.MustNewConstMetric(c.conexoes, prometheus.GaugeValue, float64(s.IdleConns()), "ociosas")
+	ch <- prometheus.MustNewConstMetric(c.conexoes, prometheus.GaugeValue, float64(s.ConstructingConns()), "construindo")
+	ch <- prometheus.MustNewConstMetric(c.maximo, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.aquisicoes, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.espera, prometheus.CounterValue, s.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.vazias, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceladas, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.criadas, prometheus.CounterValue, float64(s.NewConnsCount()))
+	ch <- prometheus.MustNewConstMetric(c.destruidas, prometheus.CounterValue, float64(s.MaxLifetimeDestroyCount()), "vida_max")
+	ch <- prometheus.MustNewConstMetric(c.destruidas, prometheus.CounterValue, float64(s.MaxIdleDestroyCount()), "ociosa_max")
+}
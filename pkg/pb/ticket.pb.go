@@ -0,0 +1,1135 @@
+// API gRPC do tickets-service. Segue as mesmas regras de permissao da API
+// HTTP; o JWT do usuario vai no metadata "authorization" como "Bearer <token>".
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: ticket.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Ticket struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Titulo          string                 `protobuf:"bytes,2,opt,name=titulo,proto3" json:"titulo,omitempty"`
+	Descricao       string                 `protobuf:"bytes,3,opt,name=descricao,proto3" json:"descricao,omitempty"`
+	Status          string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	Diagnostico     string                 `protobuf:"bytes,5,opt,name=diagnostico,proto3" json:"diagnostico,omitempty"`
+	Solucao         string                 `protobuf:"bytes,6,opt,name=solucao,proto3" json:"solucao,omitempty"`
+	Prioridade      string                 `protobuf:"bytes,7,opt,name=prioridade,proto3" json:"prioridade,omitempty"`
+	DataAbertura    *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=data_abertura,json=dataAbertura,proto3" json:"data_abertura,omitempty"`
+	DataFechamento  *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=data_fechamento,json=dataFechamento,proto3" json:"data_fechamento,omitempty"`
+	DataAtualizacao *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=data_atualizacao,json=dataAtualizacao,proto3" json:"data_atualizacao,omitempty"`
+	Tags            []string               `protobuf:"bytes,11,rep,name=tags,proto3" json:"tags,omitempty"`
+	CategoriaId     int64                  `protobuf:"varint,12,opt,name=categoria_id,json=categoriaId,proto3" json:"categoria_id,omitempty"`
+	ResponsavelId   int64                  `protobuf:"varint,13,opt,name=responsavel_id,json=responsavelId,proto3" json:"responsavel_id,omitempty"`
+	UserId          int64                  `protobuf:"varint,14,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Cc              []int64                `protobuf:"varint,15,rep,packed,name=cc,proto3" json:"cc,omitempty"`
+	Watchers        []int64                `protobuf:"varint,16,rep,packed,name=watchers,proto3" json:"watchers,omitempty"`
+	// Perfis vindos do users-service.
+	Author        *Perfil `protobuf:"bytes,17,opt,name=author,proto3" json:"author,omitempty"`
+	Responsavel   *Perfil `protobuf:"bytes,18,opt,name=responsavel,proto3" json:"responsavel,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ticket) Reset() {
+	*x = Ticket{}
+	mi := &file_ticket_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ticket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ticket) ProtoMessage() {}
+
+func (x *Ticket) ProtoReflect() protoreflect.Message {
+	mi := &file_ticket_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ticket.ProtoReflect.Descriptor instead.
+func (*Ticket) Descriptor() ([]byte, []int) {
+	return file_ticket_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Ticket) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Ticket) GetTitulo() string {
+	if x != nil {
+		return x.Titulo
+	}
+	return ""
+}
+
+func (x *Ticket) GetDescricao() string {
+	if x != nil {
+		return x.Descricao
+	}
+	return ""
+}
+
+func (x *Ticket) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Ticket) GetDiagnostico() string {
+	if x != nil {
+		return x.Diagnostico
+	}
+	return ""
+}
+
+func (x *Ticket) GetSolucao() string {
+	if x != nil {
+		return x.Solucao
+	}
+	return ""
+}
+
+func (x *Ticket) GetPrioridade() string {
+	if x != nil {
+		return x.Prioridade
+	}
+	return ""
+}
+
+func (x *Ticket) GetDataAbertura() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DataAbertura
+	}
+	return nil
+}
+
+func (x *Ticket) GetDataFechamento() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DataFechamento
+	}
+	return nil
+}
+
+func (x *Ticket) GetDataAtualizacao() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DataAtualizacao
+	}
+	return nil
+}
+
+func (x *Ticket) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Ticket) GetCategoriaId() int64 {
+	if x != nil {
+		return x.CategoriaId
+	}
+	return 0
+}
+
+func (x *Ticket) GetResponsavelId() int64 {
+	if x != nil {
+		return x.ResponsavelId
+	}
+	return 0
+}
+
+func (x *Ticket) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Ticket) GetCc() []int64 {
+	if x != nil {
+		return x.Cc
+	}
+	return nil
+}
+
+func (x *Ticket) GetWatchers() []int64 {
+	if x != nil {
+		return x.Watchers
+	}
+	return nil
+}
+
+func (x *Ticket) GetAuthor() *Perfil {
+	if x != nil {
+		return x.Author
+	}
+	return nil
+}
+
+func (x *Ticket) GetResponsavel() *Perfil {
+	if x != nil {
+		return x.Responsavel
+	}
+	return nil
+}
+
+type Perfil struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Nome  string                 `protobuf:"bytes,2,opt,name=nome,proto3" json:"nome,omitempty"`
+	Email string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	// Perfil parcial, so com o ID, porque o users-service nao respondeu.
+	Indisponivel  bool `protobuf:"varint,4,opt,name=indisponivel,proto3" json:"indisponivel,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Perfil) Reset() {
+	*x = Perfil{}
+	mi := &file_ticket_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Perfil) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Perfil) ProtoMessage() {}
+
+func (x *Perfil) ProtoReflect() protoreflect.Message {
+	mi := &file_ticket_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Perfil.ProtoReflect.Descriptor instead.
+func (*Perfil) Descriptor() ([]byte, []int) {
+	return file_ticket_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Perfil) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Perfil) GetNome() string {
+	if x != nil {
+		return x.Nome
+	}
+	return ""
+}
+
+func (x *Perfil) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *Perfil) GetIndisponivel() bool {
+	if x != nil {
+		return x.Indisponivel
+	}
+	return false
+}
+
+type Comment struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	TicketId  int64                  `protobuf:"varint,2,opt,name=ticket_id,json=ticketId,proto3" json:"ticket_id,omitempty"`
+	UserId    int64                  `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Descricao string                 `protobuf:"bytes,4,opt,name=descricao,proto3" json:"descricao,omitempty"`
+	// "publico" ou "interno"; notas internas so podem ser criadas e lidas por agentes.
+	Tipo          string                 `protobuf:"bytes,5,opt,name=tipo,proto3" json:"tipo,omitempty"`
+	Data          *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+	Mencoes       []int64                `protobuf:"varint,7,rep,packed,name=mencoes,proto3" json:"mencoes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Comment) Reset() {
+	*x = Comment{}
+	mi := &file_ticket_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Comment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Comment) ProtoMessage() {}
+
+func (x *Comment) ProtoReflect() protoreflect.Message {
+	mi := &file_ticket_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Comment.ProtoReflect.Descriptor instead.
+func (*Comment) Descriptor() ([]byte, []int) {
+	return file_ticket_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Comment) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Comment) GetTicketId() int64 {
+	if x != nil {
+		return x.TicketId
+	}
+	return 0
+}
+
+func (x *Comment) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Comment) GetDescricao() string {
+	if x != nil {
+		return x.Descricao
+	}
+	return ""
+}
+
+func (x *Comment) GetTipo() string {
+	if x != nil {
+		return x.Tipo
+	}
+	return ""
+}
+
+func (x *Comment) GetData() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Comment) GetMencoes() []int64 {
+	if x != nil {
+		return x.Mencoes
+	}
+	return nil
+}
+
+type CreateTicketRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Titulo        string                 `protobuf:"bytes,1,opt,name=titulo,proto3" json:"titulo,omitempty"`
+	Descricao     string                 `protobuf:"bytes,2,opt,name=descricao,proto3" json:"descricao,omitempty"`
+	Prioridade    string                 `protobuf:"bytes,3,opt,name=prioridade,proto3" json:"prioridade,omitempty"`
+	CategoriaId   int64                  `protobuf:"varint,4,opt,name=categoria_id,json=categoriaId,proto3" json:"categoria_id,omitempty"`
+	Tags          []string               `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTicketRequest) Reset() {
+	*x = CreateTicketRequest{}
+	mi := &file_ticket_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTicketRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTicketRequest) ProtoMessage() {}
+
+func (x *CreateTicketRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ticket_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTicketRequest.ProtoReflect.Descriptor instead.
+func (*CreateTicketRequest) Descriptor() ([]byte, []int) {
+	return file_ticket_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateTicketRequest) GetTitulo() string {
+	if x != nil {
+		return x.Titulo
+	}
+	return ""
+}
+
+func (x *CreateTicketRequest) GetDescricao() string {
+	if x != nil {
+		return x.Descricao
+	}
+	return ""
+}
+
+func (x *CreateTicketRequest) GetPrioridade() string {
+	if x != nil {
+		return x.Prioridade
+	}
+	return ""
+}
+
+func (x *CreateTicketRequest) GetCategoriaId() int64 {
+	if x != nil {
+		return x.CategoriaId
+	}
+	return 0
+}
+
+func (x *CreateTicketRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type GetTicketRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTicketRequest) Reset() {
+	*x = GetTicketRequest{}
+	mi := &file_ticket_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTicketRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTicketRequest) ProtoMessage() {}
+
+func (x *GetTicketRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ticket_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTicketRequest.ProtoReflect.Descriptor instead.
+func (*GetTicketRequest) Descriptor() ([]byte, []int) {
+	return file_ticket_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetTicketRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+// Campos vazios ou zerados sao ignorados.
+type ListTicketsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Prioridade    string                 `protobuf:"bytes,2,opt,name=prioridade,proto3" json:"prioridade,omitempty"`
+	CategoriaId   int64                  `protobuf:"varint,3,opt,name=categoria_id,json=categoriaId,proto3" json:"categoria_id,omitempty"`
+	ResponsavelId int64                  `protobuf:"varint,4,opt,name=responsavel_id,json=responsavelId,proto3" json:"responsavel_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTicketsRequest) Reset() {
+	*x = ListTicketsRequest{}
+	mi := &file_ticket_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTicketsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTicketsRequest) ProtoMessage() {}
+
+func (x *ListTicketsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ticket_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTicketsRequest.ProtoReflect.Descriptor instead.
+func (*ListTicketsRequest) Descriptor() ([]byte, []int) {
+	return file_ticket_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListTicketsRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListTicketsRequest) GetPrioridade() string {
+	if x != nil {
+		return x.Prioridade
+	}
+	return ""
+}
+
+func (x *ListTicketsRequest) GetCategoriaId() int64 {
+	if x != nil {
+		return x.CategoriaId
+	}
+	return 0
+}
+
+func (x *ListTicketsRequest) GetResponsavelId() int64 {
+	if x != nil {
+		return x.ResponsavelId
+	}
+	return 0
+}
+
+type ListTicketsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tickets       []*Ticket              `protobuf:"bytes,1,rep,name=tickets,proto3" json:"tickets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTicketsResponse) Reset() {
+	*x = ListTicketsResponse{}
+	mi := &file_ticket_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTicketsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTicketsResponse) ProtoMessage() {}
+
+func (x *ListTicketsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ticket_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTicketsResponse.ProtoReflect.Descriptor instead.
+func (*ListTicketsResponse) Descriptor() ([]byte, []int) {
+	return file_ticket_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListTicketsResponse) GetTickets() []*Ticket {
+	if x != nil {
+		return x.Tickets
+	}
+	return nil
+}
+
+type UpdateTicketStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateTicketStatusRequest) Reset() {
+	*x = UpdateTicketStatusRequest{}
+	mi := &file_ticket_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateTicketStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateTicketStatusRequest) ProtoMessage() {}
+
+func (x *UpdateTicketStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ticket_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateTicketStatusRequest.ProtoReflect.Descriptor instead.
+func (*UpdateTicketStatusRequest) Descriptor() ([]byte, []int) {
+	return file_ticket_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UpdateTicketStatusRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateTicketStatusRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type CreateCommentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TicketId      int64                  `protobuf:"varint,1,opt,name=ticket_id,json=ticketId,proto3" json:"ticket_id,omitempty"`
+	Descricao     string                 `protobuf:"bytes,2,opt,name=descricao,proto3" json:"descricao,omitempty"`
+	Tipo          string                 `protobuf:"bytes,3,opt,name=tipo,proto3" json:"tipo,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCommentRequest) Reset() {
+	*x = CreateCommentRequest{}
+	mi := &file_ticket_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCommentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCommentRequest) ProtoMessage() {}
+
+func (x *CreateCommentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ticket_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCommentRequest.ProtoReflect.Descriptor instead.
+func (*CreateCommentRequest) Descriptor() ([]byte, []int) {
+	return file_ticket_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CreateCommentRequest) GetTicketId() int64 {
+	if x != nil {
+		return x.TicketId
+	}
+	return 0
+}
+
+func (x *CreateCommentRequest) GetDescricao() string {
+	if x != nil {
+		return x.Descricao
+	}
+	return ""
+}
+
+func (x *CreateCommentRequest) GetTipo() string {
+	if x != nil {
+		return x.Tipo
+	}
+	return ""
+}
+
+type ListCommentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TicketId      int64                  `protobuf:"varint,1,opt,name=ticket_id,json=ticketId,proto3" json:"ticket_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCommentsRequest) Reset() {
+	*x = ListCommentsRequest{}
+	mi := &file_ticket_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCommentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCommentsRequest) ProtoMessage() {}
+
+func (x *ListCommentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ticket_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCommentsRequest.ProtoReflect.Descriptor instead.
+func (*ListCommentsRequest) Descriptor() ([]byte, []int) {
+	return file_ticket_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListCommentsRequest) GetTicketId() int64 {
+	if x != nil {
+		return x.TicketId
+	}
+	return 0
+}
+
+type ListCommentsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Comments      []*Comment             `protobuf:"bytes,1,rep,name=comments,proto3" json:"comments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCommentsResponse) Reset() {
+	*x = ListCommentsResponse{}
+	mi := &file_ticket_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCommentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCommentsResponse) ProtoMessage() {}
+
+func (x *ListCommentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ticket_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCommentsResponse.ProtoReflect.Descriptor instead.
+func (*ListCommentsResponse) Descriptor() ([]byte, []int) {
+	return file_ticket_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListCommentsResponse) GetComments() []*Comment {
+	if x != nil {
+		return x.Comments
+	}
+	return nil
+}
+
+type WatchTicketsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Tipos de evento (ex: ticket.created); vazio recebe todos.
+	Tipos []string `protobuf:"bytes,1,rep,name=tipos,proto3" json:"tipos,omitempty"`
+	// Restringe aos tickets informados; vazio recebe todos os visiveis.
+	TicketIds []int64 `protobuf:"varint,2,rep,packed,name=ticket_ids,json=ticketIds,proto3" json:"ticket_ids,omitempty"`
+	// So os tickets que o usuario acompanha (autor, responsavel, watcher ou copia).
+	Acompanhando bool `protobuf:"varint,3,opt,name=acompanhando,proto3" json:"acompanhando,omitempty"`
+	// Retoma a partir do evento seguinte a este ID.
+	LastEventId   int64 `protobuf:"varint,4,opt,name=last_event_id,json=lastEventId,proto3" json:"last_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchTicketsRequest) Reset() {
+	*x = WatchTicketsRequest{}
+	mi := &file_ticket_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchTicketsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchTicketsRequest) ProtoMessage() {}
+
+func (x *WatchTicketsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ticket_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchTicketsRequest.ProtoReflect.Descriptor instead.
+func (*WatchTicketsRequest) Descriptor() ([]byte, []int) {
+	return file_ticket_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *WatchTicketsRequest) GetTipos() []string {
+	if x != nil {
+		return x.Tipos
+	}
+	return nil
+}
+
+func (x *WatchTicketsRequest) GetTicketIds() []int64 {
+	if x != nil {
+		return x.TicketIds
+	}
+	return nil
+}
+
+func (x *WatchTicketsRequest) GetAcompanhando() bool {
+	if x != nil {
+		return x.Acompanhando
+	}
+	return false
+}
+
+func (x *WatchTicketsRequest) GetLastEventId() int64 {
+	if x != nil {
+		return x.LastEventId
+	}
+	return 0
+}
+
+type TicketEvent struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Tipo     string                 `protobuf:"bytes,2,opt,name=tipo,proto3" json:"tipo,omitempty"`
+	TicketId int64                  `protobuf:"varint,3,opt,name=ticket_id,json=ticketId,proto3" json:"ticket_id,omitempty"`
+	AtorId   int64                  `protobuf:"varint,4,opt,name=ator_id,json=atorId,proto3" json:"ator_id,omitempty"`
+	// Os mesmos dados JSON do evento no stream SSE.
+	Dados         []byte                 `protobuf:"bytes,5,opt,name=dados,proto3" json:"dados,omitempty"`
+	CriadoEm      *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=criado_em,json=criadoEm,proto3" json:"criado_em,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TicketEvent) Reset() {
+	*x = TicketEvent{}
+	mi := &file_ticket_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TicketEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TicketEvent) ProtoMessage() {}
+
+func (x *TicketEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_ticket_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TicketEvent.ProtoReflect.Descriptor instead.
+func (*TicketEvent) Descriptor() ([]byte, []int) {
+	return file_ticket_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TicketEvent) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *TicketEvent) GetTipo() string {
+	if x != nil {
+		return x.Tipo
+	}
+	return ""
+}
+
+func (x *TicketEvent) GetTicketId() int64 {
+	if x != nil {
+		return x.TicketId
+	}
+	return 0
+}
+
+func (x *TicketEvent) GetAtorId() int64 {
+	if x != nil {
+		return x.AtorId
+	}
+	return 0
+}
+
+func (x *TicketEvent) GetDados() []byte {
+	if x != nil {
+		return x.Dados
+	}
+	return nil
+}
+
+func (x *TicketEvent) GetCriadoEm() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CriadoEm
+	}
+	return nil
+}
+
+var File_ticket_proto protoreflect.FileDescriptor
+
+const file_ticket_proto_rawDesc = "" +
+	"\n" +
+	"\fticket.proto\x12\x13helpdesk.tickets.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa6\x05\n" +
+	"\x06Ticket\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x16\n" +
+	"\x06titulo\x18\x02 \x01(\tR\x06titulo\x12\x1c\n" +
+	"\tdescricao\x18\x03 \x01(\tR\tdescricao\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12 \n" +
+	"\vdiagnostico\x18\x05 \x01(\tR\vdiagnostico\x12\x18\n" +
+	"\asolucao\x18\x06 \x01(\tR\asolucao\x12\x1e\n" +
+	"\n" +
+	"prioridade\x18\a \x01(\tR\n" +
+	"prioridade\x12?\n" +
+	"\rdata_abertura\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\fdataAbertura\x12C\n" +
+	"\x0fdata_fechamento\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x0edataFechamento\x12E\n" +
+	"\x10data_atualizacao\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\x0fdataAtualizacao\x12\x12\n" +
+	"\x04tags\x18\v \x03(\tR\x04tags\x12!\n" +
+	"\fcategoria_id\x18\f \x01(\x03R\vcategoriaId\x12%\n" +
+	"\x0eresponsavel_id\x18\r \x01(\x03R\rresponsavelId\x12\x17\n" +
+	"\auser_id\x18\x0e \x01(\x03R\x06userId\x12\x0e\n" +
+	"\x02cc\x18\x0f \x03(\x03R\x02cc\x12\x1a\n" +
+	"\bwatchers\x18\x10 \x03(\x03R\bwatchers\x123\n" +
+	"\x06author\x18\x11 \x01(\v2\x1b.helpdesk.tickets.v1.PerfilR\x06author\x12=\n" +
+	"\vresponsavel\x18\x12 \x01(\v2\x1b.helpdesk.tickets.v1.PerfilR\vresponsavel\"f\n" +
+	"\x06Perfil\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04nome\x18\x02 \x01(\tR\x04nome\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\"\n" +
+	"\findisponivel\x18\x04 \x01(\bR\findisponivel\"\xcb\x01\n" +
+	"\aComment\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1b\n" +
+	"\tticket_id\x18\x02 \x01(\x03R\bticketId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\x03R\x06userId\x12\x1c\n" +
+	"\tdescricao\x18\x04 \x01(\tR\tdescricao\x12\x12\n" +
+	"\x04tipo\x18\x05 \x01(\tR\x04tipo\x12.\n" +
+	"\x04data\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x04data\x12\x18\n" +
+	"\amencoes\x18\a \x03(\x03R\amencoes\"\xa2\x01\n" +
+	"\x13CreateTicketRequest\x12\x16\n" +
+	"\x06titulo\x18\x01 \x01(\tR\x06titulo\x12\x1c\n" +
+	"\tdescricao\x18\x02 \x01(\tR\tdescricao\x12\x1e\n" +
+	"\n" +
+	"prioridade\x18\x03 \x01(\tR\n" +
+	"prioridade\x12!\n" +
+	"\fcategoria_id\x18\x04 \x01(\x03R\vcategoriaId\x12\x12\n" +
+	"\x04tags\x18\x05 \x03(\tR\x04tags\"\"\n" +
+	"\x10GetTicketRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\x96\x01\n" +
+	"\x12ListTicketsRequest\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x1e\n" +
+	"\n" +
+	"prioridade\x18\x02 \x01(\tR\n" +
+	"prioridade\x12!\n" +
+	"\fcategoria_id\x18\x03 \x01(\x03R\vcategoriaId\x12%\n" +
+	"\x0eresponsavel_id\x18\x04 \x01(\x03R\rresponsavelId\"L\n" +
+	"\x13ListTicketsResponse\x125\n" +
+	"\atickets\x18\x01 \x03(\v2\x1b.helpdesk.tickets.v1.TicketR\atickets\"C\n" +
+	"\x19UpdateTicketStatusRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\"e\n" +
+	"\x14CreateCommentRequest\x12\x1b\n" +
+	"\tticket_id\x18\x01 \x01(\x03R\bticketId\x12\x1c\n" +
+	"\tdescricao\x18\x02 \x01(\tR\tdescricao\x12\x12\n" +
+	"\x04tipo\x18\x03 \x01(\tR\x04tipo\"2\n" +
+	"\x13ListCommentsRequest\x12\x1b\n" +
+	"\tticket_id\x18\x01 \x01(\x03R\bticketId\"P\n" +
+	"\x14ListCommentsResponse\x128\n" +
+	"\bcomments\x18\x01 \x03(\v2\x1c.helpdesk.tickets.v1.CommentR\bcomments\"\x92\x01\n" +
+	"\x13WatchTicketsRequest\x12\x14\n" +
+	"\x05tipos\x18\x01 \x03(\tR\x05tipos\x12\x1d\n" +
+	"\n" +
+	"ticket_ids\x18\x02 \x03(\x03R\tticketIds\x12\"\n" +
+	"\facompanhando\x18\x03 \x01(\bR\facompanhando\x12\"\n" +
+	"\rlast_event_id\x18\x04 \x01(\x03R\vlastEventId\"\xb6\x01\n" +
+	"\vTicketEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04tipo\x18\x02 \x01(\tR\x04tipo\x12\x1b\n" +
+	"\tticket_id\x18\x03 \x01(\x03R\bticketId\x12\x17\n" +
+	"\aator_id\x18\x04 \x01(\x03R\x06atorId\x12\x14\n" +
+	"\x05dados\x18\x05 \x01(\fR\x05dados\x127\n" +
+	"\tcriado_em\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\bcriadoEm2\x99\x05\n" +
+	"\rTicketService\x12U\n" +
+	"\fCreateTicket\x12(.helpdesk.tickets.v1.CreateTicketRequest\x1a\x1b.helpdesk.tickets.v1.Ticket\x12O\n" +
+	"\tGetTicket\x12%.helpdesk.tickets.v1.GetTicketRequest\x1a\x1b.helpdesk.tickets.v1.Ticket\x12`\n" +
+	"\vListTickets\x12'.helpdesk.tickets.v1.ListTicketsRequest\x1a(.helpdesk.tickets.v1.ListTicketsResponse\x12a\n" +
+	"\x12UpdateTicketStatus\x12..helpdesk.tickets.v1.UpdateTicketStatusRequest\x1a\x1b.helpdesk.tickets.v1.Ticket\x12X\n" +
+	"\rCreateComment\x12).helpdesk.tickets.v1.CreateCommentRequest\x1a\x1c.helpdesk.tickets.v1.Comment\x12c\n" +
+	"\fListComments\x12(.helpdesk.tickets.v1.ListCommentsRequest\x1a).helpdesk.tickets.v1.ListCommentsResponse\x12\\\n" +
+	"\fWatchTickets\x12(.helpdesk.tickets.v1.WatchTicketsRequest\x1a .helpdesk.tickets.v1.TicketEvent0\x01B\x14Z\x12helpdesk/pkg/pb;pbb\x06proto3"
+
+var (
+	file_ticket_proto_rawDescOnce sync.Once
+	file_ticket_proto_rawDescData []byte
+)
+
+func file_ticket_proto_rawDescGZIP() []byte {
+	file_ticket_proto_rawDescOnce.Do(func() {
+		file_ticket_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ticket_proto_rawDesc), len(file_ticket_proto_rawDesc)))
+	})
+	return file_ticket_proto_rawDescData
+}
+
+var file_ticket_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_ticket_proto_goTypes = []any{
+	(*Ticket)(nil),                    // 0: helpdesk.tickets.v1.Ticket
+	(*Perfil)(nil),                    // 1: helpdesk.tickets.v1.Perfil
+	(*Comment)(nil),                   // 2: helpdesk.tickets.v1.Comment
+	(*CreateTicketRequest)(nil),       // 3: helpdesk.tickets.v1.CreateTicketRequest
+	(*GetTicketRequest)(nil),          // 4: helpdesk.tickets.v1.GetTicketRequest
+	(*ListTicketsRequest)(nil),        // 5: helpdesk.tickets.v1.ListTicketsRequest
+	(*ListTicketsResponse)(nil),       // 6: helpdesk.tickets.v1.ListTicketsResponse
+	(*UpdateTicketStatusRequest)(nil), // 7: helpdesk.tickets.v1.UpdateTicketStatusRequest
+	(*CreateCommentRequest)(nil),      // 8: helpdesk.tickets.v1.CreateCommentRequest
+	(*ListCommentsRequest)(nil),       // 9: helpdesk.tickets.v1.ListCommentsRequest
+	(*ListCommentsResponse)(nil),      // 10: helpdesk.tickets.v1.ListCommentsResponse
+	(*WatchTicketsRequest)(nil),       // 11: helpdesk.tickets.v1.WatchTicketsRequest
+	(*TicketEvent)(nil),               // 12: helpdesk.tickets.v1.TicketEvent
+	(*timestamppb.Timestamp)(nil),     // 13: google.protobuf.Timestamp
+}
+var file_ticket_proto_depIdxs = []int32{
+	13, // 0: helpdesk.tickets.v1.Ticket.data_abertura:type_name -> google.protobuf.Timestamp
+	13, // 1: helpdesk.tickets.v1.Ticket.data_fechamento:type_name -> google.protobuf.Timestamp
+	13, // 2: helpdesk.tickets.v1.Ticket.data_atualizacao:type_name -> google.protobuf.Timestamp
+	1,  // 3: helpdesk.tickets.v1.Ticket.author:type_name -> helpdesk.tickets.v1.Perfil
+	1,  // 4: helpdesk.tickets.v1.Ticket.responsavel:type_name -> helpdesk.tickets.v1.Perfil
+	13, // 5: helpdesk.tickets.v1.Comment.data:type_name -> google.protobuf.Timestamp
+	0,  // 6: helpdesk.tickets.v1.ListTicketsResponse.tickets:type_name -> helpdesk.tickets.v1.Ticket
+	2,  // 7: helpdesk.tickets.v1.ListCommentsResponse.comments:type_name -> helpdesk.tickets.v1.Comment
+	13, // 8: helpdesk.tickets.v1.TicketEvent.criado_em:type_name -> google.protobuf.Timestamp
+	3,  // 9: helpdesk.tickets.v1.TicketService.CreateTicket:input_type -> helpdesk.tickets.v1.CreateTicketRequest
+	4,  // 10: helpdesk.tickets.v1.TicketService.GetTicket:input_type -> helpdesk.tickets.v1.GetTicketRequest
+	5,  // 11: helpdesk.tickets.v1.TicketService.ListTickets:input_type -> helpdesk.tickets.v1.ListTicketsRequest
+	7,  // 12: helpdesk.tickets.v1.TicketService.UpdateTicketStatus:input_type -> helpdesk.tickets.v1.UpdateTicketStatusRequest
+	8,  // 13: helpdesk.tickets.v1.TicketService.CreateComment:input_type -> helpdesk.tickets.v1.CreateCommentRequest
+	9,  // 14: helpdesk.tickets.v1.TicketService.ListComments:input_type -> helpdesk.tickets.v1.ListCommentsRequest
+	11, // 15: helpdesk.tickets.v1.TicketService.WatchTickets:input_type -> helpdesk.tickets.v1.WatchTicketsRequest
+	0,  // 16: helpdesk.tickets.v1.TicketService.CreateTicket:output_type -> helpdesk.tickets.v1.Ticket
+	0,  // 17: helpdesk.tickets.v1.TicketService.GetTicket:output_type -> helpdesk.tickets.v1.Ticket
+	6,  // 18: helpdesk.tickets.v1.TicketService.ListTickets:output_type -> helpdesk.tickets.v1.ListTicketsResponse
+	0,  // 19: helpdesk.tickets.v1.TicketService.UpdateTicketStatus:output_type -> helpdesk.tickets.v1.Ticket
+	2,  // 20: helpdesk.tickets.v1.TicketService.CreateComment:output_type -> helpdesk.tickets.v1.Comment
+	10, // 21: helpdesk.tickets.v1.TicketService.ListComments:output_type -> helpdesk.tickets.v1.ListCommentsResponse
+	12, // 22: helpdesk.tickets.v1.TicketService.WatchTickets:output_type -> helpdesk.tickets.v1.TicketEvent
+	16, // [16:23] is the sub-list for method output_type
+	9,  // [9:16] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_ticket_proto_init() }
+func file_ticket_proto_init() {
+	if File_ticket_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ticket_proto_rawDesc), len(file_ticket_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ticket_proto_goTypes,
+		DependencyIndexes: file_ticket_proto_depIdxs,
+		MessageInfos:      file_ticket_proto_msgTypes,
+	}.Build()
+	File_ticket_proto = out.File
+	file_ticket_proto_goTypes = nil
+	file_ticket_proto_depIdxs = nil
+}
@@ -0,0 +1,522 @@
+// API interna do users-service, usada pelos outros servicos do helpdesk.
+// Nao e exposta a usuarios finais: as chamadas se autenticam com o token de
+// servico emitido por POST /services/token (metadata "authorization" como
+// "Bearer <token>"), e cada metodo exige um escopo do token.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: user_directory.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// User traz apenas os dados publicos do perfil, sem senha nem documentos.
+type User struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Nome          string                 `protobuf:"bytes,2,opt,name=nome,proto3" json:"nome,omitempty"`
+	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	TipoUser      string                 `protobuf:"bytes,4,opt,name=tipo_user,json=tipoUser,proto3" json:"tipo_user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_user_directory_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_user_directory_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_user_directory_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *User) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *User) GetNome() string {
+	if x != nil {
+		return x.Nome
+	}
+	return ""
+}
+
+func (x *User) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *User) GetTipoUser() string {
+	if x != nil {
+		return x.TipoUser
+	}
+	return ""
+}
+
+type GetUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserRequest) Reset() {
+	*x = GetUserRequest{}
+	mi := &file_user_directory_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserRequest) ProtoMessage() {}
+
+func (x *GetUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_user_directory_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserRequest.ProtoReflect.Descriptor instead.
+func (*GetUserRequest) Descriptor() ([]byte, []int) {
+	return file_user_directory_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetUserRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type GetUserByEmailRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserByEmailRequest) Reset() {
+	*x = GetUserByEmailRequest{}
+	mi := &file_user_directory_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserByEmailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserByEmailRequest) ProtoMessage() {}
+
+func (x *GetUserByEmailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_user_directory_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserByEmailRequest.ProtoReflect.Descriptor instead.
+func (*GetUserByEmailRequest) Descriptor() ([]byte, []int) {
+	return file_user_directory_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetUserByEmailRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+type BatchGetUsersRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// No maximo 500 IDs por chamada.
+	Ids           []int64 `protobuf:"varint,1,rep,packed,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetUsersRequest) Reset() {
+	*x = BatchGetUsersRequest{}
+	mi := &file_user_directory_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetUsersRequest) ProtoMessage() {}
+
+func (x *BatchGetUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_user_directory_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetUsersRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetUsersRequest) Descriptor() ([]byte, []int) {
+	return file_user_directory_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BatchGetUsersRequest) GetIds() []int64 {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type BatchGetUsersResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Users          []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	NaoEncontrados []int64                `protobuf:"varint,2,rep,packed,name=nao_encontrados,json=naoEncontrados,proto3" json:"nao_encontrados,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *BatchGetUsersResponse) Reset() {
+	*x = BatchGetUsersResponse{}
+	mi := &file_user_directory_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetUsersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetUsersResponse) ProtoMessage() {}
+
+func (x *BatchGetUsersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_user_directory_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetUsersResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetUsersResponse) Descriptor() ([]byte, []int) {
+	return file_user_directory_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchGetUsersResponse) GetUsers() []*User {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+func (x *BatchGetUsersResponse) GetNaoEncontrados() []int64 {
+	if x != nil {
+		return x.NaoEncontrados
+	}
+	return nil
+}
+
+type LookupUsersRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// No maximo 50 handles por chamada.
+	Handles       []string `protobuf:"bytes,1,rep,name=handles,proto3" json:"handles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupUsersRequest) Reset() {
+	*x = LookupUsersRequest{}
+	mi := &file_user_directory_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupUsersRequest) ProtoMessage() {}
+
+func (x *LookupUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_user_directory_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupUsersRequest.ProtoReflect.Descriptor instead.
+func (*LookupUsersRequest) Descriptor() ([]byte, []int) {
+	return file_user_directory_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *LookupUsersRequest) GetHandles() []string {
+	if x != nil {
+		return x.Handles
+	}
+	return nil
+}
+
+type LookupUsersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*UserHandle          `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupUsersResponse) Reset() {
+	*x = LookupUsersResponse{}
+	mi := &file_user_directory_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupUsersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupUsersResponse) ProtoMessage() {}
+
+func (x *LookupUsersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_user_directory_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupUsersResponse.ProtoReflect.Descriptor instead.
+func (*LookupUsersResponse) Descriptor() ([]byte, []int) {
+	return file_user_directory_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LookupUsersResponse) GetUsers() []*UserHandle {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+// UserHandle e o usuario que respondeu pelo handle pedido.
+type UserHandle struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          *User                  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Handle        string                 `protobuf:"bytes,2,opt,name=handle,proto3" json:"handle,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserHandle) Reset() {
+	*x = UserHandle{}
+	mi := &file_user_directory_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserHandle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserHandle) ProtoMessage() {}
+
+func (x *UserHandle) ProtoReflect() protoreflect.Message {
+	mi := &file_user_directory_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserHandle.ProtoReflect.Descriptor instead.
+func (*UserHandle) Descriptor() ([]byte, []int) {
+	return file_user_directory_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UserHandle) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *UserHandle) GetHandle() string {
+	if x != nil {
+		return x.Handle
+	}
+	return ""
+}
+
+var File_user_directory_proto protoreflect.FileDescriptor
+
+const file_user_directory_proto_rawDesc = "" +
+	"\n" +
+	"\x14user_directory.proto\x12\x11helpdesk.users.v1\"]\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04nome\x18\x02 \x01(\tR\x04nome\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\x1b\n" +
+	"\ttipo_user\x18\x04 \x01(\tR\btipoUser\" \n" +
+	"\x0eGetUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"-\n" +
+	"\x15GetUserByEmailRequest\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\"(\n" +
+	"\x14BatchGetUsersRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\x03R\x03ids\"o\n" +
+	"\x15BatchGetUsersResponse\x12-\n" +
+	"\x05users\x18\x01 \x03(\v2\x17.helpdesk.users.v1.UserR\x05users\x12'\n" +
+	"\x0fnao_encontrados\x18\x02 \x03(\x03R\x0enaoEncontrados\".\n" +
+	"\x12LookupUsersRequest\x12\x18\n" +
+	"\ahandles\x18\x01 \x03(\tR\ahandles\"J\n" +
+	"\x13LookupUsersResponse\x123\n" +
+	"\x05users\x18\x01 \x03(\v2\x1d.helpdesk.users.v1.UserHandleR\x05users\"Q\n" +
+	"\n" +
+	"UserHandle\x12+\n" +
+	"\x04user\x18\x01 \x01(\v2\x17.helpdesk.users.v1.UserR\x04user\x12\x16\n" +
+	"\x06handle\x18\x02 \x01(\tR\x06handle2\xed\x02\n" +
+	"\rUserDirectory\x12E\n" +
+	"\aGetUser\x12!.helpdesk.users.v1.GetUserRequest\x1a\x17.helpdesk.users.v1.User\x12S\n" +
+	"\x0eGetUserByEmail\x12(.helpdesk.users.v1.GetUserByEmailRequest\x1a\x17.helpdesk.users.v1.User\x12b\n" +
+	"\rBatchGetUsers\x12'.helpdesk.users.v1.BatchGetUsersRequest\x1a(.helpdesk.users.v1.BatchGetUsersResponse\x12\\\n" +
+	"\vLookupUsers\x12%.helpdesk.users.v1.LookupUsersRequest\x1a&.helpdesk.users.v1.LookupUsersResponseB\x14Z\x12helpdesk/pkg/pb;pbb\x06proto3"
+
+var (
+	file_user_directory_proto_rawDescOnce sync.Once
+	file_user_directory_proto_rawDescData []byte
+)
+
+func file_user_directory_proto_rawDescGZIP() []byte {
+	file_user_directory_proto_rawDescOnce.Do(func() {
+		file_user_directory_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_user_directory_proto_rawDesc), len(file_user_directory_proto_rawDesc)))
+	})
+	return file_user_directory_proto_rawDescData
+}
+
+var file_user_directory_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_user_directory_proto_goTypes = []any{
+	(*User)(nil),                  // 0: helpdesk.users.v1.User
+	(*GetUserRequest)(nil),        // 1: helpdesk.users.v1.GetUserRequest
+	(*GetUserByEmailRequest)(nil), // 2: helpdesk.users.v1.GetUserByEmailRequest
+	(*BatchGetUsersRequest)(nil),  // 3: helpdesk.users.v1.BatchGetUsersRequest
+	(*BatchGetUsersResponse)(nil), // 4: helpdesk.users.v1.BatchGetUsersResponse
+	(*LookupUsersRequest)(nil),    // 5: helpdesk.users.v1.LookupUsersRequest
+	(*LookupUsersResponse)(nil),   // 6: helpdesk.users.v1.LookupUsersResponse
+	(*UserHandle)(nil),            // 7: helpdesk.users.v1.UserHandle
+}
+var file_user_directory_proto_depIdxs = []int32{
+	0, // 0: helpdesk.users.v1.BatchGetUsersResponse.users:type_name -> helpdesk.users.v1.User
+	7, // 1: helpdesk.users.v1.LookupUsersResponse.users:type_name -> helpdesk.users.v1.UserHandle
+	0, // 2: helpdesk.users.v1.UserHandle.user:type_name -> helpdesk.users.v1.User
+	1, // 3: helpdesk.users.v1.UserDirectory.GetUser:input_type -> helpdesk.users.v1.GetUserRequest
+	2, // 4: helpdesk.users.v1.UserDirectory.GetUserByEmail:input_type -> helpdesk.users.v1.GetUserByEmailRequest
+	3, // 5: helpdesk.users.v1.UserDirectory.BatchGetUsers:input_type -> helpdesk.users.v1.BatchGetUsersRequest
+	5, // 6: helpdesk.users.v1.UserDirectory.LookupUsers:input_type -> helpdesk.users.v1.LookupUsersRequest
+	0, // 7: helpdesk.users.v1.UserDirectory.GetUser:output_type -> helpdesk.users.v1.User
+	0, // 8: helpdesk.users.v1.UserDirectory.GetUserByEmail:output_type -> helpdesk.users.v1.User
+	4, // 9: helpdesk.users.v1.UserDirectory.BatchGetUsers:output_type -> helpdesk.users.v1.BatchGetUsersResponse
+	6, // 10: helpdesk.users.v1.UserDirectory.LookupUsers:output_type -> helpdesk.users.v1.LookupUsersResponse
+	7, // [7:11] is the sub-list for method output_type
+	3, // [3:7] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_user_directory_proto_init() }
+func file_user_directory_proto_init() {
+	if File_user_directory_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_user_directory_proto_rawDesc), len(file_user_directory_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_user_directory_proto_goTypes,
+		DependencyIndexes: file_user_directory_proto_depIdxs,
+		MessageInfos:      file_user_directory_proto_msgTypes,
+	}.Build()
+	File_user_directory_proto = out.File
+	file_user_directory_proto_goTypes = nil
+	file_user_directory_proto_depIdxs = nil
+}
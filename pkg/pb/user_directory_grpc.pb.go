@@ -0,0 +1,252 @@
+// API interna do users-service, usada pelos outros servicos do helpdesk.
+// Nao e exposta a usuarios finais: as chamadas se autenticam com o token de
+// servico emitido por POST /services/token (metadata "authorization" como
+// "Bearer <token>"), e cada metodo exige um escopo do token.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: user_directory.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	UserDirectory_GetUser_FullMethodName        = "/helpdesk.users.v1.UserDirectory/GetUser"
+	UserDirectory_GetUserByEmail_FullMethodName = "/helpdesk.users.v1.UserDirectory/GetUserByEmail"
+	UserDirectory_BatchGetUsers_FullMethodName  = "/helpdesk.users.v1.UserDirectory/BatchGetUsers"
+	UserDirectory_LookupUsers_FullMethodName    = "/helpdesk.users.v1.UserDirectory/LookupUsers"
+)
+
+// UserDirectoryClient is the client API for UserDirectory service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type UserDirectoryClient interface {
+	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error)
+	// Busca o usuario dono do email, sem diferenciar maiusculas. Usado para
+	// identificar o remetente dos emails recebidos pelo suporte.
+	GetUserByEmail(ctx context.Context, in *GetUserByEmailRequest, opts ...grpc.CallOption) (*User, error)
+	// Busca varios usuarios em uma chamada. IDs inexistentes voltam em
+	// nao_encontrados, sem erro.
+	BatchGetUsers(ctx context.Context, in *BatchGetUsersRequest, opts ...grpc.CallOption) (*BatchGetUsersResponse, error)
+	// Resolve handles de mencao (parte local do email ou email completo).
+	// Handles ambiguos ou desconhecidos ficam de fora da resposta.
+	LookupUsers(ctx context.Context, in *LookupUsersRequest, opts ...grpc.CallOption) (*LookupUsersResponse, error)
+}
+
+type userDirectoryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUserDirectoryClient(cc grpc.ClientConnInterface) UserDirectoryClient {
+	return &userDirectoryClient{cc}
+}
+
+func (c *userDirectoryClient) GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(User)
+	err := c.cc.Invoke(ctx, UserDirectory_GetUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) GetUserByEmail(ctx context.Context, in *GetUserByEmailRequest, opts ...grpc.CallOption) (*User, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(User)
+	err := c.cc.Invoke(ctx, UserDirectory_GetUserByEmail_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) BatchGetUsers(ctx context.Context, in *BatchGetUsersRequest, opts ...grpc.CallOption) (*BatchGetUsersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetUsersResponse)
+	err := c.cc.Invoke(ctx, UserDirectory_BatchGetUsers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) LookupUsers(ctx context.Context, in *LookupUsersRequest, opts ...grpc.CallOption) (*LookupUsersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LookupUsersResponse)
+	err := c.cc.Invoke(ctx, UserDirectory_LookupUsers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UserDirectoryServer is the server API for UserDirectory service.
+// All implementations must embed UnimplementedUserDirectoryServer
+// for forward compatibility.
+type UserDirectoryServer interface {
+	GetUser(context.Context, *GetUserRequest) (*User, error)
+	// Busca o usuario dono do email, sem diferenciar maiusculas. Usado para
+	// identificar o remetente dos emails recebidos pelo suporte.
+	GetUserByEmail(context.Context, *GetUserByEmailRequest) (*User, error)
+	// Busca varios usuarios em uma chamada. IDs inexistentes voltam em
+	// nao_encontrados, sem erro.
+	BatchGetUsers(context.Context, *BatchGetUsersRequest) (*BatchGetUsersResponse, error)
+	// Resolve handles de mencao (parte local do email ou email completo).
+	// Handles ambiguos ou desconhecidos ficam de fora da resposta.
+	LookupUsers(context.Context, *LookupUsersRequest) (*LookupUsersResponse, error)
+	mustEmbedUnimplementedUserDirectoryServer()
+}
+
+// UnimplementedUserDirectoryServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedUserDirectoryServer struct{}
+
+func (UnimplementedUserDirectoryServer) GetUser(context.Context, *GetUserRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUser not implemented")
+}
+func (UnimplementedUserDirectoryServer) GetUserByEmail(context.Context, *GetUserByEmailRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserByEmail not implemented")
+}
+func (UnimplementedUserDirectoryServer) BatchGetUsers(context.Context, *BatchGetUsersRequest) (*BatchGetUsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetUsers not implemented")
+}
+func (UnimplementedUserDirectoryServer) LookupUsers(context.Context, *LookupUsersRequest) (*LookupUsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupUsers not implemented")
+}
+func (UnimplementedUserDirectoryServer) mustEmbedUnimplementedUserDirectoryServer() {}
+func (UnimplementedUserDirectoryServer) testEmbeddedByValue()                       {}
+
+// UnsafeUserDirectoryServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UserDirectoryServer will
+// result in compilation errors.
+type UnsafeUserDirectoryServer interface {
+	mustEmbedUnimplementedUserDirectoryServer()
+}
+
+func RegisterUserDirectoryServer(s grpc.ServiceRegistrar, srv UserDirectoryServer) {
+	// If the following call pancis, it indicates UnimplementedUserDirectoryServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&UserDirectory_ServiceDesc, srv)
+}
+
+func _UserDirectory_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserDirectory_GetUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).GetUser(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_GetUserByEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserByEmailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).GetUserByEmail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserDirectory_GetUserByEmail_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).GetUserByEmail(ctx, req.(*GetUserByEmailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_BatchGetUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).BatchGetUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserDirectory_BatchGetUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).BatchGetUsers(ctx, req.(*BatchGetUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_LookupUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).LookupUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserDirectory_LookupUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).LookupUsers(ctx, req.(*LookupUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UserDirectory_ServiceDesc is the grpc.ServiceDesc for UserDirectory service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UserDirectory_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "helpdesk.users.v1.UserDirectory",
+	HandlerType: (*UserDirectoryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetUser",
+			Handler:    _UserDirectory_GetUser_Handler,
+		},
+		{
+			MethodName: "GetUserByEmail",
+			Handler:    _UserDirectory_GetUserByEmail_Handler,
+		},
+		{
+			MethodName: "BatchGetUsers",
+			Handler:    _UserDirectory_BatchGetUsers_Handler,
+		},
+		{
+			MethodName: "LookupUsers",
+			Handler:    _UserDirectory_LookupUsers_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "user_directory.proto",
+}
@@ -0,0 +1,173 @@
+// Package problema padroniza as respostas de erro das APIs HTTP no formato
+// RFC 7807 (application/problem+json). Alem dos campos da RFC, cada resposta
+// leva um codigo estavel, que os clientes podem comparar sem depender do
+// texto em portugues, o ID da requisicao e, nos erros de validacao, o motivo
+// de cada campo recusado.
+package problema
+
+import (
+	"encoding/json"
+	"errors"
+	"helpdesk/pkg/registro"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// TipoJSON e o Content-Type das respostas de erro.
+const TipoJSON = "application/problem+json"
+
+// Codigos estaveis dos erros. Um codigo nunca muda de significado; novos
+// casos ganham codigos novos.
+const (
+	CodigoRequisicaoInvalida = "requisicao_invalida"
+	CodigoValidacao          = "validacao"
+	CodigoNaoAutenticado     = "nao_autenticado"
+	CodigoSemPermissao       = "sem_permissao"
+	CodigoNaoEncontrado      = "nao_encontrado"
+	CodigoConflito           = "conflito"
+	CodigoMuitoGrande        = "muito_grande"
+	CodigoTipoNaoSuportado   = "tipo_nao_suportado"
+	CodigoNaoProcessavel     = "nao_processavel"
+	CodigoReferenciaInvalida = "referencia_invalida"
+	CodigoErroInterno        = "erro_interno"
+	CodigoFalhaExterna       = "falha_externa"
+	CodigoIndisponivel       = "indisponivel"
+)
+
+// Codigos do Postgres tratados por Erro.
+const (
+	pgViolacaoUnica            = "23505"
+	pgViolacaoChaveEstrangeira = "23503"
+)
+
+// titulos e o resumo de cada codigo, fixo como pede a RFC.
+var titulos = map[string]string{
+	CodigoRequisicaoInvalida: "Requisição inválida",
+	CodigoValidacao:          "Dados inválidos",
+	CodigoNaoAutenticado:     "Não autenticado",
+	CodigoSemPermissao:       "Permissão negada",
+	CodigoNaoEncontrado:      "Registro não encontrado",
+	CodigoConflito:           "Conflito com um registro existente",
+	CodigoMuitoGrande:        "Requisição grande demais",
+	CodigoTipoNaoSuportado:   "Tipo de conteúdo não suportado",
+	CodigoNaoProcessavel:     "Requisição não processável",
+	CodigoReferenciaInvalida: "Referência a um registro inexistente",
+	CodigoErroInterno:        "Erro interno",
+	CodigoFalhaExterna:       "Falha em um serviço externo",
+	CodigoIndisponivel:       "Serviço indisponível",
+}
+
+// porStatus e o codigo usado por Escrever para cada status HTTP.
+var porStatus = map[int]string{
+	http.StatusBadRequest:            CodigoRequisicaoInvalida,
+	http.StatusUnauthorized:          CodigoNaoAutenticado,
+	http.StatusForbidden:             CodigoSemPermissao,
+	http.StatusNotFound:              CodigoNaoEncontrado,
+	http.StatusConflict:              CodigoConflito,
+	http.StatusRequestEntityTooLarge: CodigoMuitoGrande,
+	http.StatusUnsupportedMediaType:  CodigoTipoNaoSuportado,
+	http.StatusUnprocessableEntity:   CodigoNaoProcessavel,
+	http.StatusInternalServerError:   CodigoErroInterno,
+	http.StatusBadGateway:            CodigoFalhaExterna,
+	http.StatusServiceUnavailable:    CodigoIndisponivel,
+}
+
+// Problema e o corpo das respostas de erro.
+type Problema struct {
+	Type      string          `json:"type"`
+	Title     string          `json:"title"`
+	Status    int             `json:"status"`
+	Detail    string          `json:"detail,omitempty"`
+	Instance  string          `json:"instance,omitempty"`
+	Codigo    string          `json:"codigo"`
+	RequestID string          `json:"request_id,omitempty"`
+	Campos    []CampoInvalido `json:"campos,omitempty"`
+}
+
+// CampoInvalido explica por que um campo da requisicao foi recusado.
+type CampoInvalido struct {
+	Campo    string `json:"campo"`
+	Mensagem string `json:"mensagem"`
+}
+
+// ErroValidacao e devolvido pelas validacoes; Erro o responde como 400 com
+// os campos recusados.
+type ErroValidacao struct {
+	Campos []CampoInvalido
+}
+
+func (e *ErroValidacao) Error() string {
+	mensagens := make([]string, len(e.Campos))
+	for i, c := range e.Campos {
+		mensagens[i] = c.Mensagem
+	}
+	return strings.Join(mensagens, "; ")
+}
+
+// Invalido cria o erro de validacao de um campo.
+func Invalido(campo, mensagem string) error {
+	return &ErroValidacao{Campos: []CampoInvalido{{Campo: campo, Mensagem: mensagem}}}
+}
+
+// Novo monta o problema com o codigo dado.
+func Novo(status int, codigo, detalhe string) Problema {
+	return Problema{
+		Type:   "urn:helpdesk:problema:" + codigo,
+		Title:  titulos[codigo],
+		Status: status,
+		Detail: detalhe,
+		Codigo: codigo,
+	}
+}
+
+// Responder escreve o problema, completando a instancia e o ID da requisicao.
+func Responder(w http.ResponseWriter, r *http.Request, p Problema) {
+	p.Instance = r.URL.Path
+	p.RequestID = registro.ID(r.Context())
+
+	w.Header().Set("Content-Type", TipoJSON)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Escrever responde o status com o codigo padrao dele. Substitui o
+// http.Error nos handlers.
+func Escrever(w http.ResponseWriter, r *http.Request, status int, detalhe string) {
+	codigo, ok := porStatus[status]
+	if !ok {
+		codigo = CodigoErroInterno
+		if status < http.StatusInternalServerError {
+			codigo = CodigoRequisicaoInvalida
+		}
+	}
+	Responder(w, r, Novo(status, codigo, detalhe))
+}
+
+// Erro responde o erro devolvido pelo repositorio ou por uma validacao:
+// registro inexistente vira 404, violacao de unicidade 409, chave estrangeira
+// para um registro inexistente 422 e ErroValidacao 400 com os campos. Os
+// demais sao inesperados: sao registrados no log e respondidos como 500 com
+// o detalhe dado, sem expor a mensagem original.
+func Erro(w http.ResponseWriter, r *http.Request, err error, detalhe string) {
+	var validacao *ErroValidacao
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &validacao):
+		p := Novo(http.StatusBadRequest, CodigoValidacao, validacao.Error())
+		p.Campos = validacao.Campos
+		Responder(w, r, p)
+	case errors.Is(err, pgx.ErrNoRows):
+		Responder(w, r, Novo(http.StatusNotFound, CodigoNaoEncontrado, "Registro inexistente"))
+	case errors.As(err, &pgErr) && pgErr.Code == pgViolacaoUnica:
+		Responder(w, r, Novo(http.StatusConflict, CodigoConflito, "Já existe um registro com esses dados"))
+	case errors.As(err, &pgErr) && pgErr.Code == pgViolacaoChaveEstrangeira:
+		Responder(w, r, Novo(http.StatusUnprocessableEntity, CodigoReferenciaInvalida, "A requisição referencia um registro inexistente"))
+	default:
+		registro.Logger(r.Context()).Error(detalhe, "erro", err)
+		Responder(w, r, Novo(http.StatusInternalServerError, CodigoErroInterno, detalhe))
+	}
+}
@@ -0,0 +1,86 @@
+package problema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"helpdesk/pkg/registro"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func responder(t *testing.T, escrever func(w http.ResponseWriter, r *http.Request)) (*httptest.ResponseRecorder, Problema) {
+	req := httptest.NewRequest(http.MethodGet, "/tickets/42", nil)
+	req = req.WithContext(registro.ComID(req.Context(), "req-1"))
+	rr := httptest.NewRecorder()
+	escrever(rr, req)
+
+	var p Problema
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&p))
+	return rr, p
+}
+
+func TestEscrever(t *testing.T) {
+	rr, p := responder(t, func(w http.ResponseWriter, r *http.Request) {
+		Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+	})
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, TipoJSON, rr.Header().Get("Content-Type"))
+	assert.Equal(t, Problema{
+		Type:      "urn:helpdesk:problema:sem_permissao",
+		Title:     "Permissão negada",
+		Status:    http.StatusForbidden,
+		Detail:    "Permissão não concedida",
+		Instance:  "/tickets/42",
+		Codigo:    CodigoSemPermissao,
+		RequestID: "req-1",
+	}, p)
+}
+
+func TestErro_MapeiaErros(t *testing.T) {
+	casos := []struct {
+		err    error
+		status int
+		codigo string
+	}{
+		{pgx.ErrNoRows, http.StatusNotFound, CodigoNaoEncontrado},
+		{fmt.Errorf("consulta: %w", pgx.ErrNoRows), http.StatusNotFound, CodigoNaoEncontrado},
+		{&pgconn.PgError{Code: "23505"}, http.StatusConflict, CodigoConflito},
+		{&pgconn.PgError{Code: "23503"}, http.StatusUnprocessableEntity, CodigoReferenciaInvalida},
+		{&pgconn.PgError{Code: "57014"}, http.StatusInternalServerError, CodigoErroInterno},
+		{errors.New("conexão recusada"), http.StatusInternalServerError, CodigoErroInterno},
+	}
+	for _, c := range casos {
+		rr, p := responder(t, func(w http.ResponseWriter, r *http.Request) {
+			Erro(w, r, c.err, "Erro ao consultar o ticket no banco de dados")
+		})
+		assert.Equal(t, c.status, rr.Code, c.err)
+		assert.Equal(t, c.codigo, p.Codigo, c.err)
+		assert.Equal(t, "req-1", p.RequestID)
+	}
+}
+
+func TestErro_NaoExpoeErroInesperado(t *testing.T) {
+	_, p := responder(t, func(w http.ResponseWriter, r *http.Request) {
+		Erro(w, r, errors.New("senha do banco errada"), "Erro ao consultar o ticket no banco de dados")
+	})
+	assert.Equal(t, "Erro ao consultar o ticket no banco de dados", p.Detail)
+}
+
+func TestErro_Validacao(t *testing.T) {
+	rr, p := responder(t, func(w http.ResponseWriter, r *http.Request) {
+		Erro(w, r, Invalido("url", "URL inválida"), "Erro ao validar a requisição")
+	})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, CodigoValidacao, p.Codigo)
+	assert.Equal(t, "URL inválida", p.Detail)
+	assert.Equal(t, []CampoInvalido{{Campo: "url", Mensagem: "URL inválida"}}, p.Campos)
+}
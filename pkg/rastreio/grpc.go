@@ -0,0 +1,109 @@
+package rastreio
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// metadados adapta o metadata do gRPC ao propagador do OpenTelemetry.
+type metadados metadata.MD
+
+func (m metadados) Get(chave string) string {
+	if v := metadata.MD(m).Get(chave); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (m metadados) Set(chave, valor string) {
+	metadata.MD(m).Set(chave, valor)
+}
+
+func (m metadados) Keys() []string {
+	chaves := make([]string, 0, len(m))
+	for k := range m {
+		chaves = append(chaves, k)
+	}
+	return chaves
+}
+
+// ClienteUnario abre um span para cada chamada gRPC feita e envia o
+// traceparent no metadata, para o servidor continuar o mesmo trace.
+func ClienteUnario(ctx context.Context, metodo string, req, resp any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, span := tracer().Start(ctx, nomeRPC(metodo),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(atributosRPC(metodo)...))
+	defer span.End()
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	otel.GetTextMapPropagator().Inject(ctx, metadados(md))
+	err := invoker(metadata.NewOutgoingContext(ctx, md), metodo, req, resp, cc, opts...)
+	registrarStatus(span, err)
+	return err
+}
+
+// ServidorUnario continua o trace recebido no metadata da chamada.
+func ServidorUnario(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, span := iniciarServidor(ctx, info.FullMethod)
+	defer span.End()
+
+	resp, err := handler(ctx, req)
+	registrarStatus(span, err)
+	return resp, err
+}
+
+// ServidorStream e o ServidorUnario das chamadas com stream: o span cobre o
+// stream inteiro.
+func ServidorStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := iniciarServidor(ss.Context(), info.FullMethod)
+	defer span.End()
+
+	err := handler(srv, &streamComContexto{ServerStream: ss, ctx: ctx})
+	registrarStatus(span, err)
+	return err
+}
+
+type streamComContexto struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *streamComContexto) Context() context.Context {
+	return s.ctx
+}
+
+func iniciarServidor(ctx context.Context, metodo string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadados(md))
+	return tracer().Start(ctx, nomeRPC(metodo),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(atributosRPC(metodo)...))
+}
+
+// nomeRPC tira a barra inicial: "helpdesk.users.v1.UserDirectory/GetUser".
+func nomeRPC(metodo string) string {
+	return strings.TrimPrefix(metodo, "/")
+}
+
+func atributosRPC(metodo string) []attribute.KeyValue {
+	servico, nome, _ := strings.Cut(nomeRPC(metodo), "/")
+	return []attribute.KeyValue{semconv.RPCSystemGRPC, semconv.RPCService(servico), semconv.RPCMethod(nome)}
+}
+
+func registrarStatus(span trace.Span, err error) {
+	s := status.Convert(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(s.Code())))
+	if err != nil {
+		span.SetStatus(codes.Error, s.Message())
+	}
+}
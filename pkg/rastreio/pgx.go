@@ -0,0 +1,50 @@
+package rastreio
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Pgx e o pgx.QueryTracer que abre um span por consulta. So registra
+// consultas feitas dentro de um trace: as que rodam com context.Background()
+// nao tem a quem pertencer e virariam ruido.
+type Pgx struct{}
+
+func (Pgx) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	operacao := operacaoSQL(data.SQL)
+	ctx, _ = tracer().Start(ctx, "postgres "+operacao,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL, semconv.DBOperationName(operacao), semconv.DBQueryText(data.SQL)))
+	return ctx
+}
+
+func (Pgx) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	// Nenhuma linha e uma resposta, nao uma falha do banco.
+	if data.Err != nil && !errors.Is(data.Err, pgx.ErrNoRows) {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.End()
+}
+
+// operacaoSQL e o primeiro comando da consulta, em maiusculas ("SELECT").
+func operacaoSQL(sql string) string {
+	campos := strings.Fields(sql)
+	if len(campos) == 0 {
+		return "SQL"
+	}
+	return strings.ToUpper(campos[0])
+}
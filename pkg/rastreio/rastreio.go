@@ -0,0 +1,59 @@
+// Package rastreio liga o OpenTelemetry nos servicos: configura o exportador
+// de spans e a propagacao W3C (traceparent) e instrumenta as rotas do chi, as
+// consultas do pgx e as chamadas gRPC entre os servicos. Com o exportador
+// "nenhum" os spans nao sao gravados, mas o contexto recebido continua sendo
+// repassado adiante.
+package rastreio
+
+import (
+	"context"
+	"fmt"
+	"helpdesk/pkg/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const nomeInstrumentacao = "helpdesk/pkg/rastreio"
+
+func tracer() trace.Tracer {
+	return otel.Tracer(nomeInstrumentacao)
+}
+
+// Iniciar configura o provedor global de spans do servico. A funcao devolvida
+// envia os spans pendentes e deve ser chamada no desligamento.
+func Iniciar(ctx context.Context, servico string, cfg config.Rastreio) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exportador sdktrace.SpanExporter
+	var err error
+	switch cfg.Exportador {
+	case config.RastreioStdout:
+		exportador, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case config.RastreioOTLP:
+		var opcoes []otlptracegrpc.Option
+		if cfg.Endpoint != "" {
+			opcoes = append(opcoes, otlptracegrpc.WithEndpointURL(cfg.Endpoint))
+		}
+		exportador, err = otlptracegrpc.New(ctx, opcoes...)
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar o exportador de spans %s: %w", cfg.Exportador, err)
+	}
+
+	recurso, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(servico)))
+	if err != nil {
+		return nil, err
+	}
+	provedor := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exportador), sdktrace.WithResource(recurso))
+	otel.SetTracerProvider(provedor)
+	return provedor.Shutdown, nil
+}
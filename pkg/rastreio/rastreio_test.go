@@ -0,0 +1,130 @@
+package rastreio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func gravador(t *testing.T) *tracetest.SpanRecorder {
+	anterior := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(anterior) })
+	gravador := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(gravador)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return gravador
+}
+
+func TestHTTP_SpanComRotaEPaiRecebido(t *testing.T) {
+	spans := gravador(t)
+
+	r := chi.NewRouter()
+	r.Use(HTTP)
+	r.Get("/tickets/{id}", func(w http.ResponseWriter, r *http.Request) {
+		// As consultas do handler viram filhas do span da requisicao.
+		ctx := Pgx{}.TraceQueryStart(r.Context(), nil, pgx.TraceQueryStartData{SQL: "select * from tickets where id=$1"})
+		Pgx{}.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: pgx.ErrNoRows})
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/tickets/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	terminados := spans.Ended()
+	require.Len(t, terminados, 2)
+	consulta, requisicao := terminados[0], terminados[1]
+	assert.Equal(t, "GET /tickets/{id}", requisicao.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", requisicao.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", requisicao.Parent().SpanID().String())
+	assert.Equal(t, "Error", requisicao.Status().Code.String())
+
+	assert.Equal(t, "postgres SELECT", consulta.Name())
+	assert.Equal(t, requisicao.SpanContext().SpanID(), consulta.Parent().SpanID())
+	assert.Equal(t, "Unset", consulta.Status().Code.String(), "nenhuma linha nao e erro")
+}
+
+func TestPgx_IgnoraConsultasForaDeTrace(t *testing.T) {
+	spans := gravador(t)
+
+	ctx := Pgx{}.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	Pgx{}.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+
+	assert.Empty(t, spans.Ended())
+}
+
+func TestGRPC_PropagaOTrace(t *testing.T) {
+	spans := gravador(t)
+
+	servidor := func(ctx context.Context, req any) (any, error) {
+		return nil, errors.New("falhou")
+	}
+	// O invoker entrega o metadata de saida como metadata de entrada, como a rede faria.
+	invoker := func(ctx context.Context, metodo string, req, resp any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		_, err := ServidorUnario(metadata.NewIncomingContext(context.Background(), md), req,
+			&grpc.UnaryServerInfo{FullMethod: metodo}, servidor)
+		return err
+	}
+
+	ctx, pai := otel.Tracer("teste").Start(context.Background(), "POST /tickets")
+	err := ClienteUnario(ctx, "/helpdesk.users.v1.UserDirectory/BatchGetUsers", nil, nil, nil, invoker)
+	pai.End()
+	assert.Error(t, err)
+
+	terminados := spans.Ended()
+	require.Len(t, terminados, 3)
+	noServidor, noCliente := terminados[0], terminados[1]
+	assert.Equal(t, "helpdesk.users.v1.UserDirectory/BatchGetUsers", noCliente.Name())
+	assert.Equal(t, trace.SpanKindClient, noCliente.SpanKind())
+	assert.Equal(t, pai.SpanContext().SpanID(), noCliente.Parent().SpanID())
+	assert.Equal(t, trace.SpanKindServer, noServidor.SpanKind())
+	assert.Equal(t, noCliente.SpanContext().SpanID(), noServidor.Parent().SpanID())
+	assert.Equal(t, pai.SpanContext().TraceID(), noServidor.SpanContext().TraceID())
+}
+
+// streamFalso e um grpc.ServerStream com so o contexto.
+type streamFalso struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s streamFalso) Context() context.Context { return s.ctx }
+
+func TestGRPC_ServidorStream(t *testing.T) {
+	spans := gravador(t)
+
+	// O cliente manda o traceparent no metadata, como o ClienteUnario.
+	ctx, pai := otel.Tracer("teste").Start(context.Background(), "cliente")
+	md := metadata.MD{}
+	otel.GetTextMapPropagator().Inject(ctx, metadados(md))
+	pai.End()
+
+	var dentroDoSpan bool
+	handler := func(srv any, ss grpc.ServerStream) error {
+		dentroDoSpan = trace.SpanContextFromContext(ss.Context()).TraceID() == pai.SpanContext().TraceID()
+		return nil
+	}
+	ss := streamFalso{ctx: metadata.NewIncomingContext(context.Background(), md)}
+	assert.NoError(t, ServidorStream(nil, ss, &grpc.StreamServerInfo{FullMethod: "/helpdesk.tickets.v1.TicketService/WatchTickets"}, handler))
+
+	assert.True(t, dentroDoSpan, "o handler deveria rodar no trace do cliente")
+	terminados := spans.Ended()
+	require.Len(t, terminados, 2)
+	assert.Equal(t, "helpdesk.tickets.v1.TicketService/WatchTickets", terminados[1].Name())
+	assert.Equal(t, trace.SpanKindServer, terminados[1].SpanKind())
+}
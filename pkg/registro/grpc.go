@@ -0,0 +1,89 @@
+package registro
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// metadadoID e o CabecalhoID no metadata do gRPC, que usa chaves minusculas.
+const metadadoID = "x-request-id"
+
+// ClienteUnario envia o ID de requisicao do contexto no metadata.
+func ClienteUnario(ctx context.Context, metodo string, req, resp any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if id := ID(ctx); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, metadadoID, id)
+	}
+	return invoker(ctx, metodo, req, resp, cc, opts...)
+}
+
+// ServidorUnario e o HTTP para as chamadas gRPC recebidas: usa o ID do
+// metadata (ou gera um) e escreve o log de acesso da chamada. As checagens
+// de saude saem so no nivel debug.
+func ServidorUnario(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	inicio := time.Now()
+	ctx, a := iniciarChamada(ctx)
+	resp, err := handler(ctx, req)
+	registrarChamada(ctx, info.FullMethod, a, inicio, err)
+	return resp, err
+}
+
+// ServidorStream e o ServidorUnario das chamadas com stream. O log de acesso
+// sai quando o stream termina, com a duracao total.
+func ServidorStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	inicio := time.Now()
+	ctx, a := iniciarChamada(ss.Context())
+	err := handler(srv, &streamComContexto{ServerStream: ss, ctx: ctx})
+	registrarChamada(ctx, info.FullMethod, a, inicio, err)
+	return err
+}
+
+type streamComContexto struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *streamComContexto) Context() context.Context {
+	return s.ctx
+}
+
+// iniciarChamada poe no contexto o ID de requisicao e o registro do acesso.
+func iniciarChamada(ctx context.Context) (context.Context, *acesso) {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(metadadoID); len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if !idValido(id) {
+		id = NovoID()
+	}
+	a := &acesso{}
+	return context.WithValue(ComID(ctx, id), chaveAcesso, a), a
+}
+
+func registrarChamada(ctx context.Context, metodo string, a *acesso, inicio time.Time, err error) {
+	codigo := status.Code(err)
+	nivel := slog.LevelInfo
+	switch {
+	case strings.HasPrefix(metodo, "/grpc.health.v1.Health/"):
+		nivel = slog.LevelDebug
+	case codigo == codes.Unknown || codigo == codes.Internal || codigo == codes.Unavailable || codigo == codes.DataLoss:
+		nivel = slog.LevelError
+	}
+	atributos := []slog.Attr{
+		slog.String("metodo", metodo),
+		slog.String("codigo", codigo.String()),
+		slog.Float64("duracao_ms", float64(time.Since(inicio).Microseconds())/1000),
+	}
+	if a.usuarioID != 0 {
+		atributos = append(atributos, slog.Int64("usuario_id", a.usuarioID))
+	}
+	Logger(ctx).LogAttrs(ctx, nivel, "chamada grpc", atributos...)
+}
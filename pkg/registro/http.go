@@ -0,0 +1,91 @@
+package registro
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+// CabecalhoID e o cabecalho HTTP do ID de requisicao.
+const CabecalhoID = "X-Request-ID"
+
+// sondas sao as rotas chamadas a cada poucos segundos pelo orquestrador e
+// pelo Prometheus; o acesso a elas so sai no nivel debug.
+var sondas = map[string]bool{"/health": true, "/livez": true, "/readyz": true, "/metrics": true}
+
+// acesso junta o que os middlewares internos descobrem sobre a requisicao e
+// que so o log de acesso, mais externo, escreve.
+type acesso struct {
+	usuarioID int64
+}
+
+// DefinirUsuario registra quem fez a requisicao, para o log de acesso. E
+// chamado pelo middleware de autenticacao de cada servico.
+func DefinirUsuario(ctx context.Context, id int64) {
+	if a, ok := ctx.Value(chaveAcesso).(*acesso); ok {
+		a.usuarioID = id
+	}
+}
+
+// idValido aceita IDs recebidos de fora so se forem curtos e sem caracteres
+// que atrapalhem os logs.
+func idValido(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// HTTP usa o X-Request-ID recebido (ou gera um), devolve-o na resposta,
+// coloca no contexto o logger da requisicao e, ao fim, escreve o log de
+// acesso com rota, status, latencia e usuario.
+func HTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inicio := time.Now()
+		id := r.Header.Get(CabecalhoID)
+		if !idValido(id) {
+			id = NovoID()
+		}
+		w.Header().Set(CabecalhoID, id)
+
+		a := &acesso{}
+		ctx := context.WithValue(ComID(r.Context(), id), chaveAcesso, a)
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		atributos := []slog.Attr{
+			slog.String("metodo", r.Method),
+			slog.String("caminho", r.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("duracao_ms", float64(time.Since(inicio).Microseconds())/1000),
+			slog.Int("bytes", ww.BytesWritten()),
+		}
+		if rc := chi.RouteContext(ctx); rc != nil && rc.RoutePattern() != "" {
+			atributos = append(atributos, slog.String("rota", rc.RoutePattern()))
+		}
+		if a.usuarioID != 0 {
+			atributos = append(atributos, slog.Int64("usuario_id", a.usuarioID))
+		}
+		nivel := slog.LevelInfo
+		switch {
+		case sondas[r.URL.Path]:
+			nivel = slog.LevelDebug
+		case status >= http.StatusInternalServerError:
+			nivel = slog.LevelError
+		}
+		Logger(ctx).LogAttrs(ctx, nivel, "requisicao", atributos...)
+	})
+}
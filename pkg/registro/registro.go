@@ -0,0 +1,77 @@
+// Package registro e o log estruturado dos servicos, sobre o log/slog. Cada
+// requisicao recebe um ID (X-Request-ID), que vai no contexto junto com um
+// logger ja marcado com ele; o ID segue para o users-service nas chamadas
+// gRPC e para os jobs enfileirados, de modo que tudo o que uma requisicao
+// causou pode ser achado pelo mesmo request_id.
+package registro
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"helpdesk/pkg/config"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type chave int
+
+const (
+	chaveID chave = iota
+	chaveLogger
+	chaveAcesso
+)
+
+// Configurar troca o logger padrao pelo do servico. Depois disso o pacote
+// log tambem escreve pelo slog, no mesmo formato.
+func Configurar(saida io.Writer, servico string, cfg config.Log) {
+	var nivel slog.Level
+	nivel.UnmarshalText([]byte(cfg.Nivel))
+	opcoes := &slog.HandlerOptions{Level: nivel}
+
+	var handler slog.Handler = slog.NewJSONHandler(saida, opcoes)
+	if cfg.Formato == "texto" {
+		handler = slog.NewTextHandler(saida, opcoes)
+	}
+	slog.SetDefault(slog.New(handler).With("servico", servico))
+}
+
+// NovoID gera um ID de requisicao aleatorio.
+func NovoID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ComID guarda o ID de requisicao no contexto, junto com um logger que o
+// inclui em toda linha.
+func ComID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, chaveID, id)
+	return ComLogger(ctx, slog.Default().With("request_id", id))
+}
+
+// ID devolve o ID de requisicao do contexto, ou "" se nao houver.
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(chaveID).(string)
+	return id
+}
+
+// ComLogger guarda o logger no contexto.
+func ComLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, chaveLogger, l)
+}
+
+// Logger devolve o logger do contexto, ou o padrao. Dentro de um trace, as
+// linhas levam tambem o trace_id.
+func Logger(ctx context.Context) *slog.Logger {
+	l, ok := ctx.Value(chaveLogger).(*slog.Logger)
+	if !ok {
+		l = slog.Default()
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		l = l.With("trace_id", sc.TraceID().String())
+	}
+	return l
+}
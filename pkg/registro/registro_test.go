@@ -0,0 +1,142 @@
+package registro
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"helpdesk/pkg/config"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// capturar troca o logger padrao por um que escreve em memoria e devolve as
+// linhas JSON decodificadas.
+func capturar(t *testing.T) func() []map[string]any {
+	anterior := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(anterior) })
+	var saida bytes.Buffer
+	Configurar(&saida, "tickets-service", config.Log{Formato: "json", Nivel: "info"})
+
+	return func() []map[string]any {
+		var linhas []map[string]any
+		for _, l := range strings.Split(strings.TrimSpace(saida.String()), "\n") {
+			var linha map[string]any
+			require.NoError(t, json.Unmarshal([]byte(l), &linha))
+			linhas = append(linhas, linha)
+		}
+		return linhas
+	}
+}
+
+func TestHTTP_IDELogDeAcesso(t *testing.T) {
+	linhas := capturar(t)
+
+	var idNoHandler string
+	r := chi.NewRouter()
+	r.Use(HTTP)
+	r.Get("/tickets/{id}", func(w http.ResponseWriter, r *http.Request) {
+		idNoHandler = ID(r.Context())
+		DefinirUsuario(r.Context(), 7)
+		Logger(r.Context()).Warn("ticket sem responsavel")
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/tickets/42", nil)
+	req.Header.Set(CabecalhoID, "abc-123")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, "abc-123", idNoHandler)
+	assert.Equal(t, "abc-123", rr.Header().Get(CabecalhoID))
+
+	l := linhas()
+	require.Len(t, l, 2)
+	assert.Equal(t, "ticket sem responsavel", l[0]["msg"])
+	assert.Equal(t, "abc-123", l[0]["request_id"])
+	assert.Equal(t, "tickets-service", l[0]["servico"])
+	assert.Equal(t, "requisicao", l[1]["msg"])
+	assert.Equal(t, "abc-123", l[1]["request_id"])
+	assert.Equal(t, "/tickets/{id}", l[1]["rota"])
+	assert.Equal(t, float64(http.StatusNotFound), l[1]["status"])
+	assert.Equal(t, float64(7), l[1]["usuario_id"])
+	assert.Contains(t, l[1], "duracao_ms")
+}
+
+func TestHTTP_GeraIDQuandoAusenteOuInvalido(t *testing.T) {
+	capturar(t)
+	h := HTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, recebido := range []string{"", "id com espaco\n", strings.Repeat("a", 200)} {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.Header.Set(CabecalhoID, recebido)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		id := rr.Header().Get(CabecalhoID)
+		assert.Len(t, id, 32)
+		assert.NotEqual(t, recebido, id)
+	}
+}
+
+func TestGRPC_PropagaID(t *testing.T) {
+	linhas := capturar(t)
+
+	var idNoServidor string
+	servidor := func(ctx context.Context, req any) (any, error) {
+		idNoServidor = ID(ctx)
+		return nil, nil
+	}
+	invoker := func(ctx context.Context, metodo string, req, resp any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		_, err := ServidorUnario(metadata.NewIncomingContext(context.Background(), md), req,
+			&grpc.UnaryServerInfo{FullMethod: "/helpdesk.users.v1.UserDirectory/GetUser"}, servidor)
+		return err
+	}
+
+	ctx := ComID(context.Background(), "req-1")
+	assert.NoError(t, ClienteUnario(ctx, "/helpdesk.users.v1.UserDirectory/GetUser", nil, nil, nil, invoker))
+
+	assert.Equal(t, "req-1", idNoServidor)
+	l := linhas()
+	require.Len(t, l, 1)
+	assert.Equal(t, "chamada grpc", l[0]["msg"])
+	assert.Equal(t, "OK", l[0]["codigo"])
+	assert.Equal(t, "req-1", l[0]["request_id"])
+}
+
+// streamFalso e um grpc.ServerStream com so o contexto.
+type streamFalso struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s streamFalso) Context() context.Context { return s.ctx }
+
+func TestGRPC_ServidorStream(t *testing.T) {
+	linhas := capturar(t)
+
+	var idNoServidor string
+	handler := func(srv any, ss grpc.ServerStream) error {
+		idNoServidor = ID(ss.Context())
+		return nil
+	}
+	md := metadata.Pairs(metadadoID, "req-7")
+	ss := streamFalso{ctx: metadata.NewIncomingContext(context.Background(), md)}
+	assert.NoError(t, ServidorStream(nil, ss, &grpc.StreamServerInfo{FullMethod: "/helpdesk.tickets.v1.TicketService/WatchTickets"}, handler))
+
+	assert.Equal(t, "req-7", idNoServidor)
+	l := linhas()
+	require.Len(t, l, 1)
+	assert.Equal(t, "chamada grpc", l[0]["msg"])
+	assert.Equal(t, "/helpdesk.tickets.v1.TicketService/WatchTickets", l[0]["metodo"])
+	assert.Equal(t, "req-7", l[0]["request_id"])
+}
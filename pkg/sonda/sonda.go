@@ -0,0 +1,119 @@
+// Package sonda implementa as sondas de vida e de prontidao dos servicos.
+// /livez so diz que o processo responde; /readyz roda as verificacoes das
+// dependencias e deixa de responder ok assim que o desligamento comeca, para
+// que o balanceador pare de mandar requisicoes antes de o servidor fechar.
+package sonda
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Verificacao confere uma dependencia. Deve respeitar o prazo do contexto.
+type Verificacao func(ctx context.Context) error
+
+type verificacao struct {
+	nome      string
+	checar    Verificacao
+	essencial bool
+}
+
+// Sondas reune as verificacoes do servico.
+type Sondas struct {
+	timeout      time.Duration
+	verificacoes []verificacao
+	desligando   atomic.Bool
+}
+
+// Nova cria as sondas; timeout e o prazo de cada rodada de verificacoes.
+func Nova(timeout time.Duration) *Sondas {
+	return &Sondas{timeout: timeout}
+}
+
+// Adicionar registra uma dependencia sem a qual o servico nao deve receber
+// requisicoes. Deve ser chamado antes de o servidor atender.
+func (s *Sondas) Adicionar(nome string, v Verificacao) {
+	s.verificacoes = append(s.verificacoes, verificacao{nome: nome, checar: v, essencial: true})
+}
+
+// AdicionarOpcional registra uma dependencia que aparece no /readyz mas nao o
+// derruba: o servico continua util sem ela, em modo degradado.
+func (s *Sondas) AdicionarOpcional(nome string, v Verificacao) {
+	s.verificacoes = append(s.verificacoes, verificacao{nome: nome, checar: v})
+}
+
+// Desligando faz o /readyz responder 503 daqui em diante.
+func (s *Sondas) Desligando() {
+	s.desligando.Store(true)
+}
+
+type resposta struct {
+	Status       string            `json:"status"`
+	Verificacoes map[string]string `json:"verificacoes,omitempty"`
+}
+
+// LivezHandler responde enquanto o processo estiver de pe. Nao consulta
+// dependencias: uma falha no banco nao deve fazer o orquestrador reiniciar o
+// servico.
+func (s *Sondas) LivezHandler(w http.ResponseWriter, r *http.Request) {
+	escrever(w, http.StatusOK, resposta{Status: "ok"})
+}
+
+// ReadyzHandler roda todas as verificacoes em paralelo e responde 503 se
+// alguma essencial falhar ou se o servico estiver desligando.
+func (s *Sondas) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	if s.desligando.Load() {
+		escrever(w, http.StatusServiceUnavailable, resposta{Status: "desligando"})
+		return
+	}
+
+	resultados, pronto := s.Verificar(r.Context())
+	if !pronto {
+		escrever(w, http.StatusServiceUnavailable, resposta{Status: "indisponivel", Verificacoes: resultados})
+		return
+	}
+	escrever(w, http.StatusOK, resposta{Status: "ok", Verificacoes: resultados})
+}
+
+// Verificar roda as verificacoes e devolve o resultado de cada uma ("ok" ou
+// a mensagem de erro) e se todas as essenciais passaram.
+func (s *Sondas) Verificar(ctx context.Context) (map[string]string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var (
+		mu         sync.Mutex
+		wg         sync.WaitGroup
+		resultados = make(map[string]string, len(s.verificacoes))
+		pronto     = true
+	)
+	for _, v := range s.verificacoes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := v.checar(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				resultados[v.nome] = err.Error()
+				pronto = pronto && !v.essencial
+				return
+			}
+			resultados[v.nome] = "ok"
+		}()
+	}
+	wg.Wait()
+	return resultados, pronto
+}
+
+func escrever(w http.ResponseWriter, status int, corpo resposta) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(corpo)
+}
@@ -0,0 +1,67 @@
+package sonda
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readyz(s *Sondas) (int, resposta) {
+	rr := httptest.NewRecorder()
+	s.ReadyzHandler(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var corpo resposta
+	json.NewDecoder(rr.Body).Decode(&corpo)
+	return rr.Code, corpo
+}
+
+func TestReadyz(t *testing.T) {
+	var bancoFora, usersFora error
+	s := Nova(time.Second)
+	s.Adicionar("postgres", func(ctx context.Context) error { return bancoFora })
+	s.AdicionarOpcional("users-service", func(ctx context.Context) error { return usersFora })
+
+	codigo, corpo := readyz(s)
+	assert.Equal(t, http.StatusOK, codigo)
+	assert.Equal(t, map[string]string{"postgres": "ok", "users-service": "ok"}, corpo.Verificacoes)
+
+	// Sem o users-service o servico segue pronto, so degradado.
+	usersFora = errors.New("connection refused")
+	codigo, corpo = readyz(s)
+	assert.Equal(t, http.StatusOK, codigo)
+	assert.Equal(t, "connection refused", corpo.Verificacoes["users-service"])
+
+	bancoFora = errors.New("timeout")
+	codigo, corpo = readyz(s)
+	assert.Equal(t, http.StatusServiceUnavailable, codigo)
+	assert.Equal(t, "indisponivel", corpo.Status)
+
+	bancoFora = nil
+	s.Desligando()
+	codigo, corpo = readyz(s)
+	assert.Equal(t, http.StatusServiceUnavailable, codigo)
+	assert.Equal(t, "desligando", corpo.Status)
+
+	rr := httptest.NewRecorder()
+	s.LivezHandler(rr, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, rr.Code, "livez nao depende do desligamento nem das dependencias")
+}
+
+func TestVerificar_Prazo(t *testing.T) {
+	s := Nova(20 * time.Millisecond)
+	s.Adicionar("lenta", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	inicio := time.Now()
+	resultados, pronto := s.Verificar(context.Background())
+	assert.False(t, pronto)
+	assert.Equal(t, context.DeadlineExceeded.Error(), resultados["lenta"])
+	assert.Less(t, time.Since(inicio), time.Second)
+}
@@ -3,33 +3,49 @@ package auth
 import (
 	"errors"
 	"fmt"
+	"helpdesk/pkg/config"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var chaveJWT = []byte(os.Getenv("SEGREDOJWT"))
+// chaveJWT valida os tokens de usuario. Vem da configuracao, por Configurar.
+var chaveJWT []byte
+
+// validadeToken e a duracao dos tokens de login.
+var validadeToken = 60 * time.Minute
+
+// Configurar define a chave e a validade a partir da configuracao
+// carregada. Deve ser chamada antes de o servico atender.
+func Configurar(cfg config.JWT) {
+	chaveJWT = []byte(cfg.Segredo)
+	validadeToken = cfg.Validade
+}
 
 // ClaimCustom continua o mesmo, pois não depende de nenhum modelo específico.
 type ClaimCustom struct {
-	UserID int64  `json:"userID"`
-	Nome   string `json:"nome"`
-	Email  string `json:"email"`
+	UserID   int64  `json:"userID"`
+	Nome     string `json:"nome"`
+	Email    string `json:"email"`
+	TipoUser string `json:"tipoUser"`
+	// ActorID so e preenchido em tokens de personificacao: e o admin que
+	// esta de fato operando enquanto UserID e o usuario personificado.
+	ActorID int64 `json:"actorID,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // GerarToken agora recebe os dados do usuário diretamente.
 // Isso quebra a dependência que tínhamos do pacote 'model' do 'users-service'.
-func GerarToken(userID int64, nome, email string) (string, error) {
+func GerarToken(userID int64, nome, email, tipoUser string) (string, error) {
 	claims := ClaimCustom{
-		UserID: userID,
-		Nome:   nome,
-		Email:  email,
+		UserID:   userID,
+		Nome:     nome,
+		Email:    email,
+		TipoUser: tipoUser,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(60 * time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(validadeToken)),
 			Issuer:    "help-desk-api",
 		},
 	}
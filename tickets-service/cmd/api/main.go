@@ -1,83 +1,335 @@
 package main
 
 import (
+	"context"
+	"errors"
 	pkg "helpdesk/db"
+	"helpdesk/pkg/auditoria"
+	"helpdesk/pkg/config"
+	"helpdesk/pkg/metricas"
+	"helpdesk/pkg/pb"
+	"helpdesk/pkg/rastreio"
+	"helpdesk/pkg/registro"
+	"helpdesk/pkg/sonda"
+	"helpdesk/tickets-service/auth"
 	"helpdesk/tickets-service/middleware"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
+	"helpdesk/tickets-service/internal/bus"
 	"helpdesk/tickets-service/internal/handler"
+	"helpdesk/tickets-service/internal/inbound"
+	"helpdesk/tickets-service/internal/indicadores"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/notify"
+	"helpdesk/tickets-service/internal/outbox"
+	"helpdesk/tickets-service/internal/queue"
 	"helpdesk/tickets-service/internal/repository"
+	"helpdesk/tickets-service/internal/scanner"
+	"helpdesk/tickets-service/internal/storage"
+	"helpdesk/tickets-service/internal/stream"
+	"helpdesk/tickets-service/internal/users"
+	"helpdesk/tickets-service/internal/webhook"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres" // Driver do postgres para o migrate
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func main() {
-	runMigrations()
+	cfg, err := config.Carregar(config.TicketsService, os.Args[1:])
+	if err != nil {
+		falhar("Configuração inválida", "erro", err)
+	}
+	registro.Configurar(os.Stdout, cfg.Servico, cfg.Log)
+	slog.Info("Configuração carregada", "config", cfg.String())
+	auth.Configurar(cfg.JWT)
 
-	db, err := pkg.ConectaDB()
+	encerrarRastreio, err := rastreio.Iniciar(context.Background(), cfg.Servico, cfg.Rastreio)
 	if err != nil {
-		log.Fatalf("Erro ao iniciar o banco de dados: %v", err)
+		falhar("Erro ao iniciar o rastreio", "erro", err)
+	}
+
+	// SIGTERM ou SIGINT iniciam o desligamento. Os workers tem contexto
+	// proprio, cancelado so depois que HTTP e gRPC terminaram, para que os
+	// jobs das ultimas requisicoes ainda sejam enfileirados e processados.
+	ctx, parar := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer parar()
+	ctxWorkers, pararWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	rodar := func(executar func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			executar(ctxWorkers)
+		}()
 	}
 
-	var jobs = make(chan int64)
+	runMigrations(cfg.DB.URL)
 
-	for i := 0; i < 3; i++ {
-		go RunWorkers(jobs)
+	db, err := pkg.ConectaDB(cfg.DB)
+	if err != nil {
+		falhar("Erro ao iniciar o banco de dados", "erro", err)
 	}
 
 	repo := repository.NewRepository(db)
 
-	apiServer := handler.NewApiServer(repo, jobs)
+	// Bus entre replicas: o que acontece em uma replica chega a todas.
+	eventBus := bus.NovoPostgres(db)
+	rodar(eventBus.Executar)
+
+	// Perfis de usuarios vem da API gRPC interna do users-service, com cache
+	// invalidado pelo aviso que o users-service publica a cada alteracao. O
+	// cliente usa a identidade do tickets-service, entao serve tambem aos
+	// workers, que nao tem usuario.
+	configUsers := users.ConfigDe(cfg.Users)
+	usuarios, err := users.NovoCliente(configUsers)
+	if err != nil {
+		falhar("Erro ao configurar o cliente do users-service", "erro", err)
+	}
+	perfis := users.NovoResolver(usuarios, configUsers.CacheTTL)
+	eventBus.Assinar(bus.TopicoUsuarios, perfis.AoAlterarUsuario)
+
+	// Fila persistente: os jobs sobrevivem a reinicios e sao divididos entre
+	// os workers de todas as replicas, que o bus acorda a cada job novo.
+	jobs := queue.NovaFila(repo, queue.ConfigDe(cfg.Fila, cfg.Workers.Fila))
+	notificador, err := notify.NovoNotificador(repo, jobs, notify.NovoEnviador(cfg.SMTP), perfis, cfg.Notificacoes.URLBase)
+	if err != nil {
+		falhar("Erro ao carregar os templates de notificação", "erro", err)
+	}
+	entregador := webhook.NovoEntregador(repo)
+	jobs.Registrar(model.TipoJobNotificacao, queue.Tratar(notificador.Processar))
+	jobs.Registrar(model.TipoJobWebhook, queue.Tratar(entregador.Processar))
+	var sink *outbox.HTTPSink
+	if cfg.Outbox.SinkURL != "" {
+		sink = &outbox.HTTPSink{URL: cfg.Outbox.SinkURL}
+		jobs.Registrar(model.TipoJobOutboxHTTP, queue.Tratar(sink.Publicar))
+	}
+	jobs.AoEnfileirar(func(ctx context.Context) {
+		if err := eventBus.Publicar(ctx, bus.TopicoFila, nil); err != nil {
+			registro.Logger(ctx).Error("Erro ao avisar as replicas sobre o novo job", "erro", err)
+		}
+	})
+	eventBus.Assinar(bus.TopicoFila, func(ctx context.Context, dados []byte) { jobs.Acordar() })
+	rodar(jobs.Executar)
+
+	// Relay da outbox: entrega os eventos gravados junto com cada escrita.
+	relay := outbox.NovoRelay(repo)
+	relay.Assinar("notificacoes", outbox.AssinanteFunc(notify.AssinanteOutbox(jobs)))
+	relay.Assinar("webhooks", outbox.AssinanteFunc(entregador.AssinanteOutbox(jobs)))
+	relay.Assinar("bus", outbox.PublicarNoBus(eventBus))
+	if sink != nil {
+		// Por ultimo e pela fila: o destino externo nao atrasa os demais.
+		relay.Assinar("http", sink.AssinanteFila(jobs))
+	}
+	rodar(relay.Executar)
+
+	// Os streams de cada replica recebem os eventos pelo bus, ja que so uma
+	// replica por vez publica a outbox.
+	eventos := stream.NovoHub()
+	outbox.OuvirBus(eventBus, repo, eventos)
+
+	store, err := storage.Novo(cfg.Anexos, cfg.S3)
+	if err != nil {
+		falhar("Erro ao iniciar o storage de anexos", "erro", err)
+	}
+
+	apiServer := handler.NewApiServer(repo, store, scanner.Novo(cfg.Scanner), eventos, usuarios, perfis)
+	apiServer.LimitarAnexos(cfg.Anexos.MaxBytes)
+
+	// Emails recebidos pelo suporte viram tickets ou comentarios.
+	inbound.Iniciar(ctxWorkers, cfg.Email, apiServer)
+
+	// API gRPC ao lado da HTTP, com as mesmas regras; o JWT vai no metadata.
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(rastreio.ServidorUnario, registro.ServidorUnario, middleware.AuthUnario, middleware.AuditUnario("tickets-service", repo.RecordAudit)),
+		grpc.ChainStreamInterceptor(rastreio.ServidorStream, registro.ServidorStream, middleware.AuthStream),
+	)
+	pb.RegisterTicketServiceServer(grpcServer, handler.NewTicketGrpc(apiServer))
+	saudeGRPC := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, saudeGRPC)
+	go servirGRPC(grpcServer, cfg.Enderecos.GRPC)
+
+	// Metricas: latencia por rota, pool do banco e os indicadores de negocio,
+	// que consultam o banco a cada coleta.
+	prometheus.MustRegister(metricas.NovoColetorPool(db), indicadores.NovoColetor(repo, 5*time.Second))
 
 	r := chi.NewRouter()
+	r.Use(metricas.HTTP)
+	r.Use(rastreio.HTTP)
+	r.Use(registro.HTTP)
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.AuthMiddleware)
+		r.Use(auditoria.HTTP("tickets-service", repo.RecordAudit))
 		r.Post("/tickets", apiServer.CreateTicketHandler)
 		r.Post("/tickets/{id}/comments", apiServer.CreateCommentHandler)
 		r.Get("/tickets/my-tickets", apiServer.GetMyTicketsHandler)
+		r.Get("/tickets/organization", apiServer.GetOrganizationTicketsHandler)
+		r.Get("/tickets/watching", apiServer.GetWatchingTicketsHandler)
+		r.Get("/tickets/{id}/watchers", apiServer.ListWatchersHandler)
+		r.Post("/tickets/{id}/watchers", apiServer.AddWatcherHandler)
+		r.Delete("/tickets/{id}/watchers/{userID}", apiServer.RemoveWatcherHandler)
+		r.Post("/tickets/{id}/cc", apiServer.AddTicketCCHandler)
+		r.Delete("/tickets/{id}/cc/{userID}", apiServer.RemoveTicketCCHandler)
 		r.Get("/tickets", apiServer.ListTicketsHandler)
 		r.Get("/tickets/{id}", apiServer.GetTicketHandler)
 		r.Get("/tickets/{id}/comments", apiServer.ListCommentsByTicketHandler)
+		r.Get("/tickets/{id}/attachments", apiServer.ListTicketAttachmentsHandler)
+		r.Post("/tickets/{id}/attachments", apiServer.UploadTicketAttachmentHandler)
+		r.Post("/tickets/comments/{id}/attachments", apiServer.UploadCommentAttachmentHandler)
+		r.Get("/tickets/attachments/{id}/download", apiServer.DownloadAttachmentHandler)
+		r.Get("/tickets/attachments/{id}/thumbnail", apiServer.ThumbnailAttachmentHandler)
 		r.Get("/tickets/comments/users/{id}", apiServer.ListCommentsByUserHandler)
 		r.Put("/tickets/{id}", apiServer.UpdateTicketHandler)
 		r.Put("/tickets/comments/{id}", apiServer.UpdateCommentHandler)
 		r.Patch("/tickets/{id}/status", apiServer.UpdateTicketStatusHandler)
+		r.Patch("/tickets/{id}/assignee", apiServer.AssignTicketHandler)
+		r.Get("/notifications/preferences", apiServer.GetNotificationPreferencesHandler)
+		r.Put("/notifications/preferences", apiServer.UpdateNotificationPreferencesHandler)
+		r.Get("/admin/jobs", apiServer.ListJobsHandler)
+		r.Get("/admin/jobs/{id}", apiServer.GetJobHandler)
+		r.Post("/admin/jobs/{id}/retry", apiServer.RetryJobHandler)
+		r.Get("/admin/webhooks", apiServer.ListWebhooksHandler)
+		r.Post("/admin/webhooks", apiServer.CreateWebhookHandler)
+		r.Get("/admin/webhooks/{id}", apiServer.GetWebhookHandler)
+		r.Put("/admin/webhooks/{id}", apiServer.UpdateWebhookHandler)
+		r.Delete("/admin/webhooks/{id}", apiServer.DeleteWebhookHandler)
+		r.Get("/admin/webhooks/{id}/deliveries", apiServer.ListWebhookDeliveriesHandler)
+		r.Post("/admin/webhooks/{id}/test", apiServer.TestWebhookHandler)
 		r.Delete("/tickets/{id}", apiServer.DeleteTicketHandler)
 		r.Delete("/tickets/comments/{id}", apiServer.DeleteCommentHandler)
 	})
+	// Streams em tempo real: conexoes longas, sem auditoria, que tambem
+	// aceitam o token na URL.
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.TokenDaQuery)
+		r.Use(middleware.AuthMiddleware)
+		r.Get("/tickets/stream", apiServer.StreamTicketsHandler)
+		r.Get("/tickets/stream/ws", apiServer.StreamTicketsWebSocketHandler)
+	})
 	r.Get("/health", handler.HealthCheckHandler)
 
-	log.Println("Servidor HTTP iniciado na porta 8080")
-	if err := http.ListenAndServe(":8080", r); err != nil {
-		log.Fatalf("Falha ao iniciar o servidor HTTP: %v", err)
+	// Sondas: o users-service e opcional, pois sem ele os perfis saem
+	// degradados mas os tickets continuam sendo atendidos.
+	versao, err := pkg.VersaoMigracoes("db/migrations")
+	if err != nil {
+		falhar("Erro ao ler as migrações", "erro", err)
+	}
+	sondas := sonda.Nova(2 * time.Second)
+	sondas.Adicionar("postgres", db.Ping)
+	sondas.Adicionar("migracoes", pkg.ChecarMigracoes(db, versao))
+	sondas.AdicionarOpcional("users-service", usuarios.Saude)
+	r.Get("/livez", sondas.LivezHandler)
+	r.Get("/readyz", sondas.ReadyzHandler)
+	r.Handle("/metrics", metricas.Handler())
+
+	srv := &http.Server{Addr: cfg.Enderecos.HTTP, Handler: r}
+	go func() {
+		slog.Info("Servidor HTTP iniciado", "endereco", cfg.Enderecos.HTTP)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			falhar("Falha ao iniciar o servidor HTTP", "erro", err)
+		}
+	}()
+
+	<-ctx.Done()
+	parar()
+	slog.Info("Desligando: saindo de rotação", "espera", cfg.Desligamento.Espera.String())
+	sondas.Desligando()
+	saudeGRPC.Shutdown()
+	time.Sleep(cfg.Desligamento.Espera)
+
+	prazo, cancelar := context.WithTimeout(context.Background(), cfg.Desligamento.Prazo)
+	defer cancelar()
+
+	// Os streams sao conexoes longas: sao encerrados com o aviso para
+	// reconectar, senao o Shutdown esperaria por eles ate o prazo.
+	eventos.Encerrar()
+	go pararGRPC(prazo, grpcServer)
+	if err := srv.Shutdown(prazo); err != nil {
+		slog.Warn("Requisições HTTP interrompidas no prazo do desligamento", "erro", err)
+		srv.Close()
 	}
 
+	pararWorkers()
+	if !esperar(prazo, &workers) {
+		slog.Warn("Workers interrompidos no prazo do desligamento; os jobs reservados voltam à fila ao fim do lease")
+	}
+	usuarios.Close()
+	db.Close()
+	if err := encerrarRastreio(prazo); err != nil {
+		slog.Warn("Spans perdidos no desligamento", "erro", err)
+	}
+	slog.Info("Servidor desligado")
 }
 
-func runMigrations() {
+// pararGRPC espera as chamadas em andamento terminarem e, passado o prazo,
+// fecha as que restarem.
+func pararGRPC(prazo context.Context, s *grpc.Server) {
+	terminou := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(terminou)
+	}()
+	select {
+	case <-terminou:
+	case <-prazo.Done():
+		s.Stop()
+	}
+}
+
+// esperar aguarda o WaitGroup ate o prazo; devolve false se o prazo venceu.
+func esperar(prazo context.Context, wg *sync.WaitGroup) bool {
+	terminou := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(terminou)
+	}()
+	select {
+	case <-terminou:
+		return true
+	case <-prazo.Done():
+		return false
+	}
+}
+
+func servirGRPC(s *grpc.Server, endereco string) {
+	lis, err := net.Listen("tcp", endereco)
+	if err != nil {
+		falhar("Falha ao abrir a porta do servidor gRPC", "erro", err)
+	}
+	slog.Info("Servidor gRPC iniciado", "endereco", endereco)
+	if err := s.Serve(lis); err != nil {
+		falhar("Falha ao iniciar o servidor gRPC", "erro", err)
+	}
+}
+
+func runMigrations(dbURL string) {
 	migrationDir := "file://db/migrations"
-	dbURL := os.Getenv("CHAVEDB")
 	m, err := migrate.New(migrationDir, dbURL)
 	if err != nil {
-		log.Fatalf("Erro ao criar a instancia de migração: %v", err)
+		falhar("Erro ao criar a instancia de migração", "erro", err)
 	}
 
 	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatalf("Erro ao cplicar migrações: %v", err)
+		falhar("Erro ao aplicar migrações", "erro", err)
 	}
 
-	log.Println("Migrações aplicadas com sucesso!")
+	slog.Info("Migrações aplicadas com sucesso!")
 }
 
-func RunWorkers(jobs <-chan int64) {
-	for id := range jobs {
-		log.Println("Simulando o envio de notificação para o ticket: ", id)
-		time.Sleep(2 * time.Second)
-	}
+// falhar registra o erro que impede o servico de subir e encerra o processo.
+func falhar(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
@@ -0,0 +1,142 @@
+// Package breaker implementa um disjuntor (circuit breaker) para as chamadas
+// a outros servicos. Depois de varias falhas seguidas o disjuntor abre e as
+// chamadas falham na hora, sem esperar o timeout, ate passar o tempo de
+// espera; entao uma unica chamada de teste decide se ele fecha de novo.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type Estado int
+
+const (
+	Fechado    Estado = iota // Chamadas passam normalmente
+	Aberto                   // Chamadas sao recusadas com ErrAberto
+	MeioAberto               // Uma chamada de teste esta em andamento
+)
+
+func (e Estado) String() string {
+	switch e {
+	case Aberto:
+		return "aberto"
+	case MeioAberto:
+		return "meio-aberto"
+	}
+	return "fechado"
+}
+
+var ErrAberto = errors.New("disjuntor aberto: servico indisponivel")
+
+// Config ajusta quando o disjuntor abre e por quanto tempo.
+type Config struct {
+	Falhas int           // Falhas seguidas para abrir
+	Espera time.Duration // Tempo aberto antes da chamada de teste
+}
+
+// Disjuntor protege as chamadas a uma dependencia. E seguro para uso concorrente.
+type Disjuntor struct {
+	nome  string
+	cfg   Config
+	falha func(error) bool
+
+	mu       sync.Mutex
+	estado   Estado
+	falhas   int
+	abertoEm time.Time
+	agora    func() time.Time
+}
+
+// Novo cria um disjuntor fechado. falha diz quais erros contam como
+// indisponibilidade; os demais (ex: registro nao encontrado) mostram que a
+// dependencia respondeu e contam como sucesso.
+func Novo(nome string, cfg Config, falha func(error) bool) *Disjuntor {
+	if cfg.Falhas <= 0 {
+		cfg.Falhas = 5
+	}
+	if cfg.Espera <= 0 {
+		cfg.Espera = 30 * time.Second
+	}
+	return &Disjuntor{nome: nome, cfg: cfg, falha: falha, agora: time.Now}
+}
+
+// Executar chama fn se o disjuntor permitir e registra o resultado. Com o
+// disjuntor aberto devolve ErrAberto sem chamar fn.
+func (d *Disjuntor) Executar(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if err := d.permitir(); err != nil {
+		return err
+	}
+	// O registro fica no defer para que um panico em fn nao deixe a chamada
+	// de teste presa e o disjuntor meio-aberto para sempre.
+	terminou := false
+	defer func() { d.registrar(ctx, err, terminou) }()
+
+	err = fn(ctx)
+	terminou = true
+	return err
+}
+
+// Estado devolve o estado atual, para diagnostico.
+func (d *Disjuntor) Estado() Estado {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.estado
+}
+
+func (d *Disjuntor) permitir() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch d.estado {
+	case Aberto:
+		if d.agora().Sub(d.abertoEm) < d.cfg.Espera {
+			return ErrAberto
+		}
+		d.estado = MeioAberto
+		return nil
+	case MeioAberto:
+		// So a chamada de teste passa.
+		return ErrAberto
+	}
+	return nil
+}
+
+// registrar atualiza o estado com o resultado de uma chamada. terminou e
+// falso quando fn entrou em panico.
+func (d *Disjuntor) registrar(ctx context.Context, err error, terminou bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if terminou && err != nil && d.falha(err) {
+		d.falhas++
+		if d.estado == MeioAberto || d.falhas >= d.cfg.Falhas {
+			if d.estado != Aberto {
+				slog.Warn("Disjuntor aberto", "disjuntor", d.nome, "falhas", d.falhas, "erro", err)
+			}
+			d.estado = Aberto
+			d.abertoEm = d.agora()
+		}
+		return
+	}
+
+	// So uma resposta do servico conta como sucesso. Uma chamada cancelada por
+	// quem chamou, ou que nao terminou, nada diz sobre o servico: nao mexe nas
+	// falhas e, se era a chamada de teste, o disjuntor volta a aberto sem
+	// recomecar a espera, para que a proxima chamada teste de novo.
+	if !terminou || ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		if d.estado == MeioAberto {
+			d.estado = Aberto
+		}
+		return
+	}
+
+	if d.estado != Fechado {
+		slog.Info("Disjuntor fechado, o serviço voltou a responder", "disjuntor", d.nome)
+	}
+	d.estado = Fechado
+	d.falhas = 0
+}
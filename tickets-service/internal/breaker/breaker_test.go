@@ -0,0 +1,113 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errFora        = errors.New("fora do ar")
+	errInexistente = errors.New("nao encontrado")
+)
+
+func novoTeste() (*Disjuntor, *time.Time) {
+	agora := time.Now()
+	d := Novo("teste", Config{Falhas: 2, Espera: time.Minute}, func(err error) bool { return err == errFora })
+	d.agora = func() time.Time { return agora }
+	return d, &agora
+}
+
+func chamar(d *Disjuntor, resultado error) (chamou bool, err error) {
+	err = d.Executar(context.Background(), func(ctx context.Context) error {
+		chamou = true
+		return resultado
+	})
+	return chamou, err
+}
+
+func TestDisjuntor_AbreEFecha(t *testing.T) {
+	d, agora := novoTeste()
+
+	chamar(d, errFora)
+	assert.Equal(t, Fechado, d.Estado())
+	chamar(d, errFora)
+	assert.Equal(t, Aberto, d.Estado())
+
+	// Aberto, nem chega a chamar.
+	chamou, err := chamar(d, nil)
+	assert.False(t, chamou)
+	assert.ErrorIs(t, err, ErrAberto)
+
+	// Passada a espera, a chamada de teste fecha o disjuntor.
+	*agora = agora.Add(time.Minute)
+	chamou, err = chamar(d, nil)
+	assert.True(t, chamou)
+	assert.NoError(t, err)
+	assert.Equal(t, Fechado, d.Estado())
+}
+
+func TestDisjuntor_TesteFalhoReabre(t *testing.T) {
+	d, agora := novoTeste()
+	chamar(d, errFora)
+	chamar(d, errFora)
+
+	*agora = agora.Add(time.Minute)
+	chamou, _ := chamar(d, errFora)
+	assert.True(t, chamou)
+	assert.Equal(t, Aberto, d.Estado())
+
+	// A espera recomeca a partir do teste que falhou.
+	*agora = agora.Add(30 * time.Second)
+	chamou, _ = chamar(d, nil)
+	assert.False(t, chamou)
+}
+
+func TestDisjuntor_ErrosDeNegocioNaoContam(t *testing.T) {
+	d, _ := novoTeste()
+
+	chamar(d, errFora)
+	chamar(d, errInexistente)
+	chamar(d, errFora)
+	assert.Equal(t, Fechado, d.Estado(), "a resposta do servico zera as falhas seguidas")
+}
+
+func TestDisjuntor_TesteCanceladoNaoFecha(t *testing.T) {
+	d, agora := novoTeste()
+	chamar(d, errFora)
+	chamar(d, errFora)
+	*agora = agora.Add(time.Minute)
+
+	// O cancelamento nao e contado pelo falha(), mas tambem nao e resposta
+	// do servico: o disjuntor continua aberto.
+	ctx, cancelar := context.WithCancel(context.Background())
+	cancelar()
+	err := d.Executar(ctx, func(ctx context.Context) error { return ctx.Err() })
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, Aberto, d.Estado())
+
+	// A espera nao recomeca: a proxima chamada ja e o novo teste.
+	chamou, err := chamar(d, nil)
+	assert.True(t, chamou)
+	assert.NoError(t, err)
+	assert.Equal(t, Fechado, d.Estado())
+}
+
+func TestDisjuntor_PanicoNoTesteLiberaAVaga(t *testing.T) {
+	d, agora := novoTeste()
+	chamar(d, errFora)
+	chamar(d, errFora)
+	*agora = agora.Add(time.Minute)
+
+	assert.Panics(t, func() {
+		d.Executar(context.Background(), func(ctx context.Context) error { panic("falhou") })
+	})
+	assert.Equal(t, Aberto, d.Estado(), "sem o defer o disjuntor ficaria meio-aberto")
+
+	chamou, _ := chamar(d, nil)
+	assert.True(t, chamou)
+	assert.Equal(t, Fechado, d.Estado())
+}
@@ -0,0 +1,118 @@
+// Package bus distribui mensagens entre todas as replicas do tickets-service.
+// Uma mensagem publicada em um topico chega a todos os assinantes desse
+// topico, em qualquer replica. A entrega e "no maximo uma vez": uma replica
+// desconectada perde as mensagens do periodo, entao o bus serve para avisos
+// (invalidar cache, acordar workers, alimentar streams que sabem se retomar),
+// nunca como fonte de verdade.
+package bus
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Topicos usados pelo servico. Sao nomes de canais do PostgreSQL: letras
+// minusculas, digitos e _, com ate 63 caracteres.
+const (
+	TopicoEventos = "helpdesk_eventos" // Eventos de dominio publicados pela outbox
+	TopicoFila    = "helpdesk_fila"    // Ha jobs novos na fila
+	// TopicoUsuarios recebe o ID de cada usuario alterado ou removido. E
+	// publicado pelo users-service, direto no banco.
+	TopicoUsuarios = "helpdesk_usuarios"
+)
+
+// MaxMensagem e o maior payload aceito, abaixo do limite de 8000 bytes do NOTIFY.
+const MaxMensagem = 7900
+
+var ErrMensagemGrande = errors.New("mensagem maior que o limite do bus")
+
+// Handler trata uma mensagem. E chamado na goroutine que recebe as mensagens
+// do bus, entao deve ser rapido e nao bloquear.
+type Handler func(ctx context.Context, dados []byte)
+
+type Bus interface {
+	// Publicar envia a mensagem a todos os assinantes do topico.
+	Publicar(ctx context.Context, topico string, dados []byte) error
+	// Assinar registra o handler no topico e devolve a funcao que cancela a assinatura.
+	Assinar(topico string, h Handler) (cancelar func())
+}
+
+// assinantes e o registro de handlers por topico comum as implementacoes.
+type assinantes struct {
+	mu      sync.RWMutex
+	proximo int
+	topicos map[string]map[int]Handler
+}
+
+// adicionar devolve true quando o topico ainda nao tinha assinantes.
+func (a *assinantes) adicionar(topico string, h Handler) (id int, novo bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.topicos == nil {
+		a.topicos = map[string]map[int]Handler{}
+	}
+	if a.topicos[topico] == nil {
+		a.topicos[topico] = map[int]Handler{}
+		novo = true
+	}
+	a.proximo++
+	a.topicos[topico][a.proximo] = h
+	return a.proximo, novo
+}
+
+func (a *assinantes) remover(topico string, id int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.topicos[topico], id)
+	if len(a.topicos[topico]) == 0 {
+		delete(a.topicos, topico)
+	}
+}
+
+func (a *assinantes) lista() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	topicos := make([]string, 0, len(a.topicos))
+	for t := range a.topicos {
+		topicos = append(topicos, t)
+	}
+	return topicos
+}
+
+func (a *assinantes) entregar(ctx context.Context, topico string, dados []byte) {
+	a.mu.RLock()
+	handlers := make([]Handler, 0, len(a.topicos[topico]))
+	for _, h := range a.topicos[topico] {
+		handlers = append(handlers, h)
+	}
+	a.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(ctx, dados)
+	}
+}
+
+// Memoria e o bus de uma unica replica, para testes e para rodar sem banco.
+// Publicar entrega a mensagem na hora, na goroutine de quem publicou.
+type Memoria struct {
+	assinantes assinantes
+}
+
+func NovoMemoria() *Memoria {
+	return &Memoria{}
+}
+
+func (m *Memoria) Publicar(ctx context.Context, topico string, dados []byte) error {
+	if len(dados) > MaxMensagem {
+		return ErrMensagemGrande
+	}
+	m.assinantes.entregar(ctx, topico, dados)
+	return nil
+}
+
+func (m *Memoria) Assinar(topico string, h Handler) func() {
+	id, _ := m.assinantes.adicionar(topico, h)
+	var once sync.Once
+	return func() { once.Do(func() { m.assinantes.remover(topico, id) }) }
+}
@@ -0,0 +1,50 @@
+package bus
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoria(t *testing.T) {
+	b := NovoMemoria()
+	ctx := context.Background()
+
+	var recebidas []string
+	cancelar := b.Assinar(TopicoEventos, func(ctx context.Context, dados []byte) {
+		recebidas = append(recebidas, "a:"+string(dados))
+	})
+	b.Assinar(TopicoEventos, func(ctx context.Context, dados []byte) {
+		recebidas = append(recebidas, "b:"+string(dados))
+	})
+	b.Assinar(TopicoFila, func(ctx context.Context, dados []byte) {
+		recebidas = append(recebidas, "fila")
+	})
+
+	assert.NoError(t, b.Publicar(ctx, TopicoEventos, []byte("1")))
+	assert.ElementsMatch(t, []string{"a:1", "b:1"}, recebidas)
+
+	recebidas = nil
+	cancelar()
+	cancelar()
+	assert.NoError(t, b.Publicar(ctx, TopicoEventos, []byte("2")))
+	assert.Equal(t, []string{"b:2"}, recebidas)
+
+	assert.ErrorIs(t, b.Publicar(ctx, TopicoEventos, []byte(strings.Repeat("x", MaxMensagem+1))), ErrMensagemGrande)
+}
+
+func TestAssinantes_Topicos(t *testing.T) {
+	var a assinantes
+
+	id1, novo := a.adicionar("t1", func(context.Context, []byte) {})
+	assert.True(t, novo)
+	id2, novo := a.adicionar("t1", func(context.Context, []byte) {})
+	assert.False(t, novo, "o topico ja era escutado")
+
+	a.remover("t1", id1)
+	assert.Equal(t, []string{"t1"}, a.lista())
+	a.remover("t1", id2)
+	assert.Empty(t, a.lista(), "sem assinantes o topico deixa de ser escutado")
+}
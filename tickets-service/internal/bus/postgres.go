@@ -0,0 +1,132 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Postgres usa LISTEN/NOTIFY: Publicar faz NOTIFY por uma conexao qualquer do
+// pool e Executar mantem uma conexao propria, fora do pool, escutando os
+// topicos assinados. Todas as replicas ligadas ao mesmo banco recebem as
+// mensagens, inclusive a que publicou.
+type Postgres struct {
+	pool       *pgxpool.Pool
+	assinantes assinantes
+	mudou      chan struct{}
+}
+
+func NovoPostgres(pool *pgxpool.Pool) *Postgres {
+	return &Postgres{pool: pool, mudou: make(chan struct{}, 1)}
+}
+
+func (p *Postgres) Publicar(ctx context.Context, topico string, dados []byte) error {
+	if len(dados) > MaxMensagem {
+		return ErrMensagemGrande
+	}
+	_, err := p.pool.Exec(ctx, "SELECT pg_notify($1, $2)", topico, string(dados))
+	return err
+}
+
+func (p *Postgres) Assinar(topico string, h Handler) func() {
+	id, novo := p.assinantes.adicionar(topico, h)
+	if novo {
+		p.avisarMudanca()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.assinantes.remover(topico, id)
+			p.avisarMudanca()
+		})
+	}
+}
+
+func (p *Postgres) avisarMudanca() {
+	select {
+	case p.mudou <- struct{}{}:
+	default:
+	}
+}
+
+// Executar escuta os topicos ate o contexto ser cancelado, reconectando com
+// espera crescente quando a conexao cai. Mensagens publicadas enquanto a
+// conexao esta fora sao perdidas.
+func (p *Postgres) Executar(ctx context.Context) {
+	espera := time.Second
+	for ctx.Err() == nil {
+		conectou, err := p.escutar(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if conectou {
+			espera = time.Second
+		}
+		slog.Warn("Conexão do bus de eventos perdida, reconectando", "espera", espera.String(), "erro", err)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(espera):
+		}
+		espera = min(espera*2, 30*time.Second)
+	}
+}
+
+// escutar abre a conexao de escuta e entrega as notificacoes ate um erro.
+// Sempre que as assinaturas mudam, a espera e interrompida para acertar os
+// LISTEN/UNLISTEN da conexao.
+func (p *Postgres) escutar(ctx context.Context) (conectou bool, err error) {
+	conn, err := pgx.ConnectConfig(ctx, p.pool.Config().ConnConfig)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	escutando := map[string]bool{}
+	for {
+		atuais := map[string]bool{}
+		for _, topico := range p.assinantes.lista() {
+			atuais[topico] = true
+			if !escutando[topico] {
+				if _, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{topico}.Sanitize()); err != nil {
+					return true, err
+				}
+				escutando[topico] = true
+			}
+		}
+		for topico := range escutando {
+			if !atuais[topico] {
+				if _, err = conn.Exec(ctx, "UNLISTEN "+pgx.Identifier{topico}.Sanitize()); err != nil {
+					return true, err
+				}
+				delete(escutando, topico)
+			}
+		}
+
+		ctxEspera, cancelar := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-p.mudou:
+				cancelar()
+			case <-ctxEspera.Done():
+			}
+		}()
+		n, err := conn.WaitForNotification(ctxEspera)
+		mudou := ctxEspera.Err() != nil
+		cancelar()
+
+		if err != nil {
+			if mudou && ctx.Err() == nil && errors.Is(err, context.Canceled) {
+				continue
+			}
+			return true, err
+		}
+		p.assinantes.entregar(ctx, n.Channel, []byte(n.Payload))
+	}
+}
@@ -0,0 +1,524 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"helpdesk/pkg/problema"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/storage"
+	"helpdesk/tickets-service/internal/thumbnail"
+	"helpdesk/tickets-service/middleware"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// Limites de upload. O tamanho maximo por arquivo vem de anexos.max_bytes,
+// por LimitarAnexos; sem ele vale maxAnexoPadrao.
+const (
+	maxAnexoPadrao       = 10 << 20
+	maxArquivosPorUpload = 10
+)
+
+// tiposPermitidos lista os tipos MIME aceitos como anexo.
+var tiposPermitidos = map[string]bool{
+	"image/png":          true,
+	"image/jpeg":         true,
+	"image/gif":          true,
+	"image/webp":         true,
+	"application/pdf":    true,
+	"text/plain":         true,
+	"text/csv":           true,
+	"application/json":   true,
+	"application/zip":    true,
+	"application/x-gzip": true,
+}
+
+// LimitarAnexos define o tamanho maximo de cada anexo, em bytes.
+func (api *ApiServer) LimitarAnexos(maxBytes int64) {
+	api.maxAnexo = maxBytes
+}
+
+// podeVerTicket aplica a regra de leitura de um ticket: agentes e o autor
+// sempre podem; os demais precisam participar dele (copia, watcher ou manager).
+func (api *ApiServer) podeVerTicket(ctx context.Context, ticket model.Ticket) (bool, error) {
+	idReq, _ := ctx.Value(middleware.UserIDKey).(int64)
+	return api.usuarioVeTicket(ticket, idReq, agente(ctx))
+}
+
+// usuarioVeTicket e a regra de podeVerTicket para um usuario qualquer, como
+// os mencionados em um comentario.
+func (api *ApiServer) usuarioVeTicket(ticket model.Ticket, userID int64, agente bool) (bool, error) {
+	if agente || ticket.UserID == userID {
+		return true, nil
+	}
+	return api.rep.CanViewTicket(ticket.ID, userID)
+}
+
+func (api *ApiServer) UploadTicketAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	idInt, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "ID inválido, deve ser um número inteiro")
+		return
+	}
+
+	ticket, err := api.rep.GetTicketByID(r.Context(), idInt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Registro inexistente")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao obter o ticket no banco de dados")
+		return
+	}
+
+	pode, err := api.podeVerTicket(r.Context(), ticket)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao verificar as permissões do ticket")
+		return
+	}
+	if !pode {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	api.receberAnexos(w, r, ticket.ID, 0)
+}
+
+func (api *ApiServer) UploadCommentAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	idInt, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "ID do comentário inválido, deve ser um número inteiro")
+		return
+	}
+
+	comentario, err := api.rep.GetCommentByID(idInt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Comentário não encontrado")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar o comentário no banco de dados")
+		return
+	}
+
+	idReq, _ := r.Context().Value(middleware.UserIDKey).(int64)
+	if comentario.UserID != idReq {
+		problema.Escrever(w, r, http.StatusForbidden, "Acesso não concedido!")
+		return
+	}
+
+	api.receberAnexos(w, r, comentario.TicketID, comentario.ID)
+}
+
+// receberAnexos le o corpo multipart em streaming e so grava depois de
+// validar e passar no antivirus todos os arquivos: um arquivo recusado nao
+// deixa os anteriores salvos pela metade. Se algum estiver infectado, so os
+// infectados sao gravados, em quarentena, e a resposta e 422. Do contrario
+// responde com a lista criada.
+func (api *ApiServer) receberAnexos(w http.ResponseWriter, r *http.Request, ticketID, commentID int64) {
+	idReq, _ := r.Context().Value(middleware.UserIDKey).(int64)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxArquivosPorUpload*(api.maxAnexo+64<<10))
+	leitor, err := r.MultipartReader()
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "A requisição deve ser multipart/form-data")
+		return
+	}
+
+	var recebidos []arquivoRecebido
+	defer func() {
+		for _, a := range recebidos {
+			a.descartar()
+		}
+	}()
+	for {
+		parte, err := leitor.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			problema.Escrever(w, r, http.StatusBadRequest, "Erro ao ler o corpo multipart: "+err.Error())
+			return
+		}
+		if parte.FileName() == "" {
+			parte.Close()
+			continue
+		}
+
+		if len(recebidos) == maxArquivosPorUpload {
+			problema.Escrever(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Máximo de %d arquivos por envio", maxArquivosPorUpload))
+			return
+		}
+
+		recebido, status, err := api.receberArquivo(r.Context(), parte.FileName(), parte.Header.Get("Content-Type"), parte)
+		parte.Close()
+		if err != nil {
+			problema.Escrever(w, r, status, fmt.Sprintf("%s: %s (nenhum arquivo foi salvo)", parte.FileName(), err.Error()))
+			return
+		}
+		recebidos = append(recebidos, recebido)
+	}
+
+	if len(recebidos) == 0 {
+		problema.Escrever(w, r, http.StatusBadRequest, "Nenhum arquivo enviado")
+		return
+	}
+
+	var bloqueados []string
+	for _, a := range recebidos {
+		if a.anexo.Status != model.AnexoQuarentena {
+			continue
+		}
+		if _, status, err := api.guardarArquivo(r.Context(), a, ticketID, commentID, idReq); err != nil {
+			problema.Escrever(w, r, status, err.Error())
+			return
+		}
+		bloqueados = append(bloqueados, fmt.Sprintf("%q (%s)", a.anexo.NomeArquivo, a.anexo.MotivoQuarentena))
+	}
+	if len(bloqueados) > 0 {
+		problema.Escrever(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("Bloqueado pelo antivírus e colocado em quarentena: %s. Nenhum outro arquivo foi salvo", strings.Join(bloqueados, ", ")))
+		return
+	}
+
+	criados := make([]model.Attachment, 0, len(recebidos))
+	for _, a := range recebidos {
+		anexo, status, err := api.guardarArquivo(r.Context(), a, ticketID, commentID, idReq)
+		if err != nil {
+			problema.Escrever(w, r, status, err.Error())
+			return
+		}
+		preencherURLs(&anexo)
+		criados = append(criados, anexo)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(criados); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar a resposta em JSON")
+		return
+	}
+}
+
+// gravarAnexo recebe e grava um unico arquivo. E usado pela entrada de
+// emails, em que cada anexo e independente.
+func (api *ApiServer) gravarAnexo(ctx context.Context, ticketID, commentID, userID int64, nome, contentType string, conteudo io.Reader) (model.Attachment, int, error) {
+	recebido, status, err := api.receberArquivo(ctx, nome, contentType, conteudo)
+	if err != nil {
+		return model.Attachment{}, status, err
+	}
+	defer recebido.descartar()
+
+	return api.guardarArquivo(ctx, recebido, ticketID, commentID, userID)
+}
+
+// arquivoRecebido e um arquivo ja validado e verificado pelo antivirus, que
+// espera no temporario para ser gravado.
+type arquivoRecebido struct {
+	anexo   model.Attachment
+	arquivo *os.File
+}
+
+func (a arquivoRecebido) descartar() {
+	a.arquivo.Close()
+	os.Remove(a.arquivo.Name())
+}
+
+// receberArquivo copia o arquivo para um temporario, validando tamanho e tipo
+// e calculando o SHA-256. O tipo e detectado pelo conteudo, nunca pela
+// extensao ou pelo Content-Type declarado. Depois o arquivo passa pelo
+// antivirus: se estiver infectado, sera gravado em quarentena. Nada e gravado
+// aqui; em caso de erro devolve o status HTTP adequado.
+func (api *ApiServer) receberArquivo(ctx context.Context, nome, contentType string, conteudo io.Reader) (arquivoRecebido, int, error) {
+	tmp, err := os.CreateTemp("", "anexo-*")
+	if err != nil {
+		return arquivoRecebido{}, http.StatusInternalServerError, errors.New("Erro ao preparar o upload")
+	}
+	recebido := arquivoRecebido{arquivo: tmp}
+	falhar := func(status int, err error) (arquivoRecebido, int, error) {
+		recebido.descartar()
+		return arquivoRecebido{}, status, err
+	}
+
+	hash := sha256.New()
+	tamanho, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(conteudo, api.maxAnexo+1))
+	if err != nil {
+		return falhar(http.StatusBadRequest, errors.New("Erro ao receber o arquivo"))
+	}
+	if tamanho > api.maxAnexo {
+		return falhar(http.StatusRequestEntityTooLarge, fmt.Errorf("Arquivo maior que o limite de %d bytes", api.maxAnexo))
+	}
+
+	cabeca := make([]byte, 512)
+	n, _ := tmp.ReadAt(cabeca, 0)
+	mediaType := detectarTipo(cabeca[:n], contentType)
+	if !tiposPermitidos[mediaType] {
+		return falhar(http.StatusUnsupportedMediaType, fmt.Errorf("Tipo de arquivo não permitido: %s", mediaType))
+	}
+
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return falhar(http.StatusInternalServerError, errors.New("Erro ao preparar o upload"))
+	}
+	veredito, err := api.scanner.Scan(ctx, tmp)
+	if err != nil {
+		registro.Logger(ctx).Error("Erro ao verificar o anexo no antivírus", "erro", err)
+		return falhar(http.StatusServiceUnavailable, errors.New("Verificação de antivírus indisponível, tente novamente mais tarde"))
+	}
+
+	chave, err := novaChaveStorage()
+	if err != nil {
+		return falhar(http.StatusInternalServerError, errors.New("Erro ao preparar o upload"))
+	}
+
+	recebido.anexo = model.Attachment{
+		NomeArquivo: filepath.Base(strings.ReplaceAll(nome, "\\", "/")),
+		ContentType: mediaType,
+		Tamanho:     tamanho,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		Status:      model.AnexoDisponivel,
+	}
+	if veredito.Infectado {
+		chave = "quarentena/" + chave
+		recebido.anexo.Status = model.AnexoQuarentena
+		recebido.anexo.MotivoQuarentena = veredito.Assinatura
+		registro.Logger(ctx).Warn("Anexo bloqueado pelo antivírus", "arquivo", recebido.anexo.NomeArquivo, "assinatura", veredito.Assinatura)
+	}
+	recebido.anexo.ChaveStorage = chave
+
+	return recebido, http.StatusCreated, nil
+}
+
+// guardarArquivo grava o arquivo recebido no storage, com a miniatura se for
+// imagem, e registra seus metadados no banco.
+func (api *ApiServer) guardarArquivo(ctx context.Context, recebido arquivoRecebido, ticketID, commentID, userID int64) (model.Attachment, int, error) {
+	anexo := recebido.anexo
+	if _, err := recebido.arquivo.Seek(0, io.SeekStart); err != nil {
+		return model.Attachment{}, http.StatusInternalServerError, errors.New("Erro ao preparar o upload")
+	}
+	if err := api.store.Put(ctx, anexo.ChaveStorage, recebido.arquivo, anexo.Tamanho, anexo.ContentType); err != nil {
+		registro.Logger(ctx).Error("Erro ao gravar o anexo no storage", "erro", err)
+		return model.Attachment{}, http.StatusBadGateway, errors.New("Erro ao gravar o arquivo no storage")
+	}
+
+	if anexo.Status == model.AnexoDisponivel && thumbnail.Suportado(anexo.ContentType) {
+		anexo.ThumbnailChave = api.gerarThumbnail(ctx, recebido.arquivo, anexo.ChaveStorage)
+	}
+
+	anexo.TicketID = ticketID
+	anexo.CommentID = commentID
+	anexo.UserID = userID
+
+	var err error
+	anexo.ID, err = api.rep.CreateAttachment(anexo)
+	if err != nil {
+		if derr := api.store.Delete(ctx, anexo.ChaveStorage); derr != nil {
+			registro.Logger(ctx).Error("Erro ao remover anexo órfão do storage", "chave", anexo.ChaveStorage, "erro", derr)
+		}
+		return model.Attachment{}, http.StatusInternalServerError, errors.New("Erro ao adicionar o anexo no banco de dados")
+	}
+	anexo.Data = time.Now()
+
+	return anexo, http.StatusCreated, nil
+}
+
+// detectarTipo identifica o tipo MIME pelos primeiros bytes do arquivo. O
+// tipo declarado pelo cliente so e usado para refinar texto puro (CSV, JSON),
+// que nao tem assinatura propria.
+func detectarTipo(cabeca []byte, declarado string) string {
+	detectado, _, _ := mime.ParseMediaType(http.DetectContentType(cabeca))
+	if detectado == "text/plain" {
+		if d, _, err := mime.ParseMediaType(declarado); err == nil && (d == "text/csv" || d == "application/json") {
+			return d
+		}
+	}
+	return detectado
+}
+
+// gerarThumbnail grava a miniatura ao lado do original e devolve sua chave.
+// Falhas nao impedem o upload: o anexo apenas fica sem miniatura.
+func (api *ApiServer) gerarThumbnail(ctx context.Context, origem io.ReadSeeker, chave string) string {
+	if _, err := origem.Seek(0, io.SeekStart); err != nil {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	if err := thumbnail.Gerar(origem, &buf); err != nil {
+		registro.Logger(ctx).Warn("Não foi possivel gerar a miniatura", "chave", chave, "erro", err)
+		return ""
+	}
+
+	chaveThumb := chave + ".thumb.png"
+	if err := api.store.Put(ctx, chaveThumb, &buf, int64(buf.Len()), "image/png"); err != nil {
+		registro.Logger(ctx).Error("Erro ao gravar a miniatura no storage", "chave", chave, "erro", err)
+		return ""
+	}
+	return chaveThumb
+}
+
+func novaChaveStorage() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "anexos/" + time.Now().UTC().Format("2006/01/02") + "/" + hex.EncodeToString(b), nil
+}
+
+// preencherURLs monta os links de download e de miniatura do anexo.
+func preencherURLs(anexo *model.Attachment) {
+	anexo.URL = fmt.Sprintf("/tickets/attachments/%d/download", anexo.ID)
+	if anexo.ThumbnailChave != "" {
+		anexo.ThumbnailURL = fmt.Sprintf("/tickets/attachments/%d/thumbnail", anexo.ID)
+	}
+}
+
+// listarAnexosVisiveis devolve os anexos do ticket que o usuario pode ver,
+// ja com a URL de download preenchida.
+func (api *ApiServer) listarAnexosVisiveis(r *http.Request, ticketID int64) ([]model.Attachment, error) {
+	anexos, err := api.rep.ListAttachmentsByTicketID(ticketID, isAgente(r))
+	if err != nil {
+		return nil, err
+	}
+	for i := range anexos {
+		preencherURLs(&anexos[i])
+	}
+	return anexos, nil
+}
+
+func (api *ApiServer) ListTicketAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	idInt, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "ID inválido, deve ser um número inteiro")
+		return
+	}
+
+	ticket, err := api.rep.GetTicketByID(r.Context(), idInt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Registro inexistente")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao obter o ticket no banco de dados")
+		return
+	}
+
+	pode, err := api.podeVerTicket(r.Context(), ticket)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao verificar as permissões do ticket")
+		return
+	}
+	if !pode {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	anexos, err := api.listarAnexosVisiveis(r, ticket.ID)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar os anexos do ticket")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(anexos); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar a resposta em JSON")
+		return
+	}
+}
+
+// carregarAnexoAutorizado busca o anexo do parametro {id} e verifica se quem
+// fez a requisicao pode le-lo. Em caso negativo ja responde e devolve false.
+func (api *ApiServer) carregarAnexoAutorizado(w http.ResponseWriter, r *http.Request) (model.Attachment, bool) {
+	idInt, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "ID do anexo inválido, deve ser um número inteiro")
+		return model.Attachment{}, false
+	}
+
+	anexo, err := api.rep.GetAttachmentByID(idInt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Anexo não encontrado")
+		return model.Attachment{}, false
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar o anexo no banco de dados")
+		return model.Attachment{}, false
+	}
+
+	ticket, err := api.rep.GetTicketByID(r.Context(), int(anexo.TicketID))
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao obter o ticket no banco de dados")
+		return model.Attachment{}, false
+	}
+
+	pode, err := api.podeVerTicket(r.Context(), ticket)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao verificar as permissões do ticket")
+		return model.Attachment{}, false
+	}
+	// Anexos de notas internas seguem a mesma regra das notas: so agentes.
+	if !pode || (anexo.Interno && !isAgente(r)) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return model.Attachment{}, false
+	}
+
+	if anexo.Status == model.AnexoQuarentena {
+		problema.Escrever(w, r, http.StatusForbidden, "Anexo em quarentena por suspeita de malware")
+		return model.Attachment{}, false
+	}
+
+	return anexo, true
+}
+
+// DownloadAttachmentHandler envia o conteudo do anexo em streaming. Requisicoes
+// com Range e If-None-Match sao tratadas por http.ServeContent.
+func (api *ApiServer) DownloadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	anexo, ok := api.carregarAnexoAutorizado(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": anexo.NomeArquivo}))
+	w.Header().Set("ETag", `"`+anexo.SHA256+`"`)
+	api.servirDoStorage(w, r, anexo.ChaveStorage, anexo.ContentType, anexo.Data)
+}
+
+func (api *ApiServer) ThumbnailAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	anexo, ok := api.carregarAnexoAutorizado(w, r)
+	if !ok {
+		return
+	}
+
+	if anexo.ThumbnailChave == "" {
+		problema.Escrever(w, r, http.StatusNotFound, "Anexo não possui miniatura")
+		return
+	}
+
+	api.servirDoStorage(w, r, anexo.ThumbnailChave, "image/png", anexo.Data)
+}
+
+func (api *ApiServer) servirDoStorage(w http.ResponseWriter, r *http.Request, chave, contentType string, modificado time.Time) {
+	conteudo, err := api.store.Open(r.Context(), chave)
+	if errors.Is(err, storage.ErrNotFound) {
+		problema.Escrever(w, r, http.StatusNotFound, "Conteúdo do anexo não encontrado no storage")
+		return
+	} else if err != nil {
+		problema.Escrever(w, r, http.StatusBadGateway, "Erro ao abrir o anexo no storage")
+		return
+	}
+	defer conteudo.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", modificado, conteudo)
+}
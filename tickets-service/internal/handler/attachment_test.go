@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/repository"
+	"helpdesk/tickets-service/internal/scanner"
+	"helpdesk/tickets-service/internal/storage"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// scannerFake considera infectado todo arquivo que contem "EICAR".
+type scannerFake struct{}
+
+func (scannerFake) Scan(ctx context.Context, conteudo io.Reader) (scanner.Resultado, error) {
+	b, err := io.ReadAll(conteudo)
+	if err != nil {
+		return scanner.Resultado{}, err
+	}
+	if bytes.Contains(b, []byte("EICAR")) {
+		return scanner.Resultado{Infectado: true, Assinatura: "Eicar-Test-Signature"}, nil
+	}
+	return scanner.Resultado{}, nil
+}
+
+// enviarAnexos faz o upload dos arquivos (nome -> conteudo, na ordem dada)
+// no ticket 1 como agente.
+func enviarAnexos(t *testing.T, api *ApiServer, arquivos ...[2]string) *httptest.ResponseRecorder {
+	var corpo bytes.Buffer
+	mw := multipart.NewWriter(&corpo)
+	for _, a := range arquivos {
+		parte, err := mw.CreateFormFile("arquivos", a[0])
+		require.NoError(t, err)
+		_, err = parte.Write([]byte(a[1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, mw.Close())
+
+	req := requisicaoDe("POST", "/tickets/1/attachments", corpo.String(), 3, model.TipoAgente, "1")
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rr := httptest.NewRecorder()
+	api.UploadTicketAttachmentHandler(rr, req)
+	return rr
+}
+
+// arquivosGravados lista as chaves gravadas no storage local.
+func arquivosGravados(t *testing.T, dir string) []string {
+	var chaves []string
+	err := filepath.WalkDir(dir, func(caminho string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(dir, caminho)
+			chaves = append(chaves, filepath.ToSlash(rel))
+		}
+		return err
+	})
+	require.NoError(t, err)
+	return chaves
+}
+
+func TestUploadTicketAttachmentHandler_Grava(t *testing.T) {
+	dir := t.TempDir()
+	store, err := storage.NewLocal(dir)
+	require.NoError(t, err)
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, store, scannerFake{}, nil, nil, nil)
+	repo.On("GetTicketByID", 1).Return(model.Ticket{ID: 1, UserID: 9}, nil)
+	repo.On("CreateAttachment", mock.Anything).Return(int64(1), nil).Once()
+	repo.On("CreateAttachment", mock.Anything).Return(int64(2), nil).Once()
+
+	rr := enviarAnexos(t, api, [2]string{"log.txt", "linha 1"}, [2]string{"dados.csv", "a,b"})
+
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var criados []model.Attachment
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&criados))
+	require.Len(t, criados, 2)
+	assert.Equal(t, "log.txt", criados[0].NomeArquivo)
+	assert.Equal(t, model.AnexoDisponivel, criados[1].Status)
+	assert.Len(t, arquivosGravados(t, dir), 2)
+}
+
+func TestUploadTicketAttachmentHandler_InfectadoNaoSalvaOsOutros(t *testing.T) {
+	dir := t.TempDir()
+	store, err := storage.NewLocal(dir)
+	require.NoError(t, err)
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, store, scannerFake{}, nil, nil, nil)
+	repo.On("GetTicketByID", 1).Return(model.Ticket{ID: 1, UserID: 9}, nil)
+	repo.On("CreateAttachment", mock.MatchedBy(func(a model.Attachment) bool {
+		return a.Status == model.AnexoQuarentena
+	})).Return(int64(1), nil)
+
+	// O arquivo limpo vem antes do infectado e mesmo assim nao e gravado.
+	rr := enviarAnexos(t, api, [2]string{"log.txt", "linha 1"}, [2]string{"virus.txt", "EICAR"})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Contains(t, rr.Body.String(), "virus.txt")
+	repo.AssertNumberOfCalls(t, "CreateAttachment", 1)
+	gravados := arquivosGravados(t, dir)
+	require.Len(t, gravados, 1)
+	assert.True(t, strings.HasPrefix(gravados[0], "quarentena/"), gravados[0])
+}
+
+func TestUploadTicketAttachmentHandler_TipoRecusadoNaoSalvaNada(t *testing.T) {
+	dir := t.TempDir()
+	store, err := storage.NewLocal(dir)
+	require.NoError(t, err)
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, store, scannerFake{}, nil, nil, nil)
+	repo.On("GetTicketByID", 1).Return(model.Ticket{ID: 1, UserID: 9}, nil)
+
+	rr := enviarAnexos(t, api, [2]string{"log.txt", "linha 1"}, [2]string{"app.exe", "MZ\x90\x00\x03\x00\x00\x00\x04\x00"})
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+	repo.AssertNotCalled(t, "CreateAttachment", mock.Anything)
+	assert.Empty(t, arquivosGravados(t, dir))
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	ticketDoCliente   = model.Ticket{ID: 1, UserID: 9}
+	comentarioPublico = model.Comentario{ID: 1, TicketID: 1, Tipo: model.ComentarioPublico, Descricao: "Reinicie o roteador"}
+	notaInterna       = model.Comentario{ID: 2, TicketID: 1, Tipo: model.ComentarioInterno, Descricao: "Cliente ja ligou 3 vezes"}
+)
+
+func listarComentarios(t *testing.T, api *ApiServer, userID int64, tipo string) []model.Comentario {
+	rr := httptest.NewRecorder()
+	api.ListCommentsByTicketHandler(rr, requisicaoDe("GET", "/tickets/1/comments", "", userID, tipo, "1"))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var lista []model.Comentario
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&lista))
+	return lista
+}
+
+func TestListCommentsByTicketHandler_ClienteNaoVeNotasInternas(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, nil, nil)
+	repo.On("GetTicketByID", 1).Return(ticketDoCliente, nil)
+	repo.On("ListCommentsByTicketID", 1, false).Return([]model.Comentario{comentarioPublico}, nil)
+
+	lista := listarComentarios(t, api, 9, "cliente")
+
+	assert.Equal(t, []model.Comentario{comentarioPublico}, lista)
+	repo.AssertNotCalled(t, "ListCommentsByTicketID", 1, true)
+}
+
+func TestListCommentsByTicketHandler_AgenteVeTodos(t *testing.T) {
+	for _, tipo := range []string{model.TipoAgente, model.TipoAdmin} {
+		repo := new(repository.MockTicketRepository)
+		api := NewApiServer(repo, nil, nil, nil, nil, nil)
+		repo.On("GetTicketByID", 1).Return(ticketDoCliente, nil)
+		repo.On("GetTicketByID", 1).Return(ticketDoCliente, nil)
+		repo.On("ListCommentsByTicketID", 1, true).Return([]model.Comentario{comentarioPublico, notaInterna}, nil)
+
+		lista := listarComentarios(t, api, 3, tipo)
+
+		assert.Equal(t, []model.Comentario{comentarioPublico, notaInterna}, lista, tipo)
+		repo.AssertExpectations(t)
+	}
+}
+
+func TestCreateCommentHandler_ClienteNaoCriaNotaInterna(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, nil, nil)
+	repo.On("GetTicketByID", 1).Return(ticketDoCliente, nil)
+
+	rr := httptest.NewRecorder()
+	api.CreateCommentHandler(rr, requisicaoDe("POST", "/tickets/1/comments", `{"descricao":"nota","tipo":"interno"}`, 9, "cliente", "1"))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "CreateComment", mock.Anything)
+}
+
+func TestCreateCommentHandler_AgenteCriaNotaInterna(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, nil, nil)
+	repo.On("GetTicketByID", 1).Return(ticketDoCliente, nil)
+	esperado := model.Comentario{UserID: 3, TicketID: 1, Tipo: model.ComentarioInterno, Descricao: "nota"}
+	repo.On("CreateComment", esperado).Return(int64(2), nil)
+	repo.On("ReplaceCommentMentions", mock.Anything, []int64{}).Return([]int64{}, nil)
+
+	rr := httptest.NewRecorder()
+	api.CreateCommentHandler(rr, requisicaoDe("POST", "/tickets/1/comments", `{"descricao":"nota","tipo":"interno"}`, 3, model.TipoAgente, "1"))
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var criado model.Comentario
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&criado))
+	assert.Equal(t, int64(2), criado.ID)
+	assert.Equal(t, model.ComentarioInterno, criado.Tipo)
+	repo.AssertExpectations(t)
+}
+
+func TestComentarios_ClienteQueNaoVeOTicket(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, nil, nil)
+	repo.On("GetTicketByID", 1).Return(ticketDoCliente, nil)
+	repo.On("CanViewTicket", int64(1), int64(7)).Return(false, nil)
+
+	rr := httptest.NewRecorder()
+	api.ListCommentsByTicketHandler(rr, requisicaoDe("GET", "/tickets/1/comments", "", 7, "cliente", "1"))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = httptest.NewRecorder()
+	api.CreateCommentHandler(rr, requisicaoDe("POST", "/tickets/1/comments", `{"descricao":"@agente oi"}`, 7, "cliente", "1"))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	repo.AssertNotCalled(t, "ListCommentsByTicketID", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "CreateComment", mock.Anything)
+}
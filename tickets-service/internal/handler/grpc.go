@@ -0,0 +1,292 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"helpdesk/pkg/pb"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/stream"
+	"helpdesk/tickets-service/middleware"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TicketGrpc implementa o pb.TicketServiceServer sobre o mesmo ApiServer da
+// API HTTP: mesmo repositorio e mesmas regras de permissao. O usuario vem do
+// contexto, preenchido pelo middleware.AuthUnario/AuthStream.
+type TicketGrpc struct {
+	pb.UnimplementedTicketServiceServer
+	api *ApiServer
+}
+
+func NewTicketGrpc(api *ApiServer) *TicketGrpc {
+	return &TicketGrpc{api: api}
+}
+
+// erroGRPC converte os erros das regras compartilhadas em status gRPC. Erros
+// inesperados sao registrados e devolvidos como Internal com a mensagem dada.
+func erroGRPC(ctx context.Context, err error, mensagem string) error {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return status.Error(codes.NotFound, "Registro inexistente")
+	case errors.Is(err, ErrPermissao), errors.Is(err, ErrNotaInternaProibida):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, ErrTipoComentarioInvalido):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	registro.Logger(ctx).Error(mensagem, "erro", err)
+	return status.Error(codes.Internal, mensagem)
+}
+
+// ticketVisivel carrega o ticket e aplica a regra de leitura do podeVerTicket.
+// Quem nao pode ver recebe NotFound, para nao revelar que o ticket existe.
+func (g *TicketGrpc) ticketVisivel(ctx context.Context, id int64) (model.Ticket, error) {
+	if id <= 0 {
+		return model.Ticket{}, status.Error(codes.InvalidArgument, "ID inválido, deve ser um número inteiro positivo")
+	}
+	ticket, err := g.api.rep.GetTicketByID(ctx, int(id))
+	if err != nil {
+		return ticket, erroGRPC(ctx, err, "Erro ao obter dados no banco de dados")
+	}
+	pode, err := g.api.podeVerTicket(ctx, ticket)
+	if err != nil {
+		return ticket, erroGRPC(ctx, err, "Erro ao verificar as permissões do ticket")
+	}
+	if !pode {
+		return ticket, status.Error(codes.NotFound, "Registro inexistente")
+	}
+	return ticket, nil
+}
+
+func (g *TicketGrpc) CreateTicket(ctx context.Context, req *pb.CreateTicketRequest) (*pb.Ticket, error) {
+	if strings.TrimSpace(req.GetTitulo()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "O título do ticket é obrigatório")
+	}
+
+	idReq, _ := ctx.Value(middleware.UserIDKey).(int64)
+	agora := time.Now()
+	ticket := model.Ticket{
+		Titulo:          req.GetTitulo(),
+		Descricao:       req.GetDescricao(),
+		Status:          "aberto",
+		Prioridade:      req.GetPrioridade(),
+		DataAbertura:    agora,
+		DataAtualizacao: agora,
+		Tags:            req.GetTags(),
+		CategoriaID:     req.GetCategoriaId(),
+		UserID:          idReq,
+	}
+	if err := g.api.criarTicket(ctx, &ticket); err != nil {
+		return nil, erroGRPC(ctx, err, "Erro ao adicionar o ticket no banco de dados")
+	}
+	g.api.perfis.PreencherTicket(ctx, &ticket)
+	return ticketPB(ticket), nil
+}
+
+func (g *TicketGrpc) GetTicket(ctx context.Context, req *pb.GetTicketRequest) (*pb.Ticket, error) {
+	ticket, err := g.ticketVisivel(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if ticket.CC, err = g.api.rep.ListTicketCC(ticket.ID); err != nil {
+		return nil, erroGRPC(ctx, err, "Erro ao consultar os usuarios em copia do ticket")
+	}
+	if ticket.Watchers, err = g.api.rep.ListWatchers(ticket.ID); err != nil {
+		return nil, erroGRPC(ctx, err, "Erro ao consultar os watchers do ticket")
+	}
+	g.api.perfis.PreencherTicket(ctx, &ticket)
+	return ticketPB(ticket), nil
+}
+
+func (g *TicketGrpc) ListTickets(ctx context.Context, req *pb.ListTicketsRequest) (*pb.ListTicketsResponse, error) {
+	filtro := model.TicketFilter{
+		Status:        req.GetStatus(),
+		Prioridade:    req.GetPrioridade(),
+		CategoriaID:   req.GetCategoriaId(),
+		ResponsavelID: req.GetResponsavelId(),
+	}
+	if !agente(ctx) {
+		filtro.UserID, _ = ctx.Value(middleware.UserIDKey).(int64)
+	}
+
+	lista, err := g.api.rep.ListTickets(ctx, filtro)
+	if err != nil {
+		return nil, erroGRPC(ctx, err, "Erro ao obter a lista de tickets no banco de dados")
+	}
+	g.api.perfis.Preencher(ctx, lista)
+
+	resp := &pb.ListTicketsResponse{Tickets: make([]*pb.Ticket, 0, len(lista))}
+	for _, t := range lista {
+		resp.Tickets = append(resp.Tickets, ticketPB(t))
+	}
+	return resp, nil
+}
+
+func (g *TicketGrpc) UpdateTicketStatus(ctx context.Context, req *pb.UpdateTicketStatusRequest) (*pb.Ticket, error) {
+	if req.GetStatus() == "" {
+		return nil, status.Error(codes.InvalidArgument, "O status é obrigatório")
+	}
+	ticket, err := g.api.alterarStatus(ctx, req.GetId(), req.GetStatus())
+	if err != nil {
+		return nil, erroGRPC(ctx, err, "Erro ao atualizar informacoes no banco de dados")
+	}
+	return ticketPB(ticket), nil
+}
+
+func (g *TicketGrpc) CreateComment(ctx context.Context, req *pb.CreateCommentRequest) (*pb.Comment, error) {
+	if strings.TrimSpace(req.GetDescricao()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "A descrição do comentário é obrigatória")
+	}
+	if _, err := g.ticketVisivel(ctx, req.GetTicketId()); err != nil {
+		return nil, err
+	}
+
+	idReq, _ := ctx.Value(middleware.UserIDKey).(int64)
+	comentario := model.Comentario{
+		Descricao: req.GetDescricao(),
+		Data:      time.Now(),
+		UserID:    idReq,
+		TicketID:  req.GetTicketId(),
+		Tipo:      req.GetTipo(),
+	}
+	if err := g.api.criarComentario(ctx, &comentario); err != nil {
+		return nil, erroGRPC(ctx, err, "Erro ao adicionar o comentario no banco de dados")
+	}
+	return comentarioPB(comentario), nil
+}
+
+func (g *TicketGrpc) ListComments(ctx context.Context, req *pb.ListCommentsRequest) (*pb.ListCommentsResponse, error) {
+	if _, err := g.ticketVisivel(ctx, req.GetTicketId()); err != nil {
+		return nil, err
+	}
+
+	lista, err := g.api.rep.ListCommentsByTicketID(int(req.GetTicketId()), agente(ctx))
+	if err != nil {
+		return nil, erroGRPC(ctx, err, "Erro ao consultar os comentarios do ticket")
+	}
+
+	resp := &pb.ListCommentsResponse{Comments: make([]*pb.Comment, 0, len(lista))}
+	for _, c := range lista {
+		resp.Comments = append(resp.Comments, comentarioPB(c))
+	}
+	return resp, nil
+}
+
+// WatchTickets transmite os eventos como o StreamTicketsHandler. O keepalive
+// fica a cargo do HTTP/2, entao nao ha heartbeat na aplicacao.
+func (g *TicketGrpc) WatchTickets(req *pb.WatchTicketsRequest, srv pb.TicketService_WatchTicketsServer) error {
+	filtro, err := filtroWatch(req)
+	if err != nil {
+		return err
+	}
+	ctx := srv.Context()
+	g.api.aplicarPermissoes(ctx, filtro, req.GetAcompanhando())
+
+	err = stream.Transmitir(ctx, g.api.eventos, g.api.rep, filtro, req.GetLastEventId(),
+		func(e model.DomainEvent) error { return srv.Send(eventoPB(e)) },
+		func() error { return nil })
+
+	if errors.Is(err, stream.ErrAtrasado) {
+		return status.Error(codes.Unavailable, err.Error())
+	} else if err != nil && ctx.Err() == nil {
+		registro.Logger(srv.Context()).Warn("Erro no stream gRPC", "usuario_id", filtro.UserID, "erro", err)
+		return status.Error(codes.Internal, "Erro ao transmitir os eventos")
+	}
+	return nil
+}
+
+// filtroWatch e o parseStream do WatchTickets.
+func filtroWatch(req *pb.WatchTicketsRequest) (*stream.Filtro, error) {
+	filtro := &stream.Filtro{}
+	for _, tipo := range req.GetTipos() {
+		if !eventosStream[tipo] {
+			return nil, status.Errorf(codes.InvalidArgument, "Evento desconhecido: %s", tipo)
+		}
+		if filtro.Tipos == nil {
+			filtro.Tipos = map[string]bool{}
+		}
+		filtro.Tipos[tipo] = true
+	}
+	for _, id := range req.GetTicketIds() {
+		if id <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "ticket_ids inválido, deve conter números inteiros positivos")
+		}
+		if filtro.Tickets == nil {
+			filtro.Tickets = map[int64]bool{}
+		}
+		filtro.Tickets[id] = true
+	}
+	if req.GetLastEventId() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "ID do último evento inválido")
+	}
+	return filtro, nil
+}
+
+// timestampPB devolve nil para datas zeradas, como a data de fechamento de
+// um ticket ainda aberto.
+func timestampPB(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func ticketPB(t model.Ticket) *pb.Ticket {
+	return &pb.Ticket{
+		Id:              t.ID,
+		Titulo:          t.Titulo,
+		Descricao:       t.Descricao,
+		Status:          t.Status,
+		Diagnostico:     t.Diagnostico,
+		Solucao:         t.Solucao,
+		Prioridade:      t.Prioridade,
+		DataAbertura:    timestampPB(t.DataAbertura),
+		DataFechamento:  timestampPB(t.DataFechamento),
+		DataAtualizacao: timestampPB(t.DataAtualizacao),
+		Tags:            t.Tags,
+		CategoriaId:     t.CategoriaID,
+		ResponsavelId:   t.ResponsavelID,
+		UserId:          t.UserID,
+		Cc:              t.CC,
+		Watchers:        t.Watchers,
+		Author:          perfilPB(&t.Author),
+		Responsavel:     perfilPB(t.Responsavel),
+	}
+}
+
+func perfilPB(p *model.TicketAuthor) *pb.Perfil {
+	if p == nil || p.ID == 0 {
+		return nil
+	}
+	return &pb.Perfil{Id: p.ID, Nome: p.Nome, Email: p.Email, Indisponivel: p.Indisponivel}
+}
+
+func comentarioPB(c model.Comentario) *pb.Comment {
+	return &pb.Comment{
+		Id:        c.ID,
+		TicketId:  c.TicketID,
+		UserId:    c.UserID,
+		Descricao: c.Descricao,
+		Tipo:      c.Tipo,
+		Data:      timestampPB(c.Data),
+		Mencoes:   c.Mencoes,
+	}
+}
+
+func eventoPB(e model.DomainEvent) *pb.TicketEvent {
+	return &pb.TicketEvent{
+		Id:       e.ID,
+		Tipo:     e.Tipo,
+		TicketId: e.TicketID,
+		AtorId:   e.AtorID,
+		Dados:    e.Dados,
+		CriadoEm: timestampPB(e.CriadoEm),
+	}
+}
@@ -1,33 +1,76 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"helpdesk/tickets-service/auth"
+	"helpdesk/pkg/problema"
 	"helpdesk/tickets-service/internal/model"
-	"helpdesk/tickets-service/internal/repository"
+	"helpdesk/tickets-service/internal/scanner"
+	"helpdesk/tickets-service/internal/storage"
+	"helpdesk/tickets-service/internal/stream"
+	"helpdesk/tickets-service/internal/users"
+	"helpdesk/tickets-service/internal/webhook"
 	"helpdesk/tickets-service/middleware"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5"
 )
 
 type ApiServer struct {
-	rep  *repository.Repository
-	jobs chan int64
+	rep      model.TicketRepository
+	store    storage.Storage
+	scanner  scanner.Scanner
+	webhooks *webhook.Entregador
+	eventos  *stream.Hub
+	usuarios Diretorio
+	perfis   *users.Resolver
+	maxAnexo int64
 }
 
-func NewApiServer(rep *repository.Repository, jobs chan int64) *ApiServer {
+// Diretorio consulta usuarios no users-service: os @handles das mencoes, o
+// remetente dos emails recebidos e o tipo do responsavel de uma atribuicao.
+// E implementado pelo *users.Cliente.
+type Diretorio interface {
+	Mencionados(ctx context.Context, handles []string) ([]model.MentionedUser, error)
+	UsuarioPorEmail(ctx context.Context, email string) (model.Usuario, error)
+	TipoUsuario(ctx context.Context, id int64) (string, error)
+}
+
+func NewApiServer(rep model.TicketRepository, store storage.Storage, scanner scanner.Scanner, eventos *stream.Hub, usuarios Diretorio, perfis *users.Resolver) *ApiServer {
 	return &ApiServer{
-		rep:  rep,
-		jobs: jobs,
+		rep:      rep,
+		store:    store,
+		scanner:  scanner,
+		webhooks: webhook.NovoEntregador(rep),
+		eventos:  eventos,
+		usuarios: usuarios,
+		perfis:   perfis,
+		maxAnexo: maxAnexoPadrao,
 	}
 }
 
+// isAgente indica se quem fez a requisicao e da equipe de suporte (agente ou admin).
+func isAgente(r *http.Request) bool {
+	return agente(r.Context())
+}
+
+// agente e o isAgente a partir do contexto, para as chamadas gRPC.
+func agente(ctx context.Context) bool {
+	tipoUser, _ := ctx.Value(middleware.TipoUserKey).(string)
+	return tipoUser == model.TipoAgente || tipoUser == model.TipoAdmin
+}
+
+// Erros das regras compartilhadas entre a API HTTP e a gRPC.
+var (
+	ErrPermissao              = errors.New("permissão não concedida")
+	ErrNotaInternaProibida    = errors.New("apenas agentes podem criar notas internas")
+	ErrTipoComentarioInvalido = errors.New("tipo de comentário inválido, use 'publico' ou 'interno'")
+)
+
 func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	status := map[string]string{
 		"status": "ok",
@@ -36,7 +79,7 @@ func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(status); err != nil {
-		http.Error(w, "Erro ao encodificar a resposta json", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao encodificar a resposta json")
 		return
 	}
 }
@@ -44,83 +87,131 @@ func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 func (api *ApiServer) CreateTicketHandler(w http.ResponseWriter, r *http.Request) {
 	var ticket model.Ticket
 	if err := json.NewDecoder(r.Body).Decode(&ticket); err != nil {
-		http.Error(w, "Erro ao decodificar o corpo da requisição: "+err.Error(), http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar o corpo da requisição: "+err.Error())
 		return
 	}
 
 	userIdReq, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Erro ao extrair o ID do usuario da requisição", http.StatusInternalServerError)
+		problema.Escrever(w, r, http.StatusInternalServerError, "Erro ao extrair o ID do usuario da requisição")
 		return
 	}
 	ticket.UserID = userIdReq
 
-	id, err := api.rep.CreateTicket(ticket)
+	err := api.criarTicket(r.Context(), &ticket)
 	if err != nil {
-		http.Error(w, "Erro ao adicionar o ticket no banco de dados", http.StatusBadRequest)
+		problema.Erro(w, r, err, "Erro ao adicionar o ticket no banco de dados")
 		return
 	}
-	ticket.ID = id
 
-	ticket.Author, err = GetTicketAuthor(userIdReq, r)
-	if err != nil {
-		http.Error(w, "Erro ao obter dados do autor do ticker.", http.StatusBadRequest)
-		return
-	}
+	api.perfis.PreencherTicket(r.Context(), &ticket)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err = json.NewEncoder(w).Encode(ticket); err != nil {
-		http.Error(w, "Erro ao codificar o ticket em json", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao codificar o ticket em json")
 		return
 	}
-	api.jobs <- ticket.ID
+}
+
+// criarTicket grava um ticket novo. E a mesma regra para tickets abertos pela
+// API e por email.
+func (api *ApiServer) criarTicket(ctx context.Context, ticket *model.Ticket) error {
+	// Anexos sao enviados depois, por POST /tickets/{id}/attachments.
+	ticket.Anexos = nil
+
+	id, err := api.rep.CreateTicket(ctx, *ticket)
+	if err != nil {
+		return err
+	}
+	ticket.ID = id
+	return nil
 }
 
 func (api *ApiServer) ListTicketsHandler(w http.ResponseWriter, r *http.Request) {
-	lista, err := api.rep.ListTickets()
+	filtro, err := parseTicketFilter(r)
 	if err != nil {
-		http.Error(w, "Erro ao obter a lista de tickets no banco de dados", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao validar a requisição")
 		return
 	}
 
+	lista, err := api.rep.ListTickets(r.Context(), filtro)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao obter a lista de tickets no banco de dados")
+		return
+	}
+	api.perfis.Preencher(r.Context(), lista)
+
 	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
 	if err = json.NewEncoder(w).Encode(lista); err != nil {
-		http.Error(w, "Erro ao converter a lista para json", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao converter a lista para json")
 		return
 	}
 }
 
+// carregarTicketVisivel carrega o ticket e aplica a regra de leitura do
+// podeVerTicket, a mesma do ticketVisivel no gRPC. Se o ticket nao existe ou
+// o usuario nao pode ve-lo, ja responde a requisicao e devolve false.
+func (api *ApiServer) carregarTicketVisivel(w http.ResponseWriter, r *http.Request, id int) (model.Ticket, bool) {
+	ticket, err := api.rep.GetTicketByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Registro inexistente")
+		return ticket, false
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao obter o ticket no banco de dados")
+		return ticket, false
+	}
+
+	pode, err := api.podeVerTicket(r.Context(), ticket)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao verificar as permissões do ticket")
+		return ticket, false
+	}
+	if !pode {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return ticket, false
+	}
+	return ticket, true
+}
+
 func (api *ApiServer) GetTicketHandler(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	idInt, err := strconv.Atoi(id)
 	if err != nil {
-		http.Error(w, "ID inválido, deve ser um número inteiro", http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "ID inválido, deve ser um número inteiro")
 		return
 	}
 
-	ticket, err := api.rep.GetTicketByID(idInt)
-	if err == pgx.ErrNoRows {
-		http.Error(w, "Registro inexistente", http.StatusNotFound)
+	ticket, ok := api.carregarTicketVisivel(w, r, idInt)
+	if !ok {
 		return
-	} else if err != nil {
-		http.Error(w, "Erro ao obter dados no banco de dados", http.StatusBadRequest)
+	}
+
+	api.perfis.PreencherTicket(r.Context(), &ticket)
+
+	ticket.CC, err = api.rep.ListTicketCC(ticket.ID)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar os usuarios em copia do ticket")
 		return
 	}
 
-	idUser := r.Context().Value(middleware.UserIDKey).(int64)
+	ticket.Watchers, err = api.rep.ListWatchers(ticket.ID)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar os watchers do ticket")
+		return
+	}
 
-	ticket.Author, err = GetTicketAuthor(idUser, r)
+	ticket.Attachments, err = api.listarAnexosVisiveis(r, ticket.ID)
 	if err != nil {
-		http.Error(w, "Erro ao obter os dados do usuario", http.StatusBadRequest)
+		problema.Erro(w, r, err, "Erro ao consultar os anexos do ticket")
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
 	if err = json.NewEncoder(w).Encode(ticket); err != nil {
-		http.Error(w, "Erro ao converter ticket para json", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao converter ticket para json")
 		return
 	}
 }
@@ -130,14 +221,15 @@ func (api *ApiServer) GetMyTicketsHandler(w http.ResponseWriter, r *http.Request
 
 	lista, err := api.rep.GetTicketByUser(int(id))
 	if err != nil {
-		http.Error(w, "Erro ao consultar tickets do usuario", http.StatusBadRequest)
+		problema.Erro(w, r, err, "Erro ao consultar tickets do usuario")
 		return
 	}
+	api.perfis.Preencher(r.Context(), lista)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err = json.NewEncoder(w).Encode(lista); err != nil {
-		http.Error(w, "Erro ao codificar a lista de tarefas", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao codificar a lista de tarefas")
 		return
 	}
 }
@@ -146,39 +238,42 @@ func (api *ApiServer) UpdateTicketHandler(w http.ResponseWriter, r *http.Request
 	id := chi.URLParam(r, "id")
 	idInt, err := strconv.Atoi(id)
 	if err != nil {
-		http.Error(w, "ID inválido, deve ser um número inteiro", http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "ID inválido, deve ser um número inteiro")
 		return
 	}
 
-	idReq := r.Context().Value(middleware.UserIDKey)
+	idReq, _ := r.Context().Value(middleware.UserIDKey).(int64)
 
 	var ticketReq model.UpdateTicketPayload
 	if err = json.NewDecoder(r.Body).Decode(&ticketReq); err != nil {
-		http.Error(w, "Erro ao decodificar o corpo da requisição", http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar o corpo da requisição")
 		return
 	}
 
-	ticketOg, err := api.rep.GetTicketByID(idInt)
-	if err != nil {
-		http.Error(w, "Erro ao obter o ticket no banco de dados", http.StatusInternalServerError)
+	ticketOg, err := api.rep.GetTicketByID(r.Context(), idInt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Registro não encontrado")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao obter o ticket no banco de dados")
 		return
 	}
 
 	if idReq != ticketOg.UserID {
-		http.Error(w, "Permissão não concedida", http.StatusForbidden)
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
 	}
 
 	ticketOg.Titulo = ticketReq.Titulo
 	ticketOg.Descricao = ticketReq.Descricao
 	ticketOg.Prioridade = ticketReq.Prioridade
-	ticketOg.Anexos = ticketReq.Anexos
 	ticketOg.CategoriaID = ticketReq.CategoriaID
 
-	if err = api.rep.UpdateTicket(idInt, ticketOg); err == pgx.ErrNoRows {
-		http.Error(w, "Registro não encontrado", http.StatusNotFound)
+	if err = api.rep.UpdateTicket(r.Context(), idInt, ticketOg, idReq); errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Registro não encontrado")
 		return
 	} else if err != nil {
-		http.Error(w, "Erro ao modificar registro no banco de dados", http.StatusBadRequest)
+		problema.Erro(w, r, err, "Erro ao modificar registro no banco de dados")
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -192,63 +287,77 @@ func (api *ApiServer) UpdateTicketStatusHandler(w http.ResponseWriter, r *http.R
 
 	var statusReq updateStatusRequest
 	if err := json.NewDecoder(r.Body).Decode(&statusReq); err != nil {
-		http.Error(w, "Erro ao decodificar a requisição", http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar a requisição")
 		return
 	}
 
-	ticketOg, err := api.rep.GetTicketByID(int(statusReq.ID))
-	if err != nil {
-		http.Error(w, "Erro ao consultar o ticket original", http.StatusBadRequest)
+	_, err := api.alterarStatus(r.Context(), statusReq.ID, statusReq.Status)
+	if errors.Is(err, ErrPermissao) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	} else if errors.Is(err, errConsultaTicket) {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao consultar o ticket original")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao atualizar informacoes no banco de dados")
 		return
 	}
 
-	idReq := r.Context().Value(middleware.UserIDKey).(int64)
+	w.WriteHeader(http.StatusOK)
+}
 
-	if idReq != ticketOg.UserID {
-		http.Error(w, "Permissão não concedida", http.StatusForbidden)
-		return
-	}
+// errConsultaTicket envolve a falha ao ler o ticket antes de altera-lo.
+var errConsultaTicket = errors.New("erro ao consultar o ticket")
 
-	ticketOg.Status = statusReq.Status
+// alterarStatus muda o status do ticket. So o autor pode faze-lo.
+func (api *ApiServer) alterarStatus(ctx context.Context, id int64, status string) (model.Ticket, error) {
+	ticket, err := api.rep.GetTicketByID(ctx, int(id))
+	if err != nil {
+		return ticket, fmt.Errorf("%w: %w", errConsultaTicket, err)
+	}
 
-	if err := api.rep.UpdateTicket(int(ticketOg.ID), ticketOg); err != nil {
-		http.Error(w, "Erro ao atualizar informacoes no banco de dados", http.StatusInternalServerError)
-		return
+	idReq, _ := ctx.Value(middleware.UserIDKey).(int64)
+	if idReq != ticket.UserID {
+		return ticket, ErrPermissao
 	}
 
-	w.WriteHeader(http.StatusOK)
+	ticket.Status = status
+	if err := api.rep.UpdateTicket(ctx, int(ticket.ID), ticket, idReq); err != nil {
+		return ticket, err
+	}
+	return ticket, nil
 }
 
 func (api *ApiServer) DeleteTicketHandler(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	idInt, err := strconv.Atoi(id)
 	if err != nil {
-		http.Error(w, "ID inválido, deve ser um número inteiro", http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "ID inválido, deve ser um número inteiro")
 		return
 	}
 
-	ticket, err := api.rep.GetTicketByID(idInt)
+	ticket, err := api.rep.GetTicketByID(r.Context(), idInt)
 	if err != nil {
-		http.Error(w, "Ticket não encontrado no banco de dados", http.StatusBadRequest)
+		problema.Erro(w, r, err, "Erro ao consultar o ticket no banco de dados")
 		return
 	}
 
 	idReq, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Erro ao extrair o ID do usuario da requisição", http.StatusInternalServerError)
+		problema.Escrever(w, r, http.StatusInternalServerError, "Erro ao extrair o ID do usuario da requisição")
 		return
 	}
 
 	if ticket.UserID != idReq {
-		http.Error(w, "Permissão não concedida", http.StatusForbidden)
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
 		return
 	}
 
-	if err = api.rep.DeleteTicket(idInt); err == pgx.ErrNoRows {
-		http.Error(w, "Registro não encontrado", http.StatusNotFound)
+	if err = api.rep.DeleteTicket(r.Context(), idInt, idReq); err == pgx.ErrNoRows {
+		problema.Escrever(w, r, http.StatusNotFound, "Registro não encontrado")
 		return
 	} else if err != nil {
-		http.Error(w, "Erro ao remover registro do banco de dados", http.StatusBadRequest)
+		problema.Erro(w, r, err, "Erro ao remover registro do banco de dados")
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -258,51 +367,89 @@ func (api *ApiServer) CreateCommentHandler(w http.ResponseWriter, r *http.Reques
 	idStr := chi.URLParam(r, "id")
 	idInt, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "ID do ticket inválido, deve ser um número inteiro", http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "ID do ticket inválido, deve ser um número inteiro")
+		return
+	}
+
+	if _, ok := api.carregarTicketVisivel(w, r, idInt); !ok {
 		return
 	}
 
 	var comentario model.Comentario
 	if err = json.NewDecoder(r.Body).Decode(&comentario); err != nil {
-		http.Error(w, "Erro ao decodificar a requisição", http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar a requisição")
 		return
 	}
 
 	idUser, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Não foi possivel extrair o ID do usuario do token", http.StatusInternalServerError)
+		problema.Escrever(w, r, http.StatusInternalServerError, "Não foi possivel extrair o ID do usuario do token")
 		return
 	}
 
 	comentario.UserID = idUser
 	comentario.TicketID = int64(idInt)
 
-	idComent, err := api.rep.CreateComment(comentario)
-	if err != nil {
-		http.Error(w, "Erro ao adicionar o comentario no banco de dados", http.StatusBadRequest)
+	err = api.criarComentario(r.Context(), &comentario)
+	if errors.Is(err, ErrNotaInternaProibida) {
+		problema.Escrever(w, r, http.StatusForbidden, "Apenas agentes podem criar notas internas")
+		return
+	} else if errors.Is(err, ErrTipoComentarioInvalido) {
+		problema.Escrever(w, r, http.StatusBadRequest, "Tipo de comentário inválido, use 'publico' ou 'interno'")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao adicionar o comentario no banco de dados")
 		return
 	}
-	comentario.ID = idComent
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(comentario); err != nil {
-		http.Error(w, "Erro ao encodificar a resposta", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao encodificar a resposta")
 		return
 	}
 }
 
+// criarComentario valida o tipo do comentario, grava e registra as mencoes.
+// Notas internas so podem ser criadas por agentes.
+func (api *ApiServer) criarComentario(ctx context.Context, comentario *model.Comentario) error {
+	switch comentario.Tipo {
+	case "":
+		comentario.Tipo = model.ComentarioPublico
+	case model.ComentarioPublico:
+	case model.ComentarioInterno:
+		if !agente(ctx) {
+			return ErrNotaInternaProibida
+		}
+	default:
+		return ErrTipoComentarioInvalido
+	}
+
+	id, err := api.rep.CreateComment(ctx, *comentario)
+	if err != nil {
+		return err
+	}
+	comentario.ID = id
+
+	api.registrarMencoes(ctx, comentario)
+	return nil
+}
+
 func (api *ApiServer) ListCommentsByTicketHandler(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "ID do ticket inválido, deve ser um número inteiro", http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "ID do ticket inválido, deve ser um número inteiro")
 		return
 	}
 
-	lista, err := api.rep.ListCommentsByTicketID(id)
+	if _, ok := api.carregarTicketVisivel(w, r, id); !ok {
+		return
+	}
+
+	lista, err := api.rep.ListCommentsByTicketID(id, isAgente(r))
 	if err != nil {
-		http.Error(w, "Erro ao consultar o BD", http.StatusBadRequest)
+		problema.Erro(w, r, err, "Erro ao consultar o BD")
 		return
 	}
 
@@ -310,7 +457,7 @@ func (api *ApiServer) ListCommentsByTicketHandler(w http.ResponseWriter, r *http
 	w.Header().Set("Content-Type", "application/json")
 	if err = json.NewEncoder(w).Encode(lista); err != nil {
 		// Se a lista foi obtida mas a codificação falha, é um erro do servidor.
-		http.Error(w, "Erro ao codificar a resposta em JSON", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao codificar a resposta em JSON")
 		return
 	}
 }
@@ -319,20 +466,20 @@ func (api *ApiServer) ListCommentsByUserHandler(w http.ResponseWriter, r *http.R
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "ID do usuário inválido, deve ser um número inteiro", http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "ID do usuário inválido, deve ser um número inteiro")
 		return
 	}
 
-	lista, err := api.rep.ListCommentsByUserID(id)
+	lista, err := api.rep.ListCommentsByUserID(id, isAgente(r))
 	if err != nil {
-		http.Error(w, "Erro ao consultar o BD", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao consultar o BD")
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
 	if err = json.NewEncoder(w).Encode(lista); err != nil {
-		http.Error(w, "Erro ao codificar a resposta em JSON", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao codificar a resposta em JSON")
 		return
 	}
 }
@@ -341,13 +488,13 @@ func (api *ApiServer) UpdateCommentHandler(w http.ResponseWriter, r *http.Reques
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "ID do comentário inválido, deve ser um número inteiro", http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "ID do comentário inválido, deve ser um número inteiro")
 		return
 	}
 
 	idUser, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Erro ao obter o ID do usuario do cabeçalho da requisição", http.StatusUnauthorized)
+		problema.Escrever(w, r, http.StatusUnauthorized, "Erro ao obter o ID do usuario do cabeçalho da requisição")
 		return
 	}
 
@@ -355,29 +502,35 @@ func (api *ApiServer) UpdateCommentHandler(w http.ResponseWriter, r *http.Reques
 	comment.UserID = idUser
 
 	if err = json.NewDecoder(r.Body).Decode(&comment); err != nil {
-		http.Error(w, "Erro ao decodificar o corpo da requisição: "+err.Error(), http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar o corpo da requisição: "+err.Error())
 		return
 	}
 
 	commentOg, err := api.rep.GetCommentByID(id)
 	if err != nil {
-		http.Error(w, "Erro ao ocnsultar o ticket no banco de dados.", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao ocnsultar o ticket no banco de dados.")
 		return
 	}
 
 	if commentOg.UserID != idUser {
-		http.Error(w, "Acesso não concedido!", http.StatusForbidden)
+		problema.Escrever(w, r, http.StatusForbidden, "Acesso não concedido!")
 		return
 	}
 
-	if err = api.rep.UpdateComment(id, comment); err != nil {
+	if err = api.rep.UpdateComment(r.Context(), id, comment); err != nil {
 		if err == pgx.ErrNoRows {
-			http.Error(w, "Comentário não encontrado", http.StatusNotFound)
+			problema.Escrever(w, r, http.StatusNotFound, "Comentário não encontrado")
 			return
 		}
-		http.Error(w, "Erro ao atualizar o comentario no banco de dados", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao atualizar o comentario no banco de dados")
 		return
 	}
+
+	comment.ID = commentOg.ID
+	comment.TicketID = commentOg.TicketID
+	comment.Tipo = commentOg.Tipo
+	api.registrarMencoes(r.Context(), &comment)
+
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -385,72 +538,61 @@ func (api *ApiServer) DeleteCommentHandler(w http.ResponseWriter, r *http.Reques
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "ID do comentário inválido, deve ser um número inteiro", http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "ID do comentário inválido, deve ser um número inteiro")
 		return
 	}
 
 	commentOg, err := api.rep.GetCommentByID(id)
 	if err != nil {
-		http.Error(w, "Erro ao ocnsultar o ticket no banco de dados.", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao ocnsultar o ticket no banco de dados.")
 		return
 	}
 
 	idUser, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Erro ao obter o ID do usuario do cabeçalho da requisição", http.StatusUnauthorized)
+		problema.Escrever(w, r, http.StatusUnauthorized, "Erro ao obter o ID do usuario do cabeçalho da requisição")
 		return
 	}
 
 	if commentOg.UserID != idUser {
-		http.Error(w, "Acesso não concedido!", http.StatusForbidden)
+		problema.Escrever(w, r, http.StatusForbidden, "Acesso não concedido!")
 		return
 	}
 
-	if err = api.rep.DeleteComment(id); err == pgx.ErrNoRows {
-		http.Error(w, "Comentário não encontrado", http.StatusNotFound)
+	if err = api.rep.DeleteComment(r.Context(), id, idUser); err == pgx.ErrNoRows {
+		problema.Escrever(w, r, http.StatusNotFound, "Comentário não encontrado")
 		return
 	} else if err != nil {
-		http.Error(w, "Erro ao remover o comentário do banco de dados", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao remover o comentário do banco de dados")
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func GetTicketAuthor(userID int64, r *http.Request) (model.TicketAuthor, error) {
-	url := fmt.Sprintf("http://users-service:8082/users/%d", userID)
-	fmt.Printf("INFO: Serviço de tickets fazendo uma requisição interna para: %s\n", url)
-
-	tokenString, err := auth.ExtairToken(r)
-	if err != nil {
-		return model.TicketAuthor{}, err
-	}
-
-	cliente := &http.Client{
-		Timeout: 5 * time.Second,
-	}
-
-	reqInternal, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return model.TicketAuthor{}, err
+// parseTicketFilter le os filtros de listagem da query string
+// (?status=&prioridade=&categoria_id=&responsavel_id=).
+func parseTicketFilter(r *http.Request) (model.TicketFilter, error) {
+	q := r.URL.Query()
+	filtro := model.TicketFilter{
+		Status:     q.Get("status"),
+		Prioridade: q.Get("prioridade"),
 	}
 
-	reqInternal.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tokenString))
-	reqInternal.Header.Set("Content-Type", "application/json")
-
-	resposta, err := cliente.Do(reqInternal)
-	if err != nil {
-		return model.TicketAuthor{}, err
-	}
-	defer resposta.Body.Close()
-
-	if resposta.StatusCode != http.StatusOK {
-		return model.TicketAuthor{}, errors.New("erro ao fazer a requisição interna")
+	if v := q.Get("categoria_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filtro, problema.Invalido("categoria_id", "categoria_id inválido, deve ser um número inteiro")
+		}
+		filtro.CategoriaID = id
 	}
 
-	var ticketAuthor model.TicketAuthor
-	if err = json.NewDecoder(resposta.Body).Decode(&ticketAuthor); err != nil {
-		return model.TicketAuthor{}, err
+	if v := q.Get("responsavel_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filtro, problema.Invalido("responsavel_id", "responsavel_id inválido, deve ser um número inteiro")
+		}
+		filtro.ResponsavelID = id
 	}
 
-	return ticketAuthor, nil
+	return filtro, nil
 }
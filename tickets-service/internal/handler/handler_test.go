@@ -2,9 +2,18 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"helpdesk/pkg/pb"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/middleware"
 	"net/http"          // Precisamos das constantes HTTP, como 'http.StatusOK'.
 	"net/http/httptest" // A caixa de ferramentas mágica do Go para simular requisições e respostas HTTP.
 	"testing"           // O pacote fundamental para a criação de qualquer teste.
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // A função de teste deve começar com 'Test' e receber um ponteiro para 'testing.T'.
@@ -45,3 +54,193 @@ func TestHealthCheckHandler(t *testing.T) {
 	// de uma única peça de nosso código (um 'handler') com total confiança,
 	// garantindo que ela faça exatamente o que esperamos, sem depender do resto do sistema.
 }
+
+func TestParseTicketFilter(t *testing.T) {
+	req := httptest.NewRequest("GET", "/tickets?status=aberto&prioridade=alta&categoria_id=3", nil)
+
+	filtro, err := parseTicketFilter(req)
+	if err != nil {
+		t.Fatalf("Não era esperado erro ao ler o filtro: %v", err)
+	}
+
+	if filtro.Status != "aberto" || filtro.Prioridade != "alta" || filtro.CategoriaID != 3 || filtro.ResponsavelID != 0 {
+		t.Errorf("Filtro lido incorretamente: %+v", filtro)
+	}
+
+	// Um ID que não é número deve ser rejeitado em vez de ignorado.
+	req = httptest.NewRequest("GET", "/tickets?responsavel_id=abc", nil)
+	if _, err = parseTicketFilter(req); err == nil {
+		t.Errorf("Era esperado erro para responsavel_id inválido")
+	}
+}
+
+func TestParseMentions(t *testing.T) {
+	texto := "@Joao.Silva pode olhar? Copiando @maria@acme.com e @joao.silva de novo. Email solto: suporte@acme.com."
+
+	handles := parseMentions(texto)
+
+	// Repetições são ignoradas e o email solto no texto não é uma menção.
+	esperado := []string{"joao.silva", "maria@acme.com"}
+	if len(handles) != len(esperado) {
+		t.Fatalf("Menções inesperadas: esperado %v, recebido %v", esperado, handles)
+	}
+	for i := range esperado {
+		if handles[i] != esperado[i] {
+			t.Errorf("Menção %d: esperado %q, recebido %q", i, esperado[i], handles[i])
+		}
+	}
+}
+
+func TestDetectarTipo(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	executavel := []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff")
+
+	casos := []struct {
+		nome      string
+		cabeca    []byte
+		declarado string
+		esperado  string
+	}{
+		{"png declarado como pdf", png, "application/pdf", "image/png"},
+		{"executavel disfarçado de imagem", executavel, "image/png", "application/octet-stream"},
+		{"csv declarado", []byte("id,nome\n1,Joao\n"), "text/csv", "text/csv"},
+		{"html declarado como texto", []byte("<html><script>alert(1)</script>"), "text/plain", "text/html"},
+	}
+
+	for _, c := range casos {
+		if tipo := detectarTipo(c.cabeca, c.declarado); tipo != c.esperado {
+			t.Errorf("%s: esperado %q, recebido %q", c.nome, c.esperado, tipo)
+		}
+	}
+}
+
+func TestValidarPreferencias(t *testing.T) {
+	p := model.NotificationPreferences{EmailHabilitado: true, EventosDesativados: []string{model.EventoMencao}}
+	if err := validarPreferencias(&p); err != nil {
+		t.Fatalf("Não era esperado erro: %v", err)
+	}
+	if p.Idioma != model.IdiomaPortugues {
+		t.Errorf("Idioma padrão deveria ser %q, recebido %q", model.IdiomaPortugues, p.Idioma)
+	}
+
+	p = model.NotificationPreferences{Idioma: "klingon"}
+	if err := validarPreferencias(&p); err == nil {
+		t.Errorf("Era esperado erro para idioma não suportado")
+	}
+
+	p = model.NotificationPreferences{EventosDesativados: []string{"spam"}}
+	if err := validarPreferencias(&p); err == nil {
+		t.Errorf("Era esperado erro para evento desconhecido")
+	}
+}
+
+func TestValidarWebhook(t *testing.T) {
+	hook, err := validarWebhook(webhookRequest{
+		URL:     "https://itsm.acme.com/hooks/helpdesk",
+		Eventos: []string{model.DominioTicketCriado, model.DominioTicketCriado, model.DominioTicketComentado},
+	})
+	if err != nil {
+		t.Fatalf("Não era esperado erro: %v", err)
+	}
+	if len(hook.Eventos) != 2 || !hook.Ativo {
+		t.Errorf("Webhook montado incorretamente: %+v", hook)
+	}
+
+	casos := []webhookRequest{
+		{URL: "ftp://acme.com/hook", Eventos: []string{model.DominioTicketCriado}},
+		{URL: "https:///sem-host", Eventos: []string{model.DominioTicketCriado}},
+		{URL: "https://acme.com/hook"},
+		{URL: "https://acme.com/hook", Eventos: []string{"comment.mentioned"}},
+		{URL: "http://127.0.0.1:8080/admin", Eventos: []string{model.DominioTicketCriado}},
+		{URL: "http://169.254.169.254/latest/meta-data", Eventos: []string{model.DominioTicketCriado}},
+		{URL: "http://users-service:8081/users", Eventos: []string{model.DominioTicketCriado}},
+	}
+	for _, c := range casos {
+		if _, err := validarWebhook(c); err == nil {
+			t.Errorf("Era esperado erro para %+v", c)
+		}
+	}
+}
+
+func TestParseStream(t *testing.T) {
+	req := httptest.NewRequest("GET", "/tickets/stream?tipo=ticket.created,ticket.commented&ticket_id=3&ticket_id=4&escopo=acompanhando", nil)
+	req.Header.Set("Last-Event-ID", "42")
+
+	filtro, acompanhando, desde, err := parseStream(req)
+	if err != nil {
+		t.Fatalf("Não era esperado erro ao ler o stream: %v", err)
+	}
+	if len(filtro.Tipos) != 2 || !filtro.Tickets[3] || !filtro.Tickets[4] || !acompanhando || desde != 42 {
+		t.Errorf("Stream lido incorretamente: %+v, acompanhando=%v, desde=%d", filtro, acompanhando, desde)
+	}
+
+	// Sem o cabecalho, o ponto de retomada vem da query.
+	req = httptest.NewRequest("GET", "/tickets/stream/ws?last_event_id=7", nil)
+	if _, _, desde, _ = parseStream(req); desde != 7 {
+		t.Errorf("last_event_id esperado 7, recebido %d", desde)
+	}
+
+	for _, q := range []string{"tipo=spam", "ticket_id=abc", "escopo=meus", "last_event_id=-1"} {
+		req = httptest.NewRequest("GET", "/tickets/stream?"+q, nil)
+		if _, _, _, err := parseStream(req); err == nil {
+			t.Errorf("Era esperado erro para %q", q)
+		}
+	}
+}
+
+func TestCriarComentario_Regras(t *testing.T) {
+	api := &ApiServer{}
+	cliente := context.WithValue(context.Background(), middleware.TipoUserKey, "cliente")
+
+	// As regras de tipo sao checadas antes de qualquer acesso ao banco.
+	err := api.criarComentario(cliente, &model.Comentario{Tipo: model.ComentarioInterno})
+	if !errors.Is(err, ErrNotaInternaProibida) {
+		t.Errorf("Cliente não pode criar nota interna, recebido %v", err)
+	}
+	err = api.criarComentario(cliente, &model.Comentario{Tipo: "privado"})
+	if !errors.Is(err, ErrTipoComentarioInvalido) {
+		t.Errorf("Era esperado erro de tipo inválido, recebido %v", err)
+	}
+}
+
+func TestErroGRPC(t *testing.T) {
+	casos := []struct {
+		err      error
+		esperado codes.Code
+	}{
+		{pgx.ErrNoRows, codes.NotFound},
+		{ErrPermissao, codes.PermissionDenied},
+		{ErrNotaInternaProibida, codes.PermissionDenied},
+		{ErrTipoComentarioInvalido, codes.InvalidArgument},
+		{errors.New("conexão recusada"), codes.Internal},
+	}
+	for _, c := range casos {
+		if code := status.Code(erroGRPC(context.Background(), c.err, "Erro")); code != c.esperado {
+			t.Errorf("%v: esperado %v, recebido %v", c.err, c.esperado, code)
+		}
+	}
+}
+
+func TestFiltroWatch(t *testing.T) {
+	filtro, err := filtroWatch(&pb.WatchTicketsRequest{
+		Tipos:     []string{model.DominioTicketCriado},
+		TicketIds: []int64{3, 4},
+	})
+	if err != nil {
+		t.Fatalf("Não era esperado erro: %v", err)
+	}
+	if !filtro.Tipos[model.DominioTicketCriado] || !filtro.Tickets[3] || !filtro.Tickets[4] {
+		t.Errorf("Filtro montado incorretamente: %+v", filtro)
+	}
+
+	casos := []*pb.WatchTicketsRequest{
+		{Tipos: []string{"spam"}},
+		{TicketIds: []int64{0}},
+		{LastEventId: -1},
+	}
+	for _, c := range casos {
+		if _, err := filtroWatch(c); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Era esperado InvalidArgument para %v, recebido %v", c, err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/internal/inbound"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/users"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ProcessarEmail implementa inbound.Destino. Respostas que referenciam um
+// ticket existente viram comentarios publicos; as demais abrem um ticket novo.
+// As partes MIME com nome de arquivo sao gravadas como anexos.
+func (api *ApiServer) ProcessarEmail(ctx context.Context, email inbound.Email) error {
+	if email.Automatico {
+		registro.Logger(ctx).Info("Email automático ignorado", "message_id", email.MessageID, "de", email.De)
+		return nil
+	}
+
+	remetente, err := api.usuarios.UsuarioPorEmail(ctx, email.De)
+	if errors.Is(err, users.ErrNaoEncontrado) {
+		return fmt.Errorf("%w: %s", inbound.ErrRemetenteDesconhecido, email.De)
+	} else if err != nil {
+		return err
+	}
+	userID, tipoUser := remetente.ID, remetente.TipoUser
+	// O From so vale como identidade de agente se o MTA o verificou; sem
+	// isso o email e tratado como de cliente, para que ninguem comente como
+	// agente forjando o remetente.
+	agente := email.Verificado && (tipoUser == model.TipoAgente || tipoUser == model.TipoAdmin)
+
+	if ticketID := inbound.TicketReferenciado(email); ticketID != 0 {
+		ticket, err := api.rep.GetTicketByID(ctx, int(ticketID))
+		if err == nil {
+			pode := agente || ticket.UserID == userID
+			if !pode {
+				if pode, err = api.rep.CanViewTicket(ticket.ID, userID); err != nil {
+					return err
+				}
+			}
+			if pode {
+				return api.comentarPorEmail(ctx, ticket.ID, userID, email)
+			}
+			registro.Logger(ctx).Warn("Email referencia um ticket sem permissão; abrindo um ticket novo", "de", email.De, "ticket_id", ticketID)
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+	}
+
+	return api.abrirTicketPorEmail(ctx, userID, email)
+}
+
+func (api *ApiServer) abrirTicketPorEmail(ctx context.Context, userID int64, email inbound.Email) error {
+	titulo := inbound.LimparAssunto(email.Assunto)
+	if titulo == "" {
+		titulo = "(sem assunto)"
+	}
+
+	agora := time.Now()
+	ticket := model.Ticket{
+		Titulo:          titulo,
+		Descricao:       email.Texto,
+		Status:          "aberto",
+		DataAbertura:    agora,
+		DataAtualizacao: agora,
+		Tags:            []string{"email"},
+		UserID:          userID,
+	}
+	if err := api.criarTicket(ctx, &ticket); err != nil {
+		return err
+	}
+
+	api.anexarPartes(ctx, ticket.ID, 0, userID, email.Anexos)
+	registro.Logger(ctx).Info("Ticket aberto a partir de email", "ticket_id", ticket.ID, "message_id", email.MessageID, "de", email.De)
+	return nil
+}
+
+func (api *ApiServer) comentarPorEmail(ctx context.Context, ticketID, userID int64, email inbound.Email) error {
+	comentario := model.Comentario{
+		Descricao: inbound.RemoverCitacao(email.Texto),
+		Data:      time.Now(),
+		UserID:    userID,
+		TicketID:  ticketID,
+		Tipo:      model.ComentarioPublico,
+	}
+
+	id, err := api.rep.CreateComment(ctx, comentario)
+	if err != nil {
+		return err
+	}
+	comentario.ID = id
+
+	// Sem usuario logado, as mencoes sao resolvidas com a identidade do servico.
+	api.registrarMencoes(ctx, &comentario)
+	api.anexarPartes(ctx, ticketID, id, userID, email.Anexos)
+	registro.Logger(ctx).Info("Comentário adicionado a partir de email", "comentario_id", id, "ticket_id", ticketID, "message_id", email.MessageID, "de", email.De)
+	return nil
+}
+
+// anexarPartes grava os anexos do email. Um anexo recusado (tipo nao
+// permitido, grande demais, infectado) nao impede os demais nem o ticket.
+func (api *ApiServer) anexarPartes(ctx context.Context, ticketID, commentID, userID int64, partes []inbound.Anexo) {
+	for _, parte := range partes {
+		anexo, _, err := api.gravarAnexo(ctx, ticketID, commentID, userID, parte.Nome, parte.ContentType, bytes.NewReader(parte.Conteudo))
+		if err != nil {
+			registro.Logger(ctx).Warn("Anexo do email ignorado", "arquivo", parte.Nome, "ticket_id", ticketID, "erro", err)
+			continue
+		}
+		if anexo.Status == model.AnexoQuarentena {
+			registro.Logger(ctx).Warn("Anexo do email colocado em quarentena", "arquivo", parte.Nome, "ticket_id", ticketID, "motivo", anexo.MotivoQuarentena)
+		}
+	}
+}
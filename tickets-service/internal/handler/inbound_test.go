@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"helpdesk/tickets-service/internal/inbound"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestProcessarEmail_AgenteSemVerificacaoECliente(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, diretorioMencoes, nil)
+	repo.On("GetTicketByID", 1).Return(model.Ticket{ID: 1, UserID: 9}, nil)
+	repo.On("CanViewTicket", int64(1), int64(3)).Return(false, nil)
+	repo.On("CreateTicket", mock.Anything).Return(int64(2), nil)
+
+	// Um From de agente sem a verificacao do MTA nao comenta no ticket
+	// alheio: o email abre um ticket novo, como o de qualquer cliente.
+	err := api.ProcessarEmail(context.Background(), inbound.Email{
+		De:      "agente@acme.com",
+		Assunto: "Re: [Ticket #1] Impressora",
+		Texto:   "Resolvido, pode fechar.",
+	})
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "CreateComment", mock.Anything)
+}
+
+func TestProcessarEmail_RemetenteDesconhecido(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, diretorioMencoes, nil)
+
+	err := api.ProcessarEmail(context.Background(), inbound.Email{De: "ninguem@acme.com", Assunto: "Ajuda"})
+
+	assert.ErrorIs(t, err, inbound.ErrRemetenteDesconhecido)
+	repo.AssertNotCalled(t, "CreateTicket", mock.Anything)
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"helpdesk/pkg/problema"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// isAdmin indica se quem fez a requisicao e administrador.
+func isAdmin(r *http.Request) bool {
+	tipoUser, _ := r.Context().Value(middleware.TipoUserKey).(string)
+	return tipoUser == model.TipoAdmin
+}
+
+// ListJobsHandler mostra a situacao da fila: a contagem por status e os jobs
+// do status pedido (?status=, padrao "morto"; ?limite=, padrao 50).
+func (api *ApiServer) ListJobsHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = model.JobMorto
+	case model.JobPendente, model.JobProcessando, model.JobConcluido, model.JobMorto:
+	default:
+		problema.Escrever(w, r, http.StatusBadRequest, "Status inválido, use pendente, processando, concluido ou morto")
+		return
+	}
+
+	limite := 50
+	if v := r.URL.Query().Get("limite"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 500 {
+			problema.Escrever(w, r, http.StatusBadRequest, "Limite inválido, deve ser um número entre 1 e 500")
+			return
+		}
+		limite = n
+	}
+
+	contagem, err := api.rep.CountJobs()
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar a fila no banco de dados")
+		return
+	}
+	jobs, err := api.rep.ListJobs(status, limite)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar a fila no banco de dados")
+		return
+	}
+
+	resposta := struct {
+		Contagem map[string]int64 `json:"contagem"`
+		Jobs     []model.Job      `json:"jobs"`
+	}{contagem, jobs}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(resposta); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar a lista em JSON")
+		return
+	}
+}
+
+func (api *ApiServer) GetJobHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "ID do job inválido, deve ser um número inteiro")
+		return
+	}
+
+	job, err := api.rep.GetJobByID(id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Job não encontrado")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar o job no banco de dados")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(job); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar o job em JSON")
+		return
+	}
+}
+
+// RetryJobHandler devolve um job da fila de mortos para a fila, com as
+// tentativas zeradas.
+func (api *ApiServer) RetryJobHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "ID do job inválido, deve ser um número inteiro")
+		return
+	}
+
+	if err = api.rep.RequeueJob(id); errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Job não encontrado na fila de mortos")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao reenfileirar o job no banco de dados")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/internal/model"
+	"regexp"
+	"strings"
+)
+
+// mentionRegex reconhece @handle, onde handle e a parte local de um email
+// (@joao.silva) ou o email completo (@joao@acme.com). O @ nao pode vir colado
+// a uma palavra, para que emails soltos no texto nao virem mencoes.
+var mentionRegex = regexp.MustCompile(`(?:^|[^\w.@+-])@([\w.+-]+(?:@[\w-]+(?:\.[\w-]+)+)?)`)
+
+// parseMentions extrai os handles mencionados no texto, sem repeticao e em minusculas.
+func parseMentions(texto string) []string {
+	var handles []string
+	vistos := make(map[string]bool)
+
+	for _, m := range mentionRegex.FindAllStringSubmatch(texto, -1) {
+		h := strings.ToLower(strings.TrimRight(m[1], "."))
+		if h == "" || vistos[h] {
+			continue
+		}
+		vistos[h] = true
+		handles = append(handles, h)
+	}
+
+	return handles
+}
+
+// registrarMencoes resolve as mencoes do comentario no users-service, com a
+// identidade do tickets-service, e as grava; os recem-mencionados sao
+// notificados pelo evento comment.mentioned da outbox, cujo email traz o
+// titulo e o comentario. Por isso so vale a mencao de quem pode ver o ticket
+// e, em notas internas, so a de agentes.
+// Falhas sao apenas registradas: o comentario ja foi salvo e nao deve ser perdido.
+func (api *ApiServer) registrarMencoes(ctx context.Context, comentario *model.Comentario) {
+	var usuarios []model.MentionedUser
+	if handles := parseMentions(comentario.Descricao); len(handles) > 0 {
+		var err error
+		if usuarios, err = api.usuarios.Mencionados(ctx, handles); err != nil {
+			registro.Logger(ctx).Error("Erro ao resolver as menções do comentario", "comentario_id", comentario.ID, "erro", err)
+			return
+		}
+	}
+
+	ids, err := api.mencoesVisiveis(ctx, *comentario, usuarios)
+	if err != nil {
+		registro.Logger(ctx).Error("Erro ao conferir quem pode ver o ticket mencionado", "comentario_id", comentario.ID, "erro", err)
+		return
+	}
+
+	if _, err = api.rep.ReplaceCommentMentions(ctx, *comentario, ids); err != nil {
+		registro.Logger(ctx).Error("Erro ao gravar as menções do comentario", "comentario_id", comentario.ID, "erro", err)
+		return
+	}
+	comentario.Mencoes = ids
+}
+
+// mencoesVisiveis devolve os IDs dos mencionados que podem ler o comentario.
+func (api *ApiServer) mencoesVisiveis(ctx context.Context, comentario model.Comentario, usuarios []model.MentionedUser) ([]int64, error) {
+	ids := []int64{}
+	if len(usuarios) == 0 {
+		return ids, nil
+	}
+
+	ticket, err := api.rep.GetTicketByID(ctx, int(comentario.TicketID))
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range usuarios {
+		agente := u.TipoUser == model.TipoAgente || u.TipoUser == model.TipoAdmin
+		if comentario.Tipo == model.ComentarioInterno && !agente {
+			continue
+		}
+		pode, err := api.usuarioVeTicket(ticket, u.ID, agente)
+		if err != nil {
+			return nil, err
+		}
+		if pode {
+			ids = append(ids, u.ID)
+		}
+	}
+	return ids, nil
+}
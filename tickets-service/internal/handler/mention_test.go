@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/repository"
+	"helpdesk/tickets-service/internal/users"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// diretorioFake resolve handles, emails e IDs pelo mapa, como o users-service.
+type diretorioFake map[string]model.MentionedUser
+
+func (d diretorioFake) Mencionados(ctx context.Context, handles []string) ([]model.MentionedUser, error) {
+	var usuarios []model.MentionedUser
+	for _, h := range handles {
+		if u, ok := d[h]; ok {
+			usuarios = append(usuarios, u)
+		}
+	}
+	return usuarios, nil
+}
+
+func (d diretorioFake) UsuarioPorEmail(ctx context.Context, email string) (model.Usuario, error) {
+	for _, u := range d {
+		if u.Email == email {
+			return model.Usuario{ID: u.ID, Nome: u.Nome, Email: u.Email, TipoUser: u.TipoUser}, nil
+		}
+	}
+	return model.Usuario{}, users.ErrNaoEncontrado
+}
+
+func (d diretorioFake) TipoUsuario(ctx context.Context, id int64) (string, error) {
+	for _, u := range d {
+		if u.ID == id {
+			return u.TipoUser, nil
+		}
+	}
+	return "", users.ErrNaoEncontrado
+}
+
+var diretorioMencoes = diretorioFake{
+	"autor":    {ID: 9, TipoUser: "cliente"},
+	"watcher":  {ID: 5, TipoUser: "cliente"},
+	"estranho": {ID: 7, TipoUser: "cliente"},
+	"agente":   {ID: 3, Email: "agente@acme.com", TipoUser: model.TipoAgente},
+}
+
+func TestRegistrarMencoes_SoQuemVeOTicket(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, diretorioMencoes, nil)
+	repo.On("GetTicketByID", 1).Return(model.Ticket{ID: 1, UserID: 9, Titulo: "Contrato sigiloso"}, nil)
+	repo.On("CanViewTicket", int64(1), int64(5)).Return(true, nil)
+	repo.On("CanViewTicket", int64(1), int64(7)).Return(false, nil)
+	repo.On("ReplaceCommentMentions", mock.Anything, []int64{9, 5, 3}).Return([]int64{9, 5, 3}, nil)
+
+	// O estranho nao participa do ticket: nao e mencionado nem recebe o email.
+	comentario := model.Comentario{ID: 4, TicketID: 1, Tipo: model.ComentarioPublico, Descricao: "@autor @watcher @estranho @agente veja"}
+	api.registrarMencoes(context.Background(), &comentario)
+
+	assert.Equal(t, []int64{9, 5, 3}, comentario.Mencoes)
+	repo.AssertExpectations(t)
+}
+
+func TestRegistrarMencoes_NotaInternaSoAgentes(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, diretorioMencoes, nil)
+	repo.On("GetTicketByID", 1).Return(model.Ticket{ID: 1, UserID: 9}, nil)
+	repo.On("ReplaceCommentMentions", mock.Anything, []int64{3}).Return([]int64{3}, nil)
+
+	comentario := model.Comentario{ID: 4, TicketID: 1, Tipo: model.ComentarioInterno, Descricao: "@autor @watcher @agente"}
+	api.registrarMencoes(context.Background(), &comentario)
+
+	assert.Equal(t, []int64{3}, comentario.Mencoes)
+	repo.AssertNotCalled(t, "CanViewTicket", mock.Anything, mock.Anything)
+}
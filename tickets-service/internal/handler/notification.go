@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"helpdesk/pkg/problema"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/users"
+	"helpdesk/tickets-service/middleware"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+var eventosNotificacao = map[string]bool{
+	model.EventoTicketCriado:    true,
+	model.EventoStatusAlterado:  true,
+	model.EventoTicketAtribuido: true,
+	model.EventoNovoComentario:  true,
+	model.EventoMencao:          true,
+}
+
+var idiomasNotificacao = map[string]bool{
+	model.IdiomaPortugues: true,
+	model.IdiomaIngles:    true,
+}
+
+func (api *ApiServer) GetNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	idReq, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		problema.Escrever(w, r, http.StatusInternalServerError, "Não foi possivel extrair o ID do usuario do token")
+		return
+	}
+
+	preferencias, err := api.rep.GetNotificationPreferences(r.Context(), idReq)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar as preferências no banco de dados")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(preferencias); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar as preferências em JSON")
+		return
+	}
+}
+
+func (api *ApiServer) UpdateNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	idReq, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		problema.Escrever(w, r, http.StatusInternalServerError, "Não foi possivel extrair o ID do usuario do token")
+		return
+	}
+
+	var preferencias model.NotificationPreferences
+	if err := json.NewDecoder(r.Body).Decode(&preferencias); err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar a requisição")
+		return
+	}
+	if err := validarPreferencias(&preferencias); err != nil {
+		problema.Erro(w, r, err, "Erro ao validar a requisição")
+		return
+	}
+	preferencias.UserID = idReq
+
+	if err := api.rep.UpsertNotificationPreferences(preferencias); err != nil {
+		problema.Erro(w, r, err, "Erro ao gravar as preferências no banco de dados")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(preferencias); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar as preferências em JSON")
+		return
+	}
+}
+
+// validarPreferencias confere idioma e eventos e aplica o idioma padrao.
+func validarPreferencias(p *model.NotificationPreferences) error {
+	if p.Idioma == "" {
+		p.Idioma = model.IdiomaPortugues
+	}
+	if !idiomasNotificacao[p.Idioma] {
+		return problema.Invalido("idioma", fmt.Sprintf("Idioma não suportado: %s", p.Idioma))
+	}
+	for _, evento := range p.EventosDesativados {
+		if !eventosNotificacao[evento] {
+			return problema.Invalido("eventos_desativados", fmt.Sprintf("Evento de notificação desconhecido: %s", evento))
+		}
+	}
+	return nil
+}
+
+// AssignTicketHandler define o agente responsavel pelo ticket. Zero remove o
+// responsavel atual.
+func (api *ApiServer) AssignTicketHandler(w http.ResponseWriter, r *http.Request) {
+	idInt, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "ID do ticket inválido, deve ser um número inteiro")
+		return
+	}
+
+	if !isAgente(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Apenas agentes podem atribuir tickets")
+		return
+	}
+
+	var req struct {
+		ResponsavelID int64 `json:"responsavel_id"`
+	}
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar a requisição")
+		return
+	}
+
+	if req.ResponsavelID != 0 {
+		tipoUser, err := api.usuarios.TipoUsuario(r.Context(), req.ResponsavelID)
+		if errors.Is(err, users.ErrNaoEncontrado) {
+			problema.Escrever(w, r, http.StatusNotFound, "Responsável não encontrado no banco de dados")
+			return
+		} else if err != nil {
+			problema.Erro(w, r, err, "Erro ao consultar o responsável no users-service")
+			return
+		}
+		if tipoUser != model.TipoAgente && tipoUser != model.TipoAdmin {
+			problema.Escrever(w, r, http.StatusBadRequest, "O responsável deve ser um agente")
+			return
+		}
+	}
+
+	ticket, err := api.rep.GetTicketByID(r.Context(), idInt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Ticket não encontrado")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar o ticket no banco de dados")
+		return
+	}
+
+	if ticket.ResponsavelID == req.ResponsavelID {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	ticket.ResponsavelID = req.ResponsavelID
+	ticket.DataAtualizacao = time.Now()
+
+	idReq, _ := r.Context().Value(middleware.UserIDKey).(int64)
+	if err = api.rep.UpdateTicket(r.Context(), idInt, ticket, idReq); err != nil {
+		problema.Erro(w, r, err, "Erro ao atualizar informacoes no banco de dados")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"helpdesk/pkg/problema"
+	"helpdesk/tickets-service/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetOrganizationTicketsHandler lista os tickets de todos os colegas da
+// organizacao do usuario. Apenas managers da organizacao tem acesso.
+func (api *ApiServer) GetOrganizationTicketsHandler(w http.ResponseWriter, r *http.Request) {
+	idReq, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		problema.Escrever(w, r, http.StatusInternalServerError, "Erro ao extrair o ID do usuario da requisição")
+		return
+	}
+
+	filtro, err := parseTicketFilter(r)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao validar a requisição")
+		return
+	}
+
+	membro, err := api.rep.GetOrganizationMembership(idReq)
+	if errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusForbidden, "Usuario não pertence a nenhuma organização")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar a organização do usuario")
+		return
+	}
+
+	if !membro.Manager {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	lista, err := api.rep.ListTicketsByOrganization(membro.OrganizationID, filtro)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao obter a lista de tickets no banco de dados")
+		return
+	}
+	api.perfis.Preencher(r.Context(), lista)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(lista); err != nil {
+		problema.Erro(w, r, err, "Erro ao converter a lista para json")
+		return
+	}
+}
+
+// AddTicketCCHandler coloca um colega da mesma organizacao do autor em copia
+// no ticket. Pode ser feito pelo autor ou por um manager da organizacao.
+func (api *ApiServer) AddTicketCCHandler(w http.ResponseWriter, r *http.Request) {
+	idInt, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "ID inválido, deve ser um número inteiro")
+		return
+	}
+
+	var ccReq struct {
+		UserID int64 `json:"user_id"`
+	}
+	if err = json.NewDecoder(r.Body).Decode(&ccReq); err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar o corpo da requisição")
+		return
+	}
+
+	idReq, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		problema.Escrever(w, r, http.StatusInternalServerError, "Erro ao extrair o ID do usuario da requisição")
+		return
+	}
+
+	ticket, err := api.rep.GetTicketByID(r.Context(), idInt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Registro inexistente")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao obter o ticket no banco de dados")
+		return
+	}
+
+	orgAutor, err := api.rep.GetOrganizationMembership(ticket.UserID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusUnprocessableEntity, "O autor do ticket não pertence a nenhuma organização")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar a organização do autor")
+		return
+	}
+
+	if idReq != ticket.UserID {
+		orgReq, err := api.rep.GetOrganizationMembership(idReq)
+		if err != nil || !orgReq.Manager || orgReq.OrganizationID != orgAutor.OrganizationID {
+			problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+			return
+		}
+	}
+
+	orgColega, err := api.rep.GetOrganizationMembership(ccReq.UserID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		problema.Erro(w, r, err, "Erro ao consultar a organização do colega")
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) || orgColega.OrganizationID != orgAutor.OrganizationID {
+		problema.Escrever(w, r, http.StatusUnprocessableEntity, "Só é possivel colocar em copia colegas da mesma organização")
+		return
+	}
+
+	if err = api.rep.AddTicketCC(ticket.ID, ccReq.UserID); err != nil {
+		problema.Erro(w, r, err, "Erro ao adicionar o usuario em copia")
+		return
+	}
+
+	cc, err := api.rep.ListTicketCC(ticket.ID)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar os usuarios em copia do ticket")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(cc); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar a resposta em JSON")
+		return
+	}
+}
+
+// RemoveTicketCCHandler retira um usuario da copia. O autor do ticket pode
+// retirar qualquer um; o proprio usuario em copia pode se retirar.
+func (api *ApiServer) RemoveTicketCCHandler(w http.ResponseWriter, r *http.Request) {
+	idInt, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "ID inválido, deve ser um número inteiro")
+		return
+	}
+
+	userID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "ID do usuário inválido, deve ser um número inteiro")
+		return
+	}
+
+	idReq, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		problema.Escrever(w, r, http.StatusInternalServerError, "Erro ao extrair o ID do usuario da requisição")
+		return
+	}
+
+	ticket, err := api.rep.GetTicketByID(r.Context(), idInt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Registro inexistente")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao obter o ticket no banco de dados")
+		return
+	}
+
+	if idReq != ticket.UserID && idReq != userID {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	if err = api.rep.RemoveTicketCC(ticket.ID, userID); errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Usuario não está em copia neste ticket")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao remover o usuario da copia")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
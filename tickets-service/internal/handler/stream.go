@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"helpdesk/pkg/problema"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/stream"
+	"helpdesk/tickets-service/middleware"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5"
+)
+
+// eventosStream sao os eventos que podem ser assinados em ?tipo=.
+var eventosStream = map[string]bool{
+	model.DominioTicketCriado:         true,
+	model.DominioTicketAtualizado:     true,
+	model.DominioStatusAlterado:       true,
+	model.DominioTicketAtribuido:      true,
+	model.DominioTicketExcluido:       true,
+	model.DominioTicketComentado:      true,
+	model.DominioComentarioAtualizado: true,
+	model.DominioComentarioExcluido:   true,
+	model.DominioComentarioMencionou:  true,
+}
+
+// validadePermissao e por quanto tempo uma conexao reaproveita a resposta
+// de "pode ver este ticket?", para nao consultar o banco a cada evento.
+const validadePermissao = time.Minute
+
+// O token vem no cabecalho Authorization (ou em ?access_token=), nunca em
+// cookies, entao conexoes de outras origens nao herdam a sessao do usuario.
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// valoresQuery junta os valores repetidos e separados por virgula de um
+// parametro: ?tipo=a&tipo=b,c vira [a b c].
+func valoresQuery(r *http.Request, nome string) []string {
+	var valores []string
+	for _, v := range r.URL.Query()[nome] {
+		for _, parte := range strings.Split(v, ",") {
+			if parte = strings.TrimSpace(parte); parte != "" {
+				valores = append(valores, parte)
+			}
+		}
+	}
+	return valores
+}
+
+// parseStream le as assinaturas do stream (?tipo=, ?ticket_id= e
+// ?escopo=acompanhando) e o ponto de retomada, do cabecalho Last-Event-ID
+// enviado pelo EventSource ou de ?last_event_id=.
+func parseStream(r *http.Request) (filtro stream.Filtro, acompanhando bool, desde int64, err error) {
+	for _, tipo := range valoresQuery(r, "tipo") {
+		if !eventosStream[tipo] {
+			return filtro, false, 0, problema.Invalido("tipo", fmt.Sprintf("Evento desconhecido: %s", tipo))
+		}
+		if filtro.Tipos == nil {
+			filtro.Tipos = map[string]bool{}
+		}
+		filtro.Tipos[tipo] = true
+	}
+
+	for _, v := range valoresQuery(r, "ticket_id") {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			return filtro, false, 0, problema.Invalido("ticket_id", "ticket_id inválido, deve ser um número inteiro")
+		}
+		if filtro.Tickets == nil {
+			filtro.Tickets = map[int64]bool{}
+		}
+		filtro.Tickets[id] = true
+	}
+
+	switch r.URL.Query().Get("escopo") {
+	case "", "todos":
+	case "acompanhando":
+		acompanhando = true
+	default:
+		return filtro, false, 0, problema.Invalido("escopo", "Escopo inválido, use todos ou acompanhando")
+	}
+
+	ultimo := r.Header.Get("Last-Event-ID")
+	if ultimo == "" {
+		ultimo = r.URL.Query().Get("last_event_id")
+	}
+	if ultimo != "" {
+		if desde, err = strconv.ParseInt(ultimo, 10, 64); err != nil || desde < 0 {
+			return filtro, false, 0, problema.Invalido("last_event_id", "ID do último evento inválido, deve ser um número inteiro")
+		}
+	}
+
+	return filtro, acompanhando, desde, nil
+}
+
+// emCache guarda por validadePermissao as respostas de f para cada ticket.
+// Cada conexao tem o seu cache, usado por uma unica goroutine.
+func emCache(f func(ctx context.Context, ticketID int64) (bool, error)) func(ctx context.Context, ticketID int64) (bool, error) {
+	type resposta struct {
+		ok  bool
+		ate time.Time
+	}
+	cache := map[int64]resposta{}
+
+	return func(ctx context.Context, ticketID int64) (bool, error) {
+		if c, achou := cache[ticketID]; achou && time.Now().Before(c.ate) {
+			return c.ok, nil
+		}
+		ok, err := f(ctx, ticketID)
+		if err != nil {
+			return false, err
+		}
+		cache[ticketID] = resposta{ok, time.Now().Add(validadePermissao)}
+		return ok, nil
+	}
+}
+
+// filtroStream monta o filtro da conexao com as permissoes de quem a abriu.
+func (api *ApiServer) filtroStream(r *http.Request) (*stream.Filtro, int64, error) {
+	filtro, acompanhando, desde, err := parseStream(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	api.aplicarPermissoes(r.Context(), &filtro, acompanhando)
+	return &filtro, desde, nil
+}
+
+// aplicarPermissoes preenche no filtro o usuario do contexto e as regras de
+// leitura dos tickets. Vale para os streams HTTP e para o WatchTickets do gRPC.
+func (api *ApiServer) aplicarPermissoes(ctx context.Context, filtro *stream.Filtro, acompanhando bool) {
+	idReq, _ := ctx.Value(middleware.UserIDKey).(int64)
+	filtro.UserID = idReq
+	filtro.Agente = agente(ctx)
+	filtro.PodeVer = emCache(func(ctx context.Context, ticketID int64) (bool, error) {
+		ticket, err := api.rep.GetTicketByID(ctx, int(ticketID))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		return api.podeVerTicket(ctx, ticket)
+	})
+	if acompanhando {
+		filtro.Acompanha = emCache(func(ctx context.Context, ticketID int64) (bool, error) {
+			return api.rep.FollowsTicket(ctx, ticketID, idReq)
+		})
+	}
+}
+
+// StreamTicketsHandler envia os eventos de tickets e comentarios por
+// Server-Sent Events. Cada evento leva o seu ID, que o EventSource devolve em
+// Last-Event-ID ao reconectar.
+func (api *ApiServer) StreamTicketsHandler(w http.ResponseWriter, r *http.Request) {
+	filtro, desde, err := api.filtroStream(r)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao validar a requisição")
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if err = rc.Flush(); err != nil {
+		registro.Logger(r.Context()).Error("Stream SSE sem suporte a flush", "erro", err)
+		return
+	}
+
+	err = stream.Transmitir(r.Context(), api.eventos, api.rep, filtro, desde,
+		func(e model.DomainEvent) error {
+			dados, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Tipo, dados)
+			return rc.Flush()
+		},
+		func() error {
+			fmt.Fprint(w, ": ping\n\n")
+			return rc.Flush()
+		})
+
+	if errors.Is(err, stream.ErrAtrasado) {
+		fmt.Fprintf(w, "event: erro\ndata: %s\n\n", err.Error())
+		rc.Flush()
+	} else if err != nil && r.Context().Err() == nil {
+		registro.Logger(r.Context()).Warn("Erro no stream SSE", "usuario_id", filtro.UserID, "erro", err)
+	}
+}
+
+// StreamTicketsWebSocketHandler envia os mesmos eventos do stream SSE, um
+// JSON por mensagem. A retomada usa ?last_event_id= e o keepalive usa ping.
+func (api *ApiServer) StreamTicketsWebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	filtro, desde, err := api.filtroStream(r)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao validar a requisição")
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// O Upgrader ja respondeu com o erro.
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// O cliente nao envia mensagens; a leitura so processa pongs e detecta
+	// o fechamento da conexao.
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(2 * stream.Heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * stream.Heartbeat))
+	})
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	err = stream.Transmitir(ctx, api.eventos, api.rep, filtro, desde,
+		func(e model.DomainEvent) error {
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			return conn.WriteJSON(e)
+		},
+		func() error {
+			return conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second))
+		})
+
+	fechamento := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	if errors.Is(err, stream.ErrAtrasado) {
+		fechamento = websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "reconecte informando last_event_id")
+	} else if err != nil && ctx.Err() == nil {
+		registro.Logger(r.Context()).Warn("Erro no stream WebSocket", "usuario_id", filtro.UserID, "erro", err)
+	}
+	conn.WriteControl(websocket.CloseMessage, fechamento, time.Now().Add(time.Second))
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUpdateTicketHandler_SoOAutor(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, nil, nil)
+	repo.On("GetTicketByID", 1).Return(model.Ticket{ID: 1, UserID: 9}, nil)
+
+	rr := httptest.NewRecorder()
+	api.UpdateTicketHandler(rr, requisicaoDe("PUT", "/tickets/1", `{"titulo":"Invadido"}`, 5, "cliente", "1"))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "UpdateTicket", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateTicketHandler_Inexistente(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, nil, nil)
+	repo.On("GetTicketByID", 1).Return(model.Ticket{}, pgx.ErrNoRows)
+
+	rr := httptest.NewRecorder()
+	api.UpdateTicketHandler(rr, requisicaoDe("PUT", "/tickets/1", `{"titulo":"Novo"}`, 9, "cliente", "1"))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestUpdateTicketHandler_Autor(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, nil, nil)
+	repo.On("GetTicketByID", 1).Return(model.Ticket{ID: 1, UserID: 9}, nil)
+	repo.On("UpdateTicket", 1, model.Ticket{ID: 1, UserID: 9, Titulo: "Novo"}, int64(9)).Return(nil)
+
+	rr := httptest.NewRecorder()
+	api.UpdateTicketHandler(rr, requisicaoDe("PUT", "/tickets/1", `{"titulo":"Novo"}`, 9, "cliente", "1"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestGetTicketHandler_ClienteQueNaoVeOTicket(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, nil, nil)
+	repo.On("GetTicketByID", 1).Return(model.Ticket{ID: 1, UserID: 9}, nil)
+	repo.On("CanViewTicket", int64(1), int64(7)).Return(false, nil)
+
+	rr := httptest.NewRecorder()
+	api.GetTicketHandler(rr, requisicaoDe("GET", "/tickets/1", "", 7, "cliente", "1"))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "ListTicketCC", mock.Anything)
+	repo.AssertNotCalled(t, "ListWatchers", mock.Anything)
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"helpdesk/pkg/problema"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/users"
+	"helpdesk/tickets-service/middleware"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// AddWatcherHandler passa a acompanhar o ticket. Sem corpo (ou com o proprio
+// user_id) o usuario se inscreve, desde que ja possa ver o ticket; inscrever
+// outra pessoa e restrito a agentes, e so vale para quem tambem ve o ticket.
+func (api *ApiServer) AddWatcherHandler(w http.ResponseWriter, r *http.Request) {
+	idInt, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "ID inválido, deve ser um número inteiro")
+		return
+	}
+
+	idReq, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		problema.Escrever(w, r, http.StatusInternalServerError, "Erro ao extrair o ID do usuario da requisição")
+		return
+	}
+
+	var watcherReq struct {
+		UserID int64 `json:"user_id"`
+	}
+	if err = json.NewDecoder(r.Body).Decode(&watcherReq); err != nil && !errors.Is(err, io.EOF) {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar o corpo da requisição")
+		return
+	}
+	if watcherReq.UserID == 0 {
+		watcherReq.UserID = idReq
+	}
+
+	if watcherReq.UserID != idReq && !isAgente(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Apenas agentes podem adicionar outros usuarios como watchers")
+		return
+	}
+
+	ticket, err := api.rep.GetTicketByID(r.Context(), idInt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Registro inexistente")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao obter o ticket no banco de dados")
+		return
+	}
+
+	// Watchers passam a ler o ticket, entao so se inscreve quem ja pode ve-lo.
+	pode, err := api.podeVerTicket(r.Context(), ticket)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao verificar as permissões do ticket")
+		return
+	}
+	if !pode {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	if watcherReq.UserID != idReq {
+		tipoUser, err := api.usuarios.TipoUsuario(r.Context(), watcherReq.UserID)
+		if errors.Is(err, users.ErrNaoEncontrado) {
+			problema.Escrever(w, r, http.StatusUnprocessableEntity, "Usuario não encontrado")
+			return
+		} else if err != nil {
+			problema.Erro(w, r, err, "Erro ao consultar o usuario no users-service")
+			return
+		}
+		ve, err := api.usuarioVeTicket(ticket, watcherReq.UserID, tipoUser == model.TipoAgente || tipoUser == model.TipoAdmin)
+		if err != nil {
+			problema.Erro(w, r, err, "Erro ao verificar as permissões do ticket")
+			return
+		}
+		if !ve {
+			problema.Escrever(w, r, http.StatusUnprocessableEntity, "O usuario não pode ver este ticket")
+			return
+		}
+	}
+
+	if err = api.rep.AddWatcher(int64(idInt), watcherReq.UserID); err != nil {
+		problema.Erro(w, r, err, "Erro ao adicionar o watcher no banco de dados")
+		return
+	}
+
+	watchers, err := api.rep.ListWatchers(int64(idInt))
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar os watchers do ticket")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(watchers); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar a resposta em JSON")
+		return
+	}
+}
+
+// RemoveWatcherHandler deixa de acompanhar o ticket. O usuario pode remover a
+// si mesmo; agentes podem remover qualquer watcher.
+func (api *ApiServer) RemoveWatcherHandler(w http.ResponseWriter, r *http.Request) {
+	idInt, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "ID inválido, deve ser um número inteiro")
+		return
+	}
+
+	userID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "ID do usuário inválido, deve ser um número inteiro")
+		return
+	}
+
+	idReq, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		problema.Escrever(w, r, http.StatusInternalServerError, "Erro ao extrair o ID do usuario da requisição")
+		return
+	}
+
+	if userID != idReq && !isAgente(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	if _, err = api.rep.GetTicketByID(r.Context(), idInt); errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Registro inexistente")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao obter o ticket no banco de dados")
+		return
+	}
+
+	if err = api.rep.RemoveWatcher(int64(idInt), userID); errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Usuario não acompanha este ticket")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao remover o watcher do banco de dados")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *ApiServer) ListWatchersHandler(w http.ResponseWriter, r *http.Request) {
+	idInt, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "ID inválido, deve ser um número inteiro")
+		return
+	}
+
+	if _, ok := api.carregarTicketVisivel(w, r, idInt); !ok {
+		return
+	}
+
+	watchers, err := api.rep.ListWatchers(int64(idInt))
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar os watchers do ticket")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(watchers); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar a resposta em JSON")
+		return
+	}
+}
+
+// GetWatchingTicketsHandler lista os tickets que o usuario acompanha.
+func (api *ApiServer) GetWatchingTicketsHandler(w http.ResponseWriter, r *http.Request) {
+	idReq, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		problema.Escrever(w, r, http.StatusInternalServerError, "Erro ao extrair o ID do usuario da requisição")
+		return
+	}
+
+	lista, err := api.rep.ListWatchedTickets(idReq)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar os tickets acompanhados")
+		return
+	}
+	api.perfis.Preencher(r.Context(), lista)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(lista); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar a lista de tickets")
+		return
+	}
+}
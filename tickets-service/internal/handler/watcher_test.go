@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/repository"
+	"helpdesk/tickets-service/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// requisicaoDe monta a requisicao como ela chega aos handlers depois do
+// AuthMiddleware, com o {id} da rota ja preenchido.
+func requisicaoDe(metodo, alvo, corpo string, userID int64, tipo string, id string) *http.Request {
+	req := httptest.NewRequest(metodo, alvo, strings.NewReader(corpo))
+	rc := chi.NewRouteContext()
+	rc.URLParams.Add("id", id)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rc)
+	ctx = context.WithValue(ctx, middleware.UserIDKey, userID)
+	ctx = context.WithValue(ctx, middleware.TipoUserKey, tipo)
+	return req.WithContext(ctx)
+}
+
+func TestAddWatcherHandler_SoQuemVeOTicketSeInscreve(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, nil, nil)
+	repo.On("GetTicketByID", 1).Return(model.Ticket{ID: 1, UserID: 9}, nil)
+	repo.On("CanViewTicket", int64(1), int64(5)).Return(false, nil)
+
+	rr := httptest.NewRecorder()
+	api.AddWatcherHandler(rr, requisicaoDe("POST", "/tickets/1/watchers", "", 5, "cliente", "1"))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "AddWatcher", int64(1), int64(5))
+}
+
+func TestAddWatcherHandler_InscreveQuemVe(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, nil, nil)
+	repo.On("GetTicketByID", 1).Return(model.Ticket{ID: 1, UserID: 9}, nil)
+	repo.On("CanViewTicket", int64(1), int64(5)).Return(true, nil)
+	repo.On("AddWatcher", int64(1), int64(5)).Return(nil)
+	repo.On("ListWatchers", int64(1)).Return([]int64{5}, nil)
+
+	rr := httptest.NewRecorder()
+	api.AddWatcherHandler(rr, requisicaoDe("POST", "/tickets/1/watchers", "", 5, "cliente", "1"))
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestAddWatcherHandler_OutroUsuario(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, diretorioMencoes, nil)
+
+	// Cliente nao inscreve outra pessoa, nem chega a consultar o banco.
+	rr := httptest.NewRecorder()
+	api.AddWatcherHandler(rr, requisicaoDe("POST", "/tickets/1/watchers", `{"user_id":7}`, 5, "cliente", "1"))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "GetTicketByID", 1)
+
+	// Agente pode, se o inscrito tambem puder ver o ticket.
+	repo.On("GetTicketByID", 1).Return(model.Ticket{ID: 1, UserID: 9}, nil)
+	repo.On("CanViewTicket", int64(1), int64(7)).Return(true, nil)
+	repo.On("AddWatcher", int64(1), int64(7)).Return(nil)
+	repo.On("ListWatchers", int64(1)).Return([]int64{7}, nil)
+
+	rr = httptest.NewRecorder()
+	api.AddWatcherHandler(rr, requisicaoDe("POST", "/tickets/1/watchers", `{"user_id":7}`, 3, "agente", "1"))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestListWatchersHandler_ClienteQueNaoVeOTicket(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, nil, nil)
+	repo.On("GetTicketByID", 1).Return(model.Ticket{ID: 1, UserID: 9}, nil)
+	repo.On("CanViewTicket", int64(1), int64(7)).Return(false, nil)
+
+	rr := httptest.NewRecorder()
+	api.ListWatchersHandler(rr, requisicaoDe("GET", "/tickets/1/watchers", "", 7, "cliente", "1"))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "ListWatchers", int64(1))
+}
+
+func TestAddWatcherHandler_InscritoQueNaoVeOTicket(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, diretorioMencoes, nil)
+	repo.On("GetTicketByID", 1).Return(model.Ticket{ID: 1, UserID: 9}, nil)
+	repo.On("CanViewTicket", int64(1), int64(7)).Return(false, nil)
+
+	// O agente ve o ticket, mas o cliente 7 nao: inscreve-lo daria a ele
+	// acesso ao ticket.
+	rr := httptest.NewRecorder()
+	api.AddWatcherHandler(rr, requisicaoDe("POST", "/tickets/1/watchers", `{"user_id":7}`, 3, "agente", "1"))
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	rr = httptest.NewRecorder()
+	api.AddWatcherHandler(rr, requisicaoDe("POST", "/tickets/1/watchers", `{"user_id":404}`, 3, "agente", "1"))
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	repo.AssertNotCalled(t, "AddWatcher", mock.Anything, mock.Anything)
+}
+
+func TestRemoveWatcherHandler_TicketInexistente(t *testing.T) {
+	repo := new(repository.MockTicketRepository)
+	api := NewApiServer(repo, nil, nil, nil, nil, nil)
+	repo.On("GetTicketByID", 1).Return(model.Ticket{}, pgx.ErrNoRows)
+
+	req := requisicaoDe("DELETE", "/tickets/1/watchers/5", "", 5, "cliente", "1")
+	chi.RouteContext(req.Context()).URLParams.Add("userID", "5")
+	rr := httptest.NewRecorder()
+	api.RemoveWatcherHandler(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	repo.AssertNotCalled(t, "RemoveWatcher", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"helpdesk/pkg/problema"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/webhook"
+	"helpdesk/tickets-service/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// webhookRequest e o corpo de criacao e alteracao de webhooks. Ativo e
+// ponteiro para que a ausencia do campo signifique "ativo".
+type webhookRequest struct {
+	URL             string   `json:"url"`
+	Eventos         []string `json:"eventos"`
+	Segredo         string   `json:"segredo"`
+	Ativo           *bool    `json:"ativo"`
+	IncluirInternos bool     `json:"incluir_internos"`
+}
+
+// validarWebhook confere a URL, que nao pode ser da rede interna, e os
+// eventos e monta o webhook. Eventos repetidos sao descartados.
+func validarWebhook(req webhookRequest) (model.Webhook, error) {
+	if err := webhook.ValidarURL(req.URL); errors.Is(err, webhook.ErrDestinoInterno) {
+		return model.Webhook{}, problema.Invalido("url", "A URL não pode apontar para a rede interna")
+	} else if err != nil {
+		return model.Webhook{}, problema.Invalido("url", err.Error())
+	}
+
+	if len(req.Eventos) == 0 {
+		return model.Webhook{}, problema.Invalido("eventos", "Informe ao menos um evento")
+	}
+	eventos := []string{}
+	vistos := map[string]bool{}
+	for _, e := range req.Eventos {
+		if !model.EventosWebhook[e] {
+			return model.Webhook{}, problema.Invalido("eventos", fmt.Sprintf("Evento desconhecido: %s", e))
+		}
+		if !vistos[e] {
+			vistos[e] = true
+			eventos = append(eventos, e)
+		}
+	}
+
+	ativo := true
+	if req.Ativo != nil {
+		ativo = *req.Ativo
+	}
+
+	return model.Webhook{
+		URL:             req.URL,
+		Eventos:         eventos,
+		Segredo:         req.Segredo,
+		Ativo:           ativo,
+		IncluirInternos: req.IncluirInternos,
+	}, nil
+}
+
+// webhookDaRequisicao le o {id} da rota e busca o webhook, respondendo o erro
+// adequado quando nao for possivel. Devolve false se a resposta ja foi escrita.
+func (api *ApiServer) webhookDaRequisicao(w http.ResponseWriter, r *http.Request) (model.Webhook, bool) {
+	if !isAdmin(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return model.Webhook{}, false
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "ID do webhook inválido, deve ser um número inteiro")
+		return model.Webhook{}, false
+	}
+
+	hook, err := api.rep.GetWebhookByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Webhook não encontrado")
+		return model.Webhook{}, false
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar o webhook no banco de dados")
+		return model.Webhook{}, false
+	}
+
+	return hook, true
+}
+
+// CreateWebhookHandler cadastra um webhook. Sem segredo informado, um e
+// gerado; o segredo so aparece nesta resposta.
+func (api *ApiServer) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar a requisição")
+		return
+	}
+	hook, err := validarWebhook(req)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao validar a requisição")
+		return
+	}
+
+	if hook.Segredo == "" {
+		if hook.Segredo, err = webhook.GerarSegredo(); err != nil {
+			problema.Erro(w, r, err, "Erro ao gerar o segredo do webhook")
+			return
+		}
+	}
+	hook.CriadoPor, _ = r.Context().Value(middleware.UserIDKey).(int64)
+
+	hook, err = api.rep.CreateWebhook(hook)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao inserir o webhook no banco de dados")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(hook); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar o webhook em JSON")
+		return
+	}
+}
+
+func (api *ApiServer) ListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	webhooks, err := api.rep.ListWebhooks()
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar os webhooks no banco de dados")
+		return
+	}
+	for i := range webhooks {
+		webhooks[i].Segredo = ""
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(webhooks); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar a lista em JSON")
+		return
+	}
+}
+
+func (api *ApiServer) GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	hook, ok := api.webhookDaRequisicao(w, r)
+	if !ok {
+		return
+	}
+	hook.Segredo = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(hook); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar o webhook em JSON")
+		return
+	}
+}
+
+// UpdateWebhookHandler substitui a configuracao do webhook. O segredo so e
+// trocado quando enviado no corpo.
+func (api *ApiServer) UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	atual, ok := api.webhookDaRequisicao(w, r)
+	if !ok {
+		return
+	}
+
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar a requisição")
+		return
+	}
+	hook, err := validarWebhook(req)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao validar a requisição")
+		return
+	}
+
+	if err = api.rep.UpdateWebhook(atual.ID, hook); errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Webhook não encontrado")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao atualizar o webhook no banco de dados")
+		return
+	}
+
+	hook.ID, hook.CriadoPor, hook.CriadoEm, hook.Segredo = atual.ID, atual.CriadoPor, atual.CriadoEm, ""
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(hook); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar o webhook em JSON")
+		return
+	}
+}
+
+func (api *ApiServer) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "ID do webhook inválido, deve ser um número inteiro")
+		return
+	}
+
+	if err = api.rep.DeleteWebhook(id); errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Webhook não encontrado")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao excluir o webhook do banco de dados")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWebhookDeliveriesHandler mostra o log de entregas do webhook, mais
+// recentes primeiro (?limite=, padrao 50).
+func (api *ApiServer) ListWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	hook, ok := api.webhookDaRequisicao(w, r)
+	if !ok {
+		return
+	}
+
+	limite := 50
+	if v := r.URL.Query().Get("limite"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 500 {
+			problema.Escrever(w, r, http.StatusBadRequest, "Limite inválido, deve ser um número entre 1 e 500")
+			return
+		}
+		limite = n
+	}
+
+	entregas, err := api.rep.ListWebhookDeliveries(hook.ID, limite)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar as entregas no banco de dados")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(entregas); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar a lista em JSON")
+		return
+	}
+}
+
+// TestWebhookHandler envia na hora um evento "ping" ao webhook, mesmo que ele
+// esteja desativado, e devolve o resultado da entrega. Nao ha nova tentativa.
+func (api *ApiServer) TestWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	hook, ok := api.webhookDaRequisicao(w, r)
+	if !ok {
+		return
+	}
+
+	// O erro de envio ja esta descrito na entrega devolvida.
+	entrega, _ := api.webhooks.Entregar(r.Context(), hook, webhook.EventoTeste(hook))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(entrega); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar a entrega em JSON")
+		return
+	}
+}
@@ -0,0 +1,293 @@
+// Package inbound transforma emails recebidos pelo suporte em tickets e
+// comentarios. As mensagens podem chegar por um diretorio maildir, por um
+// arquivo mbox ou por um servidor SMTP proprio.
+package inbound
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DominioMessageID e o dominio usado nos Message-ID dos emails enviados pelo
+// helpdesk. Respostas a esses emails trazem o ID no In-Reply-To/References.
+const DominioMessageID = "helpdesk.local"
+
+// MaxTamanhoMensagem limita o tamanho de um email aceito, anexos incluidos.
+const MaxTamanhoMensagem = 25 << 20
+
+var (
+	ErrMensagemGrande        = errors.New("mensagem maior que o limite permitido")
+	ErrRemetenteDesconhecido = errors.New("remetente não cadastrado")
+)
+
+// Email e uma mensagem ja decodificada, pronta para virar ticket ou comentario.
+type Email struct {
+	De         string // Endereco do remetente, em minusculas
+	NomeDe     string
+	Assunto    string
+	Texto      string
+	MessageID  string
+	InReplyTo  string
+	References string
+	Anexos     []Anexo
+
+	// Automatico marca respostas automaticas (ferias, bounces, notificacoes),
+	// que nao devem virar ticket para nao criar loops de email.
+	Automatico bool
+
+	// Autenticacoes sao os cabecalhos Authentication-Results (RFC 8601)
+	// da mensagem, na ordem em que aparecem.
+	Autenticacoes []string
+
+	// Verificado indica que o MTA da empresa confirmou (DMARC) que o From
+	// nao foi forjado. E preenchido por Verificar; sem ele o remetente e
+	// so um cabecalho que qualquer um escreve.
+	Verificado bool
+}
+
+// Anexo e uma parte MIME com nome de arquivo.
+type Anexo struct {
+	Nome        string
+	ContentType string
+	Conteudo    []byte
+}
+
+// Destino recebe os emails lidos pelas fontes (maildir, mbox, SMTP).
+type Destino interface {
+	ProcessarEmail(ctx context.Context, email Email) error
+}
+
+// MessageIDTicket monta o Message-ID usado nos emails enviados sobre o ticket.
+// O sufixo diferencia emails distintos do mesmo ticket.
+func MessageIDTicket(ticketID int64, sufixo string) string {
+	return fmt.Sprintf("<ticket-%d.%s@%s>", ticketID, sufixo, DominioMessageID)
+}
+
+var (
+	assuntoTicketRegex   = regexp.MustCompile(`(?i)\[(?:ticket\s*)?#(\d+)\]`)
+	messageIDTicketRegex = regexp.MustCompile(`<ticket-(\d+)(?:\.[^@>]*)?@` + regexp.QuoteMeta(DominioMessageID) + `>`)
+)
+
+// TicketReferenciado devolve o ticket ao qual o email responde, procurando
+// primeiro nos cabecalhos In-Reply-To e References e depois por "[#123]" ou
+// "[Ticket #123]" no assunto. Devolve zero quando e um assunto novo.
+func TicketReferenciado(email Email) int64 {
+	for _, cabecalho := range []string{email.InReplyTo, email.References} {
+		if m := messageIDTicketRegex.FindStringSubmatch(cabecalho); m != nil {
+			if id, err := strconv.ParseInt(m[1], 10, 64); err == nil {
+				return id
+			}
+		}
+	}
+
+	if m := assuntoTicketRegex.FindStringSubmatch(email.Assunto); m != nil {
+		if id, err := strconv.ParseInt(m[1], 10, 64); err == nil {
+			return id
+		}
+	}
+
+	return 0
+}
+
+// LimparAssunto remove prefixos de resposta/encaminhamento e a referencia ao
+// ticket, para usar o assunto como titulo.
+func LimparAssunto(assunto string) string {
+	assunto = assuntoTicketRegex.ReplaceAllString(assunto, "")
+	for {
+		limpo := strings.TrimSpace(assunto)
+		minusculo := strings.ToLower(limpo)
+		cortado := false
+		for _, prefixo := range []string{"re:", "res:", "fw:", "fwd:", "enc:"} {
+			if strings.HasPrefix(minusculo, prefixo) {
+				limpo = limpo[len(prefixo):]
+				cortado = true
+				break
+			}
+		}
+		assunto = limpo
+		if !cortado {
+			return strings.TrimSpace(assunto)
+		}
+	}
+}
+
+var citacaoRegex = regexp.MustCompile(`(?m)^(On .+ wrote:|Em .+ escreveu:|-----\s*Original Message\s*-----|-----\s*Mensagem original\s*-----)\s*$`)
+
+// RemoverCitacao corta o historico citado no fim de uma resposta, mantendo
+// apenas o texto novo escrito pelo remetente.
+func RemoverCitacao(texto string) string {
+	if loc := citacaoRegex.FindStringIndex(texto); loc != nil {
+		texto = texto[:loc[0]]
+	}
+
+	linhas := strings.Split(texto, "\n")
+	fim := len(linhas)
+	for fim > 0 {
+		l := strings.TrimSpace(linhas[fim-1])
+		if l != "" && !strings.HasPrefix(l, ">") {
+			break
+		}
+		fim--
+	}
+
+	return strings.TrimSpace(strings.Join(linhas[:fim], "\n"))
+}
+
+// Parse decodifica uma mensagem RFC 5322, incluindo corpo MIME multipart,
+// codificacoes base64/quoted-printable e cabecalhos RFC 2047.
+func Parse(r io.Reader) (Email, error) {
+	msg, err := mail.ReadMessage(io.LimitReader(r, MaxTamanhoMensagem+1))
+	if err != nil {
+		return Email{}, fmt.Errorf("mensagem inválida: %w", err)
+	}
+
+	de, err := mail.ParseAddress(msg.Header.Get("From"))
+	if err != nil {
+		return Email{}, fmt.Errorf("remetente inválido: %w", err)
+	}
+
+	dec := new(mime.WordDecoder)
+	assunto, err := dec.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		assunto = msg.Header.Get("Subject")
+	}
+
+	email := Email{
+		De:         strings.ToLower(de.Address),
+		NomeDe:     de.Name,
+		Assunto:    strings.TrimSpace(assunto),
+		MessageID:  msg.Header.Get("Message-Id"),
+		InReplyTo:  msg.Header.Get("In-Reply-To"),
+		References: msg.Header.Get("References"),
+		Automatico: automatico(msg.Header),
+
+		Autenticacoes: msg.Header["Authentication-Results"],
+	}
+
+	var html string
+	total := 0
+	err = percorrerParte(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Disposition"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body, func(p parte) error {
+		total += len(p.conteudo)
+		if total > MaxTamanhoMensagem {
+			return ErrMensagemGrande
+		}
+
+		switch {
+		case p.nome != "":
+			email.Anexos = append(email.Anexos, Anexo{Nome: p.nome, ContentType: p.mediaType, Conteudo: p.conteudo})
+		case p.mediaType == "text/plain" && email.Texto == "":
+			email.Texto = string(p.conteudo)
+		case p.mediaType == "text/html" && html == "":
+			html = string(p.conteudo)
+		}
+		return nil
+	})
+	if err != nil {
+		return Email{}, err
+	}
+
+	if email.Texto == "" && html != "" {
+		email.Texto = textoDeHTML(html)
+	}
+	email.Texto = strings.ReplaceAll(email.Texto, "\r\n", "\n")
+
+	return email, nil
+}
+
+// automatico segue a RFC 3834 (Auto-Submitted) e o cabecalho Precedence
+// usado por listas e autorespostas antigas.
+func automatico(h mail.Header) bool {
+	if v := strings.ToLower(strings.TrimSpace(h.Get("Auto-Submitted"))); v != "" && v != "no" {
+		return true
+	}
+	switch strings.ToLower(strings.TrimSpace(h.Get("Precedence"))) {
+	case "bulk", "junk", "list", "auto_reply":
+		return true
+	}
+	return false
+}
+
+type parte struct {
+	mediaType string
+	nome      string
+	conteudo  []byte
+}
+
+// percorrerParte desce recursivamente pelas partes multipart e entrega cada
+// folha ja decodificada para visitar.
+func percorrerParte(contentType, disposicao, codificacao string, corpo io.Reader, visitar func(parte) error) error {
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "application/octet-stream", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(corpo, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("corpo multipart inválido: %w", err)
+			}
+			if err = percorrerParte(p.Header.Get("Content-Type"), p.Header.Get("Content-Disposition"), p.Header.Get("Content-Transfer-Encoding"), p, visitar); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(codificacao)) {
+	case "base64":
+		corpo = base64.NewDecoder(base64.StdEncoding, corpo)
+	case "quoted-printable":
+		corpo = quotedprintable.NewReader(corpo)
+	}
+
+	conteudo, err := io.ReadAll(io.LimitReader(corpo, MaxTamanhoMensagem+1))
+	if err != nil {
+		return fmt.Errorf("erro ao decodificar parte %s: %w", mediaType, err)
+	}
+
+	nome := params["name"]
+	if _, dparams, err := mime.ParseMediaType(disposicao); err == nil && dparams["filename"] != "" {
+		nome = dparams["filename"]
+	}
+	if nome != "" {
+		if decodificado, err := new(mime.WordDecoder).DecodeHeader(nome); err == nil {
+			nome = decodificado
+		}
+	}
+
+	return visitar(parte{mediaType: mediaType, nome: nome, conteudo: conteudo})
+}
+
+var (
+	tagRegex    = regexp.MustCompile(`(?s)<(script|style)[^>]*>.*?</(script|style)>|<[^>]+>`)
+	quebraRegex = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>`)
+)
+
+// textoDeHTML e uma conversao simples de HTML para texto, usada apenas quando
+// o email nao traz uma parte text/plain.
+func textoDeHTML(html string) string {
+	texto := quebraRegex.ReplaceAllString(html, "\n")
+	texto = tagRegex.ReplaceAllString(texto, "")
+	for de, para := range map[string]string{"&nbsp;": " ", "&lt;": "<", "&gt;": ">", "&quot;": `"`, "&#39;": "'", "&amp;": "&"} {
+		texto = strings.ReplaceAll(texto, de, para)
+	}
+	return strings.TrimSpace(string(bytes.TrimSpace([]byte(texto))))
+}
@@ -0,0 +1,134 @@
+package inbound
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const mensagemMultipart = "From: =?UTF-8?Q?Jo=C3=A3o_Silva?= <Joao@Acme.com>\r\n" +
+	"To: suporte@helpdesk.local\r\n" +
+	"Subject: =?UTF-8?Q?Impressora_n=C3=A3o_imprime?=\r\n" +
+	"Message-ID: <abc123@acme.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=\"externo\"\r\n" +
+	"\r\n" +
+	"--externo\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"interno\"\r\n" +
+	"\r\n" +
+	"--interno\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"A impressora do 2=C2=BA andar parou.\r\n" +
+	"--interno\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>A impressora parou.</p>\r\n" +
+	"--interno--\r\n" +
+	"--externo\r\n" +
+	"Content-Type: text/plain; name=\"erro.log\"\r\n" +
+	"Content-Disposition: attachment; filename=\"erro.log\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"cGFwZWwgZW1w\r\n" +
+	"YXRhZG8K\r\n" +
+	"--externo--\r\n"
+
+func TestParse(t *testing.T) {
+	email, err := Parse(strings.NewReader(mensagemMultipart))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "joao@acme.com", email.De)
+	assert.Equal(t, "João Silva", email.NomeDe)
+	assert.Equal(t, "Impressora não imprime", email.Assunto)
+	assert.Equal(t, "<abc123@acme.com>", email.MessageID)
+	assert.Equal(t, "A impressora do 2º andar parou.", email.Texto)
+
+	if assert.Len(t, email.Anexos, 1) {
+		assert.Equal(t, "erro.log", email.Anexos[0].Nome)
+		assert.Equal(t, "text/plain", email.Anexos[0].ContentType)
+		assert.Equal(t, "papel empatado\n", string(email.Anexos[0].Conteudo))
+	}
+}
+
+func TestParse_ApenasHTML(t *testing.T) {
+	msg := "From: cliente@acme.com\r\nSubject: Oi\r\nContent-Type: text/html\r\n\r\n<p>Linha 1<br>Linha &amp; 2</p>"
+
+	email, err := Parse(strings.NewReader(msg))
+	assert.NoError(t, err)
+	assert.Equal(t, "Linha 1\nLinha & 2", email.Texto)
+}
+
+func TestParse_Automatico(t *testing.T) {
+	email, err := Parse(strings.NewReader("From: a@acme.com\r\nAuto-Submitted: auto-replied\r\nSubject: Fora do escritorio\r\n\r\nVolto segunda."))
+	assert.NoError(t, err)
+	assert.True(t, email.Automatico)
+
+	email, err = Parse(strings.NewReader("From: a@acme.com\r\nAuto-Submitted: no\r\nSubject: Oi\r\n\r\ncorpo"))
+	assert.NoError(t, err)
+	assert.False(t, email.Automatico)
+}
+
+func TestParse_RemetenteInvalido(t *testing.T) {
+	_, err := Parse(strings.NewReader("Subject: sem remetente\r\n\r\ncorpo"))
+	assert.Error(t, err)
+}
+
+func TestTicketReferenciado(t *testing.T) {
+	casos := []struct {
+		nome     string
+		email    Email
+		esperado int64
+	}{
+		{"assunto novo", Email{Assunto: "Preciso de ajuda"}, 0},
+		{"referencia no assunto", Email{Assunto: "Re: [Ticket #42] Impressora"}, 42},
+		{"referencia curta", Email{Assunto: "RE: [#7] VPN"}, 7},
+		{"in-reply-to", Email{Assunto: "Re: VPN", InReplyTo: MessageIDTicket(15, "status")}, 15},
+		{"references com varios ids", Email{References: "<x@acme.com> " + MessageIDTicket(9, "criado")}, 9},
+		{"message-id de outro dominio", Email{InReplyTo: "<ticket-3.x@outro.com>"}, 0},
+	}
+
+	for _, c := range casos {
+		assert.Equal(t, c.esperado, TicketReferenciado(c.email), c.nome)
+	}
+}
+
+func TestLimparAssunto(t *testing.T) {
+	assert.Equal(t, "Impressora", LimparAssunto("Re: RES: Fwd: [Ticket #42] Impressora"))
+	assert.Equal(t, "Reunião", LimparAssunto("Reunião"))
+}
+
+func TestRemoverCitacao(t *testing.T) {
+	texto := "Resolvido, obrigado!\n\nEm seg, 1 de jan de 2024, Suporte escreveu:\n> Tente reiniciar.\n"
+	assert.Equal(t, "Resolvido, obrigado!", RemoverCitacao(texto))
+
+	texto = "Ainda falha.\n> citacao antiga\n>\n"
+	assert.Equal(t, "Ainda falha.", RemoverCitacao(texto))
+}
+
+// destinoFake guarda os emails recebidos e pode falhar para um remetente.
+type destinoFake struct {
+	mu       sync.Mutex
+	emails   []Email
+	recusado string
+}
+
+func (d *destinoFake) ProcessarEmail(ctx context.Context, email Email) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if email.De == d.recusado {
+		return ErrRemetenteDesconhecido
+	}
+	d.emails = append(d.emails, email)
+	return nil
+}
+
+func (d *destinoFake) recebidos() []Email {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Email(nil), d.emails...)
+}
@@ -0,0 +1,44 @@
+package inbound
+
+import (
+	"context"
+	"helpdesk/pkg/config"
+	"log/slog"
+)
+
+// Iniciar liga as fontes de email da secao email do config: maildir, mbox
+// e o servidor SMTP de entrada. Cada uma e opcional e roda em sua propria
+// goroutine.
+func Iniciar(ctx context.Context, cfg config.Email, destino Destino) {
+	destino = Verificar(destino, cfg.Autenticador)
+
+	if dir := cfg.Maildir; dir != "" {
+		m := &Maildir{Dir: dir, Intervalo: cfg.Intervalo, Destino: destino}
+		go func() {
+			slog.Info("Lendo emails do maildir", "dir", dir)
+			if err := m.Executar(ctx); err != nil {
+				slog.Error("Leitura do maildir encerrada", "dir", dir, "erro", err)
+			}
+		}()
+	}
+
+	if caminho := cfg.Mbox; caminho != "" {
+		m := &Mbox{Caminho: caminho, Intervalo: cfg.Intervalo, Destino: destino}
+		go func() {
+			slog.Info("Lendo emails do mbox", "caminho", caminho)
+			if err := m.Executar(ctx); err != nil {
+				slog.Error("Leitura do mbox encerrada", "caminho", caminho, "erro", err)
+			}
+		}()
+	}
+
+	if endereco := cfg.SMTPEndereco; endereco != "" {
+		s := &ServidorSMTP{Endereco: endereco, Dominio: cfg.SMTPHostname, DominiosAceitos: cfg.Dominios(), Destino: destino}
+		go func() {
+			slog.Info("Servidor SMTP de entrada iniciado", "endereco", endereco)
+			if err := s.ListenAndServe(ctx); err != nil {
+				slog.Error("Servidor SMTP de entrada encerrado", "erro", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,180 @@
+package inbound
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"helpdesk/pkg/registro"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Maildir le as mensagens entregues em Dir/new por um MTA local. Mensagens
+// processadas sao movidas para Dir/cur; as que falham vao para Dir/.falhas
+// para analise manual, sem bloquear as seguintes.
+type Maildir struct {
+	Dir       string
+	Intervalo time.Duration
+	Destino   Destino
+}
+
+// Executar verifica a pasta a cada Intervalo ate o contexto ser cancelado.
+func (m *Maildir) Executar(ctx context.Context) error {
+	for _, sub := range []string{"new", "cur", "tmp", ".falhas"} {
+		if err := os.MkdirAll(filepath.Join(m.Dir, sub), 0o750); err != nil {
+			return err
+		}
+	}
+
+	return executarPeriodicamente(ctx, m.Intervalo, m.Processar)
+}
+
+// Processar le uma vez todas as mensagens pendentes em new.
+func (m *Maildir) Processar(ctx context.Context) error {
+	entradas, err := os.ReadDir(filepath.Join(m.Dir, "new"))
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entradas {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+
+		origem := filepath.Join(m.Dir, "new", e.Name())
+		destino := filepath.Join(m.Dir, "cur", e.Name()+":2,S")
+		if err := m.processarArquivo(ctx, origem); err != nil {
+			registro.Logger(ctx).Error("Erro ao processar o email", "origem", origem, "erro", err)
+			destino = filepath.Join(m.Dir, ".falhas", e.Name())
+		}
+		if err := os.Rename(origem, destino); err != nil {
+			return fmt.Errorf("erro ao mover %s: %w", origem, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *Maildir) processarArquivo(ctx context.Context, caminho string) error {
+	arquivo, err := os.Open(caminho)
+	if err != nil {
+		return err
+	}
+	defer arquivo.Close()
+
+	email, err := Parse(arquivo)
+	if err != nil {
+		return err
+	}
+	return m.Destino.ProcessarEmail(ctx, email)
+}
+
+// Mbox le um arquivo no formato mbox (mensagens separadas por linhas "From ").
+// Depois de lido, o arquivo e renomeado com o sufixo ".processado-<data>" para
+// que o MTA comece um novo.
+type Mbox struct {
+	Caminho   string
+	Intervalo time.Duration
+	Destino   Destino
+}
+
+// Executar verifica o arquivo a cada Intervalo ate o contexto ser cancelado.
+func (m *Mbox) Executar(ctx context.Context) error {
+	return executarPeriodicamente(ctx, m.Intervalo, m.Processar)
+}
+
+// Processar le uma vez todas as mensagens do arquivo, se ele existir.
+func (m *Mbox) Processar(ctx context.Context) error {
+	lido := fmt.Sprintf("%s.processado-%s", m.Caminho, time.Now().UTC().Format("20060102T150405"))
+	if err := os.Rename(m.Caminho, lido); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	arquivo, err := os.Open(lido)
+	if err != nil {
+		return err
+	}
+	defer arquivo.Close()
+
+	return LerMbox(arquivo, func(mensagem []byte) {
+		email, err := Parse(bytes.NewReader(mensagem))
+		if err == nil {
+			err = m.Destino.ProcessarEmail(ctx, email)
+		}
+		if err != nil {
+			registro.Logger(ctx).Error("Erro ao processar email do mbox", "email", lido, "erro", err)
+		}
+	})
+}
+
+// LerMbox separa as mensagens de um mbox, desfazendo o escape ">From " das
+// linhas do corpo, e chama visitar para cada uma.
+func LerMbox(r io.Reader, visitar func([]byte)) error {
+	leitor := bufio.NewReader(r)
+	var atual bytes.Buffer
+	iniciada := false
+
+	for {
+		linha, err := leitor.ReadBytes('\n')
+		if len(linha) > 0 {
+			switch {
+			case bytes.HasPrefix(linha, []byte("From ")):
+				if iniciada {
+					visitar(bytes.Clone(atual.Bytes()))
+				}
+				atual.Reset()
+				iniciada = true
+			case iniciada:
+				if bytes.HasPrefix(bytes.TrimLeft(linha, ">"), []byte("From ")) {
+					linha = linha[1:]
+				}
+				if atual.Len()+len(linha) > MaxTamanhoMensagem {
+					return ErrMensagemGrande
+				}
+				atual.Write(linha)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if iniciada {
+		visitar(atual.Bytes())
+	}
+	return nil
+}
+
+func executarPeriodicamente(ctx context.Context, intervalo time.Duration, processar func(context.Context) error) error {
+	if intervalo <= 0 {
+		intervalo = 30 * time.Second
+	}
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		if err := processar(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Erro ao ler a caixa de entrada de emails", "erro", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package inbound
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaildir_Processar(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"new", "cur", ".falhas"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, sub), 0o750))
+	}
+	os.WriteFile(filepath.Join(dir, "new", "1.msg"), []byte("From: a@acme.com\r\nSubject: Um\r\n\r\nprimeiro"), 0o640)
+	os.WriteFile(filepath.Join(dir, "new", "2.msg"), []byte("From: estranho@fora.com\r\nSubject: Dois\r\n\r\nsegundo"), 0o640)
+
+	destino := &destinoFake{recusado: "estranho@fora.com"}
+	m := &Maildir{Dir: dir, Destino: destino}
+	assert.NoError(t, m.Processar(context.Background()))
+
+	recebidos := destino.recebidos()
+	if assert.Len(t, recebidos, 1) {
+		assert.Equal(t, "Um", recebidos[0].Assunto)
+	}
+
+	// A pasta new fica vazia: o processado vai para cur e o recusado para .falhas.
+	pendentes, _ := os.ReadDir(filepath.Join(dir, "new"))
+	assert.Empty(t, pendentes)
+	assert.FileExists(t, filepath.Join(dir, "cur", "1.msg:2,S"))
+	assert.FileExists(t, filepath.Join(dir, ".falhas", "2.msg"))
+}
+
+func TestLerMbox(t *testing.T) {
+	mbox := "From a@acme.com Mon Jan  1 00:00:00 2024\n" +
+		"From: a@acme.com\nSubject: Um\n\nlinha\n>From aqui escapado\n\n" +
+		"From b@acme.com Mon Jan  1 00:01:00 2024\n" +
+		"From: b@acme.com\nSubject: Dois\n\nsegundo\n"
+
+	var mensagens []string
+	err := LerMbox(strings.NewReader(mbox), func(m []byte) {
+		mensagens = append(mensagens, string(m))
+	})
+	assert.NoError(t, err)
+
+	if assert.Len(t, mensagens, 2) {
+		assert.Contains(t, mensagens[0], "\nFrom aqui escapado\n")
+		assert.True(t, strings.HasPrefix(mensagens[1], "From: b@acme.com\n"))
+	}
+}
+
+func TestMbox_Processar(t *testing.T) {
+	caminho := filepath.Join(t.TempDir(), "suporte.mbox")
+	os.WriteFile(caminho, []byte("From a@acme.com Mon Jan  1 00:00:00 2024\nFrom: a@acme.com\nSubject: Um\n\ncorpo\n"), 0o640)
+
+	destino := &destinoFake{}
+	m := &Mbox{Caminho: caminho, Destino: destino}
+	assert.NoError(t, m.Processar(context.Background()))
+	assert.Len(t, destino.recebidos(), 1)
+	assert.NoFileExists(t, caminho)
+
+	// Sem arquivo novo nao ha nada a fazer.
+	assert.NoError(t, m.Processar(context.Background()))
+	assert.Len(t, destino.recebidos(), 1)
+}
@@ -0,0 +1,197 @@
+package inbound
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"helpdesk/pkg/registro"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// ServidorSMTP e um servidor SMTP minimo que apenas recebe mensagens para o
+// suporte. Ele nao faz relay: toda mensagem aceita e entregue ao Destino.
+// Deve ficar atras do MTA da empresa, que cuida de TLS, SPF e anti-spam.
+type ServidorSMTP struct {
+	Endereco string
+	Dominio  string
+	Destino  Destino
+
+	// Dominios aceitos em RCPT TO. Vazio aceita qualquer destinatario.
+	DominiosAceitos []string
+}
+
+const timeoutSMTP = 5 * time.Minute
+
+// ListenAndServe escuta em Endereco ate o contexto ser cancelado.
+func (s *ServidorSMTP) ListenAndServe(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.Endereco)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve atende conexoes vindas de ln ate o contexto ser cancelado.
+func (s *ServidorSMTP) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go s.atender(ctx, conn)
+	}
+}
+
+func (s *ServidorSMTP) atender(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	dominio := s.Dominio
+	if dominio == "" {
+		dominio = "localhost"
+	}
+
+	responder := func(codigo int, texto string) bool {
+		conn.SetDeadline(time.Now().Add(timeoutSMTP))
+		return tp.PrintfLine("%d %s", codigo, texto) == nil
+	}
+
+	if !responder(220, dominio+" ESMTP helpdesk") {
+		return
+	}
+
+	var remetente string
+	var destinatarios []string
+	for {
+		linha, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		comando, arg, _ := strings.Cut(linha, " ")
+		comando = strings.ToUpper(comando)
+
+		switch comando {
+		case "HELO":
+			responder(250, dominio)
+		case "EHLO":
+			conn.SetDeadline(time.Now().Add(timeoutSMTP))
+			tp.PrintfLine("250-%s", dominio)
+			tp.PrintfLine("250-SIZE %d", MaxTamanhoMensagem)
+			tp.PrintfLine("250 8BITMIME")
+		case "MAIL":
+			endereco, ok := enderecoSMTP(arg, "FROM:")
+			if !ok {
+				responder(501, "Sintaxe: MAIL FROM:<endereco>")
+				continue
+			}
+			remetente, destinatarios = endereco, nil
+			responder(250, "OK")
+		case "RCPT":
+			if remetente == "" {
+				responder(503, "MAIL FROM primeiro")
+				continue
+			}
+			endereco, ok := enderecoSMTP(arg, "TO:")
+			if !ok {
+				responder(501, "Sintaxe: RCPT TO:<endereco>")
+				continue
+			}
+			if !s.aceitaDestinatario(endereco) {
+				responder(550, "Destinatario nao atendido por este servidor")
+				continue
+			}
+			destinatarios = append(destinatarios, endereco)
+			responder(250, "OK")
+		case "DATA":
+			if len(destinatarios) == 0 {
+				responder(503, "RCPT TO primeiro")
+				continue
+			}
+			responder(354, "Envie a mensagem terminando com <CRLF>.<CRLF>")
+			codigo, texto := s.receberMensagem(ctx, tp.DotReader())
+			responder(codigo, texto)
+			remetente, destinatarios = "", nil
+		case "RSET":
+			remetente, destinatarios = "", nil
+			responder(250, "OK")
+		case "NOOP":
+			responder(250, "OK")
+		case "QUIT":
+			responder(221, "Ate logo")
+			return
+		default:
+			responder(502, "Comando nao implementado")
+		}
+	}
+}
+
+// receberMensagem le o DATA e entrega ao Destino, devolvendo a resposta SMTP.
+// Remetentes desconhecidos sao recusados de forma permanente (5xx); erros
+// internos pedem nova tentativa ao MTA (4xx).
+func (s *ServidorSMTP) receberMensagem(ctx context.Context, dados io.Reader) (int, string) {
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(dados, MaxTamanhoMensagem+1))
+	if err != nil {
+		return 451, "Erro ao receber a mensagem"
+	}
+	if n > MaxTamanhoMensagem {
+		io.Copy(io.Discard, dados)
+		return 552, "Mensagem maior que o limite permitido"
+	}
+
+	email, err := Parse(&buf)
+	if err != nil {
+		return 554, "Mensagem invalida"
+	}
+
+	if err = s.Destino.ProcessarEmail(ctx, email); err != nil {
+		registro.Logger(ctx).Error("Erro ao processar email recebido por SMTP", "de", email.De, "erro", err)
+		if errors.Is(err, ErrRemetenteDesconhecido) {
+			return 550, "Remetente nao cadastrado no helpdesk"
+		}
+		return 451, "Erro temporario ao processar a mensagem"
+	}
+
+	return 250, "Mensagem recebida"
+}
+
+func (s *ServidorSMTP) aceitaDestinatario(endereco string) bool {
+	if len(s.DominiosAceitos) == 0 {
+		return true
+	}
+	_, dominio, _ := strings.Cut(endereco, "@")
+	for _, aceito := range s.DominiosAceitos {
+		if strings.EqualFold(dominio, aceito) {
+			return true
+		}
+	}
+	return false
+}
+
+// enderecoSMTP extrai o endereco de "FROM:<a@b>" ou "TO:<a@b>", ignorando
+// parametros ESMTP como SIZE=.
+func enderecoSMTP(arg, prefixo string) (string, bool) {
+	if len(arg) < len(prefixo) || !strings.EqualFold(arg[:len(prefixo)], prefixo) {
+		return "", false
+	}
+	resto := strings.TrimSpace(arg[len(prefixo):])
+	if !strings.HasPrefix(resto, "<") {
+		return "", false
+	}
+	fim := strings.Index(resto, ">")
+	if fim < 0 {
+		return "", false
+	}
+	return strings.ToLower(resto[1:fim]), true
+}
@@ -0,0 +1,48 @@
+package inbound
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func iniciarServidorSMTP(t *testing.T, destino Destino) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	s := &ServidorSMTP{Dominio: "helpdesk.local", Destino: destino, DominiosAceitos: []string{"helpdesk.local"}}
+	go s.Serve(ctx, ln)
+	return ln.Addr().String()
+}
+
+func TestServidorSMTP(t *testing.T) {
+	destino := &destinoFake{recusado: "estranho@fora.com"}
+	endereco := iniciarServidorSMTP(t, destino)
+
+	msg := []byte("From: cliente@acme.com\r\nSubject: Re: [#5] VPN\r\n\r\nContinua caindo.\r\n.linha com ponto\r\n")
+	err := smtp.SendMail(endereco, nil, "cliente@acme.com", []string{"suporte@helpdesk.local"}, msg)
+	assert.NoError(t, err)
+
+	recebidos := destino.recebidos()
+	if assert.Len(t, recebidos, 1) {
+		assert.Equal(t, int64(5), TicketReferenciado(recebidos[0]))
+		assert.Equal(t, "Continua caindo.\n.linha com ponto\n", recebidos[0].Texto)
+	}
+
+	// Remetente desconhecido e recusado de forma permanente.
+	msg = []byte("From: estranho@fora.com\r\nSubject: Oi\r\n\r\nspam\r\n")
+	err = smtp.SendMail(endereco, nil, "estranho@fora.com", []string{"suporte@helpdesk.local"}, msg)
+	assert.ErrorContains(t, err, "550")
+
+	// O servidor nao faz relay para outros dominios.
+	err = smtp.SendMail(endereco, nil, "cliente@acme.com", []string{"alguem@gmail.com"}, msg)
+	assert.ErrorContains(t, err, "550")
+	assert.Len(t, destino.recebidos(), 1)
+}
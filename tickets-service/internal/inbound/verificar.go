@@ -0,0 +1,56 @@
+package inbound
+
+import (
+	"context"
+	"strings"
+)
+
+// Verificar devolve um Destino que marca Email.Verificado antes de repassar
+// a mensagem. O remetente e considerado verificado so quando um cabecalho
+// Authentication-Results do autenticador dado (o authserv-id do MTA da
+// empresa) traz dmarc=pass para o dominio do From. O MTA precisa remover os
+// Authentication-Results com o proprio authserv-id que cheguem de fora, como
+// manda a RFC 8601; sem autenticador nenhum email e verificado.
+func Verificar(destino Destino, autenticador string) Destino {
+	return verificador{destino: destino, autenticador: autenticador}
+}
+
+type verificador struct {
+	destino      Destino
+	autenticador string
+}
+
+func (v verificador) ProcessarEmail(ctx context.Context, email Email) error {
+	email.Verificado = remetenteVerificado(email, v.autenticador)
+	return v.destino.ProcessarEmail(ctx, email)
+}
+
+func remetenteVerificado(email Email, autenticador string) bool {
+	if autenticador == "" {
+		return false
+	}
+	_, dominio, ok := strings.Cut(email.De, "@")
+	if !ok {
+		return false
+	}
+
+	for _, cabecalho := range email.Autenticacoes {
+		partes := strings.Split(cabecalho, ";")
+		// O authserv-id pode vir seguido da versao: "mx.acme.com 1".
+		if id, _, _ := strings.Cut(strings.TrimSpace(partes[0]), " "); !strings.EqualFold(id, autenticador) {
+			continue
+		}
+		for _, resultado := range partes[1:] {
+			campos := strings.Fields(strings.ToLower(resultado))
+			if len(campos) == 0 || campos[0] != "dmarc=pass" {
+				continue
+			}
+			for _, c := range campos[1:] {
+				if c == "header.from="+dominio {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package inbound
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerificar(t *testing.T) {
+	casos := []struct {
+		nome       string
+		cabecalhos string
+		esperado   bool
+	}{
+		{"dmarc do MTA", "Authentication-Results: mx.helpdesk.local; spf=pass smtp.mailfrom=acme.com; dmarc=pass header.from=acme.com\r\n", true},
+		{"com versao", "Authentication-Results: mx.helpdesk.local 1; dmarc=pass (p=reject) header.from=Acme.com\r\n", true},
+		{"sem cabecalho", "", false},
+		{"outro autenticador", "Authentication-Results: mx.atacante.com; dmarc=pass header.from=acme.com\r\n", false},
+		{"dmarc falhou", "Authentication-Results: mx.helpdesk.local; dmarc=fail header.from=acme.com\r\n", false},
+		{"outro dominio", "Authentication-Results: mx.helpdesk.local; dmarc=pass header.from=atacante.com\r\n", false},
+	}
+	for _, c := range casos {
+		msg := "From: Joao <joao@acme.com>\r\n" + c.cabecalhos + "Subject: Oi\r\n\r\nTexto"
+		email, err := Parse(strings.NewReader(msg))
+		require.NoError(t, err)
+
+		destino := &destinoFake{}
+		require.NoError(t, Verificar(destino, "mx.helpdesk.local").ProcessarEmail(context.Background(), email))
+		assert.Equal(t, c.esperado, destino.recebidos()[0].Verificado, c.nome)
+	}
+}
+
+func TestVerificar_SemAutenticador(t *testing.T) {
+	msg := "From: joao@acme.com\r\nAuthentication-Results: mx.helpdesk.local; dmarc=pass header.from=acme.com\r\n\r\nTexto"
+	email, err := Parse(strings.NewReader(msg))
+	require.NoError(t, err)
+
+	destino := &destinoFake{}
+	require.NoError(t, Verificar(destino, "").ProcessarEmail(context.Background(), email))
+	assert.False(t, destino.recebidos()[0].Verificado)
+}
@@ -0,0 +1,103 @@
+// Package indicadores publica no /metrics os numeros de negocio do
+// tickets-service: tickets abertos por status e prioridade e a profundidade
+// da fila de jobs. Os valores sao consultados no banco a cada coleta, entao
+// todas as replicas mostram o mesmo total.
+package indicadores
+
+import (
+	"context"
+	"helpdesk/tickets-service/internal/model"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Status e prioridade sao texto livre no banco; nos rotulos so entram os
+// valores conhecidos, e o resto vira "outra", para que um valor digitado
+// errado nao crie uma serie nova a cada ticket.
+var (
+	statusConhecidos      = map[string]bool{"": true, "aberto": true, "em_andamento": true, "pendente": true}
+	prioridadesConhecidas = map[string]bool{"": true, "baixa": true, "media": true, "alta": true, "urgente": true}
+)
+
+// rotuloOutra e o rotulo dos valores fora dos conjuntos conhecidos.
+const rotuloOutra = "outra"
+
+func rotulo(valor string, conhecidos map[string]bool) string {
+	valor = strings.ToLower(strings.TrimSpace(valor))
+	if !conhecidos[valor] {
+		return rotuloOutra
+	}
+	return valor
+}
+
+// Fonte e de onde vem as contagens. E implementada por *repository.Repository.
+type Fonte interface {
+	CountOpenTickets(ctx context.Context) ([]model.ContagemTickets, error)
+	CountJobsByTipo(ctx context.Context) ([]model.ContagemJobs, error)
+}
+
+// Coletor consulta a Fonte a cada coleta do Prometheus.
+type Coletor struct {
+	fonte   Fonte
+	timeout time.Duration
+
+	tickets *prometheus.Desc
+	jobs    *prometheus.Desc
+	falhas  *prometheus.Desc
+}
+
+// NovoColetor cria o coletor; timeout limita as consultas de cada coleta.
+func NovoColetor(fonte Fonte, timeout time.Duration) *Coletor {
+	return &Coletor{
+		fonte:   fonte,
+		timeout: timeout,
+		tickets: prometheus.NewDesc("helpdesk_tickets_abertos",
+			"Tickets nao resolvidos nem fechados, por status e prioridade.", []string{"status", "prioridade"}, nil),
+		jobs: prometheus.NewDesc("helpdesk_fila_jobs",
+			"Jobs na fila por tipo e status: pendente, processando ou morto.", []string{"tipo", "status"}, nil),
+		falhas: prometheus.NewDesc("helpdesk_indicadores_falha",
+			"1 se alguma consulta dos indicadores falhou nesta coleta.", nil, nil),
+	}
+}
+
+func (c *Coletor) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.tickets
+	ch <- c.jobs
+	ch <- c.falhas
+}
+
+// Collect nao derruba a coleta inteira quando o banco falha: as metricas da
+// consulta que falhou somem e helpdesk_indicadores_falha vai a 1.
+func (c *Coletor) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	falhou := 0.0
+	tickets, err := c.fonte.CountOpenTickets(ctx)
+	if err != nil {
+		slog.Error("Erro ao contar os tickets abertos para as métricas", "erro", err)
+		falhou = 1
+	}
+	// Valores diferentes podem cair no mesmo rotulo; os totais sao somados.
+	porRotulo := map[[2]string]int64{}
+	for _, t := range tickets {
+		porRotulo[[2]string{rotulo(t.Status, statusConhecidos), rotulo(t.Prioridade, prioridadesConhecidas)}] += t.Total
+	}
+	for r, total := range porRotulo {
+		ch <- prometheus.MustNewConstMetric(c.tickets, prometheus.GaugeValue, float64(total), r[0], r[1])
+	}
+
+	jobs, err := c.fonte.CountJobsByTipo(ctx)
+	if err != nil {
+		slog.Error("Erro ao contar os jobs da fila para as métricas", "erro", err)
+		falhou = 1
+	}
+	for _, j := range jobs {
+		ch <- prometheus.MustNewConstMetric(c.jobs, prometheus.GaugeValue, float64(j.Total), j.Tipo, j.Status)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.falhas, prometheus.GaugeValue, falhou)
+}
@@ -0,0 +1,70 @@
+package indicadores
+
+import (
+	"context"
+	"errors"
+	"helpdesk/tickets-service/internal/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type fonteFake struct {
+	tickets []model.ContagemTickets
+	jobs    []model.ContagemJobs
+	err     error
+}
+
+func (f *fonteFake) CountOpenTickets(ctx context.Context) ([]model.ContagemTickets, error) {
+	return f.tickets, nil
+}
+
+func (f *fonteFake) CountJobsByTipo(ctx context.Context) ([]model.ContagemJobs, error) {
+	return f.jobs, f.err
+}
+
+func TestColetor(t *testing.T) {
+	fonte := &fonteFake{
+		tickets: []model.ContagemTickets{
+			{Status: "aberto", Prioridade: "alta", Total: 3},
+			{Status: "em_andamento", Prioridade: "baixa", Total: 1},
+			// Valores livres fora do conjunto conhecido somam em "outra".
+			{Status: "aguardando peça", Prioridade: "alta", Total: 2},
+			{Status: "Aberto ", Prioridade: "altíssima!!", Total: 4},
+			{Status: "xyz", Prioridade: "alta", Total: 1},
+		},
+		jobs: []model.ContagemJobs{
+			{Tipo: model.TipoJobNotificacao, Status: model.JobPendente, Total: 12},
+			{Tipo: model.TipoJobWebhook, Status: model.JobMorto, Total: 2},
+		},
+	}
+	c := NovoColetor(fonte, time.Second)
+
+	assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP helpdesk_fila_jobs Jobs na fila por tipo e status: pendente, processando ou morto.
+# TYPE helpdesk_fila_jobs gauge
+helpdesk_fila_jobs{status="morto",tipo="webhook"} 2
+helpdesk_fila_jobs{status="pendente",tipo="notificacao"} 12
+# HELP helpdesk_indicadores_falha 1 se alguma consulta dos indicadores falhou nesta coleta.
+# TYPE helpdesk_indicadores_falha gauge
+helpdesk_indicadores_falha 0
+# HELP helpdesk_tickets_abertos Tickets nao resolvidos nem fechados, por status e prioridade.
+# TYPE helpdesk_tickets_abertos gauge
+helpdesk_tickets_abertos{prioridade="alta",status="aberto"} 3
+helpdesk_tickets_abertos{prioridade="baixa",status="em_andamento"} 1
+helpdesk_tickets_abertos{prioridade="alta",status="outra"} 3
+helpdesk_tickets_abertos{prioridade="outra",status="aberto"} 4
+`)))
+
+	// Com a consulta da fila falhando, os tickets continuam saindo.
+	fonte.jobs, fonte.err = nil, errors.New("timeout")
+	assert.Equal(t, 5, testutil.CollectAndCount(c))
+	assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP helpdesk_indicadores_falha 1 se alguma consulta dos indicadores falhou nesta coleta.
+# TYPE helpdesk_indicadores_falha gauge
+helpdesk_indicadores_falha 1
+`), "helpdesk_indicadores_falha"))
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Situacoes de um anexo. Arquivos acusados pelo antivirus ficam em quarentena
+// e nunca sao entregues para download.
+const (
+	AnexoDisponivel = "disponivel"
+	AnexoQuarentena = "quarentena"
+)
+
+// Attachment e o metadado de um arquivo enviado para um ticket ou comentario.
+// O conteudo fica no backend de storage, sob ChaveStorage.
+type Attachment struct {
+	ID           int64     `json:"id"`
+	TicketID     int64     `json:"ticket_id"`
+	CommentID    int64     `json:"comment_id,omitempty"`
+	UserID       int64     `json:"user_id"`
+	NomeArquivo  string    `json:"nome_arquivo"`
+	ContentType  string    `json:"content_type"`
+	Tamanho      int64     `json:"tamanho"`
+	SHA256       string    `json:"sha256"`
+	ChaveStorage string    `json:"-"`
+	Data         time.Time `json:"data"`
+	URL          string    `json:"url"`
+	Status       string    `json:"status"`
+	// MotivoQuarentena e a assinatura de malware encontrada, se houver.
+	MotivoQuarentena string `json:"motivo_quarentena,omitempty"`
+	ThumbnailChave   string `json:"-"`
+	ThumbnailURL     string `json:"thumbnail_url,omitempty"`
+	// Interno indica que o anexo pertence a uma nota interna.
+	Interno bool `json:"-"`
+}
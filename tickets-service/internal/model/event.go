@@ -0,0 +1,77 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Tipos de evento de dominio gravados na outbox junto com cada escrita.
+const (
+	DominioTicketCriado         = "ticket.created"
+	DominioTicketAtualizado     = "ticket.updated"
+	DominioStatusAlterado       = "ticket.status_changed"
+	DominioTicketAtribuido      = "ticket.assigned"
+	DominioTicketExcluido       = "ticket.deleted"
+	DominioTicketComentado      = "ticket.commented"
+	DominioComentarioAtualizado = "comment.updated"
+	DominioComentarioExcluido   = "comment.deleted"
+	DominioComentarioMencionou  = "comment.mentioned"
+)
+
+// DomainEvent e um evento da outbox. Dados traz TicketEventData nos eventos
+// ticket.* e CommentEventData nos eventos de comentario.
+type DomainEvent struct {
+	ID       int64  `json:"id"`
+	Tipo     string `json:"tipo"`
+	TicketID int64  `json:"ticket_id"`
+	AtorID   int64  `json:"ator_id,omitempty"`
+	// AtorRealID e o admin que agia em nome de AtorID, so sob personificacao.
+	AtorRealID int64           `json:"ator_real_id,omitempty"`
+	Dados      json.RawMessage `json:"dados"`
+	CriadoEm   time.Time       `json:"criado_em"`
+	// RequestID e o ID da requisicao que gravou o evento.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+type TicketEventData struct {
+	Ticket              Ticket `json:"ticket"`
+	StatusAnterior      string `json:"status_anterior,omitempty"`
+	ResponsavelAnterior int64  `json:"responsavel_anterior,omitempty"`
+}
+
+type CommentEventData struct {
+	Comentario  Comentario `json:"comentario"`
+	Mencionados []int64    `json:"mencionados,omitempty"`
+}
+
+// EventoDeComentario diz se o evento e sobre um comentario.
+func (e DomainEvent) EventoDeComentario() bool {
+	switch e.Tipo {
+	case DominioTicketComentado, DominioComentarioAtualizado, DominioComentarioExcluido, DominioComentarioMencionou:
+		return true
+	}
+	return false
+}
+
+// DadosTicket decodifica os dados de um evento ticket.*.
+func (e DomainEvent) DadosTicket() (TicketEventData, error) {
+	var d TicketEventData
+	err := json.Unmarshal(e.Dados, &d)
+	return d, err
+}
+
+// DadosComentario decodifica os dados de um evento de comentario.
+func (e DomainEvent) DadosComentario() (CommentEventData, error) {
+	var d CommentEventData
+	err := json.Unmarshal(e.Dados, &d)
+	return d, err
+}
+
+// Interno diz se o evento e de uma nota interna, que so agentes podem ver.
+func (e DomainEvent) Interno() bool {
+	if !e.EventoDeComentario() {
+		return false
+	}
+	d, err := e.DadosComentario()
+	return err != nil || d.Comentario.Tipo == ComentarioInterno
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Status de um job na fila persistente.
+const (
+	JobPendente    = "pendente"
+	JobProcessando = "processando"
+	JobConcluido   = "concluido"
+	JobMorto       = "morto" // Esgotou as tentativas; fica guardado para analise e reenvio manual
+)
+
+// Tipos de job conhecidos pelos workers.
+const (
+	TipoJobNotificacao = "notificacao" // Payload: NotificationJob
+	TipoJobWebhook     = "webhook"     // Payload: WebhookJob
+	TipoJobOutboxHTTP  = "outbox_http" // Payload: DomainEvent, para o HTTPSink da outbox
+)
+
+// Job e um trabalho da fila persistente em PostgreSQL.
+type Job struct {
+	ID            int64           `json:"id"`
+	Tipo          string          `json:"tipo"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Tentativas    int             `json:"tentativas"`
+	MaxTentativas int             `json:"max_tentativas"`
+	DisponivelEm  time.Time       `json:"disponivel_em"`
+	UltimoErro    string          `json:"ultimo_erro,omitempty"`
+	CriadoEm      time.Time       `json:"criado_em"`
+	AtualizadoEm  time.Time       `json:"atualizado_em"`
+	RequestID     string          `json:"request_id,omitempty"`   // Requisicao que enfileirou o job
+	ReservadoEm   *time.Time      `json:"reservado_em,omitempty"` // Quando o worker atual pegou o job
+}
+
+// ContagemJobs e o numero de jobs de um tipo em um status.
+type ContagemJobs struct {
+	Tipo   string
+	Status string
+	Total  int64
+}
@@ -0,0 +1,37 @@
+package model
+
+// Idiomas com templates de email disponiveis.
+const (
+	IdiomaPortugues = "pt-BR"
+	IdiomaIngles    = "en"
+)
+
+// NotificationPreferences guarda como o usuario quer ser notificado. Usuarios
+// sem linha na tabela recebem todos os eventos por email, em portugues.
+type NotificationPreferences struct {
+	UserID             int64    `json:"user_id"`
+	EmailHabilitado    bool     `json:"email_habilitado"`
+	Idioma             string   `json:"idioma"`
+	EventosDesativados []string `json:"eventos_desativados"`
+}
+
+// Recebe diz se o evento deve ser enviado por email ao usuario.
+func (p NotificationPreferences) Recebe(evento string) bool {
+	if !p.EmailHabilitado {
+		return false
+	}
+	for _, e := range p.EventosDesativados {
+		if e == evento {
+			return false
+		}
+	}
+	return true
+}
+
+// Destinatario e um usuario a ser notificado, com contato e preferencias.
+type Destinatario struct {
+	ID           int64
+	Nome         string
+	Email        string
+	Preferencias NotificationPreferences
+}
@@ -0,0 +1,50 @@
+package model
+
+import "context"
+
+// TicketRepository e o que os handlers usam do banco. E implementada por
+// *repository.Repository e, nos testes, por repository.MockTicketRepository.
+type TicketRepository interface {
+	AddTicketCC(ticketID, userID int64) error
+	AddWatcher(ticketID, userID int64) error
+	CanViewTicket(ticketID, userID int64) (bool, error)
+	CountJobs() (map[string]int64, error)
+	CreateAttachment(anexo Attachment) (int64, error)
+	CreateComment(ctx context.Context, comment Comentario) (int64, error)
+	CreateTicket(ctx context.Context, ticket Ticket) (int64, error)
+	CreateWebhook(w Webhook) (Webhook, error)
+	DeleteComment(ctx context.Context, id int, atorID int64) error
+	DeleteTicket(ctx context.Context, id int, atorID int64) error
+	DeleteWebhook(id int64) error
+	FollowsTicket(ctx context.Context, ticketID, userID int64) (bool, error)
+	GetAttachmentByID(id int64) (Attachment, error)
+	GetCommentByID(id int) (Comentario, error)
+	GetJobByID(id int64) (Job, error)
+	GetNotificationPreferences(ctx context.Context, userID int64) (NotificationPreferences, error)
+	GetOrganizationMembership(userID int64) (OrganizationMember, error)
+	GetTicketByID(ctx context.Context, id int) (Ticket, error)
+	GetTicketByUser(id int) ([]Ticket, error)
+	GetWebhookByID(ctx context.Context, id int64) (Webhook, error)
+	ListAttachmentsByTicketID(ticketID int64, incluirInternos bool) ([]Attachment, error)
+	ListCommentsByTicketID(id int, incluirInternos bool) ([]Comentario, error)
+	ListCommentsByUserID(id int, incluirInternos bool) ([]Comentario, error)
+	ListJobs(status string, limite int) ([]Job, error)
+	ListOutboxEventsAfter(ctx context.Context, id int64, limite int) ([]DomainEvent, error)
+	ListTicketCC(ticketID int64) ([]int64, error)
+	ListTickets(ctx context.Context, filtro TicketFilter) ([]Ticket, error)
+	ListTicketsByOrganization(orgID int64, filtro TicketFilter) ([]Ticket, error)
+	ListWatchedTickets(userID int64) ([]Ticket, error)
+	ListWatchers(ticketID int64) ([]int64, error)
+	ListWebhookDeliveries(webhookID int64, limite int) ([]WebhookDelivery, error)
+	ListWebhooks() ([]Webhook, error)
+	ListWebhooksForEvent(ctx context.Context, tipo string) ([]Webhook, error)
+	RemoveTicketCC(ticketID, userID int64) error
+	RemoveWatcher(ticketID, userID int64) error
+	RecordWebhookDelivery(ctx context.Context, d WebhookDelivery) (WebhookDelivery, error)
+	ReplaceCommentMentions(ctx context.Context, comment Comentario, userIDs []int64) ([]int64, error)
+	RequeueJob(id int64) error
+	UpdateComment(ctx context.Context, id int, comment Comentario) error
+	UpdateTicket(ctx context.Context, id int, ticket Ticket, atorID int64) error
+	UpdateWebhook(id int64, w Webhook) error
+	UpsertNotificationPreferences(p NotificationPreferences) error
+}
@@ -2,6 +2,12 @@ package model
 
 import "time"
 
+// Valores de tipoUser (definidos pelo users-service) relevantes para permissoes.
+const (
+	TipoAgente = "agente"
+	TipoAdmin  = "admin"
+)
+
 type Ticket struct {
 	ID              int64        `json:"id"`
 	Titulo          string       `json:"titulo"`
@@ -20,26 +26,112 @@ type Ticket struct {
 	ResponsavelID   int64        `json:"responsavel_id"`
 	UserID          int64        `json:"user_id"`
 	Author          TicketAuthor `json:"author"`
+	// Responsavel e o perfil de ResponsavelID, quando o ticket tem um.
+	Responsavel *TicketAuthor `json:"responsavel,omitempty"`
+	CC          []int64       `json:"cc"`
+	Watchers    []int64       `json:"watchers"`
+	Attachments []Attachment  `json:"attachments"`
 }
 
+// Tipos de comentario. Notas internas so sao visiveis para agentes.
+const (
+	ComentarioPublico = "publico"
+	ComentarioInterno = "interno"
+)
+
 type Comentario struct {
 	ID        int64     `json:"id"`
 	Descricao string    `json:"descricao"`
 	Data      time.Time `json:"data"`
 	UserID    int64     `json:"user_id"`
 	TicketID  int64     `json:"ticket_id"`
+	Tipo      string    `json:"tipo"`
+	Mencoes   []int64   `json:"mencoes"`
+}
+
+// ContagemTickets e o numero de tickets com um status e uma prioridade.
+type ContagemTickets struct {
+	Status     string
+	Prioridade string
+	Total      int64
 }
 
 type UpdateTicketPayload struct {
-	Titulo      string   `json:"titulo"`
-	Descricao   string   `json:"descricao"`
-	Prioridade  string   `json:"prioridade"`
-	Anexos      []string `json:"anexos"`
-	CategoriaID int64    `json:"categoria_id"`
+	Titulo      string `json:"titulo"`
+	Descricao   string `json:"descricao"`
+	Prioridade  string `json:"prioridade"`
+	CategoriaID int64  `json:"categoria_id"`
 }
 
 type TicketAuthor struct {
 	ID    int64  `json:"id"`
 	Nome  string `json:"nome"`
 	Email string `json:"email"`
+	// Indisponivel marca um perfil parcial, so com o ID, porque o
+	// users-service nao respondeu.
+	Indisponivel bool `json:"indisponivel,omitempty"`
+}
+
+// TicketFilter reune os filtros aceitos pelas listagens de tickets.
+// Campos vazios ou zerados sao ignorados.
+type TicketFilter struct {
+	Status        string
+	Prioridade    string
+	CategoriaID   int64
+	ResponsavelID int64
+	UserID        int64 // Autor do ticket
+}
+
+// OrganizationMember espelha a tabela organization_members mantida pelo users-service.
+type OrganizationMember struct {
+	OrganizationID int64 `json:"organization_id"`
+	UserID         int64 `json:"user_id"`
+	Manager        bool  `json:"manager"`
+}
+
+// Eventos que geram notificacao. Tambem sao os nomes dos templates de email
+// e os valores aceitos nas preferencias de notificacao.
+const (
+	EventoTicketCriado    = "ticket_criado"
+	EventoStatusAlterado  = "status_alterado"
+	EventoTicketAtribuido = "ticket_atribuido"
+	EventoNovoComentario  = "novo_comentario"
+	EventoMencao          = "mencao"
+)
+
+// NotificationJob e o trabalho enviado aos workers de notificacao.
+// ApenasAgentes restringe os destinatarios a equipe de suporte, como no caso
+// de notas internas que o cliente nao deve receber.
+// Quando Mencionados esta preenchido, o job avisa apenas esses usuarios de
+// que foram mencionados em um comentario do ticket.
+// AtorID e quem causou o evento; ele nao e notificado da propria acao.
+// O job do evento e dividido em um job por DestinatarioID, que envia um
+// unico email.
+type NotificationJob struct {
+	EventoID       int64   `json:"evento_id,omitempty"` // Evento da outbox que gerou o job
+	TicketID       int64   `json:"ticket_id"`
+	Evento         string  `json:"evento"`
+	ComentarioID   int64   `json:"comentario_id,omitempty"`
+	AtorID         int64   `json:"ator_id,omitempty"`
+	ApenasAgentes  bool    `json:"apenas_agentes,omitempty"`
+	Mencionados    []int64 `json:"mencionados,omitempty"`
+	DestinatarioID int64   `json:"destinatario_id,omitempty"`
+}
+
+// Usuario e o perfil do users-service com o tipo, usado nas decisoes de
+// permissao.
+type Usuario struct {
+	ID       int64  `json:"id"`
+	Nome     string `json:"nome"`
+	Email    string `json:"email"`
+	TipoUser string `json:"tipoUser"`
+}
+
+// MentionedUser e o usuario devolvido pelo users-service ao resolver um @handle.
+type MentionedUser struct {
+	ID       int64  `json:"id"`
+	Nome     string `json:"nome"`
+	Email    string `json:"email"`
+	TipoUser string `json:"tipoUser"`
+	Handle   string `json:"handle"`
 }
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// EventoTeste e o tipo do evento enviado por POST /webhooks/{id}/test.
+const EventoTeste = "ping"
+
+// EventosWebhook sao os eventos de dominio que podem ser assinados por webhooks.
+var EventosWebhook = map[string]bool{
+	DominioTicketCriado:     true,
+	DominioTicketAtualizado: true,
+	DominioStatusAlterado:   true,
+	DominioTicketAtribuido:  true,
+	DominioTicketComentado:  true,
+	DominioTicketExcluido:   true,
+}
+
+type Webhook struct {
+	ID      int64    `json:"id"`
+	URL     string   `json:"url"`
+	Eventos []string `json:"eventos"`
+	Segredo string   `json:"segredo,omitempty"` // So e devolvido na criacao
+	Ativo   bool     `json:"ativo"`
+	// IncluirInternos libera os eventos de notas internas, que por padrao
+	// nao saem do helpdesk.
+	IncluirInternos bool      `json:"incluir_internos"`
+	CriadoPor       int64     `json:"criado_por"`
+	CriadoEm        time.Time `json:"criado_em"`
+}
+
+// Recebe diz se o webhook deve receber o evento.
+func (w Webhook) Recebe(e DomainEvent) bool {
+	if !w.Ativo || (e.Interno() && !w.IncluirInternos) {
+		return false
+	}
+	for _, tipo := range w.Eventos {
+		if tipo == e.Tipo {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery registra uma tentativa de entrega de um evento a um webhook.
+type WebhookDelivery struct {
+	ID         int64     `json:"id"`
+	WebhookID  int64     `json:"webhook_id"`
+	EventoID   int64     `json:"evento_id"`
+	Tipo       string    `json:"tipo"`
+	Tentativa  int       `json:"tentativa"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Resposta   string    `json:"resposta,omitempty"`
+	Erro       string    `json:"erro,omitempty"`
+	DuracaoMs  int64     `json:"duracao_ms"`
+	Sucesso    bool      `json:"sucesso"`
+	Data       time.Time `json:"data"`
+}
+
+// WebhookJob e o payload do job que entrega um evento a um webhook.
+type WebhookJob struct {
+	WebhookID int64       `json:"webhook_id"`
+	Evento    DomainEvent `json:"evento"`
+}
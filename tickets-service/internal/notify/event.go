@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/queue"
+)
+
+// JobDoEvento converte um evento de dominio no job de notificacao
+// correspondente. Eventos que nao geram email devolvem false.
+func JobDoEvento(e model.DomainEvent) (model.NotificationJob, bool, error) {
+	job := model.NotificationJob{EventoID: e.ID, TicketID: e.TicketID, AtorID: e.AtorID}
+
+	switch e.Tipo {
+	case model.DominioTicketCriado:
+		job.Evento = model.EventoTicketCriado
+	case model.DominioStatusAlterado:
+		job.Evento = model.EventoStatusAlterado
+	case model.DominioTicketAtribuido:
+		dados, err := e.DadosTicket()
+		if err != nil {
+			return job, false, err
+		}
+		if dados.Ticket.ResponsavelID == 0 {
+			// Remocao do responsavel nao e notificada.
+			return job, false, nil
+		}
+		job.Evento = model.EventoTicketAtribuido
+	case model.DominioTicketComentado, model.DominioComentarioMencionou:
+		dados, err := e.DadosComentario()
+		if err != nil {
+			return job, false, err
+		}
+		job.ComentarioID = dados.Comentario.ID
+		job.Evento = model.EventoNovoComentario
+		job.ApenasAgentes = dados.Comentario.Tipo == model.ComentarioInterno
+		if e.Tipo == model.DominioComentarioMencionou {
+			job.Evento = model.EventoMencao
+			job.Mencionados = dados.Mencionados
+		}
+	default:
+		return job, false, nil
+	}
+
+	return job, true, nil
+}
+
+// AssinanteOutbox enfileira as notificacoes dos eventos publicados pela
+// outbox. A chave do job e o ID do evento, entao um evento reentregue nao
+// gera email duplicado.
+func AssinanteOutbox(fila *queue.Fila) func(ctx context.Context, e model.DomainEvent) error {
+	return func(ctx context.Context, e model.DomainEvent) error {
+		job, ok, err := JobDoEvento(e)
+		if err != nil || !ok {
+			return err
+		}
+		return fila.EnfileirarUnico(ctx, fmt.Sprintf("notificacao-evento-%d", e.ID), model.TipoJobNotificacao, job)
+	}
+}
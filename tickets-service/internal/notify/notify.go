@@ -0,0 +1,181 @@
+// Package notify envia por email as notificacoes de eventos dos tickets,
+// usando templates por idioma e respeitando as preferencias de cada usuario.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/internal/inbound"
+	"helpdesk/tickets-service/internal/model"
+	"net/mail"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Repositorio e o que o Notificador precisa do banco. E implementado por
+// *repository.Repository.
+type Repositorio interface {
+	GetTicketByID(ctx context.Context, id int) (model.Ticket, error)
+	GetCommentByID(id int) (model.Comentario, error)
+	ListNotificationRecipients(ticketID int64, apenasAgentes bool) ([]int64, error)
+	GetNotificationPreferences(ctx context.Context, userID int64) (model.NotificationPreferences, error)
+}
+
+// Perfis resolve nome e email no users-service com a identidade do servico,
+// ja que o worker nao tem usuario. E implementado pelo *users.Resolver.
+type Perfis interface {
+	Perfis(ctx context.Context, ids []int64) (map[int64]model.TicketAuthor, error)
+}
+
+// Fila recebe os jobs de cada destinatario. E implementada pela *queue.Fila.
+type Fila interface {
+	EnfileirarUnico(ctx context.Context, chave, tipo string, payload any) error
+}
+
+// Notificador transforma um NotificationJob em emails para os interessados.
+type Notificador struct {
+	Repo      Repositorio
+	Fila      Fila
+	Enviador  Enviador
+	Templates *Templates
+	URLBase   string
+	Perfis    Perfis
+}
+
+// NovoNotificador carrega os templates; urlBase e o endereco do helpdesk,
+// usado nos links para o ticket.
+func NovoNotificador(repo Repositorio, fila Fila, enviador Enviador, perfis Perfis, urlBase string) (*Notificador, error) {
+	templates, err := CarregarTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Notificador{
+		Repo:      repo,
+		Fila:      fila,
+		Enviador:  enviador,
+		Templates: templates,
+		URLBase:   strings.TrimSuffix(urlBase, "/"),
+		Perfis:    perfis,
+	}, nil
+}
+
+// Processar trata os dois passos de uma notificacao. O job do evento, sem
+// destinatario, e dividido em um job por interessado; cada um destes envia
+// um unico email. Assim a falha de um envio so repete o envio daquele
+// usuario, e nao o email de todos.
+func (n *Notificador) Processar(ctx context.Context, job model.NotificationJob) error {
+	if job.DestinatarioID == 0 {
+		return n.distribuir(ctx, job)
+	}
+
+	ticket, err := n.Repo.GetTicketByID(ctx, int(job.TicketID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		// O ticket foi excluido antes de o job ser processado.
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("erro ao consultar o ticket %d: %w", job.TicketID, err)
+	}
+
+	dados := Dados{Ticket: ticket}
+	if job.ComentarioID != 0 {
+		if dados.Comentario, err = n.Repo.GetCommentByID(int(job.ComentarioID)); err != nil {
+			return fmt.Errorf("erro ao consultar o comentario %d: %w", job.ComentarioID, err)
+		}
+	}
+	if n.URLBase != "" {
+		dados.URL = fmt.Sprintf("%s/tickets/%d", n.URLBase, ticket.ID)
+	}
+
+	// Carrega tambem ator e responsavel, cujos nomes aparecem nos templates.
+	// Sem o contato do destinatario o job falha e e repetido; com ele, um
+	// nome de ator ou responsavel que faltar sai em branco.
+	perfis, err := n.Perfis.Perfis(ctx, []int64{job.DestinatarioID, job.AtorID, ticket.ResponsavelID})
+	perfil, ok := perfis[job.DestinatarioID]
+	if !ok {
+		if err != nil {
+			return fmt.Errorf("erro ao consultar o contato do usuario %d: %w", job.DestinatarioID, err)
+		}
+		// O usuario foi excluido no users-service.
+		return nil
+	}
+	if perfil.Email == "" {
+		return nil
+	}
+	preferencias, err := n.Repo.GetNotificationPreferences(ctx, job.DestinatarioID)
+	if err != nil {
+		return fmt.Errorf("erro ao consultar as preferencias do usuario %d: %w", job.DestinatarioID, err)
+	}
+	if !preferencias.Recebe(job.Evento) {
+		return nil
+	}
+	dados.Destinatario = model.Destinatario{ID: perfil.ID, Nome: perfil.Nome, Email: perfil.Email, Preferencias: preferencias}
+	dados.Ator = perfis[job.AtorID].Nome
+	dados.Responsavel = perfis[ticket.ResponsavelID].Nome
+
+	return n.enviar(ctx, job, dados)
+}
+
+// distribuir enfileira um job por destinatario do evento. A chave combina
+// evento e usuario, entao repetir a distribuicao apos uma falha no meio dela
+// nao duplica os jobs ja gravados.
+func (n *Notificador) distribuir(ctx context.Context, job model.NotificationJob) error {
+	ids := job.Mencionados
+	if job.Evento != model.EventoMencao {
+		var err error
+		if ids, err = n.Repo.ListNotificationRecipients(job.TicketID, job.ApenasAgentes); err != nil {
+			return fmt.Errorf("erro ao consultar os destinatarios do ticket %d: %w", job.TicketID, err)
+		}
+	}
+
+	for _, id := range ids {
+		if id == job.AtorID {
+			continue
+		}
+		individual := job
+		individual.DestinatarioID = id
+		individual.Mencionados = nil
+
+		var chave string
+		if job.EventoID != 0 {
+			chave = fmt.Sprintf("notificacao-evento-%d-user-%d", job.EventoID, id)
+		}
+		if err := n.Fila.EnfileirarUnico(ctx, chave, model.TipoJobNotificacao, individual); err != nil {
+			return fmt.Errorf("erro ao enfileirar a notificacao do usuario %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func (n *Notificador) enviar(ctx context.Context, job model.NotificationJob, dados Dados) error {
+	assunto, texto, html, err := n.Templates.Renderizar(dados.Destinatario.Preferencias.Idioma, job.Evento, dados)
+	if err != nil {
+		return err
+	}
+
+	// Todos os emails do ticket referenciam o Message-ID do email de abertura,
+	// assim os clientes de email agrupam a conversa e as respostas voltam
+	// para o ticket pela entrada de emails.
+	conversa := inbound.MessageIDTicket(job.TicketID, "criado")
+	msg := Mensagem{
+		Para:       mail.Address{Name: dados.Destinatario.Nome, Address: dados.Destinatario.Email},
+		Assunto:    assunto,
+		Texto:      texto,
+		HTML:       html,
+		MessageID:  inbound.MessageIDTicket(job.TicketID, job.Evento+"-"+sufixoAleatorio()),
+		References: conversa,
+	}
+	if job.Evento == model.EventoTicketCriado {
+		msg.MessageID = conversa
+		msg.References = ""
+	}
+
+	if err = n.Enviador.Enviar(ctx, msg); err != nil {
+		return err
+	}
+	registro.Logger(ctx).Info("Notificação enviada", "evento", job.Evento, "ticket_id", job.TicketID, "usuario_id", dados.Destinatario.ID)
+	return nil
+}
@@ -0,0 +1,332 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"helpdesk/tickets-service/internal/inbound"
+	"helpdesk/tickets-service/internal/model"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// repoFake atende o Notificador com dados fixos. Os usuarios dao as
+// preferencias; os contatos vem de perfisDe(usuarios).
+type repoFake struct {
+	ticket       model.Ticket
+	comentario   model.Comentario
+	interessados []int64
+	usuarios     []model.Destinatario
+}
+
+func (r *repoFake) GetTicketByID(ctx context.Context, id int) (model.Ticket, error) {
+	return r.ticket, nil
+}
+func (r *repoFake) GetCommentByID(id int) (model.Comentario, error) { return r.comentario, nil }
+func (r *repoFake) ListNotificationRecipients(ticketID int64, apenasAgentes bool) ([]int64, error) {
+	return r.interessados, nil
+}
+func (r *repoFake) GetNotificationPreferences(ctx context.Context, userID int64) (model.NotificationPreferences, error) {
+	for _, u := range r.usuarios {
+		if u.ID == userID {
+			return u.Preferencias, nil
+		}
+	}
+	return model.NotificationPreferences{UserID: userID, EmailHabilitado: true, Idioma: model.IdiomaPortugues}, nil
+}
+
+// perfisFake faz as vezes do users-service. Com erro definido, devolve o
+// mapa junto com o erro, como o Resolver com o users-service fora.
+type perfisFake struct {
+	perfis map[int64]model.TicketAuthor
+	erro   error
+}
+
+func (p perfisFake) Perfis(ctx context.Context, ids []int64) (map[int64]model.TicketAuthor, error) {
+	return p.perfis, p.erro
+}
+
+func perfisDe(usuarios []model.Destinatario) perfisFake {
+	p := perfisFake{perfis: map[int64]model.TicketAuthor{}}
+	for _, u := range usuarios {
+		p.perfis[u.ID] = model.TicketAuthor{ID: u.ID, Nome: u.Nome, Email: u.Email}
+	}
+	return p
+}
+
+// filaFake executa na hora os jobs de cada destinatario, guardando as chaves.
+type filaFake struct {
+	n      *Notificador
+	chaves []string
+	erros  map[int64]error
+}
+
+func (f *filaFake) EnfileirarUnico(ctx context.Context, chave, tipo string, payload any) error {
+	job := payload.(model.NotificationJob)
+	f.chaves = append(f.chaves, chave)
+	if f.erros == nil {
+		f.erros = map[int64]error{}
+	}
+	f.erros[job.DestinatarioID] = f.n.Processar(ctx, job)
+	return nil
+}
+
+// enviadorFake falha o envio para os enderecos em falhar.
+type enviadorFake struct {
+	falhar   string
+	enviados []string
+}
+
+func (e *enviadorFake) Enviar(ctx context.Context, msg Mensagem) error {
+	if msg.Para.Address == e.falhar {
+		return errors.New("caixa cheia")
+	}
+	e.enviados = append(e.enviados, msg.Para.Address)
+	return nil
+}
+
+// caixaFake guarda os emails recebidos pelo SMTP de teste.
+type caixaFake struct {
+	mu     sync.Mutex
+	emails []inbound.Email
+}
+
+func (c *caixaFake) ProcessarEmail(ctx context.Context, email inbound.Email) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.emails = append(c.emails, email)
+	return nil
+}
+
+// smtpLocal sobe o servidor SMTP de entrada do proprio helpdesk como caixa
+// de saida de teste.
+func smtpLocal(t *testing.T) (string, *caixaFake) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	caixa := &caixaFake{}
+	go (&inbound.ServidorSMTP{Destino: caixa}).Serve(ctx, ln)
+	return ln.Addr().String(), caixa
+}
+
+func destinatario(id int64, nome, email, idioma string, habilitado bool, desativados ...string) model.Destinatario {
+	return model.Destinatario{ID: id, Nome: nome, Email: email, Preferencias: model.NotificationPreferences{
+		UserID: id, EmailHabilitado: habilitado, Idioma: idioma, EventosDesativados: desativados,
+	}}
+}
+
+func TestNotificador_Processar(t *testing.T) {
+	endereco, caixa := smtpLocal(t)
+
+	repo := &repoFake{
+		ticket:       model.Ticket{ID: 42, Titulo: "Impressora", Status: "fechado", UserID: 1},
+		interessados: []int64{1, 2, 3, 4, 5},
+		usuarios: []model.Destinatario{
+			destinatario(1, "Joao", "joao@acme.com", model.IdiomaPortugues, true),
+			destinatario(2, "Mary", "mary@acme.com", model.IdiomaIngles, true),
+			destinatario(3, "Ana", "ana@acme.com", model.IdiomaPortugues, false),
+			destinatario(4, "Bia", "bia@acme.com", model.IdiomaPortugues, true, model.EventoStatusAlterado),
+			destinatario(5, "Agente", "agente@helpdesk.local", model.IdiomaPortugues, true),
+		},
+	}
+
+	templates, err := CarregarTemplates()
+	assert.NoError(t, err)
+	n := &Notificador{
+		Repo:      repo,
+		Enviador:  &SMTP{Endereco: endereco, Remetente: mail.Address{Name: "Helpdesk", Address: "suporte@helpdesk.local"}},
+		Templates: templates,
+		URLBase:   "https://helpdesk.acme.com",
+		Perfis:    perfisDe(repo.usuarios),
+	}
+	n.Fila = &filaFake{n: n}
+
+	// O agente 5 alterou o status: ele mesmo nao e avisado, a Ana desligou os
+	// emails e a Bia desligou este evento.
+	err = n.Processar(context.Background(), model.NotificationJob{TicketID: 42, Evento: model.EventoStatusAlterado, AtorID: 5})
+	assert.NoError(t, err)
+
+	caixa.mu.Lock()
+	defer caixa.mu.Unlock()
+	if !assert.Len(t, caixa.emails, 2) {
+		return
+	}
+
+	assuntos := map[string]inbound.Email{}
+	for _, e := range caixa.emails {
+		assuntos[e.Assunto] = e
+	}
+
+	pt, ok := assuntos["[Ticket #42] Status alterado para fechado"]
+	if assert.True(t, ok, "email em portugues nao recebido: %v", assuntos) {
+		assert.Contains(t, pt.Texto, "Agente alterou o status do ticket #42")
+		assert.Contains(t, pt.Texto, "https://helpdesk.acme.com/tickets/42")
+		// A resposta a este email deve voltar para o mesmo ticket.
+		assert.Equal(t, int64(42), inbound.TicketReferenciado(inbound.Email{References: pt.MessageID}))
+	}
+
+	en, ok := assuntos["[Ticket #42] Status changed to fechado"]
+	if assert.True(t, ok, "email em ingles nao recebido: %v", assuntos) {
+		assert.True(t, strings.HasPrefix(en.Texto, "Hello Mary,"))
+	}
+}
+
+func TestNotificador_ContatosDoUsersService(t *testing.T) {
+	endereco, caixa := smtpLocal(t)
+
+	// O usuario 6 tem preferencias gravadas, mas foi excluido no users-service.
+	repo := &repoFake{
+		ticket:       model.Ticket{ID: 7, Titulo: "VPN", Status: "resolvido", UserID: 1},
+		interessados: []int64{1, 5, 6},
+		usuarios:     []model.Destinatario{destinatario(6, "", "", model.IdiomaPortugues, true)},
+	}
+	templates, err := CarregarTemplates()
+	assert.NoError(t, err)
+	n := &Notificador{
+		Repo:      repo,
+		Enviador:  &SMTP{Endereco: endereco, Remetente: mail.Address{Address: "suporte@helpdesk.local"}},
+		Templates: templates,
+		Perfis: perfisFake{perfis: map[int64]model.TicketAuthor{
+			1: {ID: 1, Nome: "Joao", Email: "joao@acme.com"},
+			5: {ID: 5, Nome: "Carla Agente", Email: "agente@helpdesk.local"},
+		}},
+	}
+	fila := &filaFake{n: n}
+	n.Fila = fila
+
+	err = n.Processar(context.Background(), model.NotificationJob{TicketID: 7, Evento: model.EventoStatusAlterado, AtorID: 5})
+	assert.NoError(t, err)
+	assert.NoError(t, fila.erros[6])
+
+	caixa.mu.Lock()
+	defer caixa.mu.Unlock()
+	if assert.Len(t, caixa.emails, 1) {
+		assert.Contains(t, caixa.emails[0].Texto, "Carla Agente alterou o status do ticket #7")
+	}
+}
+
+func TestNotificador_UsersServiceFora(t *testing.T) {
+	repo := &repoFake{ticket: model.Ticket{ID: 7, Titulo: "VPN", Status: "resolvido", UserID: 1}}
+	templates, err := CarregarTemplates()
+	assert.NoError(t, err)
+	enviador := &enviadorFake{}
+	n := &Notificador{Repo: repo, Enviador: enviador, Templates: templates, Perfis: perfisFake{erro: errors.New("users-service fora")}}
+
+	// Sem o contato do destinatario o job falha, para ser repetido depois.
+	err = n.Processar(context.Background(), model.NotificationJob{TicketID: 7, Evento: model.EventoStatusAlterado, AtorID: 5, DestinatarioID: 1})
+	assert.Error(t, err)
+	assert.Empty(t, enviador.enviados)
+}
+
+func TestNotificador_UmJobPorDestinatario(t *testing.T) {
+	repo := &repoFake{
+		ticket:       model.Ticket{ID: 42, Titulo: "Impressora", Status: "fechado", UserID: 1},
+		interessados: []int64{1, 2, 5},
+		usuarios: []model.Destinatario{
+			destinatario(1, "Joao", "joao@acme.com", model.IdiomaPortugues, true),
+			destinatario(2, "Mary", "mary@acme.com", model.IdiomaIngles, true),
+			destinatario(5, "Agente", "agente@helpdesk.local", model.IdiomaPortugues, true),
+		},
+	}
+	templates, err := CarregarTemplates()
+	assert.NoError(t, err)
+	enviador := &enviadorFake{falhar: "mary@acme.com"}
+	n := &Notificador{Repo: repo, Enviador: enviador, Templates: templates, Perfis: perfisDe(repo.usuarios)}
+	fila := &filaFake{n: n}
+	n.Fila = fila
+
+	// A distribuicao em si termina bem; so o job da Mary falha e sera
+	// repetido sozinho, sem reenviar o email do Joao.
+	err = n.Processar(context.Background(), model.NotificationJob{EventoID: 30, TicketID: 42, Evento: model.EventoStatusAlterado, AtorID: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"notificacao-evento-30-user-1", "notificacao-evento-30-user-2"}, fila.chaves)
+	assert.NoError(t, fila.erros[1])
+	assert.Error(t, fila.erros[2])
+	assert.Equal(t, []string{"joao@acme.com"}, enviador.enviados)
+
+	enviador.falhar = ""
+	err = n.Processar(context.Background(), model.NotificationJob{EventoID: 30, TicketID: 42, Evento: model.EventoStatusAlterado, AtorID: 5, DestinatarioID: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"joao@acme.com", "mary@acme.com"}, enviador.enviados)
+}
+
+func TestTemplates_TodosEventos(t *testing.T) {
+	templates, err := CarregarTemplates()
+	assert.NoError(t, err)
+
+	dados := Dados{
+		Destinatario: model.Destinatario{Nome: "Joao"},
+		Ticket:       model.Ticket{ID: 7, Titulo: "VPN <caiu>", Status: "aberto"},
+		Comentario:   model.Comentario{Descricao: "Tente de novo"},
+		Ator:         "Agente",
+		Responsavel:  "Maria",
+	}
+
+	eventos := []string{model.EventoTicketCriado, model.EventoStatusAlterado, model.EventoTicketAtribuido, model.EventoNovoComentario, model.EventoMencao}
+	for _, idioma := range []string{model.IdiomaPortugues, model.IdiomaIngles} {
+		for _, evento := range eventos {
+			assunto, texto, html, err := templates.Renderizar(idioma, evento, dados)
+			if assert.NoError(t, err, "%s/%s", idioma, evento) {
+				assert.True(t, strings.HasPrefix(assunto, "[Ticket #7]"), "%s/%s: %s", idioma, evento, assunto)
+				assert.NotEmpty(t, texto)
+				// O HTML escapa o conteudo vindo do usuario.
+				assert.NotContains(t, html, "<caiu>")
+			}
+		}
+	}
+
+	// Idioma desconhecido cai para o portugues.
+	assunto, _, _, err := templates.Renderizar("fr", model.EventoTicketAtribuido, dados)
+	assert.NoError(t, err)
+	assert.Equal(t, "[Ticket #7] Ticket atribuído a Maria", assunto)
+
+	_, _, _, err = templates.Renderizar(model.IdiomaPortugues, "inexistente", dados)
+	assert.Error(t, err)
+}
+
+func TestSMTP_Cancelado(t *testing.T) {
+	// Servidor que aceita a conexao e nunca responde.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	s := &SMTP{Endereco: ln.Addr().String(), Remetente: mail.Address{Address: "suporte@helpdesk.local"}}
+	err = s.Enviar(ctx, Mensagem{Para: mail.Address{Address: "a@acme.com"}})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestJobDoEvento(t *testing.T) {
+	comentario := func(tipo string, dados string) model.DomainEvent {
+		return model.DomainEvent{ID: 1, Tipo: tipo, TicketID: 9, AtorID: 3, Dados: []byte(dados)}
+	}
+
+	job, ok, err := JobDoEvento(comentario(model.DominioTicketComentado, `{"comentario":{"id":5,"tipo":"interno"}}`))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, model.NotificationJob{EventoID: 1, TicketID: 9, Evento: model.EventoNovoComentario, ComentarioID: 5, AtorID: 3, ApenasAgentes: true}, job)
+
+	job, ok, _ = JobDoEvento(comentario(model.DominioComentarioMencionou, `{"comentario":{"id":5,"tipo":"publico"},"mencionados":[7,8]}`))
+	assert.True(t, ok)
+	assert.Equal(t, model.EventoMencao, job.Evento)
+	assert.Equal(t, []int64{7, 8}, job.Mencionados)
+
+	// Remover o responsavel e editar o ticket nao geram email.
+	_, ok, _ = JobDoEvento(comentario(model.DominioTicketAtribuido, `{"ticket":{"responsavel_id":0}}`))
+	assert.False(t, ok)
+	_, ok, _ = JobDoEvento(comentario(model.DominioTicketAtualizado, `{}`))
+	assert.False(t, ok)
+}
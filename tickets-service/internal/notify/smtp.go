@@ -0,0 +1,158 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"helpdesk/pkg/config"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/internal/inbound"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"time"
+)
+
+// Mensagem e um email pronto para envio.
+type Mensagem struct {
+	Para       mail.Address
+	Assunto    string
+	Texto      string
+	HTML       string
+	MessageID  string
+	References string // Message-ID da conversa do ticket, para agrupar as respostas
+}
+
+// Enviador entrega emails.
+type Enviador interface {
+	Enviar(ctx context.Context, msg Mensagem) error
+}
+
+// SMTP envia por um servidor SMTP. Usa STARTTLS quando o servidor oferece e
+// autentica com PLAIN quando Usuario estiver preenchido.
+type SMTP struct {
+	Endereco  string
+	Remetente mail.Address
+	Usuario   string
+	Senha     string
+}
+
+func (s *SMTP) Enviar(ctx context.Context, msg Mensagem) error {
+	corpo, err := montarMensagem(s.Remetente, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.Usuario != "" {
+		host, _, _ := net.SplitHostPort(s.Endereco)
+		auth = smtp.PlainAuth("", s.Usuario, s.Senha, host)
+	}
+
+	erro := make(chan error, 1)
+	go func() {
+		erro <- smtp.SendMail(s.Endereco, auth, s.Remetente.Address, []string{msg.Para.Address}, corpo)
+	}()
+
+	select {
+	case err = <-erro:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Log apenas registra o email no log. E usado quando smtp.endereco nao esta
+// definido, como em desenvolvimento.
+type Log struct{}
+
+func (Log) Enviar(ctx context.Context, msg Mensagem) error {
+	registro.Logger(ctx).Info("Email não enviado, só registrado", "para", msg.Para.Address, "assunto", msg.Assunto)
+	return nil
+}
+
+// NovoEnviador escolhe o enviador pela secao smtp do config: sem endereco,
+// os emails so sao registrados no log.
+func NovoEnviador(cfg config.SMTP) Enviador {
+	if cfg.Endereco == "" {
+		return Log{}
+	}
+
+	remetente, err := mail.ParseAddress(cfg.Remetente)
+	if err != nil {
+		remetente = &mail.Address{Name: "Helpdesk", Address: "suporte@" + inbound.DominioMessageID}
+	}
+
+	return &SMTP{
+		Endereco:  cfg.Endereco,
+		Remetente: *remetente,
+		Usuario:   cfg.Usuario,
+		Senha:     cfg.Senha,
+	}
+}
+
+// montarMensagem gera o email multipart/alternative com as versoes texto e HTML.
+func montarMensagem(de mail.Address, msg Mensagem) ([]byte, error) {
+	var buf bytes.Buffer
+	corpo := multipart.NewWriter(&buf)
+
+	cabecalhos := []struct{ nome, valor string }{
+		{"From", de.String()},
+		{"To", msg.Para.String()},
+		{"Reply-To", de.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Assunto)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", msg.MessageID},
+		{"MIME-Version", "1.0"},
+		{"Auto-Submitted", "auto-generated"},
+		{"Content-Type", `multipart/alternative; boundary="` + corpo.Boundary() + `"`},
+	}
+	if msg.References != "" {
+		cabecalhos = append(cabecalhos, struct{ nome, valor string }{"In-Reply-To", msg.References}, struct{ nome, valor string }{"References", msg.References})
+	}
+
+	var cab bytes.Buffer
+	for _, c := range cabecalhos {
+		if c.valor != "" {
+			fmt.Fprintf(&cab, "%s: %s\r\n", c.nome, c.valor)
+		}
+	}
+	cab.WriteString("\r\n")
+
+	for _, parte := range []struct{ tipo, conteudo string }{
+		{"text/plain; charset=utf-8", msg.Texto},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := corpo.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {parte.tipo},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err = qp.Write([]byte(parte.conteudo)); err != nil {
+			return nil, err
+		}
+		if err = qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := corpo.Close(); err != nil {
+		return nil, err
+	}
+
+	return append(cab.Bytes(), buf.Bytes()...), nil
+}
+
+func sufixoAleatorio() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
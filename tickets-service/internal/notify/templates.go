@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"helpdesk/tickets-service/internal/model"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.tmpl
+var arquivosTemplates embed.FS
+
+// Dados sao os valores disponiveis para os templates de email.
+type Dados struct {
+	Destinatario model.Destinatario
+	Ticket       model.Ticket
+	Comentario   model.Comentario
+	Ator         string // Nome de quem causou o evento
+	Responsavel  string // Nome do responsavel pelo ticket
+	URL          string // Link para o ticket, se NOTIFICACOES_URL_BASE estiver definida
+}
+
+// Templates guarda os templates de texto e HTML de cada idioma. Cada evento
+// define "<evento>.assunto" e "<evento>.texto" no arquivo .txt.tmpl e
+// "<evento>.html" no arquivo .html.tmpl.
+type Templates struct {
+	texto map[string]*texttemplate.Template
+	html  map[string]*htmltemplate.Template
+}
+
+// CarregarTemplates le os templates embutidos de todos os idiomas suportados.
+func CarregarTemplates() (*Templates, error) {
+	t := &Templates{
+		texto: map[string]*texttemplate.Template{},
+		html:  map[string]*htmltemplate.Template{},
+	}
+
+	for _, idioma := range []string{model.IdiomaPortugues, model.IdiomaIngles} {
+		txt, err := texttemplate.ParseFS(arquivosTemplates, "templates/"+idioma+".txt.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		html, err := htmltemplate.ParseFS(arquivosTemplates, "templates/"+idioma+".html.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		t.texto[idioma] = txt
+		t.html[idioma] = html
+	}
+
+	return t, nil
+}
+
+// Renderizar monta assunto, texto e HTML do evento no idioma pedido. Idiomas
+// sem template caem para o portugues.
+func (t *Templates) Renderizar(idioma, evento string, dados Dados) (assunto, texto, html string, err error) {
+	txt, ok := t.texto[idioma]
+	if !ok {
+		idioma = model.IdiomaPortugues
+		txt = t.texto[idioma]
+	}
+	if txt.Lookup(evento+".assunto") == nil {
+		return "", "", "", fmt.Errorf("evento sem template: %s", evento)
+	}
+
+	var buf bytes.Buffer
+	if err = txt.ExecuteTemplate(&buf, evento+".assunto", dados); err != nil {
+		return "", "", "", err
+	}
+	assunto = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	if err = txt.ExecuteTemplate(&buf, evento+".texto", dados); err != nil {
+		return "", "", "", err
+	}
+	texto = buf.String()
+
+	buf.Reset()
+	if err = t.html[idioma].ExecuteTemplate(&buf, evento+".html", dados); err != nil {
+		return "", "", "", err
+	}
+	html = buf.String()
+
+	return assunto, texto, html, nil
+}
+
+// Suporta diz se ha templates para o idioma.
+func (t *Templates) Suporta(idioma string) bool {
+	_, ok := t.texto[idioma]
+	return ok
+}
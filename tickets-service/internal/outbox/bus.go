@@ -0,0 +1,65 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"helpdesk/tickets-service/internal/bus"
+	"helpdesk/tickets-service/internal/model"
+	"log/slog"
+)
+
+// mensagemBus leva o evento inteiro quando ele cabe no limite do bus; do
+// contrario so o ID, e quem recebe le o evento da outbox.
+type mensagemBus struct {
+	ID     int64              `json:"id"`
+	Evento *model.DomainEvent `json:"evento,omitempty"`
+}
+
+// Leitor busca um evento da outbox pelo ID. E implementado por
+// *repository.Repository.
+type Leitor interface {
+	GetOutboxEvent(ctx context.Context, id int64) (model.DomainEvent, error)
+}
+
+// PublicarNoBus repassa ao bus os eventos publicados pelo relay. Como so uma
+// replica por vez publica a outbox, e assim que as demais recebem os eventos.
+func PublicarNoBus(b bus.Bus) AssinanteFunc {
+	return func(ctx context.Context, e model.DomainEvent) error {
+		msg, err := json.Marshal(mensagemBus{ID: e.ID, Evento: &e})
+		if err != nil {
+			return err
+		}
+		if len(msg) > bus.MaxMensagem {
+			if msg, err = json.Marshal(mensagemBus{ID: e.ID}); err != nil {
+				return err
+			}
+		}
+		return b.Publicar(ctx, bus.TopicoEventos, msg)
+	}
+}
+
+// OuvirBus entrega ao assinante, nesta replica, os eventos recebidos pelo bus.
+// Serve para quem precisa dos eventos em todas as replicas, como os streams
+// em tempo real; a entrega nao e garantida (veja o pacote bus).
+func OuvirBus(b bus.Bus, leitor Leitor, a Assinante) (cancelar func()) {
+	return b.Assinar(bus.TopicoEventos, func(ctx context.Context, dados []byte) {
+		var msg mensagemBus
+		if err := json.Unmarshal(dados, &msg); err != nil {
+			slog.Warn("Mensagem de evento inválida no bus", "erro", err)
+			return
+		}
+
+		if msg.Evento == nil {
+			e, err := leitor.GetOutboxEvent(ctx, msg.ID)
+			if err != nil {
+				slog.Error("Erro ao ler o evento da outbox", "evento_id", msg.ID, "erro", err)
+				return
+			}
+			msg.Evento = &e
+		}
+
+		if err := a.Publicar(ctx, *msg.Evento); err != nil {
+			slog.Error("Erro ao entregar o evento recebido pelo bus", "evento_id", msg.ID, "erro", err)
+		}
+	})
+}
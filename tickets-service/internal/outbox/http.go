@@ -0,0 +1,77 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"helpdesk/tickets-service/internal/model"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Fila recebe os jobs do HTTPSink. E implementada pela *queue.Fila.
+type Fila interface {
+	EnfileirarUnico(ctx context.Context, chave, tipo string, payload any) error
+}
+
+// HTTPSink envia cada evento como JSON, por POST, para um servico externo
+// (um coletor de eventos, um barramento com API HTTP etc.). Qualquer resposta
+// fora da faixa 2xx conta como falha e o evento e reenviado. O cabecalho
+// X-Event-ID permite ao destino descartar repeticoes.
+//
+// No relay ele entra por AssinanteFila, e nao direto: a entrega fica com os
+// workers da fila, entao um destino lento ou fora do ar nao segura a
+// transacao da outbox nem os demais assinantes.
+type HTTPSink struct {
+	URL     string
+	Cliente *http.Client
+}
+
+func (s *HTTPSink) Publicar(ctx context.Context, evento model.DomainEvent) error {
+	corpo, err := json.Marshal(evento)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(corpo))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.FormatInt(evento.ID, 10))
+	req.Header.Set("X-Event-Type", evento.Tipo)
+
+	cliente := s.Cliente
+	if cliente == nil {
+		cliente = &http.Client{Timeout: 10 * time.Second}
+	}
+	resposta, err := cliente.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resposta.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resposta.Body, 64<<10))
+
+	if resposta.StatusCode < 200 || resposta.StatusCode > 299 {
+		return fmt.Errorf("destino respondeu %d", resposta.StatusCode)
+	}
+	return nil
+}
+
+// AssinanteFila enfileira um job do tipo model.TipoJobOutboxHTTP por evento,
+// a ser executado por Publicar. A chave e o ID do evento, entao um evento
+// reentregue pela outbox nao e enviado duas vezes.
+//
+// Eventos de notas internas nao saem: como nos webhooks e nos streams, o
+// conteudo delas fica restrito aos agentes e nao vai para exportacoes.
+func (s *HTTPSink) AssinanteFila(fila Fila) AssinanteFunc {
+	return func(ctx context.Context, e model.DomainEvent) error {
+		if e.Interno() {
+			return nil
+		}
+		return fila.EnfileirarUnico(ctx, fmt.Sprintf("outbox-http-evento-%d", e.ID), model.TipoJobOutboxHTTP, e)
+	}
+}
@@ -0,0 +1,133 @@
+// Package outbox publica os eventos de dominio gravados pelo repositorio na
+// tabela outbox_events. Cada escrita de ticket ou comentario grava seu evento
+// na mesma transacao; o Relay le esses eventos em ordem e os entrega aos
+// assinantes registrados, dentro do processo ou externos.
+//
+// A entrega e pelo menos uma vez: um evento so e marcado como publicado depois
+// que todos os assinantes o aceitaram, e se um deles falhar o evento (com os
+// seguintes) e entregue de novo a todos na proxima rodada. Assinantes devem,
+// portanto, ser idempotentes, usando o ID do evento para descartar repeticoes.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/internal/model"
+	"log/slog"
+	"time"
+)
+
+// Store e a outbox no banco. E implementado por *repository.Repository.
+type Store interface {
+	PublishOutbox(ctx context.Context, limite int, publicar func(model.DomainEvent) error) (int, error)
+	PurgeOutbox(ctx context.Context, antesDe time.Time) (int64, error)
+}
+
+// Assinante recebe os eventos publicados.
+type Assinante interface {
+	Publicar(ctx context.Context, evento model.DomainEvent) error
+}
+
+// AssinanteFunc permite usar uma funcao como Assinante.
+type AssinanteFunc func(ctx context.Context, evento model.DomainEvent) error
+
+func (f AssinanteFunc) Publicar(ctx context.Context, evento model.DomainEvent) error {
+	return f(ctx, evento)
+}
+
+// Relay le a outbox periodicamente e entrega os eventos aos assinantes.
+type Relay struct {
+	store      Store
+	assinantes []assinanteNomeado
+	acordar    chan struct{}
+
+	Intervalo time.Duration // Espera entre leituras quando nao ha eventos
+	Lote      int           // Eventos lidos por transacao
+	Retencao  time.Duration // Por quanto tempo eventos publicados sao mantidos
+}
+
+type assinanteNomeado struct {
+	nome string
+	Assinante
+}
+
+func NovoRelay(store Store) *Relay {
+	return &Relay{
+		store:     store,
+		acordar:   make(chan struct{}, 1),
+		Intervalo: 500 * time.Millisecond,
+		Lote:      100,
+		Retencao:  7 * 24 * time.Hour,
+	}
+}
+
+// Assinar registra um assinante. Os assinantes recebem cada evento na ordem
+// em que foram registrados. Deve ser chamado antes de Executar.
+func (r *Relay) Assinar(nome string, a Assinante) {
+	r.assinantes = append(r.assinantes, assinanteNomeado{nome, a})
+}
+
+// Acordar antecipa a proxima leitura da outbox, por exemplo logo apos uma
+// escrita, sem esperar o Intervalo.
+func (r *Relay) Acordar() {
+	select {
+	case r.acordar <- struct{}{}:
+	default:
+	}
+}
+
+// Executar publica eventos ate o contexto ser cancelado.
+func (r *Relay) Executar(ctx context.Context) {
+	limpeza := time.NewTicker(time.Hour)
+	defer limpeza.Stop()
+
+	r.limpar(ctx)
+	for ctx.Err() == nil {
+		n, err := r.PublicarPendentes(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("Erro ao publicar eventos da outbox", "erro", err)
+		}
+		if err == nil && n == r.Lote {
+			// Ainda ha eventos na fila.
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-r.acordar:
+		case <-limpeza.C:
+			r.limpar(ctx)
+		case <-time.After(r.Intervalo):
+		}
+	}
+}
+
+// PublicarPendentes faz uma rodada de publicacao e devolve quantos eventos
+// foram entregues.
+func (r *Relay) PublicarPendentes(ctx context.Context) (int, error) {
+	return r.store.PublishOutbox(ctx, r.Lote, func(e model.DomainEvent) error {
+		// Os jobs enfileirados pelos assinantes herdam o ID da requisicao.
+		ctxEvento := ctx
+		if e.RequestID != "" {
+			ctxEvento = registro.ComID(ctx, e.RequestID)
+		}
+		for _, a := range r.assinantes {
+			if err := a.Publicar(ctxEvento, e); err != nil {
+				return fmt.Errorf("assinante %s falhou no evento %d (%s): %w", a.nome, e.ID, e.Tipo, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *Relay) limpar(ctx context.Context) {
+	if r.Retencao <= 0 {
+		return
+	}
+	if n, err := r.store.PurgeOutbox(ctx, time.Now().Add(-r.Retencao)); err != nil {
+		slog.Error("Erro ao limpar eventos antigos da outbox", "erro", err)
+	} else if n > 0 {
+		slog.Info("Eventos antigos removidos da outbox", "eventos", n)
+	}
+}
@@ -0,0 +1,223 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"helpdesk/tickets-service/internal/bus"
+	"helpdesk/tickets-service/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storeMemoria imita PublishOutbox: entrega em ordem e para no primeiro erro.
+type storeMemoria struct {
+	mu         sync.Mutex
+	eventos    []model.DomainEvent
+	publicados map[int64]bool
+}
+
+func novoStore(tipos ...string) *storeMemoria {
+	s := &storeMemoria{publicados: map[int64]bool{}}
+	for i, tipo := range tipos {
+		s.eventos = append(s.eventos, model.DomainEvent{ID: int64(i + 1), Tipo: tipo, TicketID: 1})
+	}
+	return s
+}
+
+func (s *storeMemoria) PublishOutbox(ctx context.Context, limite int, publicar func(model.DomainEvent) error) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, e := range s.eventos {
+		if s.publicados[e.ID] {
+			continue
+		}
+		if n == limite {
+			break
+		}
+		if err := publicar(e); err != nil {
+			return n, err
+		}
+		s.publicados[e.ID] = true
+		n++
+	}
+	return n, nil
+}
+
+func (s *storeMemoria) PurgeOutbox(ctx context.Context, antesDe time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestRelay_EntregaEmOrdem(t *testing.T) {
+	store := novoStore(model.DominioTicketCriado, model.DominioTicketComentado, model.DominioStatusAlterado)
+	relay := NovoRelay(store)
+
+	var recebidos []int64
+	relay.Assinar("teste", AssinanteFunc(func(ctx context.Context, e model.DomainEvent) error {
+		recebidos = append(recebidos, e.ID)
+		return nil
+	}))
+
+	n, err := relay.PublicarPendentes(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []int64{1, 2, 3}, recebidos)
+
+	// Eventos publicados nao sao entregues de novo.
+	n, _ = relay.PublicarPendentes(context.Background())
+	assert.Equal(t, 0, n)
+}
+
+func TestRelay_PeloMenosUmaVez(t *testing.T) {
+	store := novoStore(model.DominioTicketCriado, model.DominioTicketComentado, model.DominioStatusAlterado)
+	relay := NovoRelay(store)
+
+	var primeiro, segundo []int64
+	falhar := true
+	relay.Assinar("primeiro", AssinanteFunc(func(ctx context.Context, e model.DomainEvent) error {
+		primeiro = append(primeiro, e.ID)
+		return nil
+	}))
+	relay.Assinar("segundo", AssinanteFunc(func(ctx context.Context, e model.DomainEvent) error {
+		if e.ID == 2 && falhar {
+			return errors.New("fora do ar")
+		}
+		segundo = append(segundo, e.ID)
+		return nil
+	}))
+
+	// O segundo assinante falha no evento 2: a rodada para ali.
+	n, err := relay.PublicarPendentes(context.Background())
+	assert.ErrorContains(t, err, "assinante segundo falhou no evento 2")
+	assert.Equal(t, 1, n)
+
+	falhar = false
+	n, err = relay.PublicarPendentes(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// O evento 2 chega duas vezes ao primeiro assinante, mas a ordem e mantida
+	// e nenhum evento se perde.
+	assert.Equal(t, []int64{1, 2, 2, 3}, primeiro)
+	assert.Equal(t, []int64{1, 2, 3}, segundo)
+}
+
+func TestRelay_Executar(t *testing.T) {
+	store := novoStore(model.DominioTicketCriado)
+	relay := NovoRelay(store)
+	relay.Intervalo = time.Hour
+
+	entregue := make(chan int64, 2)
+	relay.Assinar("teste", AssinanteFunc(func(ctx context.Context, e model.DomainEvent) error {
+		entregue <- e.ID
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go relay.Executar(ctx)
+
+	assert.Equal(t, int64(1), <-entregue)
+
+	// Um evento novo e entregue assim que o relay e acordado, sem esperar o intervalo.
+	store.mu.Lock()
+	store.eventos = append(store.eventos, model.DomainEvent{ID: 2, Tipo: model.DominioTicketAtualizado})
+	store.mu.Unlock()
+	relay.Acordar()
+
+	select {
+	case id := <-entregue:
+		assert.Equal(t, int64(2), id)
+	case <-time.After(2 * time.Second):
+		t.Fatal("evento nao entregue apos Acordar")
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	status := http.StatusAccepted
+	var recebido http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recebido = r.Header.Clone()
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sink := &HTTPSink{URL: srv.URL}
+	evento := model.DomainEvent{ID: 42, Tipo: model.DominioTicketCriado, Dados: []byte(`{}`)}
+
+	assert.NoError(t, sink.Publicar(context.Background(), evento))
+	assert.Equal(t, "42", recebido.Get("X-Event-ID"))
+	assert.Equal(t, "ticket.created", recebido.Get("X-Event-Type"))
+
+	status = http.StatusInternalServerError
+	assert.Error(t, sink.Publicar(context.Background(), evento))
+}
+
+// filaMemoria guarda os jobs enfileirados pela chave.
+type filaMemoria map[string]any
+
+func (f filaMemoria) EnfileirarUnico(ctx context.Context, chave, tipo string, payload any) error {
+	if _, ok := f[chave]; !ok {
+		f[chave] = payload
+	}
+	return nil
+}
+
+func TestHTTPSink_AssinanteFila(t *testing.T) {
+	fila := filaMemoria{}
+	// O destino nem existe: o relay so grava o job, sem fazer a chamada.
+	publicar := (&HTTPSink{URL: "http://127.0.0.1:1"}).AssinanteFila(fila)
+
+	evento := model.DomainEvent{ID: 42, Tipo: model.DominioTicketCriado}
+	assert.NoError(t, publicar(context.Background(), evento))
+	assert.NoError(t, publicar(context.Background(), evento))
+	assert.Equal(t, filaMemoria{"outbox-http-evento-42": evento}, fila)
+}
+
+func TestHTTPSink_AssinanteFila_IgnoraNotasInternas(t *testing.T) {
+	fila := filaMemoria{}
+	publicar := (&HTTPSink{URL: "http://127.0.0.1:1"}).AssinanteFila(fila)
+
+	for _, tipo := range []string{model.DominioTicketComentado, model.DominioComentarioAtualizado} {
+		nota, err := json.Marshal(model.CommentEventData{Comentario: model.Comentario{ID: 3, Tipo: model.ComentarioInterno}})
+		require.NoError(t, err)
+		assert.NoError(t, publicar(context.Background(), model.DomainEvent{ID: 7, Tipo: tipo, Dados: nota}))
+	}
+	assert.Empty(t, fila)
+}
+
+type leitorMemoria map[int64]model.DomainEvent
+
+func (l leitorMemoria) GetOutboxEvent(ctx context.Context, id int64) (model.DomainEvent, error) {
+	return l[id], nil
+}
+
+func TestBus_EntregaEventos(t *testing.T) {
+	b := bus.NovoMemoria()
+	grande := model.DomainEvent{ID: 2, Tipo: model.DominioTicketCriado, Dados: []byte(`"` + strings.Repeat("x", bus.MaxMensagem) + `"`)}
+	leitor := leitorMemoria{2: grande}
+
+	var recebidos []model.DomainEvent
+	OuvirBus(b, leitor, AssinanteFunc(func(ctx context.Context, e model.DomainEvent) error {
+		recebidos = append(recebidos, e)
+		return nil
+	}))
+
+	publicar := PublicarNoBus(b)
+	assert.NoError(t, publicar(context.Background(), model.DomainEvent{ID: 1, Tipo: model.DominioTicketCriado, Dados: []byte(`{}`)}))
+	// O evento maior que o limite vai so com o ID e e lido da outbox.
+	assert.NoError(t, publicar(context.Background(), grande))
+
+	if assert.Len(t, recebidos, 2) {
+		assert.Equal(t, int64(1), recebidos[0].ID)
+		assert.Equal(t, grande.Dados, recebidos[1].Dados)
+	}
+}
@@ -0,0 +1,331 @@
+// Package queue executa os jobs da fila persistente em PostgreSQL com um pool
+// de workers, novas tentativas com backoff exponencial e fila de mortos.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"helpdesk/pkg/config"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/internal/model"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Resultados de um job processado, no rotulo das metricas.
+const (
+	resultadoSucesso     = "sucesso"
+	resultadoRetentativa = "retentativa"
+	resultadoMorto       = "morto"
+)
+
+var (
+	processados = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "helpdesk_fila_jobs_processados_total",
+		Help: "Jobs executados pelos workers, por tipo e resultado: sucesso, retentativa ou morto.",
+	}, []string{"tipo", "resultado"})
+
+	duracaoJob = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "helpdesk_fila_job_duracao_segundos",
+		Help:    "Tempo de execucao de cada tentativa de job, por tipo.",
+		Buckets: []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"tipo"})
+)
+
+// Store e onde os jobs ficam guardados. E implementado por *repository.Repository.
+type Store interface {
+	EnqueueJob(ctx context.Context, tipo, chave string, payload any, maxTentativas int) (int64, error)
+	ClaimJob(ctx context.Context) (model.Job, error)
+	RecoverJobs(ctx context.Context, lease time.Duration) (int64, error)
+	PurgeJobs(ctx context.Context, antesDe time.Time) (int64, error)
+	CompleteJob(ctx context.Context, job model.Job) error
+	RetryJobLater(ctx context.Context, job model.Job, disponivelEm time.Time, erro string) error
+	KillJob(ctx context.Context, job model.Job, erro string) error
+}
+
+// Handler processa o payload de um tipo de job. Um erro faz o job ser tentado
+// de novo mais tarde.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Tratar adapta uma funcao tipada em Handler, decodificando o payload JSON.
+func Tratar[T any](f func(ctx context.Context, payload T) error) Handler {
+	return func(ctx context.Context, dados json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(dados, &payload); err != nil {
+			return Permanente(fmt.Errorf("payload inválido: %w", err))
+		}
+		return f(ctx, payload)
+	}
+}
+
+type erroPermanente struct{ err error }
+
+func (e erroPermanente) Error() string { return e.err.Error() }
+func (e erroPermanente) Unwrap() error { return e.err }
+
+// Permanente marca um erro que nao adianta tentar de novo: o job vai direto
+// para a fila de mortos.
+func Permanente(err error) error {
+	return erroPermanente{err}
+}
+
+// Config ajusta o pool de workers.
+type Config struct {
+	Workers       int           // Numero de workers concorrentes
+	Intervalo     time.Duration // Espera entre consultas quando a fila esta vazia
+	MaxTentativas int           // Tentativas antes de o job ir para a fila de mortos
+	BackoffBase   time.Duration // Espera apos a primeira falha; dobra a cada tentativa
+	BackoffMax    time.Duration
+	Lease         time.Duration // Tempo maximo de um job reservado (e executando) antes de voltar a fila
+	Retencao      time.Duration // Por quanto tempo jobs concluidos sao mantidos
+}
+
+// ConfigDe monta a Config a partir do config compartilhado.
+func ConfigDe(cfg config.Fila, workers int) Config {
+	return Config{
+		Workers:       workers,
+		Intervalo:     cfg.Intervalo,
+		MaxTentativas: cfg.MaxTentativas,
+		BackoffBase:   cfg.BackoffBase,
+		BackoffMax:    cfg.BackoffMax,
+		Lease:         cfg.Lease,
+		Retencao:      cfg.Retencao,
+	}
+}
+
+// Fila enfileira jobs e os executa com um pool de workers.
+type Fila struct {
+	store    Store
+	config   Config
+	handlers map[string]Handler
+	acordar  chan struct{}
+	aviso    func(ctx context.Context)
+}
+
+func NovaFila(store Store, config Config) *Fila {
+	return &Fila{store: store, config: config, handlers: map[string]Handler{}, acordar: make(chan struct{}, max(config.Workers, 1))}
+}
+
+// Registrar associa o handler ao tipo de job. Deve ser chamado antes de Executar.
+func (f *Fila) Registrar(tipo string, h Handler) {
+	f.handlers[tipo] = h
+}
+
+// AoEnfileirar registra uma funcao chamada depois de cada job gravado, para
+// avisar os workers das outras replicas (veja Acordar). Deve ser chamado
+// antes de Executar.
+func (f *Fila) AoEnfileirar(aviso func(ctx context.Context)) {
+	f.aviso = aviso
+}
+
+// Acordar faz os workers ociosos consultarem a fila sem esperar o Intervalo.
+func (f *Fila) Acordar() {
+	for i := 0; i < cap(f.acordar); i++ {
+		select {
+		case f.acordar <- struct{}{}:
+		default:
+			return
+		}
+	}
+}
+
+// Enfileirar grava um job do tipo informado para ser processado pelos workers.
+func (f *Fila) Enfileirar(ctx context.Context, tipo string, payload any) error {
+	return f.enfileirar(ctx, tipo, "", payload)
+}
+
+// EnfileirarUnico e como Enfileirar, mas ignora o job se outro com a mesma
+// chave ja foi enfileirado. Serve para quem pode entregar o mesmo pedido mais
+// de uma vez, como o relay da outbox.
+func (f *Fila) EnfileirarUnico(ctx context.Context, chave, tipo string, payload any) error {
+	return f.enfileirar(ctx, tipo, chave, payload)
+}
+
+func (f *Fila) enfileirar(ctx context.Context, tipo, chave string, payload any) error {
+	id, err := f.store.EnqueueJob(ctx, tipo, chave, payload, f.config.MaxTentativas)
+	if err == nil && id != 0 && f.aviso != nil {
+		f.aviso(ctx)
+	}
+	return err
+}
+
+// Executar roda os workers ate o contexto ser cancelado e espera o job em
+// andamento de cada um terminar. Junto com eles roda a manutencao da tabela.
+func (f *Fila) Executar(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		f.manter(ctx)
+	}()
+	for i := 0; i < f.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.worker(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (f *Fila) worker(ctx context.Context) {
+	for ctx.Err() == nil {
+		processou, err := f.ProcessarProximo(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("Erro ao consultar a fila de jobs", "erro", err)
+		}
+		if processou {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-f.acordar:
+		case <-time.After(f.config.Intervalo):
+		}
+	}
+}
+
+// manter devolve a fila, a cada meio lease, os jobs de workers que cairam e
+// apaga, a cada hora, os concluidos ha mais que a Retencao. Fica fora do
+// ClaimJob para que a reserva use so o indice dos pendentes.
+func (f *Fila) manter(ctx context.Context) {
+	var recuperacao <-chan time.Time
+	if f.config.Lease > 0 {
+		t := time.NewTicker(f.config.Lease / 2)
+		defer t.Stop()
+		recuperacao = t.C
+	}
+	limpeza := time.NewTicker(time.Hour)
+	defer limpeza.Stop()
+
+	f.limpar(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-recuperacao:
+			f.recuperar(ctx)
+		case <-limpeza.C:
+			f.limpar(ctx)
+		}
+	}
+}
+
+func (f *Fila) recuperar(ctx context.Context) {
+	if n, err := f.store.RecoverJobs(ctx, f.config.Lease); err != nil {
+		slog.Error("Erro ao recuperar jobs com a reserva vencida", "erro", err)
+	} else if n > 0 {
+		slog.Warn("Jobs com a reserva vencida devolvidos a fila", "jobs", n)
+	}
+}
+
+func (f *Fila) limpar(ctx context.Context) {
+	if f.config.Retencao <= 0 {
+		return
+	}
+	if n, err := f.store.PurgeJobs(ctx, time.Now().Add(-f.config.Retencao)); err != nil {
+		slog.Error("Erro ao limpar jobs concluidos", "erro", err)
+	} else if n > 0 {
+		slog.Info("Jobs concluidos removidos da fila", "jobs", n)
+	}
+}
+
+// ProcessarProximo reserva e executa um job. Devolve false quando a fila esta vazia.
+func (f *Fila) ProcessarProximo(ctx context.Context) (bool, error) {
+	job, err := f.store.ClaimJob(ctx)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	// O job ja reservado termina mesmo que o servico esteja desligando. Cada
+	// tentativa e um trace proprio, com as consultas e chamadas do handler.
+	ctxJob, span := otel.Tracer("helpdesk/tickets-service/internal/queue").Start(context.WithoutCancel(ctx), "job "+job.Tipo,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.Int64("job.id", job.ID), attribute.String("job.tipo", job.Tipo), attribute.Int("job.tentativa", job.Tentativas)))
+	defer span.End()
+	// Os logs do job levam o ID da requisicao que o causou.
+	if job.RequestID != "" {
+		ctxJob = registro.ComID(ctxJob, job.RequestID)
+	}
+	logger := registro.Logger(ctxJob).With("job_id", job.ID, "job_tipo", job.Tipo)
+	ctxJob = registro.ComLogger(ctxJob, logger)
+
+	// A execucao nao passa do lease: depois dele o job volta a fila e seria
+	// executado de novo por outro worker enquanto este ainda roda.
+	ctxExec, cancelar := ctxJob, context.CancelFunc(func() {})
+	if f.config.Lease > 0 {
+		ctxExec, cancelar = context.WithTimeout(ctxJob, f.config.Lease)
+	}
+	inicio := time.Now()
+	err = f.executar(ctxExec, job)
+	cancelar()
+	duracaoJob.WithLabelValues(job.Tipo).Observe(time.Since(inicio).Seconds())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	switch {
+	case err == nil:
+		processados.WithLabelValues(job.Tipo, resultadoSucesso).Inc()
+		err = f.store.CompleteJob(ctxJob, job)
+	case errors.As(err, new(erroPermanente)) || job.Tentativas >= job.MaxTentativas:
+		processados.WithLabelValues(job.Tipo, resultadoMorto).Inc()
+		logger.Error("Job movido para a fila de mortos", "tentativas", job.Tentativas, "erro", err)
+		err = f.store.KillJob(ctxJob, job, err.Error())
+	default:
+		processados.WithLabelValues(job.Tipo, resultadoRetentativa).Inc()
+		espera := Backoff(job.Tentativas, f.config.BackoffBase, f.config.BackoffMax)
+		logger.Warn("Job falhou, nova tentativa agendada", "tentativa", job.Tentativas, "espera", espera.Round(time.Second).String(), "erro", err)
+		err = f.store.RetryJobLater(ctxJob, job, time.Now().Add(espera), err.Error())
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		// A reserva venceu e o job ja voltou a fila: o resultado desta
+		// execucao e descartado para nao sobrescrever o da nova.
+		logger.Warn("Reserva do job vencida antes do fim da execucao, resultado descartado")
+		err = nil
+	}
+
+	return true, err
+}
+
+func (f *Fila) executar(ctx context.Context, job model.Job) (err error) {
+	h, ok := f.handlers[job.Tipo]
+	if !ok {
+		return Permanente(fmt.Errorf("tipo de job desconhecido: %s", job.Tipo))
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic no job: %v", p)
+		}
+	}()
+	return h(ctx, job.Payload)
+}
+
+// Backoff calcula a espera antes da proxima tentativa: base * 2^(tentativa-1),
+// limitada a max, com ate 20% de variacao aleatoria para espalhar as retentativas.
+func Backoff(tentativa int, base, max time.Duration) time.Duration {
+	espera := base
+	for i := 1; i < tentativa && espera < max; i++ {
+		espera *= 2
+	}
+	if espera > max {
+		espera = max
+	}
+	return espera - time.Duration(rand.Int64N(int64(espera)/5+1))
+}
@@ -0,0 +1,374 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/internal/model"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// storeMemoria imita a tabela jobs em memoria.
+type storeMemoria struct {
+	mu     sync.Mutex
+	jobs   []*model.Job
+	chaves map[string]bool
+}
+
+func (s *storeMemoria) EnqueueJob(ctx context.Context, tipo, chave string, payload any, maxTentativas int) (int64, error) {
+	dados, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if chave != "" {
+		if s.chaves == nil {
+			s.chaves = map[string]bool{}
+		}
+		if s.chaves[chave] {
+			return 0, nil
+		}
+		s.chaves[chave] = true
+	}
+	job := &model.Job{ID: int64(len(s.jobs) + 1), Tipo: tipo, Payload: dados, Status: model.JobPendente, MaxTentativas: maxTentativas, DisponivelEm: time.Now(), RequestID: registro.ID(ctx)}
+	s.jobs = append(s.jobs, job)
+	return job.ID, nil
+}
+
+func (s *storeMemoria) ClaimJob(ctx context.Context) (model.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.Status == model.JobPendente && !j.DisponivelEm.After(time.Now()) {
+			agora := time.Now()
+			j.Status = model.JobProcessando
+			j.Tentativas++
+			j.AtualizadoEm, j.ReservadoEm = agora, &agora
+			return *j, nil
+		}
+	}
+	return model.Job{}, pgx.ErrNoRows
+}
+
+func (s *storeMemoria) RecoverJobs(ctx context.Context, lease time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, j := range s.jobs {
+		if j.Status == model.JobProcessando && time.Since(*j.ReservadoEm) > lease {
+			j.Status, j.DisponivelEm, j.ReservadoEm = model.JobPendente, time.Now(), nil
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *storeMemoria) PurgeJobs(ctx context.Context, antesDe time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, j := range s.jobs {
+		if j.Status == model.JobConcluido && j.AtualizadoEm.Before(antesDe) {
+			j.Status = "apagado"
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *storeMemoria) atualizar(id int64, f func(*model.Job)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(s.jobs[id-1])
+	return nil
+}
+
+// atualizarReservado imita a condicao de CompleteJob, RetryJobLater e
+// KillJob: so muda o job que ainda tem a reserva de quem o executou.
+func (s *storeMemoria) atualizarReservado(job model.Job, f func(*model.Job)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j := s.jobs[job.ID-1]
+	if j.Status != model.JobProcessando || j.ReservadoEm == nil || !j.ReservadoEm.Equal(*job.ReservadoEm) {
+		return pgx.ErrNoRows
+	}
+	f(j)
+	j.ReservadoEm = nil
+	return nil
+}
+
+func (s *storeMemoria) CompleteJob(ctx context.Context, job model.Job) error {
+	return s.atualizarReservado(job, func(j *model.Job) { j.Status, j.AtualizadoEm = model.JobConcluido, time.Now() })
+}
+
+func (s *storeMemoria) RetryJobLater(ctx context.Context, job model.Job, disponivelEm time.Time, erro string) error {
+	return s.atualizarReservado(job, func(j *model.Job) {
+		j.Status, j.DisponivelEm, j.UltimoErro = model.JobPendente, disponivelEm, erro
+	})
+}
+
+func (s *storeMemoria) KillJob(ctx context.Context, job model.Job, erro string) error {
+	return s.atualizarReservado(job, func(j *model.Job) { j.Status, j.UltimoErro = model.JobMorto, erro })
+}
+
+func (s *storeMemoria) job(id int64) model.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.jobs[id-1]
+}
+
+// liberar torna o job disponivel imediatamente, pulando o backoff.
+func (s *storeMemoria) liberar(id int64) {
+	s.atualizar(id, func(j *model.Job) { j.DisponivelEm = time.Now() })
+}
+
+func TestFila_SucessoETipagem(t *testing.T) {
+	store := &storeMemoria{}
+	fila := NovaFila(store, Config{MaxTentativas: 3, BackoffBase: time.Minute, BackoffMax: time.Hour})
+
+	var recebido model.NotificationJob
+	fila.Registrar(model.TipoJobNotificacao, Tratar(func(ctx context.Context, job model.NotificationJob) error {
+		recebido = job
+		return nil
+	}))
+
+	ctx := context.Background()
+	assert.NoError(t, fila.Enfileirar(ctx, model.TipoJobNotificacao, model.NotificationJob{TicketID: 7, Evento: model.EventoTicketCriado}))
+
+	processou, err := fila.ProcessarProximo(ctx)
+	assert.NoError(t, err)
+	assert.True(t, processou)
+	assert.Equal(t, int64(7), recebido.TicketID)
+	assert.Equal(t, model.JobConcluido, store.job(1).Status)
+
+	// Fila vazia.
+	processou, err = fila.ProcessarProximo(ctx)
+	assert.NoError(t, err)
+	assert.False(t, processou)
+}
+
+func TestFila_SpanEIDDaRequisicao(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	anterior := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	defer otel.SetTracerProvider(anterior)
+
+	store := &storeMemoria{}
+	fila := NovaFila(store, Config{MaxTentativas: 3, BackoffBase: time.Minute, BackoffMax: time.Hour})
+	var dentroDoSpan bool
+	var idRequisicao string
+	fila.Registrar(model.TipoJobNotificacao, func(ctx context.Context, payload json.RawMessage) error {
+		dentroDoSpan = trace.SpanContextFromContext(ctx).IsValid()
+		idRequisicao = registro.ID(ctx)
+		return errors.New("smtp fora do ar")
+	})
+
+	// O job guarda o ID da requisicao que o enfileirou.
+	fila.Enfileirar(registro.ComID(context.Background(), "req-9"), model.TipoJobNotificacao, nil)
+	fila.ProcessarProximo(context.Background())
+
+	assert.True(t, dentroDoSpan, "o handler deveria rodar dentro do span do job")
+	assert.Equal(t, "req-9", idRequisicao)
+	if assert.Len(t, spans.Ended(), 1) {
+		span := spans.Ended()[0]
+		assert.Equal(t, "job notificacao", span.Name())
+		assert.Equal(t, codes.Error, span.Status().Code)
+	}
+}
+
+func TestFila_EnfileirarUnico(t *testing.T) {
+	store := &storeMemoria{}
+	fila := NovaFila(store, Config{MaxTentativas: 3})
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, fila.EnfileirarUnico(ctx, "evento-1", model.TipoJobNotificacao, model.NotificationJob{TicketID: 1}))
+	}
+	assert.NoError(t, fila.Enfileirar(ctx, model.TipoJobNotificacao, model.NotificationJob{TicketID: 2}))
+
+	// A chave repetida nao gera jobs duplicados.
+	assert.Len(t, store.jobs, 2)
+}
+
+func TestFila_AvisoEAcordar(t *testing.T) {
+	store := &storeMemoria{}
+	fila := NovaFila(store, Config{Workers: 2, Intervalo: time.Hour, MaxTentativas: 3})
+
+	processados := make(chan int64, 1)
+	fila.Registrar(model.TipoJobNotificacao, Tratar(func(ctx context.Context, job model.NotificationJob) error {
+		processados <- job.TicketID
+		return nil
+	}))
+	avisos := 0
+	fila.AoEnfileirar(func(ctx context.Context) { avisos++ })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	fim := make(chan struct{})
+	go func() {
+		fila.Executar(ctx)
+		close(fim)
+	}()
+	// Os workers encontram a fila vazia e dormem pelo Intervalo.
+	time.Sleep(50 * time.Millisecond)
+
+	assert.NoError(t, fila.EnfileirarUnico(ctx, "k", model.TipoJobNotificacao, model.NotificationJob{TicketID: 9}))
+	assert.NoError(t, fila.EnfileirarUnico(ctx, "k", model.TipoJobNotificacao, model.NotificationJob{TicketID: 9}))
+	assert.Equal(t, 1, avisos, "o job repetido nao gera aviso")
+
+	fila.Acordar()
+	select {
+	case id := <-processados:
+		assert.Equal(t, int64(9), id)
+	case <-time.After(2 * time.Second):
+		t.Fatal("job nao processado apos Acordar")
+	}
+
+	cancel()
+	<-fim
+}
+
+func TestFila_RetentativasEFilaDeMortos(t *testing.T) {
+	store := &storeMemoria{}
+	fila := NovaFila(store, Config{MaxTentativas: 3, BackoffBase: time.Minute, BackoffMax: time.Hour})
+	fila.Registrar("falha", func(ctx context.Context, payload json.RawMessage) error {
+		return errors.New("smtp fora do ar")
+	})
+
+	ctx := context.Background()
+	assert.NoError(t, fila.Enfileirar(ctx, "falha", map[string]int{"x": 1}))
+
+	// Primeira falha: volta para a fila com backoff.
+	fila.ProcessarProximo(ctx)
+	job := store.job(1)
+	assert.Equal(t, model.JobPendente, job.Status)
+	assert.Equal(t, "smtp fora do ar", job.UltimoErro)
+	assert.True(t, job.DisponivelEm.After(time.Now().Add(30*time.Second)), "o job deveria esperar o backoff")
+
+	// Durante o backoff ninguem pega o job.
+	processou, _ := fila.ProcessarProximo(ctx)
+	assert.False(t, processou)
+
+	store.liberar(1)
+	fila.ProcessarProximo(ctx)
+	store.liberar(1)
+	fila.ProcessarProximo(ctx)
+
+	// Na terceira tentativa esgotou: vai para a fila de mortos.
+	job = store.job(1)
+	assert.Equal(t, model.JobMorto, job.Status)
+	assert.Equal(t, 3, job.Tentativas)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(processados.WithLabelValues("falha", resultadoRetentativa)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(processados.WithLabelValues("falha", resultadoMorto)))
+}
+
+func TestFila_ErroPermanenteEPanic(t *testing.T) {
+	store := &storeMemoria{}
+	fila := NovaFila(store, Config{MaxTentativas: 5, BackoffBase: time.Minute, BackoffMax: time.Hour})
+	fila.Registrar(model.TipoJobNotificacao, Tratar(func(ctx context.Context, job model.NotificationJob) error { return nil }))
+	fila.Registrar("panico", func(ctx context.Context, payload json.RawMessage) error { panic("boom") })
+
+	ctx := context.Background()
+	fila.Enfileirar(ctx, model.TipoJobNotificacao, "payload que nao e um objeto")
+	fila.Enfileirar(ctx, "desconhecido", nil)
+	fila.Enfileirar(ctx, "panico", nil)
+
+	for i := 0; i < 3; i++ {
+		fila.ProcessarProximo(ctx)
+	}
+
+	// Payload invalido e tipo desconhecido nao adiantam nova tentativa.
+	assert.Equal(t, model.JobMorto, store.job(1).Status)
+	assert.Equal(t, model.JobMorto, store.job(2).Status)
+	// Um panic no handler vira erro comum e nao derruba o worker.
+	assert.Equal(t, model.JobPendente, store.job(3).Status)
+	assert.Contains(t, store.job(3).UltimoErro, "boom")
+}
+
+func TestFila_RecuperarELimpar(t *testing.T) {
+	store := &storeMemoria{}
+	fila := NovaFila(store, Config{MaxTentativas: 3, Lease: time.Minute, Retencao: time.Hour})
+	fila.Registrar(model.TipoJobNotificacao, Tratar(func(ctx context.Context, job model.NotificationJob) error { return nil }))
+
+	ctx := context.Background()
+	fila.Enfileirar(ctx, model.TipoJobNotificacao, model.NotificationJob{TicketID: 1})
+	fila.Enfileirar(ctx, model.TipoJobNotificacao, model.NotificationJob{TicketID: 2})
+
+	// O job 1 foi reservado por um worker que caiu; o 2 terminou ha tempos.
+	store.ClaimJob(ctx)
+	fila.ProcessarProximo(ctx)
+	store.atualizar(1, func(j *model.Job) { *j.ReservadoEm = time.Now().Add(-2 * time.Minute) })
+	store.atualizar(2, func(j *model.Job) { j.AtualizadoEm = time.Now().Add(-2 * time.Hour) })
+
+	// A reserva vencida nao e pega pelo ClaimJob; so volta pela recuperacao.
+	processou, _ := fila.ProcessarProximo(ctx)
+	assert.False(t, processou)
+	fila.recuperar(ctx)
+	assert.Equal(t, model.JobPendente, store.job(1).Status)
+
+	fila.limpar(ctx)
+	assert.Equal(t, "apagado", store.job(2).Status)
+	assert.Equal(t, model.JobPendente, store.job(1).Status)
+}
+
+func TestFila_ExecucaoLimitadaAoLease(t *testing.T) {
+	store := &storeMemoria{}
+	fila := NovaFila(store, Config{MaxTentativas: 3, BackoffBase: time.Minute, BackoffMax: time.Hour, Lease: 20 * time.Millisecond})
+	fila.Registrar(model.TipoJobNotificacao, Tratar(func(ctx context.Context, job model.NotificationJob) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	ctx := context.Background()
+	fila.Enfileirar(ctx, model.TipoJobNotificacao, model.NotificationJob{TicketID: 1})
+
+	processou, err := fila.ProcessarProximo(ctx)
+	assert.True(t, processou)
+	assert.NoError(t, err)
+	assert.Equal(t, model.JobPendente, store.job(1).Status)
+	assert.Contains(t, store.job(1).UltimoErro, "deadline")
+}
+
+func TestFila_ReservaVencidaNaoSobrescreve(t *testing.T) {
+	store := &storeMemoria{}
+	fila := NovaFila(store, Config{MaxTentativas: 3, Lease: time.Minute})
+	ctx := context.Background()
+	// Enquanto o job roda, a reserva vence e outro worker o pega de novo.
+	fila.Registrar(model.TipoJobNotificacao, Tratar(func(ctx context.Context, job model.NotificationJob) error {
+		store.atualizar(1, func(j *model.Job) { *j.ReservadoEm = time.Now().Add(-2 * time.Minute) })
+		store.RecoverJobs(ctx, time.Minute)
+		store.ClaimJob(ctx)
+		return nil
+	}))
+	fila.Enfileirar(ctx, model.TipoJobNotificacao, model.NotificationJob{TicketID: 1})
+
+	processou, err := fila.ProcessarProximo(ctx)
+	assert.True(t, processou)
+	assert.NoError(t, err)
+	// O primeiro worker nao marca como concluido o job que e do segundo.
+	assert.Equal(t, model.JobProcessando, store.job(1).Status)
+	assert.Equal(t, 2, store.job(1).Tentativas)
+}
+
+func TestBackoff(t *testing.T) {
+	base, max := 10*time.Second, time.Minute
+
+	for tentativa, esperado := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 3: 40 * time.Second, 4: time.Minute, 10: time.Minute} {
+		espera := Backoff(tentativa, base, max)
+		assert.LessOrEqual(t, espera, esperado, "tentativa %d", tentativa)
+		assert.GreaterOrEqual(t, espera, esperado*4/5, "tentativa %d", tentativa)
+	}
+}
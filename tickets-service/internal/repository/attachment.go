@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"helpdesk/tickets-service/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Repository) CreateAttachment(anexo model.Attachment) (int64, error) {
+	if err := s.db.QueryRow(context.Background(), "INSERT INTO anexos (ticket_id, comment_id, user_id, nome_arquivo, content_type, tamanho, sha256, chave_storage, status, motivo_quarentena, thumbnail_chave) VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), NULLIF($11, '')) returning id", anexo.TicketID, anexo.CommentID, anexo.UserID, anexo.NomeArquivo, anexo.ContentType, anexo.Tamanho, anexo.SHA256, anexo.ChaveStorage, anexo.Status, anexo.MotivoQuarentena, anexo.ThumbnailChave).Scan(&anexo.ID); err != nil {
+		return 0, err
+	}
+
+	return anexo.ID, nil
+}
+
+const selectAnexo = "SELECT a.id, a.ticket_id, COALESCE(a.comment_id, 0), a.user_id, a.nome_arquivo, a.content_type, a.tamanho, a.sha256, a.chave_storage, a.data, a.status, COALESCE(a.motivo_quarentena, ''), COALESCE(a.thumbnail_chave, ''), COALESCE(c.tipo = 'interno', FALSE) FROM anexos a LEFT JOIN comentarios c ON c.id = a.comment_id"
+
+func scanAnexo(row pgx.Row, a *model.Attachment) error {
+	return row.Scan(&a.ID, &a.TicketID, &a.CommentID, &a.UserID, &a.NomeArquivo, &a.ContentType, &a.Tamanho, &a.SHA256, &a.ChaveStorage, &a.Data, &a.Status, &a.MotivoQuarentena, &a.ThumbnailChave, &a.Interno)
+}
+
+func (s *Repository) GetAttachmentByID(id int64) (model.Attachment, error) {
+	var a model.Attachment
+	if err := scanAnexo(s.db.QueryRow(context.Background(), selectAnexo+" WHERE a.id=$1", id), &a); err != nil {
+		return model.Attachment{}, err
+	}
+
+	return a, nil
+}
+
+// ListAttachmentsByTicketID lista os anexos do ticket e de seus comentarios.
+// Anexos de notas internas e em quarentena so sao incluidos com incluirInternos.
+func (s *Repository) ListAttachmentsByTicketID(ticketID int64, incluirInternos bool) ([]model.Attachment, error) {
+	rows, err := s.db.Query(context.Background(), selectAnexo+" WHERE a.ticket_id=$1 AND ($2 OR (c.tipo IS DISTINCT FROM 'interno' AND a.status <> 'quarentena')) ORDER BY a.id", ticketID, incluirInternos)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lista := []model.Attachment{}
+	var a model.Attachment
+
+	for rows.Next() {
+		if err := scanAnexo(rows, &a); err != nil {
+			return nil, err
+		}
+		lista = append(lista, a)
+	}
+
+	return lista, rows.Err()
+}
+
+// CanViewTicket diz se o usuario participa do ticket sem ser o autor: em
+// copia, como watcher ou como manager da organizacao do autor.
+func (s *Repository) CanViewTicket(ticketID, userID int64) (bool, error) {
+	var pode bool
+	err := s.db.QueryRow(context.Background(), `SELECT
+		EXISTS (SELECT 1 FROM ticket_cc WHERE ticket_id=$1 AND user_id=$2)
+		OR EXISTS (SELECT 1 FROM ticket_watchers WHERE ticket_id=$1 AND user_id=$2)
+		OR EXISTS (
+			SELECT 1 FROM tickets t
+			JOIN organization_members autor ON autor.user_id = t.user_id
+			JOIN organization_members m ON m.organization_id = autor.organization_id
+			WHERE t.id=$1 AND m.user_id=$2 AND m.manager
+		)`, ticketID, userID).Scan(&pode)
+
+	return pode, err
+}
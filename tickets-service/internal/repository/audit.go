@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"context"
+	"helpdesk/tickets-service/internal/model"
+)
+
+func (s *Repository) RecordAudit(entrada model.AuditLog) error {
+	_, err := s.db.Exec(context.Background(), "INSERT INTO audit_logs (actor_id, user_id, servico, metodo, rota, caminho, status) VALUES ($1, $2, $3, $4, $5, $6, $7)", entrada.ActorID, entrada.UserID, entrada.Servico, entrada.Metodo, entrada.Rota, entrada.Caminho, entrada.Status)
+	return err
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const selectJob = `SELECT id, tipo, payload, status, tentativas, max_tentativas, disponivel_em, COALESCE(ultimo_erro, ''), criado_em, atualizado_em, COALESCE(request_id, ''), reservado_em FROM jobs`
+
+func scanJob(row pgx.Row) (model.Job, error) {
+	var j model.Job
+	err := row.Scan(&j.ID, &j.Tipo, &j.Payload, &j.Status, &j.Tentativas, &j.MaxTentativas, &j.DisponivelEm, &j.UltimoErro, &j.CriadoEm, &j.AtualizadoEm, &j.RequestID, &j.ReservadoEm)
+	return j, err
+}
+
+// EnqueueJob grava um job pendente. O payload e serializado em JSON. Uma
+// chave nao vazia torna o job idempotente: se ja existir um job com a mesma
+// chave, nada e gravado e o ID devolvido e zero. O ID de requisicao do
+// contexto vai junto, para os logs do job.
+func (s *Repository) EnqueueJob(ctx context.Context, tipo, chave string, payload any, maxTentativas int) (int64, error) {
+	dados, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	var chaveDB *string
+	if chave != "" {
+		chaveDB = &chave
+	}
+
+	var id int64
+	err = s.db.QueryRow(ctx, "INSERT INTO jobs (tipo, chave, payload, max_tentativas, request_id) VALUES ($1, $2, $3, $4, NULLIF($5, '')) ON CONFLICT (chave) DO NOTHING RETURNING id", tipo, chaveDB, dados, maxTentativas, registro.ID(ctx)).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return id, err
+}
+
+// ClaimJob reserva o proximo job disponivel com FOR UPDATE SKIP LOCKED, de
+// modo que varios workers (e varias replicas) nunca peguem o mesmo job. So
+// consulta os pendentes, pelo indice parcial idx_jobs_pendentes; os jobs de
+// workers que cairam voltam a fila por RecoverJobs. Devolve pgx.ErrNoRows
+// quando a fila esta vazia.
+func (s *Repository) ClaimJob(ctx context.Context) (model.Job, error) {
+	return scanJob(s.db.QueryRow(ctx, `UPDATE jobs SET status='processando', tentativas=tentativas+1, reservado_em=NOW(), atualizado_em=NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE status='pendente' AND disponivel_em <= NOW()
+			ORDER BY disponivel_em, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING id, tipo, payload, status, tentativas, max_tentativas, disponivel_em, COALESCE(ultimo_erro, ''), criado_em, atualizado_em, COALESCE(request_id, ''), reservado_em`))
+}
+
+// RecoverJobs devolve a fila os jobs reservados ha mais de lease: o worker
+// que os pegou caiu. A tentativa perdida continua contada.
+func (s *Repository) RecoverJobs(ctx context.Context, lease time.Duration) (int64, error) {
+	tag, err := s.db.Exec(ctx, "UPDATE jobs SET status='pendente', disponivel_em=NOW(), reservado_em=NULL, atualizado_em=NOW() WHERE status='processando' AND reservado_em < NOW() - make_interval(secs => $1)", lease.Seconds())
+	return tag.RowsAffected(), err
+}
+
+// PurgeJobs apaga os jobs concluidos antes de antesDe. Os mortos ficam para
+// analise e reenvio manual.
+func (s *Repository) PurgeJobs(ctx context.Context, antesDe time.Time) (int64, error) {
+	tag, err := s.db.Exec(ctx, "DELETE FROM jobs WHERE status='concluido' AND atualizado_em < $1", antesDe)
+	return tag.RowsAffected(), err
+}
+
+// CompleteJob, RetryJobLater e KillJob so alteram o job se ele ainda esta
+// com a reserva feita por quem o executou (job.ReservadoEm). Se a reserva
+// venceu e o job voltou a fila, ou ja foi pego por outro worker, nada muda e
+// o erro e pgx.ErrNoRows.
+func (s *Repository) CompleteJob(ctx context.Context, job model.Job) error {
+	return s.atualizarReservado(ctx, "UPDATE jobs SET status='concluido', ultimo_erro=NULL, atualizado_em=NOW() WHERE id=$1 AND status='processando' AND reservado_em=$2", job.ID, job.ReservadoEm)
+}
+
+// RetryJobLater devolve o job a fila para nova tentativa em disponivelEm.
+func (s *Repository) RetryJobLater(ctx context.Context, job model.Job, disponivelEm time.Time, erro string) error {
+	return s.atualizarReservado(ctx, "UPDATE jobs SET status='pendente', disponivel_em=$3, ultimo_erro=$4, reservado_em=NULL, atualizado_em=NOW() WHERE id=$1 AND status='processando' AND reservado_em=$2", job.ID, job.ReservadoEm, disponivelEm, erro)
+}
+
+// KillJob move o job para a fila de mortos (dead letter).
+func (s *Repository) KillJob(ctx context.Context, job model.Job, erro string) error {
+	return s.atualizarReservado(ctx, "UPDATE jobs SET status='morto', ultimo_erro=$3, reservado_em=NULL, atualizado_em=NOW() WHERE id=$1 AND status='processando' AND reservado_em=$2", job.ID, job.ReservadoEm, erro)
+}
+
+func (s *Repository) atualizarReservado(ctx context.Context, sql string, args ...any) error {
+	tag, err := s.db.Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ListJobs lista os jobs com o status informado, mais recentes primeiro.
+func (s *Repository) ListJobs(status string, limite int) ([]model.Job, error) {
+	rows, err := s.db.Query(context.Background(), selectJob+" WHERE status=$1 ORDER BY atualizado_em DESC, id DESC LIMIT $2", status, limite)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lista []model.Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		lista = append(lista, j)
+	}
+
+	return lista, rows.Err()
+}
+
+func (s *Repository) GetJobByID(id int64) (model.Job, error) {
+	return scanJob(s.db.QueryRow(context.Background(), selectJob+" WHERE id=$1", id))
+}
+
+// RequeueJob devolve um job morto a fila com as tentativas zeradas.
+// Devolve pgx.ErrNoRows se o job nao existe ou nao esta morto.
+func (s *Repository) RequeueJob(id int64) error {
+	tag, err := s.db.Exec(context.Background(), "UPDATE jobs SET status='pendente', tentativas=0, disponivel_em=NOW(), atualizado_em=NOW() WHERE id=$1 AND status='morto'", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// CountJobs conta os jobs por status, para o painel da fila.
+func (s *Repository) CountJobs() (map[string]int64, error) {
+	rows, err := s.db.Query(context.Background(), "SELECT status, COUNT(*) FROM jobs GROUP BY status")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contagem := map[string]int64{}
+	for rows.Next() {
+		var status string
+		var total int64
+		if err := rows.Scan(&status, &total); err != nil {
+			return nil, err
+		}
+		contagem[status] = total
+	}
+
+	return contagem, rows.Err()
+}
+
+// CountJobsByTipo conta os jobs que ainda nao terminaram bem (pendentes, em
+// processamento e mortos) por tipo e status. Os concluidos ficam de fora:
+// so crescem e nao dizem nada sobre a fila.
+func (s *Repository) CountJobsByTipo(ctx context.Context) ([]model.ContagemJobs, error) {
+	rows, err := s.db.Query(ctx, "SELECT tipo, status, COUNT(*) FROM jobs WHERE status <> $1 GROUP BY tipo, status", model.JobConcluido)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contagens []model.ContagemJobs
+	for rows.Next() {
+		var c model.ContagemJobs
+		if err := rows.Scan(&c.Tipo, &c.Status, &c.Total); err != nil {
+			return nil, err
+		}
+		contagens = append(contagens, c)
+	}
+
+	return contagens, rows.Err()
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"helpdesk/tickets-service/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ReplaceCommentMentions sincroniza as mencoes do comentario com userIDs e
+// devolve apenas os usuarios que passaram a ser mencionados agora, para que
+// quem ja tinha sido avisado nao seja notificado de novo ao editar o texto.
+// Os recem-mencionados tambem viram um evento comment.mentioned na outbox.
+func (s *Repository) ReplaceCommentMentions(ctx context.Context, comment model.Comentario, userIDs []int64) ([]int64, error) {
+	if userIDs == nil {
+		userIDs = []int64{}
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "DELETE FROM comment_mentions WHERE comment_id=$1 AND NOT (user_id = ANY($2))", comment.ID, userIDs); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, "INSERT INTO comment_mentions (comment_id, ticket_id, user_id) SELECT $1, $2, unnest($3::bigint[]) ON CONFLICT (comment_id, user_id) DO NOTHING RETURNING user_id", comment.ID, comment.TicketID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	novos, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(novos) > 0 {
+		if err = inserirEvento(ctx, tx, model.DominioComentarioMencionou, comment.TicketID, comment.UserID, model.CommentEventData{Comentario: comment, Mencionados: novos}); err != nil {
+			return nil, err
+		}
+	}
+
+	return novos, tx.Commit(ctx)
+}
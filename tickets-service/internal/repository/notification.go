@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"helpdesk/tickets-service/internal/model"
+)
+
+// GetNotificationPreferences devolve as preferencias do usuario, ou o padrao
+// (tudo habilitado, em portugues) se ele nunca as alterou. Nome e email ficam
+// no users-service; aqui so ha as preferencias.
+func (s *Repository) GetNotificationPreferences(ctx context.Context, userID int64) (model.NotificationPreferences, error) {
+	p := model.NotificationPreferences{UserID: userID}
+	err := s.db.QueryRow(ctx, `SELECT COALESCE(p.email_habilitado, TRUE), COALESCE(p.idioma, 'pt-BR'), COALESCE(p.eventos_desativados, '{}')
+		FROM (SELECT $1::bigint AS id) u LEFT JOIN notification_preferences p ON p.user_id = u.id`, userID).Scan(&p.EmailHabilitado, &p.Idioma, &p.EventosDesativados)
+
+	return p, err
+}
+
+func (s *Repository) UpsertNotificationPreferences(p model.NotificationPreferences) error {
+	if p.EventosDesativados == nil {
+		p.EventosDesativados = []string{}
+	}
+	_, err := s.db.Exec(context.Background(), `INSERT INTO notification_preferences (user_id, email_habilitado, idioma, eventos_desativados)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET email_habilitado=EXCLUDED.email_habilitado, idioma=EXCLUDED.idioma, eventos_desativados=EXCLUDED.eventos_desativados`,
+		p.UserID, p.EmailHabilitado, p.Idioma, p.EventosDesativados)
+
+	return err
+}
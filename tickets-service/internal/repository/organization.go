@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"helpdesk/tickets-service/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetOrganizationMembership retorna a organizacao do usuario ou pgx.ErrNoRows
+// quando ele nao pertence a nenhuma.
+func (s *Repository) GetOrganizationMembership(userID int64) (model.OrganizationMember, error) {
+	var m model.OrganizationMember
+	if err := s.db.QueryRow(context.Background(), "SELECT organization_id, user_id, manager FROM organization_members WHERE user_id=$1", userID).Scan(&m.OrganizationID, &m.UserID, &m.Manager); err != nil {
+		return model.OrganizationMember{}, err
+	}
+
+	return m, nil
+}
+
+func (s *Repository) ListTicketsByOrganization(orgID int64, filtro model.TicketFilter) ([]model.Ticket, error) {
+	where, args := filtroWhere(filtro, []string{"user_id IN (SELECT user_id FROM organization_members WHERE organization_id=$1)"}, []any{orgID})
+
+	rows, err := s.db.Query(context.Background(), "SELECT * FROM tickets"+where+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lista []model.Ticket
+	var ticket model.Ticket
+
+	for rows.Next() {
+		if err := rows.Scan(&ticket.ID, &ticket.Titulo, &ticket.Descricao, &ticket.Status, &ticket.Diagnostico, &ticket.Solucao, &ticket.Prioridade, &ticket.DataAbertura, &ticket.DataFechamento, &ticket.DataAtualizacao, &ticket.Anexos, &ticket.Tags, &ticket.CategoriaID, &ticket.ResponsavelID, &ticket.UserID); err != nil {
+			return nil, err
+		}
+		lista = append(lista, ticket)
+	}
+
+	return lista, rows.Err()
+}
+
+func (s *Repository) AddTicketCC(ticketID, userID int64) error {
+	_, err := s.db.Exec(context.Background(), "INSERT INTO ticket_cc (ticket_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", ticketID, userID)
+	return err
+}
+
+func (s *Repository) RemoveTicketCC(ticketID, userID int64) error {
+	row, err := s.db.Exec(context.Background(), "DELETE FROM ticket_cc WHERE ticket_id=$1 AND user_id=$2", ticketID, userID)
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() != 1 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+func (s *Repository) ListTicketCC(ticketID int64) ([]int64, error) {
+	rows, err := s.db.Query(context.Background(), "SELECT user_id FROM ticket_cc WHERE ticket_id=$1 ORDER BY user_id", ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"helpdesk/pkg/auditoria"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// chaveLockOutbox e o advisory lock que garante um unico relay publicando
+// por vez, mesmo com varias replicas, para manter a ordem dos eventos.
+const chaveLockOutbox = 4_600_036
+
+// inserirEvento grava o evento de dominio na mesma transacao da escrita que o
+// originou: ou os dois sao gravados, ou nenhum. Sob personificacao, o admin
+// que fez a requisicao vai em ator_real_id.
+func inserirEvento(ctx context.Context, tx pgx.Tx, tipo string, ticketID, atorID int64, dados any) error {
+	payload, err := json.Marshal(dados)
+	if err != nil {
+		return err
+	}
+
+	var ator, atorReal *int64
+	if atorID != 0 {
+		ator = &atorID
+	}
+	if _, realID := auditoria.Ator(ctx); realID != 0 && realID != atorID {
+		atorReal = &realID
+	}
+	_, err = tx.Exec(ctx, "INSERT INTO outbox_events (tipo, ticket_id, ator_id, ator_real_id, dados, request_id) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))", tipo, ticketID, ator, atorReal, payload, registro.ID(ctx))
+	return err
+}
+
+func scanEvento(row pgx.CollectableRow) (model.DomainEvent, error) {
+	var e model.DomainEvent
+	err := row.Scan(&e.ID, &e.Tipo, &e.TicketID, &e.AtorID, &e.AtorRealID, &e.Dados, &e.CriadoEm, &e.RequestID)
+	return e, err
+}
+
+// PublishOutbox entrega os eventos ainda nao publicados, em ordem de ID, a
+// funcao publicar e marca cada um como publicado logo depois. Para no primeiro
+// erro, de modo que o evento que falhou (e os seguintes) sejam entregues de
+// novo na proxima chamada. Se outro relay estiver publicando, nao faz nada.
+func (s *Repository) PublishOutbox(ctx context.Context, limite int, publicar func(model.DomainEvent) error) (int, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var obtido bool
+	if err = tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", chaveLockOutbox).Scan(&obtido); err != nil || !obtido {
+		return 0, err
+	}
+
+	rows, err := tx.Query(ctx, "SELECT id, tipo, ticket_id, COALESCE(ator_id, 0), COALESCE(ator_real_id, 0), dados, criado_em, COALESCE(request_id, '') FROM outbox_events WHERE publicado_em IS NULL ORDER BY id LIMIT $1", limite)
+	if err != nil {
+		return 0, err
+	}
+	eventos, err := pgx.CollectRows(rows, scanEvento)
+	if err != nil {
+		return 0, err
+	}
+
+	publicados := 0
+	var erroPublicacao error
+	for _, e := range eventos {
+		if erroPublicacao = publicar(e); erroPublicacao != nil {
+			break
+		}
+		if _, err = tx.Exec(ctx, "UPDATE outbox_events SET publicado_em=NOW() WHERE id=$1", e.ID); err != nil {
+			return 0, err
+		}
+		publicados++
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return publicados, erroPublicacao
+}
+
+// PurgeOutbox apaga os eventos ja publicados antes de antesDe.
+func (s *Repository) PurgeOutbox(ctx context.Context, antesDe time.Time) (int64, error) {
+	tag, err := s.db.Exec(ctx, "DELETE FROM outbox_events WHERE publicado_em IS NOT NULL AND publicado_em < $1", antesDe)
+	return tag.RowsAffected(), err
+}
+
+// ListOutboxEventsAfter devolve, em ordem, os eventos ja publicados com ID
+// maior que id. Serve para retomar um stream; so alcanca o periodo de retencao.
+func (s *Repository) ListOutboxEventsAfter(ctx context.Context, id int64, limite int) ([]model.DomainEvent, error) {
+	rows, err := s.db.Query(ctx, "SELECT id, tipo, ticket_id, COALESCE(ator_id, 0), COALESCE(ator_real_id, 0), dados, criado_em, COALESCE(request_id, '') FROM outbox_events WHERE id > $1 AND publicado_em IS NOT NULL ORDER BY id LIMIT $2", id, limite)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanEvento)
+}
+
+// GetOutboxEvent busca um evento pelo ID, publicado ou nao.
+func (s *Repository) GetOutboxEvent(ctx context.Context, id int64) (model.DomainEvent, error) {
+	rows, err := s.db.Query(ctx, "SELECT id, tipo, ticket_id, COALESCE(ator_id, 0), COALESCE(ator_real_id, 0), dados, criado_em, COALESCE(request_id, '') FROM outbox_events WHERE id=$1", id)
+	if err != nil {
+		return model.DomainEvent{}, err
+	}
+	return pgx.CollectExactlyOneRow(rows, scanEvento)
+}
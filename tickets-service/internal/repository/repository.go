@@ -2,11 +2,10 @@ package repository
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"helpdesk/tickets-service/internal/model"
-	"log"
+	"strings"
 
-	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -20,20 +19,31 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	}
 }
 
-func (s *Repository) CreateTicket(ticket model.Ticket) (int64, error) {
-	if err := s.db.QueryRow(context.Background(), "INSERT INTO tickets (titulo, descricao, status, diagnostico, solucao, prioridade, data_abertura, data_fechamento, data_atualizacao, anexos, tags, categoria_id, responsavel_id, user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) returning id", ticket.Titulo, ticket.Descricao, ticket.Status, ticket.Diagnostico, ticket.Solucao, ticket.Prioridade, ticket.DataAbertura, ticket.DataFechamento, ticket.DataAtualizacao, ticket.Anexos, ticket.Tags, ticket.CategoriaID, ticket.ResponsavelID, ticket.UserID).Scan(&ticket.ID); err != nil {
-		go func() {
-			log.Printf("Erro ao adicionar ticket no banco de dados: %v", err)
-		}()
+var _ model.TicketRepository = (*Repository)(nil)
+
+func (s *Repository) CreateTicket(ctx context.Context, ticket model.Ticket) (int64, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.QueryRow(ctx, "INSERT INTO tickets (titulo, descricao, status, diagnostico, solucao, prioridade, data_abertura, data_fechamento, data_atualizacao, anexos, tags, categoria_id, responsavel_id, user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) returning id", ticket.Titulo, ticket.Descricao, ticket.Status, ticket.Diagnostico, ticket.Solucao, ticket.Prioridade, ticket.DataAbertura, ticket.DataFechamento, ticket.DataAtualizacao, ticket.Anexos, ticket.Tags, ticket.CategoriaID, ticket.ResponsavelID, ticket.UserID).Scan(&ticket.ID); err != nil {
 		return 0, err
 	}
-	return ticket.ID, nil
+
+	if err = inserirEvento(ctx, tx, model.DominioTicketCriado, ticket.ID, ticket.UserID, model.TicketEventData{Ticket: ticket}); err != nil {
+		return 0, err
+	}
+
+	return ticket.ID, tx.Commit(ctx)
 }
 
-func (s *Repository) ListTickets() ([]model.Ticket, error) {
+func (s *Repository) ListTickets(ctx context.Context, filtro model.TicketFilter) ([]model.Ticket, error) {
 	var lista []model.Ticket
 	var ticket model.Ticket
-	rows, err := s.db.Query(context.Background(), "SELECT * FROM tickets")
+	where, args := filtroWhere(filtro, nil, nil)
+	rows, err := s.db.Query(ctx, "SELECT * FROM tickets"+where+" ORDER BY id", args...)
 	if err != nil {
 		return nil, err
 	}
@@ -48,9 +58,9 @@ func (s *Repository) ListTickets() ([]model.Ticket, error) {
 	return lista, nil
 }
 
-func (s *Repository) GetTicketByID(id int) (model.Ticket, error) {
+func (s *Repository) GetTicketByID(ctx context.Context, id int) (model.Ticket, error) {
 	var ticket model.Ticket
-	if err := s.db.QueryRow(context.Background(), "SELECT * FROM tickets WHERE id=$1", id).Scan(&ticket.ID, &ticket.Titulo, &ticket.Descricao, &ticket.Status, &ticket.Diagnostico, &ticket.Solucao, &ticket.Prioridade, &ticket.DataAbertura, &ticket.DataFechamento, &ticket.DataAtualizacao, &ticket.Anexos, &ticket.Tags, &ticket.CategoriaID, &ticket.ResponsavelID, &ticket.UserID); err != nil {
+	if err := s.db.QueryRow(ctx, "SELECT * FROM tickets WHERE id=$1", id).Scan(&ticket.ID, &ticket.Titulo, &ticket.Descricao, &ticket.Status, &ticket.Diagnostico, &ticket.Solucao, &ticket.Prioridade, &ticket.DataAbertura, &ticket.DataFechamento, &ticket.DataAtualizacao, &ticket.Anexos, &ticket.Tags, &ticket.CategoriaID, &ticket.ResponsavelID, &ticket.UserID); err != nil {
 		return ticket, err
 	}
 
@@ -76,62 +86,138 @@ func (s *Repository) GetTicketByUser(id int) ([]model.Ticket, error) {
 	return lista, nil
 }
 
-func (s *Repository) UpdateTicket(id int, ticket model.Ticket) error {
-	_, err := s.db.Exec(context.Background(), "UPDATE tickets SET titulo=$1, descricao=$2, status=$3, diagnostico=$4, solucao=$5, prioridade=$6, data_abertura=$7, data_fechamento=$8, data_atualizacao=$9, anexos=$10, tags=$11, categoria_id=$12, responsavel_id=$13, user_id=$14 WHERE id=$15", &ticket.Titulo, &ticket.Descricao, &ticket.Status, &ticket.Diagnostico, &ticket.Solucao, &ticket.Prioridade, &ticket.DataAbertura, &ticket.DataFechamento, &ticket.DataAtualizacao, &ticket.Anexos, &ticket.Tags, &ticket.CategoriaID, &ticket.ResponsavelID, &ticket.UserID, id)
-	if errors.Is(err, pgx.ErrNoRows) {
-		return pgx.ErrNoRows
-	} else if err != nil {
+// CountOpenTickets conta os tickets ainda nao resolvidos nem fechados por
+// status e prioridade. Status e prioridade nulos contam como vazios; os
+// valores vem crus e o pacote indicadores os reduz aos conhecidos.
+func (s *Repository) CountOpenTickets(ctx context.Context) ([]model.ContagemTickets, error) {
+	rows, err := s.db.Query(ctx, `SELECT COALESCE(status, ''), COALESCE(prioridade, ''), COUNT(*) FROM tickets
+		WHERE COALESCE(status, '') NOT IN ('resolvido', 'fechado') GROUP BY 1, 2`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contagens []model.ContagemTickets
+	for rows.Next() {
+		var c model.ContagemTickets
+		if err := rows.Scan(&c.Status, &c.Prioridade, &c.Total); err != nil {
+			return nil, err
+		}
+		contagens = append(contagens, c)
+	}
+
+	return contagens, rows.Err()
+}
+
+// UpdateTicket grava o ticket e o evento correspondente a mudanca: troca de
+// status, de responsavel ou edicao dos demais campos.
+func (s *Repository) UpdateTicket(ctx context.Context, id int, ticket model.Ticket, atorID int64) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var anterior model.Ticket
+	if err = tx.QueryRow(ctx, "SELECT * FROM tickets WHERE id=$1 FOR UPDATE", id).Scan(&anterior.ID, &anterior.Titulo, &anterior.Descricao, &anterior.Status, &anterior.Diagnostico, &anterior.Solucao, &anterior.Prioridade, &anterior.DataAbertura, &anterior.DataFechamento, &anterior.DataAtualizacao, &anterior.Anexos, &anterior.Tags, &anterior.CategoriaID, &anterior.ResponsavelID, &anterior.UserID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE tickets SET titulo=$1, descricao=$2, status=$3, diagnostico=$4, solucao=$5, prioridade=$6, data_abertura=$7, data_fechamento=$8, data_atualizacao=$9, anexos=$10, tags=$11, categoria_id=$12, responsavel_id=$13, user_id=$14 WHERE id=$15", &ticket.Titulo, &ticket.Descricao, &ticket.Status, &ticket.Diagnostico, &ticket.Solucao, &ticket.Prioridade, &ticket.DataAbertura, &ticket.DataFechamento, &ticket.DataAtualizacao, &ticket.Anexos, &ticket.Tags, &ticket.CategoriaID, &ticket.ResponsavelID, &ticket.UserID, id)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	ticket.ID = int64(id)
+	emitido := false
+	if anterior.Status != ticket.Status {
+		if err = inserirEvento(ctx, tx, model.DominioStatusAlterado, ticket.ID, atorID, model.TicketEventData{Ticket: ticket, StatusAnterior: anterior.Status}); err != nil {
+			return err
+		}
+		emitido = true
+	}
+	if anterior.ResponsavelID != ticket.ResponsavelID {
+		if err = inserirEvento(ctx, tx, model.DominioTicketAtribuido, ticket.ID, atorID, model.TicketEventData{Ticket: ticket, ResponsavelAnterior: anterior.ResponsavelID}); err != nil {
+			return err
+		}
+		emitido = true
+	}
+	if !emitido || camposAlterados(anterior, ticket) {
+		if err = inserirEvento(ctx, tx, model.DominioTicketAtualizado, ticket.ID, atorID, model.TicketEventData{Ticket: ticket}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// camposAlterados diz se algo alem de status e responsavel mudou no ticket.
+func camposAlterados(a, b model.Ticket) bool {
+	return a.Titulo != b.Titulo || a.Descricao != b.Descricao || a.Diagnostico != b.Diagnostico ||
+		a.Solucao != b.Solucao || a.Prioridade != b.Prioridade || a.CategoriaID != b.CategoriaID ||
+		strings.Join(a.Tags, "\x00") != strings.Join(b.Tags, "\x00")
 }
 
-func (s *Repository) DeleteTicket(id int) error {
-	row, err := s.db.Exec(context.Background(), "DELETE FROM tickets WHERE id=$1", id)
+func (s *Repository) DeleteTicket(ctx context.Context, id int, atorID int64) error {
+	tx, err := s.db.Begin(ctx)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback(ctx)
 
-	if row.RowsAffected() != 1 {
-		return pgx.ErrNoRows
+	var ticket model.Ticket
+	if err = tx.QueryRow(ctx, "DELETE FROM tickets WHERE id=$1 RETURNING *", id).Scan(&ticket.ID, &ticket.Titulo, &ticket.Descricao, &ticket.Status, &ticket.Diagnostico, &ticket.Solucao, &ticket.Prioridade, &ticket.DataAbertura, &ticket.DataFechamento, &ticket.DataAtualizacao, &ticket.Anexos, &ticket.Tags, &ticket.CategoriaID, &ticket.ResponsavelID, &ticket.UserID); err != nil {
+		return err
+	}
+
+	if err = inserirEvento(ctx, tx, model.DominioTicketExcluido, ticket.ID, atorID, model.TicketEventData{Ticket: ticket}); err != nil {
+		return err
 	}
 
-	return nil
+	return tx.Commit(ctx)
 }
 
-func (s *Repository) CreateComment(comment model.Comentario) (int64, error) {
-	if err := s.db.QueryRow(context.Background(), "INSERT INTO comentarios (descricao, data, user_id, ticket_id) VALUES ($1, $2, $3, $4) returning id", comment.Descricao, comment.Data, comment.UserID, comment.TicketID).Scan(&comment.ID); err != nil {
-		go func() {
-			log.Printf("Erro ao adicionar comentario no banco de dados: %v", err)
-		}()
+func (s *Repository) CreateComment(ctx context.Context, comment model.Comentario) (int64, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
 		return 0, err
 	}
+	defer tx.Rollback(ctx)
 
-	return comment.ID, nil
+	if err := tx.QueryRow(ctx, "INSERT INTO comentarios (descricao, data, user_id, ticket_id, tipo) VALUES ($1, $2, $3, $4, $5) returning id", comment.Descricao, comment.Data, comment.UserID, comment.TicketID, comment.Tipo).Scan(&comment.ID); err != nil {
+		return 0, err
+	}
+
+	if err = inserirEvento(ctx, tx, model.DominioTicketComentado, comment.TicketID, comment.UserID, model.CommentEventData{Comentario: comment}); err != nil {
+		return 0, err
+	}
+
+	return comment.ID, tx.Commit(ctx)
 }
 
 func (s *Repository) GetCommentByID(id int) (model.Comentario, error) {
 	var comentario model.Comentario
 
-	if err := s.db.QueryRow(context.Background(), "SELECT * FROM comentarios WHERE id=$1", id).Scan(&comentario.ID, &comentario.Descricao, &comentario.Data, &comentario.UserID, &comentario.TicketID); err != nil {
+	if err := s.db.QueryRow(context.Background(), "SELECT * FROM comentarios WHERE id=$1", id).Scan(&comentario.ID, &comentario.Descricao, &comentario.Data, &comentario.UserID, &comentario.TicketID, &comentario.Tipo); err != nil {
 		return model.Comentario{}, err
 	}
 
 	return comentario, nil
 }
 
-func (s *Repository) ListCommentsByTicketID(id int) ([]model.Comentario, error) {
+// ListCommentsByTicketID lista os comentarios do ticket. Notas internas so
+// sao incluidas quando incluirInternos for verdadeiro.
+func (s *Repository) ListCommentsByTicketID(id int, incluirInternos bool) ([]model.Comentario, error) {
 	var lista []model.Comentario
 	var comentario model.Comentario
 
-	rows, err := s.db.Query(context.Background(), "SELECT * FROM comentarios WHERE ticket_id=$1", id)
+	rows, err := s.db.Query(context.Background(), "SELECT * FROM comentarios WHERE ticket_id=$1 AND ($2 OR tipo='publico')", id, incluirInternos)
 	if err != nil {
 		return nil, err
 	}
 
 	for rows.Next() {
-		if err := rows.Scan(&comentario.ID, &comentario.Descricao, &comentario.Data, &comentario.UserID, &comentario.TicketID); err != nil {
+		if err := rows.Scan(&comentario.ID, &comentario.Descricao, &comentario.Data, &comentario.UserID, &comentario.TicketID, &comentario.Tipo); err != nil {
 			return nil, err
 		}
 		lista = append(lista, comentario)
@@ -140,17 +226,17 @@ func (s *Repository) ListCommentsByTicketID(id int) ([]model.Comentario, error)
 	return lista, nil
 }
 
-func (s *Repository) ListCommentsByUserID(id int) ([]model.Comentario, error) {
+func (s *Repository) ListCommentsByUserID(id int, incluirInternos bool) ([]model.Comentario, error) {
 	var lista []model.Comentario
 	var comentario model.Comentario
 
-	rows, err := s.db.Query(context.Background(), "SELECT * FROM comentarios WHERE user_id=$1", id)
+	rows, err := s.db.Query(context.Background(), "SELECT * FROM comentarios WHERE user_id=$1 AND ($2 OR tipo='publico')", id, incluirInternos)
 	if err != nil {
 		return nil, err
 	}
 
 	for rows.Next() {
-		if err := rows.Scan(&comentario.ID, &comentario.Descricao, &comentario.Data, &comentario.UserID, &comentario.TicketID); err != nil {
+		if err := rows.Scan(&comentario.ID, &comentario.Descricao, &comentario.Data, &comentario.UserID, &comentario.TicketID, &comentario.Tipo); err != nil {
 			return nil, err
 		}
 		lista = append(lista, comentario)
@@ -159,23 +245,69 @@ func (s *Repository) ListCommentsByUserID(id int) ([]model.Comentario, error) {
 	return lista, nil
 }
 
-func (s *Repository) UpdateComment(id int, comment model.Comentario) error {
-	_, err := s.db.Exec(context.Background(), "UPDATE comentarios SET descricao=$1, data=$2, user_id=$3 WHERE id=$4", &comment.Descricao, &comment.Data, &comment.UserID, id)
-	if errors.Is(err, pgx.ErrNoRows) {
+func (s *Repository) UpdateComment(ctx context.Context, id int, comment model.Comentario) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
 		return err
-	} else if err != nil {
+	}
+	defer tx.Rollback(ctx)
+
+	if err = tx.QueryRow(ctx, "UPDATE comentarios SET descricao=$1, data=$2, user_id=$3 WHERE id=$4 RETURNING id, ticket_id, tipo", &comment.Descricao, &comment.Data, &comment.UserID, id).Scan(&comment.ID, &comment.TicketID, &comment.Tipo); err != nil {
 		return err
 	}
 
-	return nil
+	if err = inserirEvento(ctx, tx, model.DominioComentarioAtualizado, comment.TicketID, comment.UserID, model.CommentEventData{Comentario: comment}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
-func (s *Repository) DeleteComment(id int) error {
-	_, err := s.db.Exec(context.Background(), "DELETE FROM comentarios WHERE id=$1", id)
-	if errors.Is(err, pgx.ErrNoRows) {
-		return pgx.ErrNoRows
-	} else if err != nil {
+func (s *Repository) DeleteComment(ctx context.Context, id int, atorID int64) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
 		return err
 	}
-	return nil
+	defer tx.Rollback(ctx)
+
+	var comment model.Comentario
+	if err = tx.QueryRow(ctx, "DELETE FROM comentarios WHERE id=$1 RETURNING id, descricao, data, user_id, ticket_id, tipo", id).Scan(&comment.ID, &comment.Descricao, &comment.Data, &comment.UserID, &comment.TicketID, &comment.Tipo); err != nil {
+		return err
+	}
+
+	if err = inserirEvento(ctx, tx, model.DominioComentarioExcluido, comment.TicketID, atorID, model.CommentEventData{Comentario: comment}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// filtroWhere acrescenta as condicoes do filtro as condicoes ja existentes e
+// devolve a clausula WHERE pronta com os argumentos na ordem dos placeholders.
+func filtroWhere(filtro model.TicketFilter, conds []string, args []any) (string, []any) {
+	add := func(coluna string, valor any) {
+		args = append(args, valor)
+		conds = append(conds, fmt.Sprintf("%s=$%d", coluna, len(args)))
+	}
+
+	if filtro.Status != "" {
+		add("status", filtro.Status)
+	}
+	if filtro.Prioridade != "" {
+		add("prioridade", filtro.Prioridade)
+	}
+	if filtro.CategoriaID != 0 {
+		add("categoria_id", filtro.CategoriaID)
+	}
+	if filtro.ResponsavelID != 0 {
+		add("responsavel_id", filtro.ResponsavelID)
+	}
+	if filtro.UserID != 0 {
+		add("user_id", filtro.UserID)
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
 }
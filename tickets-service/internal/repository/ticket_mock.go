@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"helpdesk/tickets-service/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTicketRepository substitui o Repository nos testes dos handlers. O
+// contexto nao entra nas expectativas.
+type MockTicketRepository struct {
+	mock.Mock
+}
+
+func (m *MockTicketRepository) AddTicketCC(ticketID, userID int64) error {
+	args := m.Called(ticketID, userID)
+	return args.Error(0)
+}
+
+func (m *MockTicketRepository) AddWatcher(ticketID, userID int64) error {
+	args := m.Called(ticketID, userID)
+	return args.Error(0)
+}
+
+func (m *MockTicketRepository) CanViewTicket(ticketID, userID int64) (bool, error) {
+	args := m.Called(ticketID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTicketRepository) CountJobs() (map[string]int64, error) {
+	args := m.Called()
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (m *MockTicketRepository) CreateAttachment(anexo model.Attachment) (int64, error) {
+	args := m.Called(anexo)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTicketRepository) CreateComment(ctx context.Context, comment model.Comentario) (int64, error) {
+	args := m.Called(comment)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTicketRepository) CreateTicket(ctx context.Context, ticket model.Ticket) (int64, error) {
+	args := m.Called(ticket)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTicketRepository) CreateWebhook(w model.Webhook) (model.Webhook, error) {
+	args := m.Called(w)
+	return args.Get(0).(model.Webhook), args.Error(1)
+}
+
+func (m *MockTicketRepository) DeleteComment(ctx context.Context, id int, atorID int64) error {
+	args := m.Called(id, atorID)
+	return args.Error(0)
+}
+
+func (m *MockTicketRepository) DeleteTicket(ctx context.Context, id int, atorID int64) error {
+	args := m.Called(id, atorID)
+	return args.Error(0)
+}
+
+func (m *MockTicketRepository) DeleteWebhook(id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockTicketRepository) FollowsTicket(ctx context.Context, ticketID, userID int64) (bool, error) {
+	args := m.Called(ticketID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTicketRepository) GetAttachmentByID(id int64) (model.Attachment, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.Attachment), args.Error(1)
+}
+
+func (m *MockTicketRepository) GetCommentByID(id int) (model.Comentario, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.Comentario), args.Error(1)
+}
+
+func (m *MockTicketRepository) GetJobByID(id int64) (model.Job, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.Job), args.Error(1)
+}
+
+func (m *MockTicketRepository) GetNotificationPreferences(ctx context.Context, userID int64) (model.NotificationPreferences, error) {
+	args := m.Called(userID)
+	return args.Get(0).(model.NotificationPreferences), args.Error(1)
+}
+
+func (m *MockTicketRepository) GetOrganizationMembership(userID int64) (model.OrganizationMember, error) {
+	args := m.Called(userID)
+	return args.Get(0).(model.OrganizationMember), args.Error(1)
+}
+
+func (m *MockTicketRepository) GetTicketByID(ctx context.Context, id int) (model.Ticket, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.Ticket), args.Error(1)
+}
+
+func (m *MockTicketRepository) GetTicketByUser(id int) ([]model.Ticket, error) {
+	args := m.Called(id)
+	return args.Get(0).([]model.Ticket), args.Error(1)
+}
+
+func (m *MockTicketRepository) GetWebhookByID(ctx context.Context, id int64) (model.Webhook, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.Webhook), args.Error(1)
+}
+
+func (m *MockTicketRepository) ListAttachmentsByTicketID(ticketID int64, incluirInternos bool) ([]model.Attachment, error) {
+	args := m.Called(ticketID, incluirInternos)
+	return args.Get(0).([]model.Attachment), args.Error(1)
+}
+
+func (m *MockTicketRepository) ListCommentsByTicketID(id int, incluirInternos bool) ([]model.Comentario, error) {
+	args := m.Called(id, incluirInternos)
+	return args.Get(0).([]model.Comentario), args.Error(1)
+}
+
+func (m *MockTicketRepository) ListCommentsByUserID(id int, incluirInternos bool) ([]model.Comentario, error) {
+	args := m.Called(id, incluirInternos)
+	return args.Get(0).([]model.Comentario), args.Error(1)
+}
+
+func (m *MockTicketRepository) ListJobs(status string, limite int) ([]model.Job, error) {
+	args := m.Called(status, limite)
+	return args.Get(0).([]model.Job), args.Error(1)
+}
+
+func (m *MockTicketRepository) ListOutboxEventsAfter(ctx context.Context, id int64, limite int) ([]model.DomainEvent, error) {
+	args := m.Called(id, limite)
+	return args.Get(0).([]model.DomainEvent), args.Error(1)
+}
+
+func (m *MockTicketRepository) ListTicketCC(ticketID int64) ([]int64, error) {
+	args := m.Called(ticketID)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockTicketRepository) ListTickets(ctx context.Context, filtro model.TicketFilter) ([]model.Ticket, error) {
+	args := m.Called(filtro)
+	return args.Get(0).([]model.Ticket), args.Error(1)
+}
+
+func (m *MockTicketRepository) ListTicketsByOrganization(orgID int64, filtro model.TicketFilter) ([]model.Ticket, error) {
+	args := m.Called(orgID, filtro)
+	return args.Get(0).([]model.Ticket), args.Error(1)
+}
+
+func (m *MockTicketRepository) ListWatchedTickets(userID int64) ([]model.Ticket, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.Ticket), args.Error(1)
+}
+
+func (m *MockTicketRepository) ListWatchers(ticketID int64) ([]int64, error) {
+	args := m.Called(ticketID)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockTicketRepository) ListWebhookDeliveries(webhookID int64, limite int) ([]model.WebhookDelivery, error) {
+	args := m.Called(webhookID, limite)
+	return args.Get(0).([]model.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockTicketRepository) ListWebhooks() ([]model.Webhook, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Webhook), args.Error(1)
+}
+
+func (m *MockTicketRepository) RemoveTicketCC(ticketID, userID int64) error {
+	args := m.Called(ticketID, userID)
+	return args.Error(0)
+}
+
+func (m *MockTicketRepository) RemoveWatcher(ticketID, userID int64) error {
+	args := m.Called(ticketID, userID)
+	return args.Error(0)
+}
+
+func (m *MockTicketRepository) ReplaceCommentMentions(ctx context.Context, comment model.Comentario, userIDs []int64) ([]int64, error) {
+	args := m.Called(comment, userIDs)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockTicketRepository) RequeueJob(id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockTicketRepository) UpdateComment(ctx context.Context, id int, comment model.Comentario) error {
+	args := m.Called(id, comment)
+	return args.Error(0)
+}
+
+func (m *MockTicketRepository) UpdateTicket(ctx context.Context, id int, ticket model.Ticket, atorID int64) error {
+	args := m.Called(id, ticket, atorID)
+	return args.Error(0)
+}
+
+func (m *MockTicketRepository) UpdateWebhook(id int64, w model.Webhook) error {
+	args := m.Called(id, w)
+	return args.Error(0)
+}
+
+func (m *MockTicketRepository) UpsertNotificationPreferences(p model.NotificationPreferences) error {
+	args := m.Called(p)
+	return args.Error(0)
+}
+
+func (m *MockTicketRepository) ListWebhooksForEvent(ctx context.Context, tipo string) ([]model.Webhook, error) {
+	args := m.Called(tipo)
+	return args.Get(0).([]model.Webhook), args.Error(1)
+}
+
+func (m *MockTicketRepository) RecordWebhookDelivery(ctx context.Context, d model.WebhookDelivery) (model.WebhookDelivery, error) {
+	args := m.Called(d)
+	return args.Get(0).(model.WebhookDelivery), args.Error(1)
+}
+
+var _ model.TicketRepository = (*MockTicketRepository)(nil)
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"helpdesk/tickets-service/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Repository) AddWatcher(ticketID, userID int64) error {
+	_, err := s.db.Exec(context.Background(), "INSERT INTO ticket_watchers (ticket_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", ticketID, userID)
+	return err
+}
+
+func (s *Repository) RemoveWatcher(ticketID, userID int64) error {
+	row, err := s.db.Exec(context.Background(), "DELETE FROM ticket_watchers WHERE ticket_id=$1 AND user_id=$2", ticketID, userID)
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() != 1 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+func (s *Repository) ListWatchers(ticketID int64) ([]int64, error) {
+	rows, err := s.db.Query(context.Background(), "SELECT user_id FROM ticket_watchers WHERE ticket_id=$1 ORDER BY user_id", ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+// ListWatchedTickets retorna os tickets que o usuario acompanha, seja como
+// watcher ou por ter sido colocado em copia.
+func (s *Repository) ListWatchedTickets(userID int64) ([]model.Ticket, error) {
+	rows, err := s.db.Query(context.Background(), "SELECT * FROM tickets WHERE id IN (SELECT ticket_id FROM ticket_watchers WHERE user_id=$1 UNION SELECT ticket_id FROM ticket_cc WHERE user_id=$1) ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lista []model.Ticket
+	var ticket model.Ticket
+
+	for rows.Next() {
+		if err := rows.Scan(&ticket.ID, &ticket.Titulo, &ticket.Descricao, &ticket.Status, &ticket.Diagnostico, &ticket.Solucao, &ticket.Prioridade, &ticket.DataAbertura, &ticket.DataFechamento, &ticket.DataAtualizacao, &ticket.Anexos, &ticket.Tags, &ticket.CategoriaID, &ticket.ResponsavelID, &ticket.UserID); err != nil {
+			return nil, err
+		}
+		lista = append(lista, ticket)
+	}
+
+	return lista, rows.Err()
+}
+
+// ListNotificationRecipients reune todos que devem ser avisados sobre o ticket:
+// o autor, o responsavel, os usuarios em copia e os watchers, sem repeticao.
+// Com apenasAgentes, so os destinatarios que sao agentes ou admins sao mantidos.
+func (s *Repository) ListNotificationRecipients(ticketID int64, apenasAgentes bool) ([]int64, error) {
+	rows, err := s.db.Query(context.Background(), `SELECT d.user_id FROM (
+			SELECT user_id FROM tickets WHERE id=$1 AND user_id <> 0
+			UNION SELECT responsavel_id FROM tickets WHERE id=$1 AND responsavel_id <> 0
+			UNION SELECT user_id FROM ticket_cc WHERE ticket_id=$1
+			UNION SELECT user_id FROM ticket_watchers WHERE ticket_id=$1
+		) d
+		WHERE NOT $2 OR d.user_id IN (SELECT id FROM users WHERE tipoUser IN ('agente', 'admin'))
+		ORDER BY 1`, ticketID, apenasAgentes)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+// FollowsTicket diz se o usuario acompanha o ticket: como autor, responsavel,
+// watcher ou em copia.
+func (s *Repository) FollowsTicket(ctx context.Context, ticketID, userID int64) (bool, error) {
+	var acompanha bool
+	err := s.db.QueryRow(ctx, `SELECT
+		EXISTS (SELECT 1 FROM tickets WHERE id=$1 AND (user_id=$2 OR responsavel_id=$2))
+		OR EXISTS (SELECT 1 FROM ticket_watchers WHERE ticket_id=$1 AND user_id=$2)
+		OR EXISTS (SELECT 1 FROM ticket_cc WHERE ticket_id=$1 AND user_id=$2)`, ticketID, userID).Scan(&acompanha)
+
+	return acompanha, err
+}
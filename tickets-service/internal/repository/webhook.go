@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"helpdesk/tickets-service/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const selectWebhook = `SELECT id, url, eventos, segredo, ativo, incluir_internos, COALESCE(criado_por, 0), criado_em FROM webhooks`
+
+func scanWebhook(row pgx.Row) (model.Webhook, error) {
+	var w model.Webhook
+	err := row.Scan(&w.ID, &w.URL, &w.Eventos, &w.Segredo, &w.Ativo, &w.IncluirInternos, &w.CriadoPor, &w.CriadoEm)
+	return w, err
+}
+
+func (s *Repository) CreateWebhook(w model.Webhook) (model.Webhook, error) {
+	var criadoPor *int64
+	if w.CriadoPor != 0 {
+		criadoPor = &w.CriadoPor
+	}
+
+	err := s.db.QueryRow(context.Background(), "INSERT INTO webhooks (url, eventos, segredo, ativo, incluir_internos, criado_por) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, criado_em",
+		w.URL, w.Eventos, w.Segredo, w.Ativo, w.IncluirInternos, criadoPor).Scan(&w.ID, &w.CriadoEm)
+	return w, err
+}
+
+func (s *Repository) ListWebhooks() ([]model.Webhook, error) {
+	return s.listarWebhooks(context.Background(), selectWebhook+" ORDER BY id")
+}
+
+// ListWebhooksForEvent lista os webhooks ativos que assinam o tipo de evento.
+func (s *Repository) ListWebhooksForEvent(ctx context.Context, tipo string) ([]model.Webhook, error) {
+	return s.listarWebhooks(ctx, selectWebhook+" WHERE ativo AND $1 = ANY(eventos) ORDER BY id", tipo)
+}
+
+func (s *Repository) listarWebhooks(ctx context.Context, query string, args ...any) ([]model.Webhook, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lista := []model.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		lista = append(lista, w)
+	}
+
+	return lista, rows.Err()
+}
+
+func (s *Repository) GetWebhookByID(ctx context.Context, id int64) (model.Webhook, error) {
+	return scanWebhook(s.db.QueryRow(ctx, selectWebhook+" WHERE id=$1", id))
+}
+
+// UpdateWebhook altera a assinatura. O segredo so e trocado quando informado.
+// Devolve pgx.ErrNoRows se o webhook nao existe.
+func (s *Repository) UpdateWebhook(id int64, w model.Webhook) error {
+	tag, err := s.db.Exec(context.Background(), "UPDATE webhooks SET url=$2, eventos=$3, ativo=$4, incluir_internos=$5, segredo=COALESCE(NULLIF($6, ''), segredo) WHERE id=$1",
+		id, w.URL, w.Eventos, w.Ativo, w.IncluirInternos, w.Segredo)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (s *Repository) DeleteWebhook(id int64) error {
+	tag, err := s.db.Exec(context.Background(), "DELETE FROM webhooks WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// RecordWebhookDelivery grava uma tentativa de entrega. O numero da
+// tentativa e contado a partir das entregas anteriores do mesmo evento.
+func (s *Repository) RecordWebhookDelivery(ctx context.Context, d model.WebhookDelivery) (model.WebhookDelivery, error) {
+	var statusCode *int
+	if d.StatusCode != 0 {
+		statusCode = &d.StatusCode
+	}
+
+	err := s.db.QueryRow(ctx, `INSERT INTO webhook_deliveries (webhook_id, evento_id, tipo, tentativa, status_code, resposta, erro, duracao_ms, sucesso)
+		SELECT $1, $2, $3, COUNT(*) + 1, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8
+		FROM webhook_deliveries WHERE webhook_id=$1 AND evento_id=$2 AND tipo=$3
+		RETURNING id, tentativa, data`,
+		d.WebhookID, d.EventoID, d.Tipo, statusCode, d.Resposta, d.Erro, d.DuracaoMs, d.Sucesso).Scan(&d.ID, &d.Tentativa, &d.Data)
+	return d, err
+}
+
+// ListWebhookDeliveries lista as ultimas entregas do webhook, mais recentes primeiro.
+func (s *Repository) ListWebhookDeliveries(webhookID int64, limite int) ([]model.WebhookDelivery, error) {
+	rows, err := s.db.Query(context.Background(), `SELECT id, webhook_id, evento_id, tipo, tentativa, COALESCE(status_code, 0), COALESCE(resposta, ''), COALESCE(erro, ''), duracao_ms, sucesso, data
+		FROM webhook_deliveries WHERE webhook_id=$1 ORDER BY id DESC LIMIT $2`, webhookID, limite)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lista := []model.WebhookDelivery{}
+	for rows.Next() {
+		var d model.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventoID, &d.Tipo, &d.Tentativa, &d.StatusCode, &d.Resposta, &d.Erro, &d.DuracaoMs, &d.Sucesso, &d.Data); err != nil {
+			return nil, err
+		}
+		lista = append(lista, d)
+	}
+
+	return lista, rows.Err()
+}
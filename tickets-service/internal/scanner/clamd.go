@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// tamanhoBloco e o tamanho de cada pedaco enviado no comando INSTREAM.
+// Deve ficar abaixo do StreamMaxLength configurado no clamd.
+const tamanhoBloco = 64 << 10
+
+// Clamd conversa com o daemon do ClamAV pelo protocolo INSTREAM.
+type Clamd struct {
+	rede     string
+	endereco string
+	timeout  time.Duration
+}
+
+func NewClamd(endereco string) *Clamd {
+	rede := "tcp"
+	if strings.HasPrefix(endereco, "/") {
+		rede = "unix"
+	}
+	return &Clamd{rede: rede, endereco: endereco, timeout: 2 * time.Minute}
+}
+
+// Scan envia o conteudo em blocos prefixados pelo tamanho (uint32 big-endian),
+// termina com um bloco vazio e interpreta a resposta "stream: OK" ou
+// "stream: <assinatura> FOUND".
+func (c *Clamd) Scan(ctx context.Context, conteudo io.Reader) (Resultado, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, c.rede, c.endereco)
+	if err != nil {
+		return Resultado{}, fmt.Errorf("não foi possivel conectar ao clamd: %w", err)
+	}
+	defer conn.Close()
+
+	prazo := time.Now().Add(c.timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(prazo) {
+		prazo = dl
+	}
+	conn.SetDeadline(prazo)
+
+	if _, err = conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Resultado{}, err
+	}
+
+	buf := make([]byte, tamanhoBloco)
+	tam := make([]byte, 4)
+	for {
+		n, rerr := conteudo.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(tam, uint32(n))
+			if _, err = conn.Write(tam); err != nil {
+				return Resultado{}, err
+			}
+			if _, err = conn.Write(buf[:n]); err != nil {
+				return Resultado{}, err
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return Resultado{}, rerr
+		}
+	}
+
+	if _, err = conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return Resultado{}, err
+	}
+
+	resposta, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return Resultado{}, fmt.Errorf("erro ao ler a resposta do clamd: %w", err)
+	}
+
+	return interpretarResposta(resposta)
+}
+
+func interpretarResposta(resposta string) (Resultado, error) {
+	resposta = strings.TrimSpace(strings.TrimRight(resposta, "\x00"))
+	resposta = strings.TrimPrefix(resposta, "stream: ")
+
+	switch {
+	case resposta == "OK":
+		return Resultado{}, nil
+	case strings.HasSuffix(resposta, " FOUND"):
+		return Resultado{Infectado: true, Assinatura: strings.TrimSuffix(resposta, " FOUND")}, nil
+	default:
+		return Resultado{}, fmt.Errorf("resposta inesperada do clamd: %q", resposta)
+	}
+}
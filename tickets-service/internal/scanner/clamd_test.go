@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd implementa o lado servidor do INSTREAM e acusa o arquivo de teste EICAR.
+func fakeClamd(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+
+				cmd := make([]byte, len("zINSTREAM\x00"))
+				if _, err := io.ReadFull(conn, cmd); err != nil || string(cmd) != "zINSTREAM\x00" {
+					conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+
+				var recebido bytes.Buffer
+				tam := make([]byte, 4)
+				for {
+					if _, err := io.ReadFull(conn, tam); err != nil {
+						return
+					}
+					n := binary.BigEndian.Uint32(tam)
+					if n == 0 {
+						break
+					}
+					if _, err := io.CopyN(&recebido, conn, int64(n)); err != nil {
+						return
+					}
+				}
+
+				if strings.Contains(recebido.String(), "EICAR-STANDARD-ANTIVIRUS-TEST-FILE") {
+					conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+					return
+				}
+				conn.Write([]byte("stream: OK\x00"))
+			}(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestClamd_Scan(t *testing.T) {
+	clamd := NewClamd(fakeClamd(t))
+
+	res, err := clamd.Scan(context.Background(), strings.NewReader("log de erro inofensivo"))
+	assert.NoError(t, err)
+	assert.False(t, res.Infectado)
+
+	// Conteudo maior que um bloco garante que os pedacos sao remontados.
+	grande := strings.Repeat("a", tamanhoBloco+10) + eicar
+	res, err = clamd.Scan(context.Background(), strings.NewReader(grande))
+	assert.NoError(t, err)
+	assert.True(t, res.Infectado)
+	assert.Equal(t, "Eicar-Test-Signature", res.Assinatura)
+}
+
+func TestClamd_Indisponivel(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	endereco := ln.Addr().String()
+	ln.Close()
+
+	_, err := NewClamd(endereco).Scan(context.Background(), strings.NewReader("x"))
+	assert.Error(t, err)
+}
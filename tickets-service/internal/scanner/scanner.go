@@ -0,0 +1,34 @@
+// Package scanner verifica anexos contra malware antes de disponibiliza-los.
+package scanner
+
+import (
+	"context"
+	"helpdesk/pkg/config"
+	"io"
+)
+
+// Resultado e o veredito de uma verificacao.
+type Resultado struct {
+	Infectado  bool
+	Assinatura string // Nome da ameaca encontrada, quando Infectado
+}
+
+// Scanner e implementado por cada mecanismo de antivirus suportado.
+type Scanner interface {
+	Scan(ctx context.Context, conteudo io.Reader) (Resultado, error)
+}
+
+// Noop aprova qualquer arquivo. E usado quando nenhum antivirus foi configurado.
+type Noop struct{}
+
+func (Noop) Scan(ctx context.Context, conteudo io.Reader) (Resultado, error) {
+	return Resultado{}, nil
+}
+
+// Novo usa o clamd em cfg.ClamdEndereco e, sem ele, desativa a verificacao.
+func Novo(cfg config.Scanner) Scanner {
+	if cfg.ClamdEndereco != "" {
+		return NewClamd(cfg.ClamdEndereco)
+	}
+	return Noop{}
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local guarda os anexos em um diretorio do sistema de arquivos.
+type Local struct {
+	dir string
+}
+
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("não foi possivel criar o diretorio de anexos: %w", err)
+	}
+	return &Local{dir: dir}, nil
+}
+
+// caminho resolve a chave dentro do diretorio base, recusando chaves que
+// tentem escapar dele (ex.: "../../etc/passwd").
+func (l *Local) caminho(chave string) (string, error) {
+	limpo := filepath.Clean("/" + chave)
+	if limpo == "/" || strings.Contains(chave, "\\") {
+		return "", fmt.Errorf("chave de storage inválida: %q", chave)
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(limpo)), nil
+}
+
+func (l *Local) Put(ctx context.Context, chave string, conteudo io.Reader, tamanho int64, contentType string) error {
+	destino, err := l.caminho(chave)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(destino), 0o750); err != nil {
+		return err
+	}
+
+	// Escreve em um arquivo temporario e renomeia no fim, para que um upload
+	// interrompido nunca deixe um anexo pela metade visivel para download.
+	tmp, err := os.CreateTemp(filepath.Dir(destino), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, conteudo)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if n != tamanho {
+		return fmt.Errorf("tamanho gravado (%d) difere do esperado (%d)", n, tamanho)
+	}
+
+	return os.Rename(tmp.Name(), destino)
+}
+
+func (l *Local) Open(ctx context.Context, chave string) (io.ReadSeekCloser, error) {
+	origem, err := l.caminho(chave)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(origem)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (l *Local) Delete(ctx context.Context, chave string) error {
+	alvo, err := l.caminho(chave)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(alvo); errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocal_PutOpenDelete(t *testing.T) {
+	local, err := NewLocal(t.TempDir())
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	assert.NoError(t, local.Put(ctx, "tickets/1/abc", strings.NewReader("print.png"), 9, "image/png"))
+
+	obj, err := local.Open(ctx, "tickets/1/abc")
+	assert.NoError(t, err)
+	lido, _ := io.ReadAll(obj)
+	obj.Close()
+	assert.Equal(t, "print.png", string(lido))
+
+	assert.NoError(t, local.Delete(ctx, "tickets/1/abc"))
+	_, err = local.Open(ctx, "tickets/1/abc")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLocal_ChaveFora(t *testing.T) {
+	dir := t.TempDir()
+	local, _ := NewLocal(dir + "/base")
+
+	// Chaves com ".." nao podem escapar do diretorio base.
+	assert.NoError(t, local.Put(context.Background(), "../../fora", strings.NewReader("x"), 1, ""))
+	_, err := local.Open(context.Background(), "fora")
+	assert.NoError(t, err)
+}
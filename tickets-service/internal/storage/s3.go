@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config descreve um bucket compativel com S3 (AWS, MinIO, etc.).
+type S3Config struct {
+	Endpoint  string // ex.: http://minio:9000
+	Bucket    string
+	Regiao    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3 fala diretamente a API REST do S3 com assinatura V4, em estilo de
+// caminho (endpoint/bucket/chave), que e o que o MinIO espera por padrao.
+type S3 struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+	agora    func() time.Time
+}
+
+func NewS3(cfg S3Config) (*S3, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("configuração do S3 incompleta: endpoint, bucket e credenciais são obrigatórios")
+	}
+	if cfg.Regiao == "" {
+		cfg.Regiao = "us-east-1"
+	}
+
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("endpoint do S3 inválido: %w", err)
+	}
+
+	return &S3{
+		cfg:      cfg,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 5 * time.Minute},
+		agora:    time.Now,
+	}, nil
+}
+
+func (s *S3) objetoURL(chave string) string {
+	u := *s.endpoint
+	u.Path = u.Path + "/" + s.cfg.Bucket + "/" + strings.TrimLeft(chave, "/")
+	return u.String()
+}
+
+func (s *S3) novaRequisicao(ctx context.Context, metodo, chave string, corpo io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, metodo, s.objetoURL(chave), corpo)
+}
+
+func (s *S3) executar(req *http.Request) (*http.Response, error) {
+	assinarV4(req, s.cfg.AccessKey, s.cfg.SecretKey, s.cfg.Regiao, "UNSIGNED-PAYLOAD", s.agora())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		corpo, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("S3 respondeu %d em %s %s: %s", resp.StatusCode, req.Method, req.URL.Path, strings.TrimSpace(string(corpo)))
+	}
+
+	return resp, nil
+}
+
+func (s *S3) Put(ctx context.Context, chave string, conteudo io.Reader, tamanho int64, contentType string) error {
+	req, err := s.novaRequisicao(ctx, http.MethodPut, chave, conteudo)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = tamanho
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.executar(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3) Open(ctx context.Context, chave string) (io.ReadSeekCloser, error) {
+	req, err := s.novaRequisicao(ctx, http.MethodHead, chave, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.executar(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	tamanho, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("S3 não informou o tamanho do objeto: %w", err)
+	}
+
+	return &s3Objeto{s3: s, ctx: ctx, chave: chave, tamanho: tamanho}, nil
+}
+
+func (s *S3) Delete(ctx context.Context, chave string) error {
+	req, err := s.novaRequisicao(ctx, http.MethodDelete, chave, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.executar(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// s3Objeto le o objeto sob demanda: cada Seek apenas move o cursor e a
+// proxima leitura abre um GET com Range a partir dali.
+type s3Objeto struct {
+	s3      *S3
+	ctx     context.Context
+	chave   string
+	tamanho int64
+	offset  int64
+	corpo   io.ReadCloser
+}
+
+func (o *s3Objeto) Read(p []byte) (int, error) {
+	if o.offset >= o.tamanho {
+		return 0, io.EOF
+	}
+
+	if o.corpo == nil {
+		req, err := o.s3.novaRequisicao(o.ctx, http.MethodGet, o.chave, nil)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", o.offset))
+
+		resp, err := o.s3.executar(req)
+		if err != nil {
+			return 0, err
+		}
+		o.corpo = resp.Body
+	}
+
+	n, err := o.corpo.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *s3Objeto) Seek(offset int64, whence int) (int64, error) {
+	var novo int64
+	switch whence {
+	case io.SeekStart:
+		novo = offset
+	case io.SeekCurrent:
+		novo = o.offset + offset
+	case io.SeekEnd:
+		novo = o.tamanho + offset
+	default:
+		return 0, errors.New("whence inválido")
+	}
+	if novo < 0 {
+		return 0, errors.New("posição negativa")
+	}
+
+	if novo != o.offset && o.corpo != nil {
+		o.corpo.Close()
+		o.corpo = nil
+	}
+	o.offset = novo
+	return novo, nil
+}
+
+func (o *s3Objeto) Close() error {
+	if o.corpo != nil {
+		return o.corpo.Close()
+	}
+	return nil
+}
+
+// assinarV4 adiciona a assinatura AWS Signature Version 4 a requisicao.
+// Sao assinados o host, os cabecalhos x-amz-*, Range e Content-Type.
+func assinarV4(req *http.Request, accessKey, secretKey, regiao, payloadHash string, agora time.Time) {
+	agora = agora.UTC()
+	amzDate := agora.Format("20060102T150405Z")
+	data := agora.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	cabecalhos := map[string]string{"host": req.URL.Host}
+	for nome, valores := range req.Header {
+		nome = strings.ToLower(nome)
+		if strings.HasPrefix(nome, "x-amz-") || nome == "range" || nome == "content-type" {
+			cabecalhos[nome] = strings.TrimSpace(strings.Join(valores, ","))
+		}
+	}
+
+	nomes := make([]string, 0, len(cabecalhos))
+	for nome := range cabecalhos {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+
+	var canonicos strings.Builder
+	for _, nome := range nomes {
+		canonicos.WriteString(nome + ":" + cabecalhos[nome] + "\n")
+	}
+	assinados := strings.Join(nomes, ";")
+
+	requisicaoCanonica := strings.Join([]string{
+		req.Method,
+		uriCanonica(req.URL.Path),
+		req.URL.Query().Encode(),
+		canonicos.String(),
+		assinados,
+		payloadHash,
+	}, "\n")
+
+	escopo := data + "/" + regiao + "/s3/aws4_request"
+	hashRequisicao := sha256.Sum256([]byte(requisicaoCanonica))
+	textoAssinar := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + escopo + "\n" + hex.EncodeToString(hashRequisicao[:])
+
+	chave := hmacSHA256([]byte("AWS4"+secretKey), data)
+	chave = hmacSHA256(chave, regiao)
+	chave = hmacSHA256(chave, "s3")
+	chave = hmacSHA256(chave, "aws4_request")
+	assinatura := hex.EncodeToString(hmacSHA256(chave, textoAssinar))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, escopo, assinados, assinatura))
+}
+
+// uriCanonica codifica cada segmento do caminho como o S3 exige (RFC 3986).
+func uriCanonica(caminho string) string {
+	if caminho == "" {
+		return "/"
+	}
+	segmentos := strings.Split(caminho, "/")
+	for i, seg := range segmentos {
+		segmentos[i] = strings.ReplaceAll(url.PathEscape(seg), "+", "%2B")
+	}
+	return strings.Join(segmentos, "/")
+}
+
+func hmacSHA256(chave []byte, dado string) []byte {
+	h := hmac.New(sha256.New, chave)
+	h.Write([]byte(dado))
+	return h.Sum(nil)
+}
@@ -0,0 +1,43 @@
+// Package storage guarda o conteudo dos anexos fora do banco de dados.
+// O banco guarda apenas os metadados e a chave usada aqui.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"helpdesk/pkg/config"
+	"io"
+)
+
+// ErrNotFound e devolvido quando a chave nao existe no backend.
+var ErrNotFound = errors.New("objeto não encontrado no storage")
+
+// Storage e implementado por cada backend de armazenamento de anexos.
+type Storage interface {
+	// Put grava tamanho bytes de conteudo sob a chave, substituindo o que existir.
+	Put(ctx context.Context, chave string, conteudo io.Reader, tamanho int64, contentType string) error
+	// Open devolve o objeto para leitura com suporte a Seek, o que permite
+	// responder requisicoes com Range sem baixar o arquivo inteiro.
+	Open(ctx context.Context, chave string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, chave string) error
+}
+
+// Novo monta o backend configurado: local, no diretorio anexos.Dir, ou s3,
+// no bucket da secao S3.
+func Novo(anexos config.Anexos, s3 config.S3) (Storage, error) {
+	switch anexos.Backend {
+	case config.AnexosLocal:
+		return NewLocal(anexos.Dir)
+	case config.AnexosS3:
+		return NewS3(S3Config{
+			Endpoint:  s3.Endpoint,
+			Bucket:    s3.Bucket,
+			Regiao:    s3.Regiao,
+			AccessKey: s3.AccessKey,
+			SecretKey: s3.SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("backend de anexos desconhecido: %q", anexos.Backend)
+	}
+}
@@ -0,0 +1,247 @@
+// Package stream entrega em tempo real os eventos de tickets e comentarios
+// aos clientes conectados (SSE e WebSocket), aplicando as permissoes de cada
+// um e permitindo retomar a conexao a partir do ultimo evento recebido.
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/internal/model"
+	"sync"
+	"time"
+)
+
+const (
+	// Heartbeat e o intervalo das mensagens de keepalive, abaixo do timeout
+	// de ociosidade comum em proxies e balanceadores (60s).
+	Heartbeat = 25 * time.Second
+	// BufferAssinatura e quantos eventos podem esperar por um cliente lento
+	// antes de ele ser desconectado.
+	BufferAssinatura = 256
+	// LoteHistorico e o tamanho de cada pagina lida ao retomar uma conexao.
+	LoteHistorico = 500
+)
+
+// ErrAtrasado encerra a conexao de um cliente que nao acompanhou o ritmo dos
+// eventos. Ele deve reconectar informando o ultimo evento recebido.
+var ErrAtrasado = errors.New("cliente nao acompanhou os eventos, reconecte informando o ultimo evento recebido")
+
+// ErrEncerrado encerra as conexoes quando o servico esta desligando. E um
+// ErrAtrasado: o cliente reconecta, em outra replica, sem perder eventos.
+var ErrEncerrado = fmt.Errorf("servidor desligando: %w", ErrAtrasado)
+
+// Historico devolve os eventos ja publicados depois de um ID. E implementado
+// por *repository.Repository, a partir da outbox.
+type Historico interface {
+	ListOutboxEventsAfter(ctx context.Context, id int64, limite int) ([]model.DomainEvent, error)
+}
+
+// Hub distribui os eventos publicados a todas as conexoes abertas. Implementa
+// outbox.Assinante.
+type Hub struct {
+	mu          sync.Mutex
+	assinaturas map[*Assinatura]struct{}
+	encerrado   bool
+}
+
+func NovoHub() *Hub {
+	return &Hub{assinaturas: map[*Assinatura]struct{}{}}
+}
+
+// Assinatura recebe os eventos do Hub ate ser cancelada.
+type Assinatura struct {
+	hub     *Hub
+	eventos chan model.DomainEvent
+}
+
+// Assinar abre uma assinatura. Deve ser cancelada quando a conexao terminar.
+func (h *Hub) Assinar() *Assinatura {
+	a := &Assinatura{hub: h, eventos: make(chan model.DomainEvent, BufferAssinatura)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.encerrado {
+		close(a.eventos)
+		return a
+	}
+	h.assinaturas[a] = struct{}{}
+	return a
+}
+
+// Encerrar fecha todas as assinaturas e as que vierem depois, para que as
+// conexoes longas terminem e o desligamento do servidor nao fique esperando
+// por elas.
+func (h *Hub) Encerrar() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.encerrado = true
+	for a := range h.assinaturas {
+		h.encerrar(a)
+	}
+}
+
+func (h *Hub) estaEncerrado() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.encerrado
+}
+
+// Publicar nunca bloqueia: a assinatura com o buffer cheio e encerrada.
+func (h *Hub) Publicar(ctx context.Context, e model.DomainEvent) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for a := range h.assinaturas {
+		select {
+		case a.eventos <- e:
+		default:
+			h.encerrar(a)
+		}
+	}
+	return nil
+}
+
+// Conexoes conta as assinaturas abertas.
+func (h *Hub) Conexoes() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.assinaturas)
+}
+
+// encerrar deve ser chamado com h.mu travado.
+func (h *Hub) encerrar(a *Assinatura) {
+	if _, ok := h.assinaturas[a]; ok {
+		delete(h.assinaturas, a)
+		close(a.eventos)
+	}
+}
+
+// Eventos e fechado quando a assinatura e cancelada ou fica atrasada.
+func (a *Assinatura) Eventos() <-chan model.DomainEvent {
+	return a.eventos
+}
+
+func (a *Assinatura) Cancelar() {
+	a.hub.mu.Lock()
+	a.hub.encerrar(a)
+	a.hub.mu.Unlock()
+}
+
+// Filtro decide quais eventos uma conexao recebe: as permissoes de quem
+// conectou e, opcionalmente, os tipos e tickets que ele assinou.
+type Filtro struct {
+	UserID int64
+	Agente bool
+	// Tipos e Tickets vazios aceitam todos os eventos visiveis.
+	Tipos   map[string]bool
+	Tickets map[int64]bool
+	// PodeVer aplica a regra de leitura do ticket para quem nao e agente
+	// nem autor dele.
+	PodeVer func(ctx context.Context, ticketID int64) (bool, error)
+	// Acompanha, quando definido, restringe os eventos aos tickets que o
+	// usuario acompanha.
+	Acompanha func(ctx context.Context, ticketID int64) (bool, error)
+}
+
+// Aceita diz se o evento deve ser enviado. Na duvida o evento e descartado.
+func (f *Filtro) Aceita(ctx context.Context, e model.DomainEvent) (bool, error) {
+	if len(f.Tipos) > 0 && !f.Tipos[e.Tipo] {
+		return false, nil
+	}
+	if len(f.Tickets) > 0 && !f.Tickets[e.TicketID] {
+		return false, nil
+	}
+
+	if f.Acompanha != nil {
+		if ok, err := f.Acompanha(ctx, e.TicketID); err != nil || !ok {
+			return false, err
+		}
+	}
+
+	if f.Agente {
+		return true, nil
+	}
+	if e.Interno() {
+		return false, nil
+	}
+	if !e.EventoDeComentario() {
+		// Os eventos de ticket trazem o autor; assim o autor ainda recebe a
+		// exclusao do proprio ticket, que ja nao pode ser consultado.
+		if dados, err := e.DadosTicket(); err == nil && dados.Ticket.UserID == f.UserID {
+			return true, nil
+		}
+	}
+	if f.PodeVer == nil {
+		return false, nil
+	}
+	return f.PodeVer(ctx, e.TicketID)
+}
+
+// Transmitir envia a uma conexao os eventos aceitos pelo filtro ate o
+// contexto ser cancelado ou enviar falhar. Com desde > 0, os eventos
+// publicados depois desse ID sao lidos do historico antes dos novos; a
+// assinatura e aberta antes da leitura, para que nada se perca entre as duas.
+// heartbeat e chamado a cada Heartbeat para manter a conexao viva.
+func Transmitir(ctx context.Context, hub *Hub, historico Historico, filtro *Filtro, desde int64,
+	enviar func(model.DomainEvent) error, heartbeat func() error) error {
+	a := hub.Assinar()
+	defer a.Cancelar()
+
+	entregar := func(e model.DomainEvent) error {
+		ok, err := filtro.Aceita(ctx, e)
+		if err != nil {
+			registro.Logger(ctx).Error("Erro ao filtrar o evento", "evento_id", e.ID, "usuario_id", filtro.UserID, "erro", err)
+			return nil
+		}
+		if !ok {
+			return nil
+		}
+		return enviar(e)
+	}
+
+	// Eventos lidos do historico podem chegar de novo pela assinatura.
+	reenviados := map[int64]bool{}
+	for desde > 0 {
+		eventos, err := historico.ListOutboxEventsAfter(ctx, desde, LoteHistorico)
+		if err != nil {
+			return err
+		}
+		for _, e := range eventos {
+			if err := entregar(e); err != nil {
+				return err
+			}
+			reenviados[e.ID] = true
+			desde = e.ID
+		}
+		if len(eventos) < LoteHistorico {
+			break
+		}
+	}
+
+	ticker := time.NewTicker(Heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-a.Eventos():
+			if !ok {
+				if hub.estaEncerrado() {
+					return ErrEncerrado
+				}
+				return ErrAtrasado
+			}
+			if reenviados[e.ID] {
+				continue
+			}
+			if err := entregar(e); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if err := heartbeat(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"helpdesk/tickets-service/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type historicoMemoria []model.DomainEvent
+
+func (h historicoMemoria) ListOutboxEventsAfter(ctx context.Context, id int64, limite int) ([]model.DomainEvent, error) {
+	var lista []model.DomainEvent
+	for _, e := range h {
+		if e.ID > id && len(lista) < limite {
+			lista = append(lista, e)
+		}
+	}
+	return lista, nil
+}
+
+func eventoTicket(id, ticketID, autorID int64, tipo string) model.DomainEvent {
+	dados, _ := json.Marshal(model.TicketEventData{Ticket: model.Ticket{ID: ticketID, UserID: autorID}})
+	return model.DomainEvent{ID: id, Tipo: tipo, TicketID: ticketID, Dados: dados}
+}
+
+func eventoComentario(id, ticketID int64, tipo string) model.DomainEvent {
+	dados, _ := json.Marshal(model.CommentEventData{Comentario: model.Comentario{ID: id, TicketID: ticketID, Tipo: tipo}})
+	return model.DomainEvent{ID: id, Tipo: model.DominioTicketComentado, TicketID: ticketID, Dados: dados}
+}
+
+func TestHub_DesconectaClienteLento(t *testing.T) {
+	hub := NovoHub()
+	rapida := hub.Assinar()
+	lenta := hub.Assinar()
+	defer rapida.Cancelar()
+
+	for i := 0; i <= BufferAssinatura; i++ {
+		hub.Publicar(context.Background(), model.DomainEvent{ID: int64(i + 1)})
+		if i < BufferAssinatura {
+			<-rapida.Eventos()
+		}
+	}
+
+	// A assinatura lenta lotou o buffer e foi encerrada; a outra segue ativa.
+	assert.Equal(t, 1, hub.Conexoes())
+	n := 0
+	for range lenta.Eventos() {
+		n++
+	}
+	assert.Equal(t, BufferAssinatura, n)
+	lenta.Cancelar()
+}
+
+func TestFiltro_Permissoes(t *testing.T) {
+	participa := map[int64]bool{20: true}
+	cliente := &Filtro{
+		UserID: 7,
+		PodeVer: func(ctx context.Context, ticketID int64) (bool, error) {
+			return participa[ticketID], nil
+		},
+	}
+	agente := &Filtro{UserID: 1, Agente: true}
+	ctx := context.Background()
+
+	casos := []struct {
+		nome    string
+		filtro  *Filtro
+		evento  model.DomainEvent
+		aceitar bool
+	}{
+		{"autor ve o proprio ticket", cliente, eventoTicket(1, 10, 7, model.DominioTicketCriado), true},
+		{"autor ve a exclusao do ticket", cliente, eventoTicket(2, 10, 7, model.DominioTicketExcluido), true},
+		{"cliente nao ve ticket alheio", cliente, eventoTicket(3, 11, 8, model.DominioTicketCriado), false},
+		{"participante ve ticket alheio", cliente, eventoTicket(4, 20, 8, model.DominioStatusAlterado), true},
+		{"participante ve comentario publico", cliente, eventoComentario(5, 20, model.ComentarioPublico), true},
+		{"cliente nao ve nota interna", cliente, eventoComentario(6, 20, model.ComentarioInterno), false},
+		{"agente ve nota interna", agente, eventoComentario(7, 20, model.ComentarioInterno), true},
+	}
+
+	for _, c := range casos {
+		ok, err := c.filtro.Aceita(ctx, c.evento)
+		assert.NoError(t, err, c.nome)
+		assert.Equal(t, c.aceitar, ok, c.nome)
+	}
+}
+
+func TestFiltro_Assinaturas(t *testing.T) {
+	f := &Filtro{
+		Agente:  true,
+		Tipos:   map[string]bool{model.DominioTicketComentado: true},
+		Tickets: map[int64]bool{20: true},
+	}
+	ctx := context.Background()
+
+	ok, _ := f.Aceita(ctx, eventoComentario(1, 20, model.ComentarioPublico))
+	assert.True(t, ok)
+	ok, _ = f.Aceita(ctx, eventoComentario(2, 21, model.ComentarioPublico))
+	assert.False(t, ok, "ticket nao assinado")
+	ok, _ = f.Aceita(ctx, eventoTicket(3, 20, 7, model.DominioTicketCriado))
+	assert.False(t, ok, "tipo nao assinado")
+
+	f.Acompanha = func(ctx context.Context, ticketID int64) (bool, error) { return false, errors.New("banco fora") }
+	ok, err := f.Aceita(ctx, eventoComentario(4, 20, model.ComentarioPublico))
+	assert.False(t, ok, "na duvida o evento e descartado")
+	assert.Error(t, err)
+}
+
+func TestTransmitir_RetomaDoHistorico(t *testing.T) {
+	hub := NovoHub()
+	historico := historicoMemoria{
+		eventoTicket(1, 10, 7, model.DominioTicketCriado),
+		eventoTicket(2, 10, 7, model.DominioStatusAlterado),
+		eventoTicket(3, 10, 7, model.DominioTicketAtualizado),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	recebidos := make(chan int64, 10)
+	fim := make(chan error)
+	go func() {
+		fim <- Transmitir(ctx, hub, historico, &Filtro{Agente: true}, 1,
+			func(e model.DomainEvent) error { recebidos <- e.ID; return nil },
+			func() error { return nil })
+	}()
+
+	assert.Equal(t, int64(2), <-recebidos)
+	assert.Equal(t, int64(3), <-recebidos)
+
+	// O evento 3 ja saiu do historico e e ignorado quando chega ao vivo.
+	assert.Eventually(t, func() bool { return hub.Conexoes() == 1 }, time.Second, 10*time.Millisecond)
+	hub.Publicar(ctx, historico[2])
+	hub.Publicar(ctx, eventoTicket(4, 10, 7, model.DominioTicketExcluido))
+	assert.Equal(t, int64(4), <-recebidos)
+
+	cancel()
+	assert.NoError(t, <-fim)
+	assert.Equal(t, 0, hub.Conexoes())
+}
+
+func TestHub_EncerrarTerminaAsConexoes(t *testing.T) {
+	hub := NovoHub()
+	fim := make(chan error)
+	go func() {
+		fim <- Transmitir(context.Background(), hub, historicoMemoria{}, &Filtro{Agente: true}, 0,
+			func(e model.DomainEvent) error { return nil },
+			func() error { return nil })
+	}()
+	assert.Eventually(t, func() bool { return hub.Conexoes() == 1 }, time.Second, 10*time.Millisecond)
+
+	hub.Encerrar()
+	err := <-fim
+	assert.ErrorIs(t, err, ErrEncerrado)
+	assert.ErrorIs(t, err, ErrAtrasado, "o cliente recebe o aviso para reconectar")
+
+	// Quem chega depois do encerramento sai na hora.
+	err = Transmitir(context.Background(), hub, historicoMemoria{}, &Filtro{Agente: true}, 0,
+		func(e model.DomainEvent) error { return nil },
+		func() error { return nil })
+	assert.ErrorIs(t, err, ErrEncerrado)
+}
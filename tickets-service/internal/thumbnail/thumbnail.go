@@ -0,0 +1,98 @@
+// Package thumbnail gera miniaturas dos anexos de imagem exibidas nos tickets.
+package thumbnail
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	_ "image/gif" // Registra os decodificadores suportados
+	_ "image/jpeg"
+)
+
+// TamanhoMaximo e o maior lado da miniatura, em pixels.
+const TamanhoMaximo = 256
+
+// maxPixels protege contra "bombas de descompressao": imagens pequenas em
+// bytes mas com dimensoes enormes que esgotariam a memoria ao decodificar.
+const maxPixels = 25_000_000
+
+var ErrImagemGrande = errors.New("imagem grande demais para gerar miniatura")
+
+// Suportado diz se ha decodificador para o tipo MIME informado.
+func Suportado(contentType string) bool {
+	switch contentType {
+	case "image/png", "image/jpeg", "image/gif":
+		return true
+	}
+	return false
+}
+
+// Gerar le a imagem de origem e escreve em destino uma miniatura PNG que cabe
+// em TamanhoMaximo x TamanhoMaximo, mantendo a proporcao.
+func Gerar(origem io.ReadSeeker, destino io.Writer) error {
+	cfg, _, err := image.DecodeConfig(origem)
+	if err != nil {
+		return err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return ErrImagemGrande
+	}
+
+	if _, err = origem.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	img, _, err := image.Decode(origem)
+	if err != nil {
+		return err
+	}
+
+	largura, altura := dimensoes(cfg.Width, cfg.Height)
+	return png.Encode(destino, reduzir(img, largura, altura))
+}
+
+func dimensoes(largura, altura int) (int, int) {
+	if largura <= TamanhoMaximo && altura <= TamanhoMaximo {
+		return largura, altura
+	}
+	if largura >= altura {
+		return TamanhoMaximo, max(1, altura*TamanhoMaximo/largura)
+	}
+	return max(1, largura*TamanhoMaximo/altura), TamanhoMaximo
+}
+
+// reduzir faz a media de cada bloco de pixels da origem (filtro de caixa),
+// o que evita o serrilhado de simplesmente pular pixels.
+func reduzir(src image.Image, largura, altura int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, largura, altura))
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+
+	for y := 0; y < altura; y++ {
+		y0 := b.Min.Y + y*sh/altura
+		y1 := max(y0+1, b.Min.Y+(y+1)*sh/altura)
+
+		for x := 0; x < largura; x++ {
+			x0 := b.Min.X + x*sw/largura
+			x1 := max(x0+1, b.Min.X+(x+1)*sw/largura)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+
+			// At devolve cores pre-multiplicadas; NRGBA espera nao multiplicadas.
+			c := color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)}
+			dst.Set(x, y, c)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,39 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGerar_MantemProporcao(t *testing.T) {
+	origem := image.NewRGBA(image.Rect(0, 0, 1024, 512))
+	for y := 0; y < 512; y++ {
+		for x := 0; x < 1024; x++ {
+			origem.Set(x, y, color.RGBA{R: 200, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	png.Encode(&buf, origem)
+
+	var mini bytes.Buffer
+	assert.NoError(t, Gerar(bytes.NewReader(buf.Bytes()), &mini))
+
+	img, err := png.Decode(&mini)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 256, 128), img.Bounds())
+
+	r, _, _, a := img.At(10, 10).RGBA()
+	assert.Equal(t, uint32(200), r>>8)
+	assert.Equal(t, uint32(255), a>>8)
+}
+
+func TestGerar_NaoImagem(t *testing.T) {
+	err := Gerar(bytes.NewReader([]byte("isto nao e uma imagem")), &bytes.Buffer{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,179 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/internal/breaker"
+	"helpdesk/tickets-service/internal/model"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MaxCache limita quantos perfis ficam em memoria. Ao passar do limite, os
+// expirados sao descartados e, se ainda assim nao couber, os mais antigos.
+const MaxCache = 10000
+
+// Fonte busca perfis em lote. E implementada pelo *Cliente.
+type Fonte interface {
+	Usuarios(ctx context.Context, ids []int64) (map[int64]model.TicketAuthor, error)
+}
+
+// Resolver preenche os perfis de autor e responsavel dos tickets. Os perfis
+// ficam em cache por TTL e sao descartados antes disso quando o users-service
+// avisa que o usuario mudou (AoAlterarUsuario). Tudo o que falta no cache e
+// buscado em uma unica chamada por pagina.
+type Resolver struct {
+	fonte Fonte
+	ttl   time.Duration
+
+	mu     sync.Mutex
+	perfis map[int64]perfilEmCache
+}
+
+type perfilEmCache struct {
+	perfil model.TicketAuthor
+	ate    time.Time
+}
+
+func NovoResolver(fonte Fonte, ttl time.Duration) *Resolver {
+	return &Resolver{fonte: fonte, ttl: ttl, perfis: map[int64]perfilEmCache{}}
+}
+
+// Perfis devolve os perfis dos IDs pedidos que puderam ser resolvidos. Se o
+// users-service falhar, devolve tambem o erro e, no lugar dos perfis
+// vencidos, a ultima versao em cache: um perfil desatualizado e melhor que
+// nenhum. Os que nunca foram vistos ficam de fora.
+func (r *Resolver) Perfis(ctx context.Context, ids []int64) (map[int64]model.TicketAuthor, error) {
+	perfis := make(map[int64]model.TicketAuthor, len(ids))
+	vencidos := map[int64]model.TicketAuthor{}
+	var faltando []int64
+	pedidos := map[int64]bool{}
+
+	agora := time.Now()
+	r.mu.Lock()
+	for _, id := range ids {
+		if id <= 0 || pedidos[id] {
+			continue
+		}
+		pedidos[id] = true
+		c, ok := r.perfis[id]
+		switch {
+		case ok && agora.Before(c.ate):
+			perfis[id] = c.perfil
+		case ok:
+			vencidos[id] = c.perfil
+			faltando = append(faltando, id)
+		default:
+			faltando = append(faltando, id)
+		}
+	}
+	r.mu.Unlock()
+
+	if len(faltando) == 0 {
+		return perfis, nil
+	}
+
+	buscados, err := r.fonte.Usuarios(ctx, faltando)
+	if err != nil {
+		// Com o disjuntor aberto a falha ja foi registrada ao abrir.
+		if !errors.Is(err, breaker.ErrAberto) {
+			registro.Logger(ctx).Warn("Erro ao buscar perfis no users-service, usando o cache", "perfis", len(faltando), "erro", err)
+		}
+		for id, p := range vencidos {
+			perfis[id] = p
+		}
+		return perfis, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.abrirEspaco(len(buscados))
+	ate := time.Now().Add(r.ttl)
+	for id, p := range buscados {
+		perfis[id] = p
+		r.perfis[id] = perfilEmCache{perfil: p, ate: ate}
+	}
+	return perfis, nil
+}
+
+// abrirEspaco deve ser chamado com r.mu travado.
+func (r *Resolver) abrirEspaco(novos int) {
+	if len(r.perfis)+novos <= MaxCache {
+		return
+	}
+	agora := time.Now()
+	for id, c := range r.perfis {
+		if !agora.Before(c.ate) {
+			delete(r.perfis, id)
+		}
+	}
+
+	excesso := len(r.perfis) + novos - MaxCache
+	if excesso <= 0 {
+		return
+	}
+	// Todos tem o mesmo TTL, entao os que vencem antes sao os mais antigos.
+	ids := make([]int64, 0, len(r.perfis))
+	for id := range r.perfis {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b int64) int {
+		return r.perfis[a].ate.Compare(r.perfis[b].ate)
+	})
+	for _, id := range ids[:min(excesso, len(ids))] {
+		delete(r.perfis, id)
+	}
+}
+
+// Invalidar descarta o perfil em cache do usuario.
+func (r *Resolver) Invalidar(id int64) {
+	r.mu.Lock()
+	delete(r.perfis, id)
+	r.mu.Unlock()
+}
+
+// AoAlterarUsuario e o handler do topico bus.TopicoUsuarios, em que o
+// users-service publica o ID de cada usuario alterado ou removido.
+func (r *Resolver) AoAlterarUsuario(ctx context.Context, dados []byte) {
+	id, err := strconv.ParseInt(string(dados), 10, 64)
+	if err != nil {
+		registro.Logger(ctx).Warn("Aviso de usuario alterado ignorado, ID inválido", "dados", string(dados))
+		return
+	}
+	r.Invalidar(id)
+}
+
+// Preencher completa o autor e o responsavel de cada ticket com uma unica
+// busca. Nunca falha: sem resposta do users-service, os perfis que nao
+// estavam em cache saem so com o ID e marcados como indisponiveis.
+func (r *Resolver) Preencher(ctx context.Context, tickets []model.Ticket) {
+	ids := make([]int64, 0, 2*len(tickets))
+	for _, t := range tickets {
+		ids = append(ids, t.UserID, t.ResponsavelID)
+	}
+	perfis, err := r.Perfis(ctx, ids)
+
+	perfil := func(id int64) model.TicketAuthor {
+		if p, ok := perfis[id]; ok {
+			return p
+		}
+		return model.TicketAuthor{ID: id, Indisponivel: err != nil}
+	}
+	for i := range tickets {
+		tickets[i].Author = perfil(tickets[i].UserID)
+		if tickets[i].ResponsavelID != 0 {
+			p := perfil(tickets[i].ResponsavelID)
+			tickets[i].Responsavel = &p
+		}
+	}
+}
+
+// PreencherTicket e o Preencher de um unico ticket.
+func (r *Resolver) PreencherTicket(ctx context.Context, ticket *model.Ticket) {
+	lista := []model.Ticket{*ticket}
+	r.Preencher(ctx, lista)
+	*ticket = lista[0]
+}
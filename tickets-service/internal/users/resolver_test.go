@@ -0,0 +1,109 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"helpdesk/tickets-service/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fonteFalsa struct {
+	chamadas [][]int64
+	falhar   bool
+}
+
+func (f *fonteFalsa) Usuarios(ctx context.Context, ids []int64) (map[int64]model.TicketAuthor, error) {
+	f.chamadas = append(f.chamadas, ids)
+	if f.falhar {
+		return nil, errors.New("users-service fora do ar")
+	}
+	perfis := map[int64]model.TicketAuthor{}
+	for _, id := range ids {
+		perfis[id] = model.TicketAuthor{ID: id, Nome: "Usuario"}
+	}
+	return perfis, nil
+}
+
+func TestResolver_UmaBuscaPorPagina(t *testing.T) {
+	fonte := &fonteFalsa{}
+	r := NovoResolver(fonte, time.Minute)
+
+	tickets := []model.Ticket{
+		{ID: 1, UserID: 7, ResponsavelID: 2},
+		{ID: 2, UserID: 7},
+		{ID: 3, UserID: 8, ResponsavelID: 2},
+	}
+	r.Preencher(context.Background(), tickets)
+
+	assert.Len(t, fonte.chamadas, 1)
+	assert.ElementsMatch(t, []int64{7, 2, 8}, fonte.chamadas[0])
+	assert.Equal(t, int64(7), tickets[1].Author.ID)
+	assert.Equal(t, int64(2), tickets[2].Responsavel.ID)
+	assert.Nil(t, tickets[1].Responsavel, "ticket sem responsavel")
+
+	// A segunda pagina so busca quem ainda nao esta em cache.
+	r.Preencher(context.Background(), []model.Ticket{{UserID: 7}, {UserID: 9}})
+	assert.Equal(t, []int64{9}, fonte.chamadas[1])
+}
+
+func TestResolver_Invalidacao(t *testing.T) {
+	fonte := &fonteFalsa{}
+	r := NovoResolver(fonte, time.Hour)
+
+	r.Perfis(context.Background(), []int64{7})
+	r.Perfis(context.Background(), []int64{7})
+	assert.Len(t, fonte.chamadas, 1)
+
+	r.AoAlterarUsuario(context.Background(), []byte("7"))
+	r.Perfis(context.Background(), []int64{7})
+	assert.Len(t, fonte.chamadas, 2)
+}
+
+func TestResolver_SemUsersService(t *testing.T) {
+	fonte := &fonteFalsa{}
+	r := NovoResolver(fonte, time.Hour)
+	r.Perfis(context.Background(), []int64{7})
+
+	fonte.falhar = true
+	tickets := []model.Ticket{{UserID: 7}, {UserID: 8, ResponsavelID: 7}}
+	r.Preencher(context.Background(), tickets)
+
+	// O perfil em cache continua servindo; o outro sai parcial, sem erro.
+	assert.Equal(t, "Usuario", tickets[0].Author.Nome)
+	assert.Equal(t, model.TicketAuthor{ID: 8, Indisponivel: true}, tickets[1].Author)
+	assert.Equal(t, int64(7), tickets[1].Responsavel.ID)
+}
+
+func TestResolver_UsaPerfilVencidoNaFalha(t *testing.T) {
+	fonte := &fonteFalsa{}
+	r := NovoResolver(fonte, time.Nanosecond)
+	r.Perfis(context.Background(), []int64{7})
+	time.Sleep(time.Millisecond)
+
+	fonte.falhar = true
+	perfis, err := r.Perfis(context.Background(), []int64{7})
+	assert.Error(t, err)
+	assert.Equal(t, "Usuario", perfis[7].Nome)
+	assert.Len(t, fonte.chamadas, 2, "o perfil vencido foi buscado de novo antes do fallback")
+}
+
+func TestResolver_CacheCheioDescartaOsMaisAntigos(t *testing.T) {
+	r := NovoResolver(&fonteFalsa{}, time.Hour)
+	agora := time.Now()
+	for id := int64(1); id <= MaxCache; id++ {
+		r.perfis[id] = perfilEmCache{perfil: model.TicketAuthor{ID: id}, ate: agora.Add(time.Duration(id) * time.Second)}
+	}
+
+	r.mu.Lock()
+	r.abrirEspaco(2)
+	r.mu.Unlock()
+
+	// So os dois que venceriam primeiro saem; o resto do cache continua.
+	assert.Len(t, r.perfis, MaxCache-2)
+	assert.NotContains(t, r.perfis, int64(1))
+	assert.NotContains(t, r.perfis, int64(2))
+	assert.Contains(t, r.perfis, int64(3))
+}
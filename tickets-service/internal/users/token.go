@@ -0,0 +1,113 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"helpdesk/pkg/registro"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// margemRenovacao antecipa a troca do token, para que nenhuma chamada saia
+// com um token prestes a vencer.
+const margemRenovacao = 30 * time.Second
+
+// tokenServico obtem o token de servico do tickets-service em
+// POST /services/token do users-service (client credentials) e o envia em
+// toda chamada gRPC. O token fica em cache ate perto de vencer; e o mesmo
+// para requisicoes de usuarios e para os workers, que nao tem usuario.
+type tokenServico struct {
+	url     string
+	id      string
+	segredo string
+	escopos []string
+	http    *http.Client
+
+	mu    sync.Mutex
+	token string
+	vence time.Time
+	agora func() time.Time
+}
+
+func novoTokenServico(cfg Config) *tokenServico {
+	return &tokenServico{
+		url:     cfg.URLToken,
+		id:      cfg.ServicoID,
+		segredo: cfg.ServicoSegredo,
+		escopos: []string{"usuarios.ler"},
+		http:    &http.Client{Timeout: cfg.Timeout},
+		agora:   time.Now,
+	}
+}
+
+func (t *tokenServico) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := t.obter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+// A rede entre os servicos e interna, entao a conexao nao usa TLS.
+func (t *tokenServico) RequireTransportSecurity() bool {
+	return false
+}
+
+// obter devolve o token em cache ou pede um novo. As chamadas concorrentes
+// esperam o mesmo pedido em vez de cada uma pedir o seu. Sem resposta do
+// users-service o erro e Unavailable, como o das demais chamadas, para contar
+// no disjuntor; credenciais recusadas sao Unauthenticated.
+func (t *tokenServico) obter(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && t.agora().Add(margemRenovacao).Before(t.vence) {
+		return t.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}, "scope": {strings.Join(t.escopos, " ")}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(t.id, t.segredo)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	if id := registro.ID(ctx); id != "" {
+		req.Header.Set(registro.CabecalhoID, id)
+	}
+
+	resp, err := t.http.Do(req)
+	if err != nil {
+		return "", status.Errorf(codes.Unavailable, "erro ao pedir o token de serviço: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return "", status.Errorf(codes.Unavailable, "users-service indisponível ao emitir o token de serviço: %s", resp.Status)
+	} else if resp.StatusCode != http.StatusOK {
+		return "", status.Errorf(codes.Unauthenticated, "token de serviço recusado pelo users-service: %s", resp.Status)
+	}
+
+	var corpo struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&corpo); err != nil {
+		return "", status.Errorf(codes.Unavailable, "resposta inválida ao pedir o token de serviço: %v", err)
+	}
+	if corpo.AccessToken == "" {
+		return "", status.Error(codes.Unavailable, "users-service não devolveu o token de serviço")
+	}
+
+	t.token = corpo.AccessToken
+	t.vence = t.agora().Add(time.Duration(corpo.ExpiresIn) * time.Second)
+	return t.token, nil
+}
@@ -0,0 +1,265 @@
+// Package users e o cliente da API gRPC interna do users-service
+// (UserDirectory). Uma unica conexao e reaproveitada por todas as chamadas;
+// cada chamada tem prazo proprio, as falhas de conexao sao repetidas pelo
+// proprio gRPC e um disjuntor corta as chamadas enquanto o users-service
+// estiver fora do ar. Cada chamada leva o traceparent do trace em andamento.
+// As chamadas se identificam com o token de servico do
+// tickets-service, nunca com o do usuario, e por isso funcionam tambem nos
+// workers.
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"helpdesk/pkg/config"
+	"helpdesk/pkg/pb"
+	"helpdesk/pkg/rastreio"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/internal/breaker"
+	"helpdesk/tickets-service/internal/model"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// MaxLote e o maior numero de IDs por chamada a BatchGetUsers; pedidos
+// maiores sao divididos.
+const MaxLote = 500
+
+var ErrNaoEncontrado = errors.New("usuario nao encontrado")
+
+// Config ajusta a conexao com o users-service.
+type Config struct {
+	Endereco string // host:porta da API gRPC do users-service
+	// Identidade do tickets-service: ServicoID e ServicoSegredo sao trocados
+	// em URLToken por um token de servico curto, renovado automaticamente.
+	ServicoID      string
+	ServicoSegredo string
+	URLToken       string
+	Timeout        time.Duration // Prazo de cada chamada, incluindo as repeticoes
+	Tentativas     int           // Tentativas por chamada quando o users-service esta indisponivel (maximo 5)
+	CacheTTL       time.Duration // Validade dos perfis no cache do Resolver
+	// Disjuntor: apos Disjuntor.Falhas chamadas seguidas sem resposta, as
+	// consultas falham na hora por Disjuntor.Espera.
+	Disjuntor breaker.Config
+}
+
+// ConfigDe monta a Config a partir do config compartilhado.
+func ConfigDe(cfg config.Users) Config {
+	return Config{
+		Endereco:       cfg.GRPC,
+		ServicoID:      cfg.ServicoID,
+		ServicoSegredo: cfg.ServicoSegredo,
+		URLToken:       cfg.URLToken,
+		Timeout:        cfg.Timeout,
+		Tentativas:     cfg.Tentativas,
+		CacheTTL:       cfg.CacheTTL,
+		Disjuntor:      breaker.Config{Falhas: cfg.DisjuntorFalhas, Espera: cfg.DisjuntorEspera},
+	}
+}
+
+// configServico liga as repeticoes do gRPC para UNAVAILABLE (users-service
+// fora do ar ou reiniciando), com espera crescente entre as tentativas.
+func configServico(tentativas int) string {
+	if tentativas < 2 {
+		return `{}`
+	}
+	return fmt.Sprintf(`{"methodConfig": [{
+		"name": [{"service": "helpdesk.users.v1.UserDirectory"}],
+		"retryPolicy": {
+			"maxAttempts": %d,
+			"initialBackoff": "0.1s",
+			"maxBackoff": "1s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}
+	}]}`, min(tentativas, 5))
+}
+
+// indisponivel diz quais erros contam para abrir o disjuntor: os que mostram
+// que o users-service nao respondeu. Cancelamentos de quem chamou e erros de
+// negocio (NotFound, InvalidArgument) nao contam.
+func indisponivel(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
+
+// Cliente consulta perfis no users-service. E seguro para uso concorrente.
+type Cliente struct {
+	conn      *grpc.ClientConn
+	diretorio pb.UserDirectoryClient
+	timeout   time.Duration
+	disjuntor *breaker.Disjuntor
+}
+
+// NovoCliente prepara a conexao, que so e aberta na primeira chamada; o
+// users-service nao precisa estar no ar quando o tickets-service sobe.
+func NovoCliente(cfg Config, opcoes ...grpc.DialOption) (*Cliente, error) {
+	opcoes = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(novoTokenServico(cfg)),
+		grpc.WithDefaultServiceConfig(configServico(cfg.Tentativas)),
+		grpc.WithChainUnaryInterceptor(rastreio.ClienteUnario, registro.ClienteUnario),
+	}, opcoes...)
+
+	conn, err := grpc.NewClient(cfg.Endereco, opcoes...)
+	if err != nil {
+		return nil, err
+	}
+	return &Cliente{
+		conn:      conn,
+		diretorio: pb.NewUserDirectoryClient(conn),
+		timeout:   cfg.Timeout,
+		disjuntor: breaker.Novo("users-service", cfg.Disjuntor, indisponivel),
+	}, nil
+}
+
+func (c *Cliente) Close() error {
+	return c.conn.Close()
+}
+
+// Disjuntor expoe o estado do disjuntor do users-service.
+func (c *Cliente) Disjuntor() *breaker.Disjuntor {
+	return c.disjuntor
+}
+
+// Usuario busca um perfil. Devolve ErrNaoEncontrado se o usuario nao existe
+// e breaker.ErrAberto enquanto o users-service estiver fora. O prazo e o
+// menor entre o do contexto e o Timeout configurado.
+func (c *Cliente) Usuario(ctx context.Context, id int64) (model.TicketAuthor, error) {
+	u, err := c.usuario(ctx, id)
+	if err != nil {
+		return model.TicketAuthor{}, err
+	}
+	return autor(u), nil
+}
+
+// TipoUsuario devolve o tipoUser do usuario, com os mesmos erros de Usuario.
+func (c *Cliente) TipoUsuario(ctx context.Context, id int64) (string, error) {
+	u, err := c.usuario(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return u.GetTipoUser(), nil
+}
+
+func (c *Cliente) usuario(ctx context.Context, id int64) (*pb.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var u *pb.User
+	err := c.disjuntor.Executar(ctx, func(ctx context.Context) (err error) {
+		u, err = c.diretorio.GetUser(ctx, &pb.GetUserRequest{Id: id})
+		return err
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNaoEncontrado
+	} else if err != nil {
+		return nil, fmt.Errorf("erro ao consultar o usuario %d no users-service: %w", id, err)
+	}
+	return u, nil
+}
+
+// UsuarioPorEmail busca o dono do email, com o tipo, para identificar o
+// remetente dos emails recebidos. Devolve ErrNaoEncontrado se nenhum
+// usuario usa o endereco.
+func (c *Cliente) UsuarioPorEmail(ctx context.Context, email string) (model.Usuario, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var u *pb.User
+	err := c.disjuntor.Executar(ctx, func(ctx context.Context) (err error) {
+		u, err = c.diretorio.GetUserByEmail(ctx, &pb.GetUserByEmailRequest{Email: email})
+		return err
+	})
+	if status.Code(err) == codes.NotFound {
+		return model.Usuario{}, ErrNaoEncontrado
+	} else if err != nil {
+		return model.Usuario{}, fmt.Errorf("erro ao consultar o email %s no users-service: %w", email, err)
+	}
+	return model.Usuario{ID: u.GetId(), Nome: u.GetNome(), Email: u.GetEmail(), TipoUser: u.GetTipoUser()}, nil
+}
+
+// Usuarios busca varios perfis, em lotes de MaxLote. Os IDs inexistentes
+// ficam fora do mapa.
+func (c *Cliente) Usuarios(ctx context.Context, ids []int64) (map[int64]model.TicketAuthor, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	perfis := make(map[int64]model.TicketAuthor, len(ids))
+	for inicio := 0; inicio < len(ids); inicio += MaxLote {
+		lote := ids[inicio:min(inicio+MaxLote, len(ids))]
+		var resp *pb.BatchGetUsersResponse
+		err := c.disjuntor.Executar(ctx, func(ctx context.Context) (err error) {
+			resp, err = c.diretorio.BatchGetUsers(ctx, &pb.BatchGetUsersRequest{Ids: lote})
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("erro ao consultar %d usuarios no users-service: %w", len(lote), err)
+		}
+		for _, u := range resp.GetUsers() {
+			perfis[u.GetId()] = autor(u)
+		}
+	}
+	return perfis, nil
+}
+
+// Mencionados resolve handles de mencao em usuarios. Handles ambiguos ou
+// desconhecidos ficam de fora.
+func (c *Cliente) Mencionados(ctx context.Context, handles []string) ([]model.MentionedUser, error) {
+	if len(handles) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var resp *pb.LookupUsersResponse
+	err := c.disjuntor.Executar(ctx, func(ctx context.Context) (err error) {
+		resp, err = c.diretorio.LookupUsers(ctx, &pb.LookupUsersRequest{Handles: handles})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao resolver %d menções no users-service: %w", len(handles), err)
+	}
+
+	usuarios := make([]model.MentionedUser, 0, len(resp.GetUsers()))
+	for _, h := range resp.GetUsers() {
+		u := h.GetUser()
+		usuarios = append(usuarios, model.MentionedUser{
+			ID:       u.GetId(),
+			Nome:     u.GetNome(),
+			Email:    u.GetEmail(),
+			TipoUser: u.GetTipoUser(),
+			Handle:   h.GetHandle(),
+		})
+	}
+	return usuarios, nil
+}
+
+// Saude consulta o servico de saude gRPC do users-service, sem passar pelo
+// disjuntor. E a verificacao do users-service no /readyz.
+func (c *Cliente) Saude(ctx context.Context) error {
+	resp, err := healthpb.NewHealthClient(c.conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return fmt.Errorf("users-service sem resposta: %w", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("users-service em estado %s", resp.GetStatus())
+	}
+	if c.disjuntor.Estado() != breaker.Fechado {
+		return fmt.Errorf("disjuntor do users-service %s", c.disjuntor.Estado())
+	}
+	return nil
+}
+
+func autor(u *pb.User) model.TicketAuthor {
+	return model.TicketAuthor{ID: u.GetId(), Nome: u.GetNome(), Email: u.GetEmail()}
+}
@@ -0,0 +1,219 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"helpdesk/pkg/pb"
+	"helpdesk/tickets-service/internal/breaker"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// diretorioFalso falha as primeiras chamadas com Unavailable e guarda a
+// credencial recebida.
+type diretorioFalso struct {
+	pb.UnimplementedUserDirectoryServer
+	falhas     atomic.Int32
+	chamadas   atomic.Int32
+	credencial atomic.Value
+}
+
+func (d *diretorioFalso) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	d.chamadas.Add(1)
+	md, _ := metadata.FromIncomingContext(ctx)
+	d.credencial.Store(md.Get("authorization"))
+
+	if d.falhas.Add(-1) >= 0 {
+		return nil, status.Error(codes.Unavailable, "reiniciando")
+	}
+	if req.GetId() != 1 {
+		return nil, status.Error(codes.NotFound, "nao existe")
+	}
+	return &pb.User{Id: 1, Nome: "Ana", Email: "ana@acme.com"}, nil
+}
+
+func (d *diretorioFalso) GetUserByEmail(ctx context.Context, req *pb.GetUserByEmailRequest) (*pb.User, error) {
+	d.chamadas.Add(1)
+	if req.GetEmail() != "ana@acme.com" {
+		return nil, status.Error(codes.NotFound, "nao existe")
+	}
+	return &pb.User{Id: 1, Nome: "Ana", Email: "ana@acme.com", TipoUser: "agente"}, nil
+}
+
+func (d *diretorioFalso) BatchGetUsers(ctx context.Context, req *pb.BatchGetUsersRequest) (*pb.BatchGetUsersResponse, error) {
+	d.chamadas.Add(1)
+	resp := &pb.BatchGetUsersResponse{}
+	for _, id := range req.GetIds() {
+		resp.Users = append(resp.Users, &pb.User{Id: id})
+	}
+	return resp, nil
+}
+
+func (d *diretorioFalso) LookupUsers(ctx context.Context, req *pb.LookupUsersRequest) (*pb.LookupUsersResponse, error) {
+	d.chamadas.Add(1)
+	resp := &pb.LookupUsersResponse{}
+	for _, h := range req.GetHandles() {
+		if h == "ana" {
+			resp.Users = append(resp.Users, &pb.UserHandle{Handle: h, User: &pb.User{Id: 1, Nome: "Ana", TipoUser: "agente"}})
+		}
+	}
+	return resp, nil
+}
+
+// emissorFalso faz o papel do POST /services/token do users-service: aceita
+// so o segredo "s3nha" e numera os tokens emitidos.
+func emissorFalso(t *testing.T, validade int64) (*httptest.Server, *atomic.Int32) {
+	emitidos := &atomic.Int32{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, segredo, _ := r.BasicAuth()
+		if r.PostFormValue("grant_type") != "client_credentials" || id != "tickets-service" || segredo != "s3nha" {
+			http.Error(w, "Credenciais do serviço inválidas", http.StatusUnauthorized)
+			return
+		}
+		n := emitidos.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{"access_token": fmt.Sprintf("token-%d", n), "token_type": "Bearer", "expires_in": validade})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, emitidos
+}
+
+func novoTeste(t *testing.T, cfg Config) (*Cliente, *diretorioFalso) {
+	lis := bufconn.Listen(1 << 20)
+	d := &diretorioFalso{}
+	s := grpc.NewServer()
+	pb.RegisterUserDirectoryServer(s, d)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	emissor, _ := emissorFalso(t, 600)
+	cfg.Endereco = "passthrough:///bufnet"
+	cfg.URLToken = emissor.URL
+	cfg.ServicoID = "tickets-service"
+	if cfg.ServicoSegredo == "" {
+		cfg.ServicoSegredo = "s3nha"
+	}
+	c, err := NovoCliente(cfg, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}))
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c, d
+}
+
+func TestCliente_RepeteQuandoIndisponivel(t *testing.T) {
+	c, d := novoTeste(t, Config{Timeout: 5 * time.Second, Tentativas: 3})
+	d.falhas.Store(2)
+
+	u, err := c.Usuario(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Ana", u.Nome)
+	assert.Equal(t, int32(3), d.chamadas.Load())
+	assert.Equal(t, []string{"Bearer token-1"}, d.credencial.Load())
+
+	_, err = c.Usuario(context.Background(), 2)
+	assert.ErrorIs(t, err, ErrNaoEncontrado)
+}
+
+func TestCliente_DesisteAposAsTentativas(t *testing.T) {
+	c, d := novoTeste(t, Config{Timeout: 5 * time.Second, Tentativas: 2})
+	d.falhas.Store(5)
+
+	_, err := c.Usuario(context.Background(), 1)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(2), d.chamadas.Load())
+}
+
+func TestCliente_DisjuntorAbre(t *testing.T) {
+	c, d := novoTeste(t, Config{Timeout: 5 * time.Second, Tentativas: 1, Disjuntor: breaker.Config{Falhas: 2, Espera: time.Minute}})
+	d.falhas.Store(10)
+
+	for i := 0; i < 2; i++ {
+		_, err := c.Usuario(context.Background(), 1)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	}
+
+	// Aberto, o disjuntor responde sem chamar o users-service.
+	_, err := c.Usuario(context.Background(), 1)
+	assert.ErrorIs(t, err, breaker.ErrAberto)
+	assert.Equal(t, int32(2), d.chamadas.Load())
+	assert.Equal(t, breaker.Aberto, c.Disjuntor().Estado())
+}
+
+func TestCliente_UsuariosEmLotes(t *testing.T) {
+	c, d := novoTeste(t, Config{Timeout: 5 * time.Second, Tentativas: 1})
+
+	ids := make([]int64, MaxLote+10)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+	perfis, err := c.Usuarios(context.Background(), ids)
+	require.NoError(t, err)
+	assert.Len(t, perfis, len(ids))
+	assert.Equal(t, int32(2), d.chamadas.Load())
+}
+
+func TestCliente_SemTokenDeServico(t *testing.T) {
+	c, d := novoTeste(t, Config{ServicoSegredo: "errado", Timeout: 5 * time.Second, Tentativas: 1})
+
+	_, err := c.Usuario(context.Background(), 1)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, int32(0), d.chamadas.Load(), "sem token a chamada nem sai")
+}
+
+func TestCliente_Mencionados(t *testing.T) {
+	c, _ := novoTeste(t, Config{Timeout: 5 * time.Second, Tentativas: 1})
+
+	mencionados, err := c.Mencionados(context.Background(), []string{"ana", "ninguem"})
+	require.NoError(t, err)
+	require.Len(t, mencionados, 1)
+	assert.Equal(t, int64(1), mencionados[0].ID)
+	assert.Equal(t, "ana", mencionados[0].Handle)
+	assert.Equal(t, "agente", mencionados[0].TipoUser)
+}
+
+func TestCliente_UsuarioPorEmail(t *testing.T) {
+	c, _ := novoTeste(t, Config{Timeout: 5 * time.Second, Tentativas: 1})
+
+	u, err := c.UsuarioPorEmail(context.Background(), "ana@acme.com")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), u.ID)
+	assert.Equal(t, "agente", u.TipoUser)
+
+	_, err = c.UsuarioPorEmail(context.Background(), "ninguem@acme.com")
+	assert.ErrorIs(t, err, ErrNaoEncontrado)
+}
+
+func TestTokenServico_RenovaAntesDeVencer(t *testing.T) {
+	emissor, emitidos := emissorFalso(t, 300)
+	ts := novoTokenServico(Config{URLToken: emissor.URL, ServicoID: "tickets-service", ServicoSegredo: "s3nha", Timeout: time.Second})
+	agora := time.Now()
+	ts.agora = func() time.Time { return agora }
+
+	md, err := ts.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token-1", md["authorization"])
+
+	// Dentro da validade o token em cache e reaproveitado.
+	agora = agora.Add(4 * time.Minute)
+	md, _ = ts.GetRequestMetadata(context.Background())
+	assert.Equal(t, "Bearer token-1", md["authorization"])
+
+	// Perto de vencer, um novo e pedido.
+	agora = agora.Add(45 * time.Second)
+	md, _ = ts.GetRequestMetadata(context.Background())
+	assert.Equal(t, "Bearer token-2", md["authorization"])
+	assert.Equal(t, int32(2), emitidos.Load())
+}
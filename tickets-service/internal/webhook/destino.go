@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrDestinoInterno e devolvido quando o webhook aponta para a rede interna:
+// loopback, faixas privadas, link-local (metadados da nuvem) e afins. Sem
+// essa trava, quem cadastra webhooks poderia usar as entregas, e o log das
+// respostas, para alcancar servicos que nao sao expostos.
+var ErrDestinoInterno = errors.New("destino do webhook na rede interna")
+
+// cgnat e a faixa compartilhada 100.64.0.0/10, usada tambem por alguns
+// provedores de Kubernetes para os pods.
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// sufixosInternos sao nomes que so resolvem dentro do cluster ou da maquina.
+var sufixosInternos = []string{".localhost", ".local", ".internal", ".cluster.local"}
+
+// ValidarURL confere, no cadastro, que a URL e http ou https e nao aponta
+// para um nome ou endereco interno. Um nome publico que resolva para a rede
+// interna ainda e barrado na conexao, por enderecoPermitido.
+func ValidarURL(bruta string) error {
+	u, err := url.Parse(bruta)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("URL inválida, deve ser um endereço http ou https")
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !enderecoPermitido(ip) {
+			return ErrDestinoInterno
+		}
+		return nil
+	}
+	// Nomes sem dominio, como "users-service" ou "postgres", sao servicos
+	// do proprio cluster.
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return ErrDestinoInterno
+	}
+	for _, s := range sufixosInternos {
+		if strings.HasSuffix(host, s) {
+			return ErrDestinoInterno
+		}
+	}
+	return nil
+}
+
+// enderecoPermitido diz se o webhook pode conectar no endereco.
+func enderecoPermitido(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !cgnat.Contains(ip)
+}
+
+// controlarDestino roda a cada conexao, ja com o endereco resolvido, entao
+// vale tambem para nomes cujo DNS muda depois do cadastro.
+func controlarDestino(network, endereco string, c syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(endereco)
+	if err != nil {
+		return err
+	}
+	if !enderecoPermitido(ap.Addr()) {
+		return ErrDestinoInterno
+	}
+	return nil
+}
+
+// transporteExterno e o transporte das entregas: so conecta em enderecos
+// publicos e ignora HTTP_PROXY, que faria a conexao (e a checagem) ser com
+// o proxy e nao com o destino.
+func transporteExterno() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = (&net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   controlarDestino,
+	}).DialContext
+	return t
+}
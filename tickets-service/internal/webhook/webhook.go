@@ -0,0 +1,209 @@
+// Package webhook entrega os eventos de dominio dos tickets a servicos
+// externos (ferramentas de ITSM, chat etc.) que os assinaram. Cada entrega e
+// um job da fila, de modo que as novas tentativas com backoff e a fila de
+// mortos vem da propria fila; cada tentativa fica registrada no log de entregas.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/queue"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Cabecalhos enviados em cada entrega. X-Event-ID e X-Event-Type seguem o
+// HTTPSink da outbox; o ID do evento e o mesmo em todas as tentativas e
+// permite ao destino descartar repeticoes.
+const (
+	CabecalhoEventoID   = "X-Event-ID"
+	CabecalhoEventoTipo = "X-Event-Type"
+	CabecalhoWebhookID  = "X-Webhook-ID"
+	CabecalhoTimestamp  = "X-Webhook-Timestamp"
+	CabecalhoAssinatura = "X-Webhook-Signature"
+)
+
+// MaxResposta e quanto do corpo da resposta e guardado no log de entregas.
+const MaxResposta = 1024
+
+// Store e o que o Entregador precisa do banco. E implementado por
+// *repository.Repository.
+type Store interface {
+	ListWebhooksForEvent(ctx context.Context, tipo string) ([]model.Webhook, error)
+	GetWebhookByID(ctx context.Context, id int64) (model.Webhook, error)
+	RecordWebhookDelivery(ctx context.Context, d model.WebhookDelivery) (model.WebhookDelivery, error)
+}
+
+// Assinar calcula a assinatura enviada em X-Webhook-Signature: o
+// HMAC-SHA256, com o segredo do webhook, de "<timestamp>.<corpo>". Incluir o
+// timestamp permite ao destino recusar entregas antigas reenviadas por terceiros.
+func Assinar(segredo string, timestamp int64, corpo []byte) string {
+	mac := hmac.New(sha256.New, []byte(segredo))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(corpo)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verificar confere uma assinatura gerada por Assinar em tempo constante.
+func Verificar(segredo, assinatura string, timestamp int64, corpo []byte) bool {
+	return hmac.Equal([]byte(assinatura), []byte(Assinar(segredo, timestamp, corpo)))
+}
+
+// GerarSegredo cria um segredo aleatorio para webhooks cadastrados sem um.
+func GerarSegredo() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// EventoTeste monta o evento enviado pelo endpoint de teste do webhook.
+func EventoTeste(w model.Webhook) model.DomainEvent {
+	dados, _ := json.Marshal(map[string]int64{"webhook_id": w.ID})
+	return model.DomainEvent{Tipo: model.EventoTeste, Dados: dados, CriadoEm: time.Now().UTC()}
+}
+
+// Entregador faz o POST assinado de cada evento e registra o resultado.
+type Entregador struct {
+	Store   Store
+	Cliente *http.Client
+}
+
+func NovoEntregador(store Store) *Entregador {
+	return &Entregador{
+		Store: store,
+		Cliente: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: transporteExterno(),
+			// Um redirecionamento transformaria o POST em GET sem corpo;
+			// a resposta 3xx conta como falha e aparece no log.
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Entregar envia o evento ao webhook e grava a tentativa no log de entregas.
+// Qualquer resposta fora da faixa 2xx e devolvida como erro.
+func (e *Entregador) Entregar(ctx context.Context, w model.Webhook, evento model.DomainEvent) (model.WebhookDelivery, error) {
+	entrega := model.WebhookDelivery{WebhookID: w.ID, EventoID: evento.ID, Tipo: evento.Tipo}
+
+	inicio := time.Now()
+	status, resposta, errEnvio := e.enviar(ctx, w, evento)
+	entrega.DuracaoMs = time.Since(inicio).Milliseconds()
+	entrega.StatusCode = status
+	entrega.Resposta = resposta
+	if errEnvio == nil && (status < 200 || status > 299) {
+		errEnvio = fmt.Errorf("destino respondeu %d", status)
+	}
+	if errEnvio != nil {
+		entrega.Erro = errEnvio.Error()
+	}
+	entrega.Sucesso = errEnvio == nil
+
+	// O log nao deve depender do contexto da entrega, que pode ter expirado.
+	registrada, err := e.Store.RecordWebhookDelivery(context.WithoutCancel(ctx), entrega)
+	if err != nil {
+		registro.Logger(ctx).Error("Erro ao registrar a entrega do evento ao webhook", "evento_id", evento.ID, "webhook_id", w.ID, "erro", err)
+	} else {
+		entrega = registrada
+	}
+
+	return entrega, errEnvio
+}
+
+func (e *Entregador) enviar(ctx context.Context, w model.Webhook, evento model.DomainEvent) (int, string, error) {
+	corpo, err := json.Marshal(evento)
+	if err != nil {
+		return 0, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(corpo))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "helpdesk-webhooks/1.0")
+	req.Header.Set(CabecalhoEventoID, strconv.FormatInt(evento.ID, 10))
+	req.Header.Set(CabecalhoEventoTipo, evento.Tipo)
+	req.Header.Set(CabecalhoWebhookID, strconv.FormatInt(w.ID, 10))
+	req.Header.Set(CabecalhoTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(CabecalhoAssinatura, Assinar(w.Segredo, timestamp, corpo))
+
+	cliente := e.Cliente
+	if cliente == nil {
+		cliente = http.DefaultClient
+	}
+	resposta, err := cliente.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resposta.Body.Close()
+
+	inicio, _ := io.ReadAll(io.LimitReader(resposta.Body, MaxResposta))
+	io.Copy(io.Discard, io.LimitReader(resposta.Body, 64<<10))
+
+	return resposta.StatusCode, strings.ToValidUTF8(string(inicio), ""), nil
+}
+
+// Processar trata um job de entrega. O webhook e relido para que uma
+// assinatura excluida, desativada ou alterada depois do evento nao receba mais
+// nada; o erro de envio volta para a fila, que agenda a proxima tentativa.
+func (e *Entregador) Processar(ctx context.Context, job model.WebhookJob) error {
+	w, err := e.Store.GetWebhookByID(ctx, job.WebhookID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("erro ao consultar o webhook %d: %w", job.WebhookID, err)
+	}
+	if !w.Recebe(job.Evento) {
+		return nil
+	}
+
+	_, err = e.Entregar(ctx, w, job.Evento)
+	return err
+}
+
+// AssinanteOutbox enfileira uma entrega por webhook interessado em cada
+// evento publicado pela outbox. A chave do job combina webhook e evento,
+// entao um evento reentregue pela outbox nao gera entrega duplicada.
+func (e *Entregador) AssinanteOutbox(fila *queue.Fila) func(ctx context.Context, evento model.DomainEvent) error {
+	return func(ctx context.Context, evento model.DomainEvent) error {
+		if !model.EventosWebhook[evento.Tipo] {
+			return nil
+		}
+
+		webhooks, err := e.Store.ListWebhooksForEvent(ctx, evento.Tipo)
+		if err != nil {
+			return err
+		}
+
+		for _, w := range webhooks {
+			if !w.Recebe(evento) {
+				continue
+			}
+			chave := fmt.Sprintf("webhook-%d-evento-%d", w.ID, evento.ID)
+			if err := fila.EnfileirarUnico(ctx, chave, model.TipoJobWebhook, model.WebhookJob{WebhookID: w.ID, Evento: evento}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
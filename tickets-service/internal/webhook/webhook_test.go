@@ -0,0 +1,211 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"helpdesk/tickets-service/internal/model"
+	"helpdesk/tickets-service/internal/queue"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// storeMemoria guarda webhooks, entregas e jobs em memoria.
+type storeMemoria struct {
+	mu       sync.Mutex
+	webhooks []model.Webhook
+	entregas []model.WebhookDelivery
+	jobs     map[string]model.WebhookJob
+}
+
+func (s *storeMemoria) ListWebhooksForEvent(ctx context.Context, tipo string) ([]model.Webhook, error) {
+	var lista []model.Webhook
+	for _, w := range s.webhooks {
+		if w.Ativo {
+			lista = append(lista, w)
+		}
+	}
+	return lista, nil
+}
+
+func (s *storeMemoria) GetWebhookByID(ctx context.Context, id int64) (model.Webhook, error) {
+	for _, w := range s.webhooks {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return model.Webhook{}, pgx.ErrNoRows
+}
+
+func (s *storeMemoria) RecordWebhookDelivery(ctx context.Context, d model.WebhookDelivery) (model.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d.ID = int64(len(s.entregas) + 1)
+	for _, e := range s.entregas {
+		if e.WebhookID == d.WebhookID && e.EventoID == d.EventoID {
+			d.Tentativa = e.Tentativa
+		}
+	}
+	d.Tentativa++
+	s.entregas = append(s.entregas, d)
+	return d, nil
+}
+
+func (s *storeMemoria) EnqueueJob(ctx context.Context, tipo, chave string, payload any, maxTentativas int) (int64, error) {
+	if _, ok := s.jobs[chave]; ok {
+		return 0, nil
+	}
+	s.jobs[chave] = payload.(model.WebhookJob)
+	return int64(len(s.jobs)), nil
+}
+
+func (s *storeMemoria) ClaimJob(ctx context.Context) (model.Job, error) {
+	return model.Job{}, pgx.ErrNoRows
+}
+func (s *storeMemoria) RecoverJobs(ctx context.Context, lease time.Duration) (int64, error) {
+	return 0, nil
+}
+func (s *storeMemoria) PurgeJobs(ctx context.Context, antesDe time.Time) (int64, error) {
+	return 0, nil
+}
+func (s *storeMemoria) CompleteJob(ctx context.Context, job model.Job) error { return nil }
+func (s *storeMemoria) RetryJobLater(ctx context.Context, job model.Job, disponivelEm time.Time, erro string) error {
+	return nil
+}
+func (s *storeMemoria) KillJob(ctx context.Context, job model.Job, erro string) error { return nil }
+
+func eventoComentario(id int64, tipo string) model.DomainEvent {
+	dados, _ := json.Marshal(model.CommentEventData{Comentario: model.Comentario{ID: 7, Tipo: tipo}})
+	return model.DomainEvent{ID: id, Tipo: model.DominioTicketComentado, TicketID: 1, Dados: dados}
+}
+
+func TestAssinar(t *testing.T) {
+	corpo := []byte(`{"id":1}`)
+	assinatura := Assinar("segredo", 1700000000, corpo)
+
+	assert.Equal(t, "sha256=", assinatura[:7])
+	assert.True(t, Verificar("segredo", assinatura, 1700000000, corpo))
+	assert.False(t, Verificar("outro", assinatura, 1700000000, corpo))
+	assert.False(t, Verificar("segredo", assinatura, 1700000001, corpo))
+	assert.False(t, Verificar("segredo", assinatura, 1700000000, []byte(`{"id":2}`)))
+}
+
+func TestEntregar(t *testing.T) {
+	status := http.StatusOK
+	var verificada bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		corpo, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(CabecalhoTimestamp), 10, 64)
+		verificada = Verificar("s3gredo", r.Header.Get(CabecalhoAssinatura), timestamp, corpo)
+		w.WriteHeader(status)
+		w.Write([]byte("resposta do destino"))
+	}))
+	defer srv.Close()
+
+	store := &storeMemoria{}
+	e := NovoEntregador(store)
+	// O servidor de teste escuta no loopback, que as entregas de verdade recusam.
+	e.Cliente.Transport = srv.Client().Transport
+	w := model.Webhook{ID: 3, URL: srv.URL, Segredo: "s3gredo", Ativo: true}
+	evento := model.DomainEvent{ID: 10, Tipo: model.DominioTicketCriado, TicketID: 1, Dados: []byte(`{}`)}
+
+	entrega, err := e.Entregar(context.Background(), w, evento)
+	assert.NoError(t, err)
+	assert.True(t, verificada, "a assinatura deve conferir com o segredo do webhook")
+	assert.True(t, entrega.Sucesso)
+	assert.Equal(t, http.StatusOK, entrega.StatusCode)
+	assert.Equal(t, 1, entrega.Tentativa)
+
+	status = http.StatusBadGateway
+	entrega, err = e.Entregar(context.Background(), w, evento)
+	assert.Error(t, err)
+	assert.False(t, entrega.Sucesso)
+	assert.Equal(t, http.StatusBadGateway, entrega.StatusCode)
+	assert.Equal(t, "resposta do destino", entrega.Resposta)
+	assert.Equal(t, 2, entrega.Tentativa)
+	assert.Len(t, store.entregas, 2)
+}
+
+func TestEntregar_DestinoInterno(t *testing.T) {
+	recebeu := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recebeu = true
+		w.Write([]byte("segredo interno"))
+	}))
+	defer srv.Close()
+
+	store := &storeMemoria{}
+	w := model.Webhook{ID: 3, URL: srv.URL, Ativo: true}
+	entrega, err := NovoEntregador(store).Entregar(context.Background(), w, model.DomainEvent{ID: 10, Tipo: model.DominioTicketCriado})
+
+	assert.ErrorIs(t, err, ErrDestinoInterno)
+	assert.False(t, recebeu, "a conexao com o loopback deveria ser recusada")
+	assert.Empty(t, entrega.Resposta)
+}
+
+func TestValidarURL(t *testing.T) {
+	for _, u := range []string{"https://itsm.acme.com/hooks", "http://203.0.113.10:8080/hook"} {
+		assert.NoError(t, ValidarURL(u), u)
+	}
+	internas := []string{
+		"http://localhost/hook",
+		"http://127.0.0.1:8081/users",
+		"http://[::1]/hook",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://100.64.0.1/hook",
+		"http://0.0.0.0/hook",
+		"http://users-service:8081/users",
+		"http://postgres.default.svc.cluster.local/",
+		"http://metadata.google.internal/",
+	}
+	for _, u := range internas {
+		assert.ErrorIs(t, ValidarURL(u), ErrDestinoInterno, u)
+	}
+	assert.Error(t, ValidarURL("ftp://acme.com/hook"))
+}
+
+func TestAssinanteOutbox(t *testing.T) {
+	store := &storeMemoria{
+		jobs: map[string]model.WebhookJob{},
+		webhooks: []model.Webhook{
+			{ID: 1, Ativo: true, Eventos: []string{model.DominioTicketCriado, model.DominioTicketComentado}},
+			{ID: 2, Ativo: true, Eventos: []string{model.DominioTicketComentado}, IncluirInternos: true},
+			{ID: 3, Ativo: false, Eventos: []string{model.DominioTicketCriado}},
+		},
+	}
+	publicar := NovoEntregador(store).AssinanteOutbox(queue.NovaFila(store, queue.Config{MaxTentativas: 3}))
+
+	criado := model.DomainEvent{ID: 1, Tipo: model.DominioTicketCriado, TicketID: 1}
+	assert.NoError(t, publicar(context.Background(), criado))
+	assert.NoError(t, publicar(context.Background(), criado))
+	assert.Len(t, store.jobs, 1, "um evento reentregue nao deve gerar outra entrega")
+	assert.Contains(t, store.jobs, "webhook-1-evento-1")
+
+	// Nota interna so vai para quem pediu eventos internos.
+	assert.NoError(t, publicar(context.Background(), eventoComentario(2, model.ComentarioInterno)))
+	assert.Contains(t, store.jobs, "webhook-2-evento-2")
+	assert.NotContains(t, store.jobs, "webhook-1-evento-2")
+
+	// Eventos que nao podem ser assinados sao ignorados.
+	assert.NoError(t, publicar(context.Background(), model.DomainEvent{ID: 3, Tipo: model.DominioComentarioMencionou}))
+	assert.Len(t, store.jobs, 2)
+}
+
+func TestProcessar_WebhookExcluidoOuDesativado(t *testing.T) {
+	store := &storeMemoria{webhooks: []model.Webhook{{ID: 1, URL: "http://127.0.0.1:1", Ativo: false, Eventos: []string{model.DominioTicketCriado}}}}
+	e := NovoEntregador(store)
+	evento := model.DomainEvent{ID: 1, Tipo: model.DominioTicketCriado}
+
+	assert.NoError(t, e.Processar(context.Background(), model.WebhookJob{WebhookID: 99, Evento: evento}))
+	assert.NoError(t, e.Processar(context.Background(), model.WebhookJob{WebhookID: 1, Evento: evento}))
+	assert.Empty(t, store.entregas)
+}
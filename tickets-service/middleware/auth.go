@@ -2,15 +2,29 @@ package middleware
 
 import (
 	"context"
+	"helpdesk/pkg/auditoria"
+	"helpdesk/pkg/problema"
+	"helpdesk/pkg/registro"
 	"helpdesk/tickets-service/auth"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
 // Definimos uma chave para usar no contexto e evitar colisões.
 type contextKey string
 
-const UserIDKey contextKey = "userID"
+const (
+	UserIDKey   contextKey = "userID"
+	TipoUserKey contextKey = "tipoUser"
+	// ActorIDKey guarda quem realmente fez a requisicao. E igual a UserIDKey,
+	// exceto quando um admin esta personificando outro usuario.
+	ActorIDKey contextKey = "actorID"
+)
+
+// HeaderPersonificacao e enviado em todas as respostas feitas com um token de
+// personificacao, para que o front-end exiba o aviso de "logado como".
+const HeaderPersonificacao = "X-Impersonated-By"
 
 // AuthMiddleware é a "muralha magica".
 // Ele recebe um 'handler' (a proxima sala do castelo) e retorna um novo 'handler' (o portao com o guarda).
@@ -19,7 +33,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		// ... (lógica para extrair o token do header)
 		headerParts := strings.Split(r.Header.Get("Authorization"), " ")
 		if len(headerParts) != 2 || strings.ToLower(headerParts[0]) != "bearer" {
-			http.Error(w, "Formato do cabeçalho de autorização é invalido", http.StatusUnauthorized)
+			problema.Escrever(w, r, http.StatusUnauthorized, "Formato do cabeçalho de autorização é invalido")
 			return
 		}
 		tokenString := headerParts[1]
@@ -27,14 +41,43 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		// CHAMADA ATUALIZADA: Agora usamos nossa função de validação centralizada!
 		claims, err := auth.ValidarToken(tokenString)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusUnauthorized)
+			problema.Escrever(w, r, http.StatusUnauthorized, err.Error())
 			return
 		}
 
-		// Injetamos o ID do usuário (vindo das claims) no contexto da requisição.
-		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+		if claims.ActorID != 0 {
+			w.Header().Set(HeaderPersonificacao, strconv.FormatInt(claims.ActorID, 10))
+		}
 
 		// Passa a requisição com o novo contexto para o próximo handler.
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r.WithContext(ComUsuario(r.Context(), claims)))
+	})
+}
+
+// ComUsuario injeta no contexto o usuario autenticado pelo token e o informa
+// ao log de acesso. E o mesmo contexto para requisicoes HTTP e chamadas gRPC.
+func ComUsuario(ctx context.Context, claims *auth.ClaimCustom) context.Context {
+	registro.DefinirUsuario(ctx, claims.UserID)
+	ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
+	ctx = context.WithValue(ctx, TipoUserKey, claims.TipoUser)
+
+	actorID := claims.UserID
+	if claims.ActorID != 0 {
+		actorID = claims.ActorID
+	}
+	ctx = auditoria.ComAtor(ctx, claims.UserID, actorID)
+	return context.WithValue(ctx, ActorIDKey, actorID)
+}
+
+// TokenDaQuery aceita o token em ?access_token= quando nao ha cabecalho
+// Authorization. Existe para o EventSource e o WebSocket do navegador, que nao
+// permitem enviar cabecalhos; deve vir antes do AuthMiddleware e ser usado so
+// nas rotas de stream, para que o token nao circule em URLs sem necessidade.
+func TokenDaQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		next.ServeHTTP(w, r)
 	})
 }
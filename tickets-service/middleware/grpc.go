@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"helpdesk/pkg/auditoria"
+	"helpdesk/pkg/registro"
+	"helpdesk/tickets-service/auth"
+	"helpdesk/tickets-service/internal/model"
+	"path"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// autenticarGRPC le o JWT do metadata "authorization" ("Bearer <token>"),
+// como o AuthMiddleware faz com o cabecalho HTTP.
+func autenticarGRPC(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	valores := md.Get("authorization")
+	if len(valores) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "Token ausente no metadata authorization")
+	}
+
+	partes := strings.Split(valores[0], " ")
+	if len(partes) != 2 || strings.ToLower(partes[0]) != "bearer" {
+		return nil, status.Error(codes.Unauthenticated, "Formato do metadata authorization é invalido")
+	}
+
+	claims, err := auth.ValidarToken(partes[1])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return ComUsuario(ctx, claims), nil
+}
+
+// AuthUnario e o AuthMiddleware das chamadas gRPC unarias. A checagem de
+// saude (grpc.health.v1) dispensa o token.
+func AuthUnario(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if publico(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	ctx, err := autenticarGRPC(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// AuthStream e o AuthMiddleware das chamadas gRPC com stream.
+func AuthStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if publico(info.FullMethod) {
+		return handler(srv, ss)
+	}
+	ctx, err := autenticarGRPC(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &streamComContexto{ServerStream: ss, ctx: ctx})
+}
+
+func publico(metodo string) bool {
+	return strings.HasPrefix(metodo, "/grpc.health.v1.Health/")
+}
+
+type streamComContexto struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *streamComContexto) Context() context.Context {
+	return s.ctx
+}
+
+// AuditUnario registra as chamadas gRPC de escrita, como o auditoria.HTTP faz
+// com as rotas HTTP. Metodo e "GRPC", Rota e o nome completo do metodo e
+// Status e o codigo gRPC da resposta. Deve vir depois do AuthUnario.
+func AuditUnario(servico string, registrar func(model.AuditLog) error) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if publico(info.FullMethod) || leitura(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		resp, err := handler(ctx, req)
+
+		userID, actorID := auditoria.Ator(ctx)
+		entrada := model.AuditLog{
+			ActorID: actorID,
+			UserID:  userID,
+			Servico: servico,
+			Metodo:  "GRPC",
+			Rota:    info.FullMethod,
+			Caminho: info.FullMethod,
+			Status:  int(status.Code(err)),
+		}
+		if errReg := registrar(entrada); errReg != nil {
+			registro.Logger(ctx).Error("Erro ao registrar log de auditoria", "erro", errReg)
+		}
+		return resp, err
+	}
+}
+
+// leitura reconhece os metodos que so consultam dados pelo nome (Get*, List*, Watch*).
+func leitura(metodo string) bool {
+	nome := path.Base(metodo)
+	return strings.HasPrefix(nome, "Get") || strings.HasPrefix(nome, "List") || strings.HasPrefix(nome, "Watch")
+}
@@ -2,31 +2,48 @@ package auth
 
 import (
 	"fmt"
-	"os"
+	"helpdesk/pkg/config"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var chaveJWT = []byte(os.Getenv("SEGREDOJWT"))
+// chaveJWT assina os tokens de usuario. Vem da configuracao, por Configurar.
+var chaveJWT []byte
+
+// validadeToken e a duracao dos tokens de login.
+var validadeToken = 60 * time.Minute
+
+// Configurar define as chaves e a validade a partir da configuracao
+// carregada. Deve ser chamada antes de o servico atender.
+func Configurar(cfg config.JWT) {
+	chaveJWT = []byte(cfg.Segredo)
+	chaveServico = []byte(cfg.SegredoServicos)
+	validadeToken = cfg.Validade
+}
 
 // ClaimCustom continua o mesmo, pois não depende de nenhum modelo específico.
 type ClaimCustom struct {
-	UserID int64  `json:"userID"`
-	Nome   string `json:"nome"`
-	Email  string `json:"email"`
+	UserID   int64  `json:"userID"`
+	Nome     string `json:"nome"`
+	Email    string `json:"email"`
+	TipoUser string `json:"tipoUser"`
+	// ActorID so e preenchido em tokens de personificacao: e o admin que
+	// esta de fato operando enquanto UserID e o usuario personificado.
+	ActorID int64 `json:"actorID,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // GerarToken agora recebe os dados do usuário diretamente.
 // Isso quebra a dependência que tínhamos do pacote 'model' do 'users-service'.
-func GerarToken(userID int64, nome, email string) (string, error) {
+func GerarToken(userID int64, nome, email, tipoUser string) (string, error) {
 	claims := ClaimCustom{
-		UserID: userID,
-		Nome:   nome,
-		Email:  email,
+		UserID:   userID,
+		Nome:     nome,
+		Email:    email,
+		TipoUser: tipoUser,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(60 * time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(validadeToken)),
 			Issuer:    "help-desk-api",
 		},
 	}
@@ -34,6 +51,29 @@ func GerarToken(userID int64, nome, email string) (string, error) {
 	return token.SignedString(chaveJWT)
 }
 
+// DuracaoPersonificacao limita o tempo de vida dos tokens de "logar como".
+const DuracaoPersonificacao = 15 * time.Minute
+
+// GerarTokenPersonificacao emite um token curto em nome do usuario personificado,
+// guardando o admin responsavel em ActorID.
+func GerarTokenPersonificacao(userID int64, nome, email, tipoUser string, actorID int64) (string, time.Time, error) {
+	expira := time.Now().Add(DuracaoPersonificacao)
+	claims := ClaimCustom{
+		UserID:   userID,
+		Nome:     nome,
+		Email:    email,
+		TipoUser: tipoUser,
+		ActorID:  actorID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expira),
+			Issuer:    "help-desk-api",
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	assinado, err := token.SignedString(chaveJWT)
+	return assinado, expira, err
+}
+
 // ValidarToken pode ser melhorado para retornar as 'claims' em caso de sucesso.
 func ValidarToken(tokenString string) (*ClaimCustom, error) {
 	claims := &ClaimCustom{}
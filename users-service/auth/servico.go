@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Escopos dos tokens de servico. Cada metodo interno exige um deles.
+const (
+	EscopoUsuariosLer = "usuarios.ler"
+)
+
+// DuracaoTokenServico e a validade dos tokens emitidos para os servicos, que
+// os renovam sozinhos antes de vencer.
+const DuracaoTokenServico = 10 * time.Minute
+
+// audienciaServico separa os tokens de servico dos de usuario: um token de
+// login nunca passa na ValidarTokenServico, nem o contrario.
+const audienciaServico = "helpdesk-interno"
+
+// chaveServico assina os tokens de servico. E diferente da chaveJWT para
+// que o vazamento de uma nao comprometa a outra. Vem de Configurar.
+var chaveServico []byte
+
+var ErrClienteInvalido = errors.New("cliente de serviço inválido")
+
+// ClienteServico e a identidade de um servico interno: o segredo com que ele
+// pede tokens e os escopos que pode receber.
+type ClienteServico struct {
+	ID      string
+	Segredo string
+	Escopos []string
+}
+
+// ClientesServico indexa os clientes pelo ID.
+type ClientesServico map[string]ClienteServico
+
+// ParseClientesServico le uma lista separada por virgula de
+// "id:segredo:escopo1 escopo2", por exemplo
+// "tickets-service:s3nha:usuarios.ler", como a de
+// config.ServicoClientes. Entradas malformadas sao um erro.
+func ParseClientesServico(valor string) (ClientesServico, error) {
+	clientes := ClientesServico{}
+	for _, entrada := range strings.Split(valor, ",") {
+		if entrada = strings.TrimSpace(entrada); entrada == "" {
+			continue
+		}
+		partes := strings.SplitN(entrada, ":", 3)
+		if len(partes) != 3 || partes[0] == "" || partes[1] == "" {
+			return nil, fmt.Errorf("cliente de serviço malformado, use id:segredo:escopos: %q", partes[0])
+		}
+		clientes[partes[0]] = ClienteServico{ID: partes[0], Segredo: partes[1], Escopos: strings.Fields(partes[2])}
+	}
+	return clientes, nil
+}
+
+// Autenticar confere o segredo do cliente em tempo constante.
+func (c ClientesServico) Autenticar(id, segredo string) (ClienteServico, error) {
+	cliente, ok := c[id]
+	if !ok || subtle.ConstantTimeCompare([]byte(segredo), []byte(cliente.Segredo)) != 1 {
+		return ClienteServico{}, ErrClienteInvalido
+	}
+	return cliente, nil
+}
+
+// ClaimServico identifica o servico que fez a chamada e o que ele pode fazer.
+type ClaimServico struct {
+	Servico string   `json:"servico"`
+	Escopos []string `json:"escopos"`
+	jwt.RegisteredClaims
+}
+
+// Permite diz se o token da o escopo pedido.
+func (c *ClaimServico) Permite(escopo string) bool {
+	return slices.Contains(c.Escopos, escopo)
+}
+
+// GerarTokenServico emite um token curto para o cliente, com os escopos
+// pedidos que ele pode receber (todos, se nenhum for pedido).
+func GerarTokenServico(cliente ClienteServico, pedidos []string) (string, ClaimServico, error) {
+	escopos := cliente.Escopos
+	if len(pedidos) > 0 {
+		escopos = nil
+		for _, e := range pedidos {
+			if !slices.Contains(cliente.Escopos, e) {
+				return "", ClaimServico{}, fmt.Errorf("escopo não permitido para %s: %s", cliente.ID, e)
+			}
+			escopos = append(escopos, e)
+		}
+	}
+	chave := chaveServico
+	if len(chave) == 0 {
+		return "", ClaimServico{}, errors.New("SEGREDO_TOKENS_SERVICO não definido")
+	}
+
+	agora := time.Now()
+	claims := ClaimServico{
+		Servico: cliente.ID,
+		Escopos: escopos,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "servico:" + cliente.ID,
+			Audience:  jwt.ClaimStrings{audienciaServico},
+			IssuedAt:  jwt.NewNumericDate(agora),
+			ExpiresAt: jwt.NewNumericDate(agora.Add(DuracaoTokenServico)),
+			Issuer:    "users-service",
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(chave)
+	return token, claims, err
+}
+
+// ValidarTokenServico aceita apenas tokens emitidos pela GerarTokenServico.
+func ValidarTokenServico(tokenString string) (*ClaimServico, error) {
+	chave := chaveServico
+	if len(chave) == 0 {
+		return nil, errors.New("SEGREDO_TOKENS_SERVICO não definido")
+	}
+
+	claims := &ClaimServico{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return chave, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(audienciaServico), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("token de serviço inválido: %w", err)
+	}
+	if claims.Servico == "" {
+		return nil, errors.New("token de serviço sem identidade")
+	}
+	return claims, nil
+}
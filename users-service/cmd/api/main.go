@@ -1,60 +1,191 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	pkg "helpdesk/db"
+	"helpdesk/pkg/auditoria"
+	"helpdesk/pkg/config"
+	"helpdesk/pkg/metricas"
+	"helpdesk/pkg/pb"
+	"helpdesk/pkg/rastreio"
+	"helpdesk/pkg/registro"
+	"helpdesk/pkg/sonda"
+	"helpdesk/users-service/auth"
 	"helpdesk/users-service/internal/handler"
 	"helpdesk/users-service/internal/repository"
 	"helpdesk/users-service/middleware"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func main() {
-	runMigrations()
+	cfg, err := config.Carregar(config.UsersService, os.Args[1:])
+	if err != nil {
+		falhar("Configuração inválida", "erro", err)
+	}
+	registro.Configurar(os.Stdout, cfg.Servico, cfg.Log)
+	slog.Info("Configuração carregada", "config", cfg.String())
+	auth.Configurar(cfg.JWT)
+
+	encerrarRastreio, err := rastreio.Iniciar(context.Background(), cfg.Servico, cfg.Rastreio)
+	if err != nil {
+		falhar("Erro ao iniciar o rastreio", "erro", err)
+	}
+
+	// SIGTERM ou SIGINT iniciam o desligamento.
+	ctx, parar := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer parar()
 
-	db, err := pkg.ConectaDB()
+	runMigrations(cfg.DB.URL)
+
+	db, err := pkg.ConectaDB(cfg.DB)
 	if err != nil {
-		log.Fatalf("Erro ao iniciar o banco de dados: %v", err)
+		falhar("Erro ao iniciar o banco de dados", "erro", err)
 	}
 
 	repo := repository.NewRepository(db)
 	apiServer := handler.NewApiServer(repo)
 
+	// Identidades dos servicos internos: cada um troca o proprio segredo por
+	// um token curto em /services/token e o usa na API gRPC interna, em vez
+	// do token do usuario.
+	clientes, err := auth.ParseClientesServico(cfg.ServicoClientes.Clientes)
+	if err != nil {
+		falhar("Erro ao carregar os clientes de serviço", "erro", err)
+	}
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(rastreio.ServidorUnario, registro.ServidorUnario, middleware.TokenServico(middleware.MetodosInternos)))
+	pb.RegisterUserDirectoryServer(grpcServer, handler.NewDiretorioGrpc(repo))
+	saudeGRPC := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, saudeGRPC)
+	go servirGRPC(grpcServer, cfg.Enderecos.GRPC)
+
+	prometheus.MustRegister(metricas.NovoColetorPool(db))
+
 	r := chi.NewRouter()
+	r.Use(metricas.HTTP)
+	r.Use(rastreio.HTTP)
+	r.Use(registro.HTTP)
 	r.Get("/health", handler.HealthCheckHandler)
+
+	versao, err := pkg.VersaoMigracoes("db/migrations")
+	if err != nil {
+		falhar("Erro ao ler as migrações", "erro", err)
+	}
+	sondas := sonda.Nova(2 * time.Second)
+	sondas.Adicionar("postgres", db.Ping)
+	sondas.Adicionar("migracoes", pkg.ChecarMigracoes(db, versao))
+	r.Get("/livez", sondas.LivezHandler)
+	r.Get("/readyz", sondas.ReadyzHandler)
+	r.Handle("/metrics", metricas.Handler())
 	r.Post("/users", apiServer.CreateUserHandler)
 	r.Post("/users/login", apiServer.LoginUserHandler)
+	r.Post("/services/token", handler.ServiceTokenHandler(clientes))
 
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.AuthMiddleware)
+		r.Use(auditoria.HTTP("users-service", repo.RecordAudit))
 		r.Get("/users/me", apiServer.GetMeHandler)
+		r.Get("/users/me/mentions", apiServer.GetMyMentionsHandler)
+		r.Post("/users/me/mentions/{id}/resolve", apiServer.ResolveMentionHandler)
 		r.Get("/users", apiServer.ListUsersHandler)
 		r.Get("/users/{id}", apiServer.GetUserHandler)
 		r.Put("/users/{id}", apiServer.UpdateUserHandler)
 		r.Delete("/users/{id}", apiServer.DeleteUserHandler)
+		r.Post("/organizations", apiServer.CreateOrganizationHandler)
+		r.Get("/organizations/{id}/members", apiServer.ListOrganizationMembersHandler)
+		r.Post("/organizations/{id}/members", apiServer.AddOrganizationMemberHandler)
+		r.Delete("/organizations/{id}/members/{userID}", apiServer.RemoveOrganizationMemberHandler)
+		r.Post("/users/{id}/impersonate", apiServer.ImpersonateUserHandler)
+		r.Patch("/users/{id}/tipo", apiServer.UpdateUserTipoHandler)
+		r.Get("/audit-logs", apiServer.ListAuditLogsHandler)
 	})
-	http.ListenAndServe(":8082", r)
-	fmt.Println("Servidor HTTP iniciado na porta 8082")
+
+	srv := &http.Server{Addr: cfg.Enderecos.HTTP, Handler: r}
+	go func() {
+		slog.Info("Servidor HTTP iniciado", "endereco", cfg.Enderecos.HTTP)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			falhar("Falha ao iniciar o servidor HTTP", "erro", err)
+		}
+	}()
+
+	<-ctx.Done()
+	parar()
+	slog.Info("Desligando: saindo de rotação", "espera", cfg.Desligamento.Espera.String())
+	sondas.Desligando()
+	saudeGRPC.Shutdown()
+	time.Sleep(cfg.Desligamento.Espera)
+
+	prazo, cancelar := context.WithTimeout(context.Background(), cfg.Desligamento.Prazo)
+	defer cancelar()
+	go pararGRPC(prazo, grpcServer)
+	if err := srv.Shutdown(prazo); err != nil {
+		slog.Warn("Requisições HTTP interrompidas no prazo do desligamento", "erro", err)
+		srv.Close()
+	}
+	db.Close()
+	if err := encerrarRastreio(prazo); err != nil {
+		slog.Warn("Spans perdidos no desligamento", "erro", err)
+	}
+	slog.Info("Servidor desligado")
+}
+
+// pararGRPC espera as chamadas em andamento terminarem e, passado o prazo,
+// fecha as que restarem.
+func pararGRPC(prazo context.Context, s *grpc.Server) {
+	terminou := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(terminou)
+	}()
+	select {
+	case <-terminou:
+	case <-prazo.Done():
+		s.Stop()
+	}
 }
 
-func runMigrations() {
+func servirGRPC(s *grpc.Server, endereco string) {
+	lis, err := net.Listen("tcp", endereco)
+	if err != nil {
+		falhar("Falha ao abrir a porta do servidor gRPC", "erro", err)
+	}
+	slog.Info("Servidor gRPC iniciado", "endereco", endereco)
+	if err := s.Serve(lis); err != nil {
+		falhar("Falha ao iniciar o servidor gRPC", "erro", err)
+	}
+}
+
+func runMigrations(dbURL string) {
 	migrationDir := "file://db/migrations"
-	dbURL := os.Getenv("CHAVEDB")
 	m, err := migrate.New(migrationDir, dbURL)
 	if err != nil {
-		log.Fatalf("Erro ao criar a instancia de migração: %v", err)
+		falhar("Erro ao criar a instancia de migração", "erro", err)
 	}
 
 	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatalf("Erro ao aplicar migrações: %v", err)
+		falhar("Erro ao aplicar migrações", "erro", err)
 	}
 
-	log.Println("Migrações aplicadas com sucesso!")
+	slog.Info("Migrações aplicadas com sucesso!")
+}
+
+// falhar registra o erro que impede o servico de subir e encerra o processo.
+func falhar(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"helpdesk/pkg/problema"
+	"helpdesk/pkg/registro"
+	"helpdesk/users-service/auth"
+	"helpdesk/users-service/internal/model"
+	"helpdesk/users-service/middleware"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type impersonateResponse struct {
+	Token    string    `json:"token"`
+	ExpiraEm time.Time `json:"expira_em"`
+	UserID   int64     `json:"user_id"`
+	ActorID  int64     `json:"actor_id"`
+}
+
+// ImpersonateUserHandler permite que um admin "logue como" outro usuario para
+// reproduzir o que ele ve. O token emitido e curto e carrega o admin como ator,
+// de modo que as acoes de escrita sao auditadas em nome dele.
+func (api *ApiServer) ImpersonateUserHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	userIDReq, _ := r.Context().Value(middleware.UserIDKey).(int64)
+	actorID, ok := r.Context().Value(middleware.ActorIDKey).(int64)
+	if !ok {
+		problema.Escrever(w, r, http.StatusInternalServerError, "Não foi possivel extrair o ID do usuario do token")
+		return
+	}
+
+	if actorID != userIDReq {
+		problema.Escrever(w, r, http.StatusForbidden, "Não é possivel personificar a partir de uma sessão personificada")
+		return
+	}
+
+	idInt, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao converter o ID para inteiro")
+		return
+	}
+
+	alvo, err := api.rep.FindUserByID(int64(idInt))
+	if errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Usuario não encontrado no banco de dados")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar o usuario no banco de dados")
+		return
+	}
+
+	if alvo.TipoUser == model.TipoAdmin {
+		problema.Escrever(w, r, http.StatusForbidden, "Não é permitido personificar outro administrador")
+		return
+	}
+
+	token, expira, err := auth.GerarTokenPersonificacao(alvo.ID, alvo.Nome, alvo.Email, alvo.TipoUser, actorID)
+	if err != nil {
+		problema.Erro(w, r, err, "Não foi possivel gerar o tokenJwt")
+		return
+	}
+
+	registro.Logger(r.Context()).Info("Admin iniciou personificação", "admin_id", actorID, "usuario_id", alvo.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(impersonateResponse{Token: token, ExpiraEm: expira, UserID: alvo.ID, ActorID: actorID}); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar o token JWT")
+		return
+	}
+}
+
+// ListAuditLogsHandler expoe a trilha de auditoria para admins.
+// Aceita ?actor_id= para filtrar e ?limit= (padrao 100, maximo 1000).
+func (api *ApiServer) ListAuditLogsHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	var actorID int64
+	if v := r.URL.Query().Get("actor_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			problema.Escrever(w, r, http.StatusBadRequest, "actor_id inválido, deve ser um número inteiro")
+			return
+		}
+		actorID = id
+	}
+
+	limite := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 1000 {
+			problema.Escrever(w, r, http.StatusBadRequest, "limit inválido, deve estar entre 1 e 1000")
+			return
+		}
+		limite = n
+	}
+
+	lista, err := api.rep.ListAuditLogs(actorID, limite)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar os logs de auditoria")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(lista); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar a lista em JSON")
+		return
+	}
+}
+
+type tipoUserRequest struct {
+	TipoUser string `json:"tipoUser"`
+}
+
+// UpdateUserTipoHandler muda o papel de um usuario. So admins podem fazer
+// isso; o cadastro e o PUT /users/{id} nunca alteram o tipo. O novo papel
+// vale a partir do proximo token do usuario.
+func (api *ApiServer) UpdateUserTipoHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	idInt, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao converter o ID para inteiro")
+		return
+	}
+
+	var req tipoUserRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar a requisição")
+		return
+	}
+	if !model.TiposUser[req.TipoUser] {
+		problema.Erro(w, r, problema.Invalido("tipoUser", "Tipo de usuario inválido, use cliente, agente ou admin"), "Erro ao validar a requisição")
+		return
+	}
+
+	if err = api.rep.UpdateUserTipo(idInt, req.TipoUser); errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Usuario não encontrado no banco de dados")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao atualizar o tipo do usuario no banco de dados")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"encoding/json"
+	"helpdesk/pkg/auditoria"
+	"helpdesk/users-service/auth"
+	"helpdesk/users-service/internal/model"
+	"helpdesk/users-service/internal/repository"
+	"helpdesk/users-service/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpersonateUserHandler(t *testing.T) {
+	mockRepo := new(repository.MockUserRepository)
+	apiServer := NewApiServer(mockRepo)
+
+	cliente := model.User{ID: 2, Nome: "Cliente", Email: "cliente@acme.com", TipoUser: model.TipoCliente}
+	mockRepo.On("FindUserByID", cliente.ID).Return(cliente, nil)
+
+	adminToken, _ := auth.GerarToken(1, "Admin", "admin@acme.com", model.TipoAdmin)
+
+	router := chi.NewRouter()
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+		r.Post("/users/{id}/impersonate", apiServer.ImpersonateUserHandler)
+		r.Get("/users/me", apiServer.GetMeHandler)
+	})
+
+	req := httptest.NewRequest("POST", "/users/2/impersonate", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var resposta impersonateResponse
+	_ = json.NewDecoder(rr.Body).Decode(&resposta)
+
+	claims, err := auth.ValidarToken(resposta.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, cliente.ID, claims.UserID)
+	assert.Equal(t, int64(1), claims.ActorID)
+
+	// Com o token personificado, as respostas carregam o aviso de personificacao.
+	req = httptest.NewRequest("GET", "/users/me", nil)
+	req.Header.Set("Authorization", "Bearer "+resposta.Token)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get(middleware.HeaderPersonificacao))
+
+	// E nao e possivel encadear uma nova personificacao a partir dele.
+	req = httptest.NewRequest("POST", "/users/2/impersonate", nil)
+	req.Header.Set("Authorization", "Bearer "+resposta.Token)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuditMiddleware_RegistraAtorReal(t *testing.T) {
+	var registrado model.AuditLog
+	registrar := func(entrada model.AuditLog) error {
+		registrado = entrada
+		return nil
+	}
+
+	token, _, _ := auth.GerarTokenPersonificacao(2, "Cliente", "cliente@acme.com", model.TipoCliente, 1)
+
+	router := chi.NewRouter()
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+		r.Use(auditoria.HTTP("users-service", registrar))
+		r.Delete("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+
+	req := httptest.NewRequest("DELETE", "/users/2", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, int64(1), registrado.ActorID)
+	assert.Equal(t, int64(2), registrado.UserID)
+	assert.Equal(t, "/users/{id}", registrado.Rota)
+	assert.Equal(t, http.StatusNoContent, registrado.Status)
+}
+
+func TestUpdateUserTipoHandler(t *testing.T) {
+	mockRepo := new(repository.MockUserRepository)
+	apiServer := NewApiServer(mockRepo)
+	mockRepo.On("UpdateUserTipo", int64(2), model.TipoAgente).Return(nil)
+
+	router := chi.NewRouter()
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+		r.Patch("/users/{id}/tipo", apiServer.UpdateUserTipoHandler)
+	})
+
+	adminToken, _ := auth.GerarToken(1, "Admin", "admin@acme.com", model.TipoAdmin)
+	clienteToken, _ := auth.GerarToken(2, "Cliente", "cliente@acme.com", model.TipoCliente)
+
+	casos := []struct {
+		token    string
+		corpo    string
+		esperado int
+	}{
+		{adminToken, `{"tipoUser":"agente"}`, http.StatusNoContent},
+		{adminToken, `{"tipoUser":"root"}`, http.StatusBadRequest},
+		// Ninguem se promove sozinho.
+		{clienteToken, `{"tipoUser":"admin"}`, http.StatusForbidden},
+	}
+	for _, c := range casos {
+		req := httptest.NewRequest("PATCH", "/users/2/tipo", strings.NewReader(c.corpo))
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, c.esperado, rr.Code, c.corpo)
+	}
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNumberOfCalls(t, "UpdateUserTipo", 1)
+}
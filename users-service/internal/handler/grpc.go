@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"helpdesk/pkg/pb"
+	"helpdesk/pkg/registro"
+	"helpdesk/users-service/internal/model"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MaxLoteUsuarios e o maior numero de IDs aceito por BatchGetUsers.
+const MaxLoteUsuarios = 500
+
+// DiretorioGrpc implementa o pb.UserDirectoryServer, a API interna usada
+// pelos outros servicos para consultar perfis. A autenticacao e a lista de
+// metodos permitidos ficam no middleware.TokenServico.
+type DiretorioGrpc struct {
+	pb.UnimplementedUserDirectoryServer
+	rep model.UserRepository
+}
+
+func NewDiretorioGrpc(rep model.UserRepository) *DiretorioGrpc {
+	return &DiretorioGrpc{rep: rep}
+}
+
+func (d *DiretorioGrpc) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	if req.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "ID inválido, deve ser um número inteiro positivo")
+	}
+
+	user, err := d.rep.FindUserByID(req.GetId())
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, status.Error(codes.NotFound, "Usuario não encontrado no banco de dados")
+	} else if err != nil {
+		registro.Logger(ctx).Error("Erro ao consultar o usuario", "usuario_id", req.GetId(), "erro", err)
+		return nil, status.Error(codes.Internal, "Erro ao consultar o usuario no banco de dados")
+	}
+	return usuarioPB(user), nil
+}
+
+func (d *DiretorioGrpc) GetUserByEmail(ctx context.Context, req *pb.GetUserByEmailRequest) (*pb.User, error) {
+	email := strings.TrimSpace(req.GetEmail())
+	if email == "" {
+		return nil, status.Error(codes.InvalidArgument, "O email é obrigatório")
+	}
+
+	user, err := d.rep.FindUserByEmailAddress(email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, status.Error(codes.NotFound, "Usuario não encontrado no banco de dados")
+	} else if err != nil {
+		registro.Logger(ctx).Error("Erro ao consultar o usuario por email", "erro", err)
+		return nil, status.Error(codes.Internal, "Erro ao consultar o usuario no banco de dados")
+	}
+	return usuarioPB(user), nil
+}
+
+func (d *DiretorioGrpc) BatchGetUsers(ctx context.Context, req *pb.BatchGetUsersRequest) (*pb.BatchGetUsersResponse, error) {
+	if len(req.GetIds()) > MaxLoteUsuarios {
+		return nil, status.Errorf(codes.InvalidArgument, "No máximo %d usuarios por chamada", MaxLoteUsuarios)
+	}
+
+	// IDs repetidos ou invalidos nao vao ao banco.
+	pedidos := map[int64]bool{}
+	var ids []int64
+	for _, id := range req.GetIds() {
+		if id > 0 && !pedidos[id] {
+			pedidos[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	resp := &pb.BatchGetUsersResponse{}
+	if len(ids) == 0 {
+		return resp, nil
+	}
+
+	usuarios, err := d.rep.FindUsersByIDs(ids)
+	if err != nil {
+		registro.Logger(ctx).Error("Erro ao consultar usuarios", "usuarios", len(ids), "erro", err)
+		return nil, status.Error(codes.Internal, "Erro ao consultar os usuarios no banco de dados")
+	}
+
+	achados := map[int64]bool{}
+	for _, u := range usuarios {
+		achados[u.ID] = true
+		resp.Users = append(resp.Users, usuarioPB(u))
+	}
+	for _, id := range ids {
+		if !achados[id] {
+			resp.NaoEncontrados = append(resp.NaoEncontrados, id)
+		}
+	}
+	return resp, nil
+}
+
+// LookupUsers resolve handles de mencao para os servicos, sem depender do
+// token de quem escreveu o comentario.
+func (d *DiretorioGrpc) LookupUsers(ctx context.Context, req *pb.LookupUsersRequest) (*pb.LookupUsersResponse, error) {
+	var handles []string
+	for _, h := range req.GetHandles() {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			handles = append(handles, h)
+		}
+	}
+	if len(handles) > maxHandles {
+		return nil, status.Errorf(codes.InvalidArgument, "No máximo %d handles por chamada", maxHandles)
+	}
+
+	resp := &pb.LookupUsersResponse{}
+	if len(handles) == 0 {
+		return resp, nil
+	}
+
+	usuarios, err := d.rep.FindUsersByHandles(handles)
+	if err != nil {
+		registro.Logger(ctx).Error("Erro ao consultar os usuarios por handle", "handles", len(handles), "erro", err)
+		return nil, status.Error(codes.Internal, "Erro ao consultar os usuarios no banco de dados")
+	}
+
+	for _, p := range resolveHandles(handles, usuarios) {
+		resp.Users = append(resp.Users, &pb.UserHandle{
+			User:   &pb.User{Id: p.ID, Nome: p.Nome, Email: p.Email, TipoUser: p.TipoUser},
+			Handle: p.Handle,
+		})
+	}
+	return resp, nil
+}
+
+// usuarioPB expoe so o perfil publico: senha, telefone e documento ficam de fora.
+func usuarioPB(u model.User) *pb.User {
+	return &pb.User{
+		Id:       u.ID,
+		Nome:     u.Nome,
+		Email:    u.Email,
+		TipoUser: u.TipoUser,
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"helpdesk/pkg/pb"
+	"helpdesk/users-service/internal/model"
+	"helpdesk/users-service/internal/repository"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDiretorioGrpc_GetUser(t *testing.T) {
+	mockRepo := new(repository.MockUserRepository)
+	d := NewDiretorioGrpc(mockRepo)
+
+	mockRepo.On("FindUserByID", int64(1)).Return(model.User{ID: 1, Nome: "Ana", Senha: "hash", Email: "ana@acme.com", CpfCnpj: "123"}, nil)
+	mockRepo.On("FindUserByID", int64(2)).Return(model.User{}, pgx.ErrNoRows)
+
+	u, err := d.GetUser(context.Background(), &pb.GetUserRequest{Id: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "Ana", u.GetNome())
+	assert.Equal(t, "ana@acme.com", u.GetEmail())
+
+	_, err = d.GetUser(context.Background(), &pb.GetUserRequest{Id: 2})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = d.GetUser(context.Background(), &pb.GetUserRequest{Id: 0})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestDiretorioGrpc_GetUserByEmail(t *testing.T) {
+	mockRepo := new(repository.MockUserRepository)
+	d := NewDiretorioGrpc(mockRepo)
+
+	mockRepo.On("FindUserByEmailAddress", "Ana@Acme.com").Return(model.User{ID: 1, Nome: "Ana", TipoUser: "agente", Email: "ana@acme.com"}, nil)
+	mockRepo.On("FindUserByEmailAddress", "ninguem@acme.com").Return(model.User{}, pgx.ErrNoRows)
+
+	u, err := d.GetUserByEmail(context.Background(), &pb.GetUserByEmailRequest{Email: " Ana@Acme.com "})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), u.GetId())
+	assert.Equal(t, "agente", u.GetTipoUser())
+
+	_, err = d.GetUserByEmail(context.Background(), &pb.GetUserByEmailRequest{Email: "ninguem@acme.com"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = d.GetUserByEmail(context.Background(), &pb.GetUserByEmailRequest{Email: " "})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestDiretorioGrpc_BatchGetUsers(t *testing.T) {
+	mockRepo := new(repository.MockUserRepository)
+	d := NewDiretorioGrpc(mockRepo)
+
+	// Repetidos e invalidos nao chegam ao banco.
+	mockRepo.On("FindUsersByIDs", []int64{1, 3}).Return([]model.User{{ID: 1, Nome: "Ana"}}, nil)
+
+	resp, err := d.BatchGetUsers(context.Background(), &pb.BatchGetUsersRequest{Ids: []int64{1, 3, 1, 0}})
+	assert.NoError(t, err)
+	assert.Len(t, resp.GetUsers(), 1)
+	assert.Equal(t, []int64{3}, resp.GetNaoEncontrados())
+	mockRepo.AssertExpectations(t)
+
+	_, err = d.BatchGetUsers(context.Background(), &pb.BatchGetUsersRequest{Ids: make([]int64, MaxLoteUsuarios+1)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestDiretorioGrpc_LookupUsers(t *testing.T) {
+	mockRepo := new(repository.MockUserRepository)
+	d := NewDiretorioGrpc(mockRepo)
+
+	mockRepo.On("FindUsersByHandles", []string{"joao", "ninguem"}).Return([]model.User{{ID: 1, Nome: "Joao", Email: "joao@acme.com", TipoUser: "agente"}}, nil)
+
+	resp, err := d.LookupUsers(context.Background(), &pb.LookupUsersRequest{Handles: []string{" Joao ", "ninguem", ""}})
+	assert.NoError(t, err)
+	assert.Len(t, resp.GetUsers(), 1)
+	assert.Equal(t, "joao", resp.GetUsers()[0].GetHandle())
+	assert.Equal(t, "agente", resp.GetUsers()[0].GetUser().GetTipoUser())
+
+	_, err = d.LookupUsers(context.Background(), &pb.LookupUsersRequest{Handles: make([]string, maxHandles+1)})
+	assert.NoError(t, err, "handles vazios sao descartados antes do limite")
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"helpdesk/pkg/problema"
 	"helpdesk/users-service/auth"
 	"helpdesk/users-service/internal/model"
 	"helpdesk/users-service/middleware"
@@ -10,8 +11,9 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 type ApiServer struct {
@@ -39,13 +41,16 @@ func (api *ApiServer) CreateUserHandler(w http.ResponseWriter, r *http.Request)
 	var usuario model.User
 
 	if err := json.NewDecoder(r.Body).Decode(&usuario); err != nil {
-		http.Error(w, "Erro ao decodificar a requisição", http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar a requisição")
 		return
 	}
+	// O cadastro e publico: todo usuario nasce cliente e so um admin muda o
+	// tipo, por PATCH /users/{id}/tipo.
+	usuario.TipoUser = model.TipoCliente
 
 	newID, err := api.rep.CreateUser(usuario)
 	if err != nil {
-		http.Error(w, "Erro ao inserir o usuario no banco de dados", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao inserir o usuario no banco de dados")
 		return
 	}
 
@@ -54,7 +59,7 @@ func (api *ApiServer) CreateUserHandler(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err = json.NewEncoder(w).Encode(usuario); err != nil {
-		http.Error(w, "Erro ao codificar o usuario em JSON", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao codificar o usuario em JSON")
 		return
 	}
 }
@@ -62,14 +67,14 @@ func (api *ApiServer) CreateUserHandler(w http.ResponseWriter, r *http.Request)
 func (api *ApiServer) ListUsersHandler(w http.ResponseWriter, r *http.Request) {
 	usuarios, err := api.rep.FindAllUsers()
 	if err != nil {
-		http.Error(w, "Erro ao consultar o banco de dados", http.StatusBadRequest)
+		problema.Erro(w, r, err, "Erro ao consultar os usuarios no banco de dados")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err = json.NewEncoder(w).Encode(usuarios); err != nil {
-		http.Error(w, "Erro ao codificar a lista em JSON", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao codificar a lista em JSON")
 		return
 	}
 }
@@ -78,23 +83,23 @@ func (api *ApiServer) GetUserHandler(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	idInt, err := strconv.Atoi(id)
 	if err != nil {
-		http.Error(w, "Erro ao converter o ID para inteiro", http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao converter o ID para inteiro")
 		return
 	}
 
 	user, err := api.rep.FindUserByID(int64(idInt))
 	if errors.Is(err, pgx.ErrNoRows) {
-		http.Error(w, "Usuario não encontrado no banco de dados", http.StatusNotFound)
+		problema.Escrever(w, r, http.StatusNotFound, "Usuario não encontrado no banco de dados")
 		return
 	} else if err != nil {
-		http.Error(w, "Erro ao consultar o usuario no banco de dados", http.StatusBadRequest)
+		problema.Erro(w, r, err, "Erro ao consultar o usuario no banco de dados")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err = json.NewEncoder(w).Encode(user); err != nil {
-		http.Error(w, "Erro ao codificar o usuario em json", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao codificar o usuario em json")
 		return
 	}
 }
@@ -103,7 +108,7 @@ func (api *ApiServer) UpdateUserHandler(w http.ResponseWriter, r *http.Request)
 	id := chi.URLParam(r, "id")
 	idInt, err := strconv.Atoi(id)
 	if err != nil {
-		http.Error(w, "Erro ao converter o ID para inteiro", http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao converter o ID para inteiro")
 		return
 	}
 
@@ -111,22 +116,22 @@ func (api *ApiServer) UpdateUserHandler(w http.ResponseWriter, r *http.Request)
 
 	idReq, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Não foi possivel extrair o ID do usuario do token", http.StatusInternalServerError)
+		problema.Escrever(w, r, http.StatusInternalServerError, "Não foi possivel extrair o ID do usuario do token")
 		return
 	}
 
 	if int64(idInt) != idReq {
-		http.Error(w, "Permissão não concedida", http.StatusForbidden)
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
 		return
 	}
 
 	if err = json.NewDecoder(r.Body).Decode(&u); err != nil {
-		http.Error(w, "Erro ao decodificar o corpo da requisição", http.StatusInternalServerError)
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar o corpo da requisição")
 		return
 	}
 
 	if err = api.rep.UpdateUser(int64(idInt), u); err != nil {
-		http.Error(w, "Erro ao atualizar o usuario no banco de dados", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao atualizar o usuario no banco de dados")
 		return
 	}
 }
@@ -135,52 +140,59 @@ func (api *ApiServer) DeleteUserHandler(w http.ResponseWriter, r *http.Request)
 	id := chi.URLParam(r, "id")
 	idInt, err := strconv.Atoi(id)
 	if err != nil {
-		http.Error(w, "Erro ao converter o ID para inteiro", http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao converter o ID para inteiro")
 		return
 	}
 
 	idReq, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Não foi possivel extrair o ID do usuario do token", http.StatusInternalServerError)
+		problema.Escrever(w, r, http.StatusInternalServerError, "Não foi possivel extrair o ID do usuario do token")
 		return
 	}
 
 	if int64(idInt) != idReq {
-		http.Error(w, "Permissão não concedida", http.StatusForbidden)
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
 		return
 	}
 
 	if err = api.rep.DeleteUser(int64(idInt)); errors.Is(err, pgx.ErrNoRows) {
-		http.Error(w, "Usuario não encontrado no banco de dados", http.StatusNotFound)
+		problema.Escrever(w, r, http.StatusNotFound, "Usuario não encontrado no banco de dados")
 		return
 	} else if err != nil {
-		http.Error(w, "Erro ao excluir o usuario do banco de dados", http.StatusBadRequest)
+		problema.Erro(w, r, err, "Erro ao excluir o usuario do banco de dados")
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// logins conta as tentativas de login por resultado: sucesso, falha
+// (credenciais recusadas) ou erro (falha do servico ao emitir o token).
+var logins = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "helpdesk_logins_total",
+	Help: "Tentativas de login por resultado: sucesso, falha ou erro.",
+}, []string{"resultado"})
+
 func (api *ApiServer) LoginUserHandler(w http.ResponseWriter, r *http.Request) {
 	var loginReq model.LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&loginReq); err != nil {
-		http.Error(w, "Erro ao decodificar o corpo da requisição", http.StatusBadRequest)
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar o corpo da requisição")
 		return
 	}
 
 	userDB, err := api.rep.FindUserByEmail(loginReq)
 	if err != nil {
-		http.Error(w, "Email ou senha incorretos", http.StatusUnauthorized)
+		logins.WithLabelValues("falha").Inc()
+		problema.Escrever(w, r, http.StatusUnauthorized, "Email ou senha incorretos")
 		return
 	}
 
-	tokenJwt, err := auth.GerarToken(userDB.ID, userDB.Nome, userDB.Email)
+	tokenJwt, err := auth.GerarToken(userDB.ID, userDB.Nome, userDB.Email, userDB.TipoUser)
 	if err != nil {
-		if err == jwt.ErrTokenExpired {
-			http.Error(w, "Token expirado", http.StatusUnauthorized)
-			return
-		}
-		http.Error(w, "Não foi possivel gerar o tokenJwt", http.StatusUnauthorized)
+		logins.WithLabelValues("erro").Inc()
+		problema.Erro(w, r, err, "Não foi possivel gerar o token JWT")
+		return
 	}
+	logins.WithLabelValues("sucesso").Inc()
 
 	w.Header().Add("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -188,7 +200,7 @@ func (api *ApiServer) LoginUserHandler(w http.ResponseWriter, r *http.Request) {
 	response := map[string]string{"token": tokenJwt}
 	err = json.NewEncoder(w).Encode(response)
 	if err != nil {
-		http.Error(w, "Erro ao codigicar o token JWT", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao codigicar o token JWT")
 		return
 	}
 }
@@ -196,20 +208,20 @@ func (api *ApiServer) LoginUserHandler(w http.ResponseWriter, r *http.Request) {
 func (s *ApiServer) GetMeHandler(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Não foi possivel extrair o ID do usuario do token", http.StatusInternalServerError)
+		problema.Escrever(w, r, http.StatusInternalServerError, "Não foi possivel extrair o ID do usuario do token")
 		return
 	}
 
 	user, err := s.rep.FindUserByID(int64(userID))
 	if err != nil {
-		http.Error(w, "Erro ao encontrar o usuario no banco de dados", http.StatusBadRequest)
+		problema.Erro(w, r, err, "Erro ao encontrar o usuario no banco de dados")
 		return
 	}
 
 	w.Header().Add("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err = json.NewEncoder(w).Encode(user); err != nil {
-		http.Error(w, "Erro ao codificar o usuario", http.StatusInternalServerError)
+		problema.Erro(w, r, err, "Erro ao codificar o usuario")
 		return
 	}
 }
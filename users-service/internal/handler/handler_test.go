@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"helpdesk/pkg/problema"
 	"helpdesk/users-service/auth"
 	"helpdesk/users-service/internal/model"
 	"helpdesk/users-service/internal/repository"
@@ -18,6 +19,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -38,7 +40,10 @@ func TestCreateUserHandler(t *testing.T) {
 	//Configuramos o mock. Dizemos a ele:
 	//"Eu espero que o método 'CreateUser' seja chamado com o 'userInput'.
 	// Quando isso acontecer, você deve retornar o ID '1' e nenhum erro."
-	mockRepo.On("CreateUser", userInput).Return(int64(1), nil)
+	// O tipo enviado e ignorado: o cadastro publico sempre cria clientes.
+	esperado := userInput
+	esperado.TipoUser = model.TipoCliente
+	mockRepo.On("CreateUser", esperado).Return(int64(1), nil)
 
 	//Criamos nosso ApiServer usando o REPOSITORIO FALSO.
 	apiServer := NewApiServer(mockRepo)
@@ -62,6 +67,7 @@ func TestCreateUserHandler(t *testing.T) {
 	assert.NoError(t, err)                     // Não deve haver erro ao decodificar a resposta.
 	assert.Equal(t, int64(1), userResponse.ID) //O ID deve ser 1.
 	assert.Equal(t, userInput.Nome, userResponse.Nome)
+	assert.Equal(t, model.TipoCliente, userResponse.TipoUser)
 
 	mockRepo.AssertExpectations(t)
 }
@@ -83,7 +89,9 @@ func TestCreateUserHandler_RepositoryError(t *testing.T) {
 	//Agora, instruimos nosso dublê de uma forma diferente
 	//"Eu espero que 'CreateUser' seja chamado com 'userInput'.
 	//Quando isso acontecer, você deve retornar um ID zero E um NOVO ERRO."
-	mockRepo.On("CreateUser", userInput).Return(int64(0), errors.New("erro de banco de dados"))
+	esperado := userInput
+	esperado.TipoUser = model.TipoCliente
+	mockRepo.On("CreateUser", esperado).Return(int64(0), errors.New("erro de banco de dados"))
 	apiServer := NewApiServer(mockRepo)
 
 	body, _ := json.Marshal(userInput)
@@ -128,7 +136,8 @@ func TestListUserHandler_RepositoryError(t *testing.T) {
 
 	apiServer.ListUsersHandler(rr, req)
 
-	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, problema.TipoJSON, rr.Header().Get("Content-Type"))
 
 	mockRepo.AssertExpectations(t)
 
@@ -253,6 +262,25 @@ func TestLoginUserHandler(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestLoginUserHandler_ContaResultados(t *testing.T) {
+	mockRepo := new(repository.MockUserRepository)
+	certo := model.LoginRequest{Email: "igorgantunes@hotmail.com", Senha: "senha123"}
+	errado := model.LoginRequest{Email: "igorgantunes@hotmail.com", Senha: "errada"}
+	mockRepo.On("FindUserByEmail", certo).Return(model.User{ID: 1, Email: certo.Email}, nil)
+	mockRepo.On("FindUserByEmail", errado).Return(model.User{}, errors.New("senha incorreta"))
+	apiServer := NewApiServer(mockRepo)
+
+	sucessos := testutil.ToFloat64(logins.WithLabelValues("sucesso"))
+	falhas := testutil.ToFloat64(logins.WithLabelValues("falha"))
+	for _, login := range []model.LoginRequest{certo, errado, errado} {
+		body, _ := json.Marshal(login)
+		apiServer.LoginUserHandler(httptest.NewRecorder(), httptest.NewRequest("POST", "/users/login", bytes.NewReader(body)))
+	}
+
+	assert.Equal(t, sucessos+1, testutil.ToFloat64(logins.WithLabelValues("sucesso")))
+	assert.Equal(t, falhas+2, testutil.ToFloat64(logins.WithLabelValues("falha")))
+}
+
 func TestGetMeHandler_Success(t *testing.T) {
 	mockRepo := new(repository.MockUserRepository)
 	apiServer := NewApiServer(mockRepo)
@@ -265,7 +293,7 @@ func TestGetMeHandler_Success(t *testing.T) {
 
 	mockRepo.On("FindUserByID", mockUser.ID).Return(mockUser, nil)
 
-	token, _ := auth.GerarToken(mockUser.ID, mockUser.Nome, mockUser.Email)
+	token, _ := auth.GerarToken(mockUser.ID, mockUser.Nome, mockUser.Email, mockUser.TipoUser)
 
 	req := httptest.NewRequest("GET", "/users/me", nil)
 	req.Header.Set("Authorization", "Bearer "+token)
@@ -295,7 +323,7 @@ func TestUpdateUserHandler_Forbidden(t *testing.T) {
 	apiServer := NewApiServer(mockRepo)
 
 	attackerUser := model.User{ID: 1, Email: "igorgantunes@hotmail.com", Nome: "Atacante"}
-	attackerToken, _ := auth.GerarToken(attackerUser.ID, attackerUser.Nome, attackerUser.Email)
+	attackerToken, _ := auth.GerarToken(attackerUser.ID, attackerUser.Nome, attackerUser.Email, attackerUser.TipoUser)
 
 	targetUserID := int64(2)
 
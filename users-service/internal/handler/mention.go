@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"helpdesk/pkg/problema"
+	"helpdesk/users-service/internal/model"
+	"helpdesk/users-service/middleware"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxHandles limita quantos handles podem ser resolvidos em uma chamada.
+const maxHandles = 50
+
+// resolveHandles escolhe, para cada handle de mencao, o unico usuario que o
+// representa. O email completo tem prioridade; a parte local so vale se for
+// unica; handles ambiguos ou desconhecidos ficam de fora. So e exposto aos
+// servicos, pelo LookupUsers do gRPC: email e tipo de usuarios arbitrarios
+// nao sao para clientes finais.
+func resolveHandles(handles []string, usuarios []model.User) []model.UserProfile {
+	perfis := []model.UserProfile{}
+
+	for _, h := range handles {
+		var escolhido *model.User
+		candidatos := 0
+
+		for i := range usuarios {
+			email := strings.ToLower(usuarios[i].Email)
+			if email == h {
+				escolhido = &usuarios[i]
+				candidatos = 1
+				break
+			}
+			if local, _, _ := strings.Cut(email, "@"); local == h {
+				escolhido = &usuarios[i]
+				candidatos++
+			}
+		}
+
+		if candidatos != 1 {
+			continue
+		}
+
+		perfis = append(perfis, model.UserProfile{
+			ID:       escolhido.ID,
+			Nome:     escolhido.Nome,
+			Email:    escolhido.Email,
+			TipoUser: escolhido.TipoUser,
+			Handle:   h,
+		})
+	}
+
+	return perfis
+}
+
+// GetMyMentionsHandler lista as mencoes ainda nao resolvidas do usuario.
+func (api *ApiServer) GetMyMentionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		problema.Escrever(w, r, http.StatusInternalServerError, "Não foi possivel extrair o ID do usuario do token")
+		return
+	}
+
+	lista, err := api.rep.ListUnresolvedMentions(userID)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar as menções no banco de dados")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(lista); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar a lista em JSON")
+		return
+	}
+}
+
+func (api *ApiServer) ResolveMentionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		problema.Escrever(w, r, http.StatusInternalServerError, "Não foi possivel extrair o ID do usuario do token")
+		return
+	}
+
+	idInt, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao converter o ID para inteiro")
+		return
+	}
+
+	if err = api.rep.ResolveMention(int64(idInt), userID); errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Menção não encontrada")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao atualizar a menção no banco de dados")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
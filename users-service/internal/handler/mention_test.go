@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"helpdesk/users-service/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveHandles(t *testing.T) {
+	usuarios := []model.User{
+		{ID: 1, Nome: "Joao", Email: "joao@acme.com"},
+		{ID: 2, Nome: "Maria", Email: "maria@acme.com"},
+		{ID: 3, Nome: "Maria", Email: "maria@outra.com"},
+	}
+
+	perfis := resolveHandles([]string{"joao", "maria", "maria@outra.com", "ninguem"}, usuarios)
+
+	// "maria" e ambiguo e "ninguem" nao existe: so dois handles sao resolvidos.
+	assert.Len(t, perfis, 2)
+	assert.Equal(t, int64(1), perfis[0].ID)
+	assert.Equal(t, "joao", perfis[0].Handle)
+	assert.Equal(t, int64(3), perfis[1].ID)
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"helpdesk/pkg/problema"
+	"helpdesk/users-service/internal/model"
+	"helpdesk/users-service/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// isAdmin verifica o tipo de usuario que o AuthMiddleware extraiu do token.
+func isAdmin(r *http.Request) bool {
+	tipoUser, _ := r.Context().Value(middleware.TipoUserKey).(string)
+	return tipoUser == model.TipoAdmin
+}
+
+func (api *ApiServer) CreateOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	var org model.Organization
+	if err := json.NewDecoder(r.Body).Decode(&org); err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar a requisição")
+		return
+	}
+
+	if org.Nome == "" {
+		problema.Escrever(w, r, http.StatusBadRequest, "O nome da organização é obrigatório")
+		return
+	}
+
+	newID, err := api.rep.CreateOrganization(org)
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao inserir a organização no banco de dados")
+		return
+	}
+	org.ID = newID
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(org); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar a organização em JSON")
+		return
+	}
+}
+
+func (api *ApiServer) ListOrganizationMembersHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	idInt, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao converter o ID para inteiro")
+		return
+	}
+
+	membros, err := api.rep.ListOrganizationMembers(int64(idInt))
+	if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar os membros da organização")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(membros); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar a lista em JSON")
+		return
+	}
+}
+
+func (api *ApiServer) AddOrganizationMemberHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	idInt, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao converter o ID para inteiro")
+		return
+	}
+
+	var member model.OrganizationMember
+	if err = json.NewDecoder(r.Body).Decode(&member); err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar a requisição")
+		return
+	}
+	member.OrganizationID = int64(idInt)
+
+	if _, err = api.rep.FindOrganizationByID(member.OrganizationID); errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Organização não encontrada no banco de dados")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar a organização no banco de dados")
+		return
+	}
+
+	if _, err = api.rep.FindUserByID(member.UserID); errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Usuario não encontrado no banco de dados")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao consultar o usuario no banco de dados")
+		return
+	}
+
+	if err = api.rep.AddOrganizationMember(member); err != nil {
+		problema.Erro(w, r, err, "Erro ao adicionar o membro na organização")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(member); err != nil {
+		problema.Erro(w, r, err, "Erro ao codificar o membro em JSON")
+		return
+	}
+}
+
+func (api *ApiServer) RemoveOrganizationMemberHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		problema.Escrever(w, r, http.StatusForbidden, "Permissão não concedida")
+		return
+	}
+
+	orgID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao converter o ID para inteiro")
+		return
+	}
+
+	userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		problema.Escrever(w, r, http.StatusBadRequest, "Erro ao converter o ID do usuario para inteiro")
+		return
+	}
+
+	if err = api.rep.RemoveOrganizationMember(int64(orgID), int64(userID)); errors.Is(err, pgx.ErrNoRows) {
+		problema.Escrever(w, r, http.StatusNotFound, "Membro não encontrado na organização")
+		return
+	} else if err != nil {
+		problema.Erro(w, r, err, "Erro ao remover o membro da organização")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
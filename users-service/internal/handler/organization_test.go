@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"helpdesk/users-service/auth"
+	"helpdesk/users-service/internal/model"
+	"helpdesk/users-service/internal/repository"
+	"helpdesk/users-service/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateOrganizationHandler(t *testing.T) {
+	mockRepo := new(repository.MockUserRepository)
+	apiServer := NewApiServer(mockRepo)
+
+	orgInput := model.Organization{Nome: "ACME"}
+	mockRepo.On("CreateOrganization", orgInput).Return(int64(7), nil)
+
+	token, _ := auth.GerarToken(1, "Admin", "admin@acme.com", model.TipoAdmin)
+
+	body, _ := json.Marshal(orgInput)
+	req := httptest.NewRequest("POST", "/organizations", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+
+	router := chi.NewRouter()
+	router.With(middleware.AuthMiddleware).Post("/organizations", apiServer.CreateOrganizationHandler)
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	var orgOutput model.Organization
+	_ = json.NewDecoder(rr.Body).Decode(&orgOutput)
+	assert.Equal(t, int64(7), orgOutput.ID)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAddOrganizationMemberHandler_Forbidden(t *testing.T) {
+	mockRepo := new(repository.MockUserRepository)
+	apiServer := NewApiServer(mockRepo)
+
+	token, _ := auth.GerarToken(2, "Cliente", "cliente@acme.com", model.TipoCliente)
+
+	body, _ := json.Marshal(model.OrganizationMember{UserID: 2, Manager: true})
+	req := httptest.NewRequest("POST", "/organizations/7/members", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+
+	router := chi.NewRouter()
+	router.With(middleware.AuthMiddleware).Post("/organizations/{id}/members", apiServer.AddOrganizationMemberHandler)
+	router.ServeHTTP(rr, req)
+
+	// Um cliente nao pode se promover a manager; o repositorio nem deve ser consultado.
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	mockRepo.AssertExpectations(t)
+}
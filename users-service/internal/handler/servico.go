@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"helpdesk/pkg/problema"
+	"helpdesk/pkg/registro"
+	"helpdesk/users-service/auth"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type tokenServicoResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// ServiceTokenHandler emite tokens de servico no fluxo client credentials do
+// OAuth 2: o servico envia grant_type=client_credentials, o ID e o segredo
+// (por Basic auth ou nos campos client_id e client_secret) e, opcionalmente,
+// os escopos que quer em scope. Nao passa pelo AuthMiddleware: quem chama e
+// um servico, nao um usuario.
+func ServiceTokenHandler(clientes auth.ClientesServico) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			problema.Escrever(w, r, http.StatusBadRequest, "Erro ao decodificar a requisição")
+			return
+		}
+		if r.PostForm.Get("grant_type") != "client_credentials" {
+			problema.Escrever(w, r, http.StatusBadRequest, "grant_type deve ser client_credentials")
+			return
+		}
+
+		id, segredo, ok := r.BasicAuth()
+		if !ok {
+			id, segredo = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+		cliente, err := clientes.Autenticar(id, segredo)
+		if errors.Is(err, auth.ErrClienteInvalido) {
+			registro.Logger(r.Context()).Warn("Pedido de token de serviço recusado", "cliente", id)
+			w.Header().Set("WWW-Authenticate", `Basic realm="servicos"`)
+			problema.Escrever(w, r, http.StatusUnauthorized, "Credenciais do serviço inválidas")
+			return
+		}
+
+		token, claims, err := auth.GerarTokenServico(cliente, strings.Fields(r.PostForm.Get("scope")))
+		if err != nil {
+			registro.Logger(r.Context()).Warn("Erro ao emitir o token de serviço", "cliente", cliente.ID, "erro", err)
+			problema.Escrever(w, r, http.StatusBadRequest, "Não foi possível emitir o token do serviço")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		if err = json.NewEncoder(w).Encode(tokenServicoResponse{
+			AccessToken: token,
+			TokenType:   "Bearer",
+			ExpiresIn:   int64(time.Until(claims.ExpiresAt.Time).Seconds()),
+			Scope:       strings.Join(claims.Escopos, " "),
+		}); err != nil {
+			problema.Erro(w, r, err, "Erro ao codificar o token em JSON")
+			return
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"helpdesk/pkg/config"
+	"helpdesk/users-service/auth"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pedirToken(t *testing.T, clientes auth.ClientesServico, form url.Values, id, segredo string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/services/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if id != "" {
+		req.SetBasicAuth(id, segredo)
+	}
+	rr := httptest.NewRecorder()
+	ServiceTokenHandler(clientes).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestServiceTokenHandler(t *testing.T) {
+	auth.Configurar(config.JWT{Segredo: "chave-de-teste", SegredoServicos: "chave-de-teste", Validade: time.Hour})
+	clientes, err := auth.ParseClientesServico("tickets-service:s3nha:usuarios.ler")
+	require.NoError(t, err)
+	form := url.Values{"grant_type": {"client_credentials"}}
+
+	rr := pedirToken(t, clientes, form, "tickets-service", "s3nha")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp tokenServicoResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Equal(t, "Bearer", resp.TokenType)
+	assert.Equal(t, "usuarios.ler", resp.Scope)
+
+	claims, err := auth.ValidarTokenServico(resp.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "tickets-service", claims.Servico)
+	assert.True(t, claims.Permite(auth.EscopoUsuariosLer))
+
+	// Um token de usuario nao serve como token de servico, nem assinado com a mesma chave.
+	tokenUsuario, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.ClaimCustom{UserID: 1, TipoUser: "admin",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}}).SignedString([]byte("chave-de-teste"))
+	_, err = auth.ValidarTokenServico(tokenUsuario)
+	assert.Error(t, err)
+
+	rr = pedirToken(t, clientes, form, "tickets-service", "errado")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = pedirToken(t, clientes, url.Values{"grant_type": {"password"}}, "tickets-service", "s3nha")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	escopoAlheio := url.Values{"grant_type": {"client_credentials"}, "scope": {"usuarios.escrever"}}
+	rr = pedirToken(t, clientes, escopoAlheio, "tickets-service", "s3nha")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
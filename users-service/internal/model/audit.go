@@ -0,0 +1,8 @@
+package model
+
+import "helpdesk/pkg/auditoria"
+
+// AuditLog e uma acao de escrita registrada pelo middleware de auditoria
+// (auditoria.HTTP). ActorID difere de UserID quando a acao foi feita sob
+// personificacao.
+type AuditLog = auditoria.Entrada
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Mention e uma mencao (@usuario) feita em um comentario do tickets-service.
+type Mention struct {
+	ID        int64     `json:"id"`
+	CommentID int64     `json:"comment_id"`
+	TicketID  int64     `json:"ticket_id"`
+	UserID    int64     `json:"user_id"`
+	Resolvida bool      `json:"resolvida"`
+	Data      time.Time `json:"data"`
+}
+
+// UserProfile e a visao publica de um usuario, usada para resolver mencoes.
+type UserProfile struct {
+	ID       int64  `json:"id"`
+	Nome     string `json:"nome"`
+	Email    string `json:"email"`
+	TipoUser string `json:"tipoUser"`
+	Handle   string `json:"handle"`
+}
@@ -0,0 +1,15 @@
+package model
+
+// Organization representa a empresa cliente a qual os usuarios pertencem.
+type Organization struct {
+	ID   int64  `json:"id"`
+	Nome string `json:"nome"`
+}
+
+// OrganizationMember liga um usuario a sua organizacao. Cada usuario pertence
+// a no maximo uma organizacao; Manager permite ver os tickets de todos os colegas.
+type OrganizationMember struct {
+	OrganizationID int64 `json:"organization_id"`
+	UserID         int64 `json:"user_id"`
+	Manager        bool  `json:"manager"`
+}
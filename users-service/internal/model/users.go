@@ -1,5 +1,20 @@
 package model
 
+// Valores aceitos em User.TipoUser.
+const (
+	TipoCliente = "cliente"
+	TipoAgente  = "agente"
+	TipoAdmin   = "admin"
+)
+
+// TiposUser e o conjunto dos valores aceitos em User.TipoUser.
+var TiposUser = map[string]bool{TipoCliente: true, TipoAgente: true, TipoAdmin: true}
+
+// CanalUsuarioAlterado e o canal do PostgreSQL (NOTIFY) em que cada usuario
+// alterado ou removido e anunciado pelo ID. O tickets-service o escuta como
+// bus.TopicoUsuarios para invalidar o cache de perfis.
+const CanalUsuarioAlterado = "helpdesk_usuarios"
+
 type User struct {
 	ID       int64  `json:"id"`
 	Nome     string `json:"nome"`
@@ -14,9 +29,22 @@ type UserRepository interface {
 	CreateUser(user User) (int64, error)
 	FindAllUsers() ([]User, error)
 	FindUserByID(id int64) (User, error)
+	FindUsersByIDs(ids []int64) ([]User, error)
 	FindUserByEmail(loginReq LoginRequest) (User, error)
+	FindUserByEmailAddress(email string) (User, error)
 	UpdateUser(id int64, user User) error
+	UpdateUserTipo(id int64, tipo string) error
 	DeleteUser(id int64) error
+	CreateOrganization(org Organization) (int64, error)
+	FindOrganizationByID(id int64) (Organization, error)
+	ListOrganizationMembers(orgID int64) ([]OrganizationMember, error)
+	AddOrganizationMember(member OrganizationMember) error
+	RemoveOrganizationMember(orgID, userID int64) error
+	RecordAudit(entrada AuditLog) error
+	ListAuditLogs(actorID int64, limite int) ([]AuditLog, error)
+	FindUsersByHandles(handles []string) ([]User, error)
+	ListUnresolvedMentions(userID int64) ([]Mention, error)
+	ResolveMention(id, userID int64) error
 }
 
 type LoginRequest struct {
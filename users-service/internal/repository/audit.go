@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"helpdesk/users-service/internal/model"
+)
+
+func (s *Repository) RecordAudit(entrada model.AuditLog) error {
+	_, err := s.db.Exec(context.Background(), "INSERT INTO audit_logs (actor_id, user_id, servico, metodo, rota, caminho, status) VALUES ($1, $2, $3, $4, $5, $6, $7)", entrada.ActorID, entrada.UserID, entrada.Servico, entrada.Metodo, entrada.Rota, entrada.Caminho, entrada.Status)
+	return err
+}
+
+// ListAuditLogs retorna os registros mais recentes primeiro. actorID igual a
+// zero lista as acoes de todos os atores.
+func (s *Repository) ListAuditLogs(actorID int64, limite int) ([]model.AuditLog, error) {
+	rows, err := s.db.Query(context.Background(), "SELECT id, actor_id, user_id, servico, metodo, COALESCE(rota, ''), caminho, status, data FROM audit_logs WHERE ($1 = 0 OR actor_id = $1) ORDER BY data DESC, id DESC LIMIT $2", actorID, limite)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lista []model.AuditLog
+	var a model.AuditLog
+
+	for rows.Next() {
+		if err := rows.Scan(&a.ID, &a.ActorID, &a.UserID, &a.Servico, &a.Metodo, &a.Rota, &a.Caminho, &a.Status, &a.Data); err != nil {
+			return nil, err
+		}
+		lista = append(lista, a)
+	}
+
+	return lista, rows.Err()
+}
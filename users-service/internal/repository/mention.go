@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"helpdesk/users-service/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// FindUsersByHandles busca usuarios cujo email completo ou parte local do
+// email (antes do @) coincide com algum dos handles, ignorando maiusculas.
+func (s *Repository) FindUsersByHandles(handles []string) ([]model.User, error) {
+	rows, err := s.db.Query(context.Background(), "SELECT * FROM users WHERE lower(email) = ANY($1) OR lower(split_part(email, '@', 1)) = ANY($1)", handles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usuarios []model.User
+	var u model.User
+
+	for rows.Next() {
+		if err := rows.Scan(&u.ID, &u.Nome, &u.Senha, &u.TipoUser, &u.Email, &u.Telefone, &u.CpfCnpj); err != nil {
+			return nil, err
+		}
+		usuarios = append(usuarios, u)
+	}
+
+	return usuarios, rows.Err()
+}
+
+func (s *Repository) ListUnresolvedMentions(userID int64) ([]model.Mention, error) {
+	rows, err := s.db.Query(context.Background(), "SELECT id, comment_id, ticket_id, user_id, resolvida, data FROM comment_mentions WHERE user_id=$1 AND NOT resolvida ORDER BY data DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lista []model.Mention
+	var m model.Mention
+
+	for rows.Next() {
+		if err := rows.Scan(&m.ID, &m.CommentID, &m.TicketID, &m.UserID, &m.Resolvida, &m.Data); err != nil {
+			return nil, err
+		}
+		lista = append(lista, m)
+	}
+
+	return lista, rows.Err()
+}
+
+// ResolveMention marca a mencao como tratada. So o proprio mencionado pode
+// resolve-la; para os demais a mencao e tratada como inexistente.
+func (s *Repository) ResolveMention(id, userID int64) error {
+	row, err := s.db.Exec(context.Background(), "UPDATE comment_mentions SET resolvida=TRUE WHERE id=$1 AND user_id=$2", id, userID)
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() != 1 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"helpdesk/users-service/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Repository) CreateOrganization(org model.Organization) (int64, error) {
+	if err := s.db.QueryRow(context.Background(), "INSERT INTO organizations (nome) VALUES ($1) returning id", org.Nome).Scan(&org.ID); err != nil {
+		return 0, err
+	}
+
+	return org.ID, nil
+}
+
+func (s *Repository) FindOrganizationByID(id int64) (model.Organization, error) {
+	var org model.Organization
+
+	if err := s.db.QueryRow(context.Background(), "SELECT id, nome FROM organizations WHERE id=$1", id).Scan(&org.ID, &org.Nome); err != nil {
+		return model.Organization{}, err
+	}
+
+	return org, nil
+}
+
+func (s *Repository) ListOrganizationMembers(orgID int64) ([]model.OrganizationMember, error) {
+	rows, err := s.db.Query(context.Background(), "SELECT organization_id, user_id, manager FROM organization_members WHERE organization_id=$1 ORDER BY user_id", orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var membros []model.OrganizationMember
+	var m model.OrganizationMember
+
+	for rows.Next() {
+		if err := rows.Scan(&m.OrganizationID, &m.UserID, &m.Manager); err != nil {
+			return nil, err
+		}
+		membros = append(membros, m)
+	}
+
+	return membros, rows.Err()
+}
+
+// AddOrganizationMember insere o usuario na organizacao ou, se ele ja for membro
+// de alguma, move-o para a nova organizacao com o novo valor de manager.
+func (s *Repository) AddOrganizationMember(member model.OrganizationMember) error {
+	_, err := s.db.Exec(context.Background(), "INSERT INTO organization_members (user_id, organization_id, manager) VALUES ($1, $2, $3) ON CONFLICT (user_id) DO UPDATE SET organization_id=EXCLUDED.organization_id, manager=EXCLUDED.manager", member.UserID, member.OrganizationID, member.Manager)
+	return err
+}
+
+func (s *Repository) RemoveOrganizationMember(orgID, userID int64) error {
+	row, err := s.db.Exec(context.Background(), "DELETE FROM organization_members WHERE organization_id=$1 AND user_id=$2", orgID, userID)
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() != 1 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
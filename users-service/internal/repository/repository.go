@@ -3,7 +3,6 @@ package repository
 import (
 	"context"
 	"helpdesk/users-service/internal/model"
-	"log"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -26,9 +25,6 @@ func (s *Repository) CreateUser(user model.User) (int64, error) {
 		return 0, err
 	}
 	if err = s.db.QueryRow(context.Background(), "INSERT INTO users (nome, senha, tipoUser, email, telefone, cpfCnpj) VALUES ($1, $2, $3, $4, $5, $6) returning id", user.Nome, string(senha), user.TipoUser, user.Email, user.Telefone, user.CpfCnpj).Scan(&user.ID); err != nil {
-		go func() {
-			log.Printf("Erro ao inserir o usuario no banco de dados: %v", err)
-		}()
 		return 0, err
 	}
 
@@ -38,9 +34,6 @@ func (s *Repository) CreateUser(user model.User) (int64, error) {
 func (s *Repository) FindAllUsers() ([]model.User, error) {
 	rows, err := s.db.Query(context.Background(), "SELECT * FROM users")
 	if err != nil {
-		go func() {
-			log.Printf("Erro ao consultar o banco de dados: %v", err)
-		}()
 		return nil, err
 	}
 	defer rows.Close()
@@ -50,18 +43,12 @@ func (s *Repository) FindAllUsers() ([]model.User, error) {
 
 	for rows.Next() {
 		if err := rows.Scan(&u.ID, &u.Nome, &u.Senha, &u.TipoUser, &u.Email, &u.Telefone, &u.CpfCnpj); err != nil {
-			go func() {
-				log.Printf("Erro ao decodificar o usuario: %v", err)
-			}()
 			return nil, err
 		}
 		usuarios = append(usuarios, u)
 	}
 
 	if err = rows.Err(); err != nil {
-		go func() {
-			log.Printf("Erro ao consultar o banco de dados: %v", err)
-		}()
 		return nil, err
 	}
 
@@ -72,9 +59,39 @@ func (s *Repository) FindUserByID(id int64) (model.User, error) {
 	var u model.User
 
 	if err := s.db.QueryRow(context.Background(), "SELECT * FROM users WHERE id=$1", id).Scan(&u.ID, &u.Nome, &u.Senha, &u.TipoUser, &u.Email, &u.Telefone, &u.CpfCnpj); err != nil {
-		go func() {
-			log.Printf("Erro ao decodificar o usuario: %v", err)
-		}()
+		return u, err
+	}
+
+	return u, nil
+}
+
+// FindUsersByIDs busca varios usuarios de uma vez. IDs inexistentes sao ignorados.
+func (s *Repository) FindUsersByIDs(ids []int64) ([]model.User, error) {
+	rows, err := s.db.Query(context.Background(), "SELECT * FROM users WHERE id = ANY($1)", ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usuarios []model.User
+	var u model.User
+
+	for rows.Next() {
+		if err := rows.Scan(&u.ID, &u.Nome, &u.Senha, &u.TipoUser, &u.Email, &u.Telefone, &u.CpfCnpj); err != nil {
+			return nil, err
+		}
+		usuarios = append(usuarios, u)
+	}
+
+	return usuarios, rows.Err()
+}
+
+// FindUserByEmailAddress busca o usuario pelo email, sem diferenciar
+// maiusculas e sem conferir senha.
+func (s *Repository) FindUserByEmailAddress(email string) (model.User, error) {
+	var u model.User
+
+	if err := s.db.QueryRow(context.Background(), "SELECT * FROM users WHERE lower(email)=lower($1)", email).Scan(&u.ID, &u.Nome, &u.Senha, &u.TipoUser, &u.Email, &u.Telefone, &u.CpfCnpj); err != nil {
 		return u, err
 	}
 
@@ -95,8 +112,27 @@ func (s *Repository) FindUserByEmail(loginReq model.LoginRequest) (model.User, e
 	return u, nil
 }
 
+// UpdateUser, UpdateUserTipo e DeleteUser avisam no canal
+// model.CanalUsuarioAlterado, na mesma instrucao da escrita, para que os
+// outros servicos descartem os perfis que guardam em cache.
+//
+// UpdateUser nao altera o tipo: ele e feito pelo proprio usuario.
 func (s *Repository) UpdateUser(id int64, user model.User) error {
-	row, err := s.db.Exec(context.Background(), "UPDATE users SET nome=$1, senha=$2, tipoUser=$3, email=$4, telefone=$5, cpfCnpj=$6 WHERE id=$7", user.Nome, user.Senha, user.TipoUser, user.Email, user.Telefone, user.CpfCnpj, id)
+	row, err := s.db.Exec(context.Background(), "WITH u AS (UPDATE users SET nome=$1, senha=$2, email=$3, telefone=$4, cpfCnpj=$5 WHERE id=$6 RETURNING id) SELECT pg_notify($7, id::text) FROM u", user.Nome, user.Senha, user.Email, user.Telefone, user.CpfCnpj, id, model.CanalUsuarioAlterado)
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() != 1 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+// UpdateUserTipo muda o papel do usuario (cliente, agente ou admin).
+func (s *Repository) UpdateUserTipo(id int64, tipo string) error {
+	row, err := s.db.Exec(context.Background(), "WITH u AS (UPDATE users SET tipoUser=$1 WHERE id=$2 RETURNING id) SELECT pg_notify($3, id::text) FROM u", tipo, id, model.CanalUsuarioAlterado)
 	if err != nil {
 		return err
 	}
@@ -109,7 +145,7 @@ func (s *Repository) UpdateUser(id int64, user model.User) error {
 }
 
 func (s *Repository) DeleteUser(id int64) error {
-	row, err := s.db.Exec(context.Background(), "DELETE FROM users WHERE id=$1", id)
+	row, err := s.db.Exec(context.Background(), "WITH u AS (DELETE FROM users WHERE id=$1 RETURNING id) SELECT pg_notify($2, id::text) FROM u", id, model.CanalUsuarioAlterado)
 	if err != nil {
 		return err
 	}
@@ -26,6 +26,16 @@ func (m *MockUserRepository) FindUserByID(id int64) (model.User, error) {
 	return args.Get(0).(model.User), args.Error(1)
 }
 
+func (m *MockUserRepository) FindUsersByIDs(ids []int64) ([]model.User, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindUserByEmailAddress(email string) (model.User, error) {
+	args := m.Called(email)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
 func (m *MockUserRepository) FindUserByEmail(loginReq model.LoginRequest) (model.User, error) {
 	args := m.Called(loginReq)
 	return args.Get(0).(model.User), args.Error(1)
@@ -36,7 +46,62 @@ func (m *MockUserRepository) UpdateUser(id int64, user model.User) error {
 	return args.Error(1)
 }
 
+func (m *MockUserRepository) UpdateUserTipo(id int64, tipo string) error {
+	args := m.Called(id, tipo)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) DeleteUser(id int64) error {
 	args := m.Called(id)
 	return args.Error(1)
 }
+
+func (m *MockUserRepository) CreateOrganization(org model.Organization) (int64, error) {
+	args := m.Called(org)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) FindOrganizationByID(id int64) (model.Organization, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.Organization), args.Error(1)
+}
+
+func (m *MockUserRepository) ListOrganizationMembers(orgID int64) ([]model.OrganizationMember, error) {
+	args := m.Called(orgID)
+	return args.Get(0).([]model.OrganizationMember), args.Error(1)
+}
+
+func (m *MockUserRepository) AddOrganizationMember(member model.OrganizationMember) error {
+	args := m.Called(member)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) RemoveOrganizationMember(orgID, userID int64) error {
+	args := m.Called(orgID, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) RecordAudit(entrada model.AuditLog) error {
+	args := m.Called(entrada)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ListAuditLogs(actorID int64, limite int) ([]model.AuditLog, error) {
+	args := m.Called(actorID, limite)
+	return args.Get(0).([]model.AuditLog), args.Error(1)
+}
+
+func (m *MockUserRepository) FindUsersByHandles(handles []string) ([]model.User, error) {
+	args := m.Called(handles)
+	return args.Get(0).([]model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) ListUnresolvedMentions(userID int64) ([]model.Mention, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.Mention), args.Error(1)
+}
+
+func (m *MockUserRepository) ResolveMention(id, userID int64) error {
+	args := m.Called(id, userID)
+	return args.Error(0)
+}
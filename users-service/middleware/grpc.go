@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"helpdesk/pkg/registro"
+	"helpdesk/users-service/auth"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MetodosInternos e a lista de metodos gRPC que os servicos podem chamar,
+// com o escopo que cada um exige do token. Metodo fora da lista e recusado,
+// mesmo que esteja registrado no servidor.
+var MetodosInternos = map[string]string{
+	"/helpdesk.users.v1.UserDirectory/GetUser":        auth.EscopoUsuariosLer,
+	"/helpdesk.users.v1.UserDirectory/GetUserByEmail": auth.EscopoUsuariosLer,
+	"/helpdesk.users.v1.UserDirectory/BatchGetUsers":  auth.EscopoUsuariosLer,
+	"/helpdesk.users.v1.UserDirectory/LookupUsers":    auth.EscopoUsuariosLer,
+}
+
+// ServicoKey guarda no contexto o ID do servico que fez a chamada.
+const ServicoKey contextKey = "servico"
+
+// TokenServico protege a API gRPC interna: so aceita chamadas com um token
+// de servico valido (auth.ValidarTokenServico) no metadata "authorization"
+// ("Bearer <token>") e com o escopo que o metodo exige em permitidos. Tokens
+// de usuario nao servem aqui. A checagem de saude dispensa o token.
+func TokenServico(permitidos map[string]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		valores := md.Get("authorization")
+		if len(valores) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "Token de serviço ausente")
+		}
+
+		partes := strings.Split(valores[0], " ")
+		if len(partes) != 2 || strings.ToLower(partes[0]) != "bearer" {
+			return nil, status.Error(codes.Unauthenticated, "Formato do token inválido")
+		}
+		claims, err := auth.ValidarTokenServico(partes[1])
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "Token de serviço inválido")
+		}
+
+		escopo, ok := permitidos[info.FullMethod]
+		if !ok || !claims.Permite(escopo) {
+			registro.Logger(ctx).Warn("Chamada interna recusada", "cliente", claims.Servico, "metodo", info.FullMethod)
+			return nil, status.Error(codes.PermissionDenied, "Serviço sem permissão para este método")
+		}
+		return handler(context.WithValue(ctx, ServicoKey, claims.Servico), req)
+	}
+}